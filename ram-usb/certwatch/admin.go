@@ -0,0 +1,57 @@
+package certwatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"ramusb/mtlsauth"
+)
+
+// AdminReloadHandler returns the handler for POST /admin/reload: forces a
+// synchronous Reload and returns the resulting certificate fingerprint, so
+// an operator rotating a CA or leaf can confirm the rollout actually took
+// without guessing from logs alone.
+//
+// Callers are expected to still wrap this behind their own VerifyMTLS for
+// transport-level authentication; AdminReloadHandler only adds the
+// additional restriction that the peer certificate must carry one of the
+// current RouteConfig.AdminSANs - an empty or unconfigured allowlist denies
+// every caller, so this endpoint is safe to register unconditionally.
+//
+// Responds 200 with {"fingerprint": "..."} on success, 403 if the peer isn't
+// an admin SAN, or 500 if Reload itself fails (e.g. the new certificate's
+// identity doesn't match this service's configured identity).
+func (w *Watcher) AdminReloadHandler() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(rw, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			http.Error(rw, "Client certificate required", http.StatusUnauthorized)
+			return
+		}
+
+		peer := r.TLS.PeerCertificates[0]
+		admins := w.state().routes.AdminSANs
+		policy := mtlsauth.SANPolicy{AllowedDNS: admins, AllowedURIs: admins}
+		if err := policy.Authorize(peer); err != nil {
+			log.Printf("certwatch: admin reload denied (CN=%s, DNS=%v, URIs=%v): %v",
+				peer.Subject.CommonName, peer.DNSNames, peer.URIs, err)
+			http.Error(rw, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		fingerprint, err := w.Reload()
+		if err != nil {
+			log.Printf("certwatch: admin-triggered reload failed: %v", err)
+			http.Error(rw, fmt.Sprintf("Reload failed: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(map[string]string{"fingerprint": fingerprint})
+	}
+}
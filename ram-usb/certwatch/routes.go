@@ -0,0 +1,43 @@
+package certwatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// RouteConfig is the hot-reloadable operational metadata Watcher reloads
+// alongside TLS material, read from Sources.RoutesFile. Today it carries
+// only the admin-SAN allowlist AdminReloadHandler is restricted to, letting
+// an operator add or rotate who may trigger a reload without restarting the
+// service; it's the natural place to grow further hot-reloadable routing
+// decisions later.
+type RouteConfig struct {
+	AdminSANs []string `json:"admin_sans"` // DNS or URI SANs permitted to call admin endpoints, e.g. POST /admin/reload
+}
+
+// loadRouteConfig reads and JSON-decodes path. An empty path, or a path that
+// doesn't exist yet, is treated as an empty RouteConfig (no admin SANs
+// allowed, so AdminReloadHandler denies every caller) rather than an error,
+// so a deployment that doesn't need hot-reloadable admin access can omit
+// routes.json entirely.
+func loadRouteConfig(path string) (RouteConfig, error) {
+	if path == "" {
+		return RouteConfig{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return RouteConfig{}, nil
+	}
+	if err != nil {
+		return RouteConfig{}, fmt.Errorf("read %s: %v", path, err)
+	}
+
+	var cfg RouteConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return RouteConfig{}, fmt.Errorf("parse %s: %v", path, err)
+	}
+	return cfg, nil
+}
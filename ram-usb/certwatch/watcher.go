@@ -0,0 +1,241 @@
+/*
+Hot-reloadable server TLS material for Security-Switch and Database-Vault.
+
+Both services used to load their CA pool and server certificate once at
+startup via tls.LoadX509KeyPair/x509.CertPool and never look at the files
+again, so rotating the CA or revoking a compromised leaf meant restarting
+the process and dropping every in-flight request. Watcher keeps the same
+files on disk as the source of truth, but re-reads them on change and swaps
+the result into the live tls.Config through GetConfigForClient/GetCertificate
+closures backed by an atomic.Value, so a rotation is a file write instead of
+a restart.
+*/
+package certwatch
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+
+	"ramusb/mtlsauth"
+)
+
+// Sources holds the files a single Watcher reloads together on every Reload call.
+type Sources struct {
+	CACertFile     string // CA pool client certificates must chain to
+	ServerCertFile string
+	ServerKeyFile  string
+	RoutesFile     string // JSON-encoded RouteConfig (see routes.go); empty disables admin-SAN reload entirely
+
+	// ServiceName is this service's identity, e.g. "security-switch". A
+	// reloaded ServerCertFile whose leaf doesn't carry the matching
+	// "spiffe://ramusb/<ServiceName>" URI SAN is rejected by Reload, which
+	// keeps serving the previous certificate instead - see pki/client.go
+	// for where that SAN convention comes from.
+	ServiceName string
+}
+
+// state is the immutable snapshot Watcher swaps in atomically on each
+// successful Reload; readers never see a pool built from one reload paired
+// with a certificate built from another.
+type state struct {
+	pool        *x509.CertPool
+	cert        tls.Certificate
+	fingerprint string // hex SHA-256 of the server certificate's DER encoding
+	routes      RouteConfig
+}
+
+// Watcher holds the live, hot-reloadable TLS material for one mTLS server.
+//
+// Construct with New, which performs the first Reload synchronously so a
+// Watcher is always immediately usable. Run the returned Watcher's Watch
+// method in its own goroutine to pick up subsequent file changes.
+type Watcher struct {
+	sources Sources
+	current atomic.Value // *state
+
+	fsw *fsnotify.Watcher
+}
+
+// New builds a Watcher over sources, performing an initial synchronous
+// Reload so the returned Watcher is immediately usable even before Watch
+// starts picking up later file changes.
+//
+// Returns error if the initial Reload fails or the underlying fsnotify
+// watcher cannot be created.
+func New(sources Sources) (*Watcher, error) {
+	w := &Watcher{sources: sources}
+	if _, err := w.Reload(); err != nil {
+		return nil, err
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("certwatch: failed to create file watcher: %v", err)
+	}
+	for _, dir := range watchedDirs(sources) {
+		if err := fsw.Add(dir); err != nil {
+			fsw.Close()
+			return nil, fmt.Errorf("certwatch: failed to watch %s: %v", dir, err)
+		}
+	}
+	w.fsw = fsw
+
+	return w, nil
+}
+
+// watchedDirs returns the distinct parent directories of every configured
+// source file. fsnotify watches directories rather than files, since most
+// editors and cert-management tooling replace a file via rename rather than
+// writing it in place, an event a file-level watch would miss entirely.
+func watchedDirs(sources Sources) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, f := range []string{sources.CACertFile, sources.ServerCertFile, sources.ServerKeyFile, sources.RoutesFile} {
+		if f == "" {
+			continue
+		}
+		dir := filepath.Dir(f)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// Watch blocks forever, reloading on every fsnotify event that touches one
+// of Sources' files. Run it in its own goroutine, for the lifetime of the process.
+//
+// A reload failure (unreadable file, or the new certificate's SAN not
+// matching Sources.ServiceName) is logged and otherwise ignored: Watcher
+// keeps serving whatever state its last successful Reload produced.
+func (w *Watcher) Watch() {
+	defer w.fsw.Close()
+	for {
+		select {
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			if !w.relevant(event.Name) || event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			if fingerprint, err := w.Reload(); err != nil {
+				log.Printf("certwatch: reload failed, keeping previous TLS material: %v", err)
+			} else {
+				log.Printf("certwatch: reloaded TLS material for %q (fingerprint=%s)", w.sources.ServiceName, fingerprint)
+			}
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("certwatch: file watcher error: %v", err)
+		}
+	}
+}
+
+// relevant reports whether name refers to one of Sources' watched files.
+func (w *Watcher) relevant(name string) bool {
+	for _, f := range []string{w.sources.CACertFile, w.sources.ServerCertFile, w.sources.ServerKeyFile, w.sources.RoutesFile} {
+		if f != "" && filepath.Clean(name) == filepath.Clean(f) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reload re-reads every file in Sources and, if they all parse and the
+// server certificate's identity matches Sources.ServiceName, atomically
+// swaps them in as the state every subsequent GetCertificate/GetConfigForClient
+// call sees.
+//
+// Safety check: a reloaded ServerCertFile whose leaf doesn't carry a
+// "spiffe://ramusb/<ServiceName>" URI SAN is rejected without touching the
+// previously loaded state, so a leaf rotated into the wrong identity can
+// never get live - the service keeps serving its last-known-good certificate
+// and this error surfaces to the caller (e.g. POST /admin/reload's response).
+//
+// Returns the new certificate's fingerprint on success.
+func (w *Watcher) Reload() (string, error) {
+	caPEM, err := os.ReadFile(w.sources.CACertFile)
+	if err != nil {
+		return "", fmt.Errorf("read CA certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return "", fmt.Errorf("parse CA certificate")
+	}
+
+	cert, err := tls.LoadX509KeyPair(w.sources.ServerCertFile, w.sources.ServerKeyFile)
+	if err != nil {
+		return "", fmt.Errorf("load server certificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return "", fmt.Errorf("parse server certificate leaf: %v", err)
+	}
+
+	wantID := "spiffe://ramusb/" + w.sources.ServiceName
+	if id, ok := mtlsauth.SPIFFEID(leaf); !ok || id != wantID {
+		return "", fmt.Errorf("server certificate identity %q does not match expected %q, keeping previous certificate", id, wantID)
+	}
+
+	routes, err := loadRouteConfig(w.sources.RoutesFile)
+	if err != nil {
+		return "", fmt.Errorf("load routes file: %v", err)
+	}
+
+	fingerprint := fingerprintOf(leaf)
+	w.current.Store(&state{pool: pool, cert: cert, fingerprint: fingerprint, routes: routes})
+
+	return fingerprint, nil
+}
+
+// fingerprintOf returns the hex-encoded SHA-256 digest of leaf's DER
+// encoding, a stable value operators can compare against `openssl x509
+// -fingerprint -sha256` output to confirm a rollout picked up the intended certificate.
+func fingerprintOf(leaf *x509.Certificate) string {
+	sum := sha256.Sum256(leaf.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// state returns the most recently loaded state. Always safe to call once
+// New has returned successfully.
+func (w *Watcher) state() *state {
+	return w.current.Load().(*state)
+}
+
+// Fingerprint returns the currently live server certificate's fingerprint.
+func (w *Watcher) Fingerprint() string {
+	return w.state().fingerprint
+}
+
+// GetCertificate implements the tls.Config.GetCertificate signature,
+// returning whatever server certificate the most recent Reload produced.
+func (w *Watcher) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert := w.state().cert
+	return &cert, nil
+}
+
+// GetConfigForClient implements the tls.Config.GetConfigForClient signature.
+// A server's top-level tls.Config is built once and its ClientCAs field
+// never changes afterward, so reloading the CA pool requires handing back an
+// entirely new *tls.Config per handshake rather than mutating GetCertificate alone.
+func (w *Watcher) GetConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	s := w.state()
+	return &tls.Config{
+		GetCertificate: w.GetCertificate,
+		ClientCAs:      s.pool,
+		ClientAuth:     tls.RequireAndVerifyClientCert,
+		MinVersion:     tls.VersionTLS13,
+	}, nil
+}
@@ -0,0 +1,85 @@
+/*
+configtest loads and prints the resolved R.A.M.-U.S.B. configuration for a
+named service, so operators can dry-run a deployment's configuration (config
+file plus environment overrides) before pointing a real service at it.
+
+Usage:
+
+	configtest -service security-switch
+*/
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	ramusbconfig "ramusb/config"
+)
+
+func main() {
+	service := flag.String("service", "", "service name: entry-hub, security-switch, or database-vault")
+	flag.Parse()
+
+	if *service == "" {
+		fmt.Fprintln(os.Stderr, "configtest: -service is required")
+		os.Exit(2)
+	}
+
+	cfg, err := ramusbconfig.Load(*service)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "configtest: failed to load configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	// REDACTED RENDERING
+	// Never print secret material (encryption key, Vault credentials) to a terminal or log
+	redacted := redact(*cfg)
+	output, err := json.MarshalIndent(redacted, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "configtest: failed to render configuration: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(output))
+
+	// AGGREGATED VALIDATION
+	// Report every configuration problem found, not just the first
+	if err := ramusbconfig.Validate(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "\nconfigtest: configuration is invalid:\n%v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("\nconfigtest: configuration is valid")
+}
+
+// redact returns cfg with secret-bearing fields replaced by a fixed placeholder.
+func redact(cfg ramusbconfig.Config) ramusbconfig.Config {
+	const placeholder = "[REDACTED]"
+
+	if cfg.Encryption.Key != "" {
+		cfg.Encryption.Key = placeholder
+	}
+	if cfg.KeyProvider.VaultToken != "" {
+		cfg.KeyProvider.VaultToken = placeholder
+	}
+	if cfg.KeyProvider.VaultSecretID != "" {
+		cfg.KeyProvider.VaultSecretID = placeholder
+	}
+	if cfg.KeyProvider.AWSKMSCiphertext != "" {
+		cfg.KeyProvider.AWSKMSCiphertext = placeholder
+	}
+	if cfg.PublicTLS.VaultToken != "" {
+		cfg.PublicTLS.VaultToken = placeholder
+	}
+	if cfg.Session.SigningKey != "" {
+		cfg.Session.SigningKey = placeholder
+	}
+	if cfg.Database.URL != "" {
+		cfg.Database.URL = placeholder
+	}
+	if cfg.Database.MongoURI != "" {
+		cfg.Database.MongoURI = placeholder
+	}
+	return cfg
+}
@@ -0,0 +1,401 @@
+/*
+Shared layered configuration for R.A.M.-U.S.B. distributed services.
+
+Replaces the hardcoded Tailscale IPs, ports, and certificate paths previously
+duplicated across Entry-Hub, Security-Switch, and Database-Vault's own
+config.GetConfig() functions. Loads a single Config struct hierarchy from an
+optional HCL/YAML/JSON file with environment variable overrides and sane
+development defaults, via Viper, so every service resolves configuration the
+same way. Each service still exposes its own GetConfig(), which loads a
+Config from this module and maps it onto that service's existing field
+names, keeping this package the one place deployment-shaped configuration
+logic lives.
+*/
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+)
+
+// ServerConfig holds parameters for accepting authenticated inbound mTLS (or
+// plain HTTPS, for Entry-Hub) connections.
+type ServerConfig struct {
+	BindAddress     string `mapstructure:"bind_address"` // Host to listen on (the port is Port), Tailscale-scoped in production
+	Port            string `mapstructure:"port"`
+	CertFile        string `mapstructure:"cert_file"`
+	KeyFile         string `mapstructure:"key_file"`
+	CACertFile      string `mapstructure:"ca_cert_file"`      // Empty for Entry-Hub, which has no client certs to verify
+	HealthCheckPort string `mapstructure:"health_check_port"` // Database-Vault's plaintext /healthz liveness port; empty elsewhere
+	TLSMode         string `mapstructure:"tls_mode"`          // "mutual_tls" (default), "tls", or "disabled" - see ramusb/tlsprofile
+	RoutesFile      string `mapstructure:"routes_file"`       // JSON admin-SAN allowlist, hot-reloaded alongside certs - see ramusb/certwatch. Empty disables POST /admin/reload entirely
+	MaxBodyBytes    int64  `mapstructure:"max_body_bytes"`    // Request body ceiling enforced via http.MaxBytesReader, default 64 KiB
+}
+
+// ClientConfig holds parameters for outgoing mTLS connections to the next
+// hop in the distributed pipeline (Entry-Hub -> Security-Switch -> Database-Vault).
+type ClientConfig struct {
+	TargetAddress string `mapstructure:"target_address"` // Tailscale IP:port of the downstream service
+	CertFile      string `mapstructure:"cert_file"`
+	KeyFile       string `mapstructure:"key_file"`
+	CACertFile    string `mapstructure:"ca_cert_file"`
+	TLSMode       string `mapstructure:"tls_mode"` // "mutual_tls" (default), "tls", or "disabled" - see ramusb/tlsprofile
+}
+
+// DatabaseConfig holds Database-Vault's storage backend selection and
+// connection parameters. Driver selects which database-vault/storage/driver
+// backend is opened ("postgres", "sqlite", "bolt", or "mongo", default
+// "postgres"); the remaining fields are driver-specific and ignored by the
+// others - see database-vault/storage.StorageConfig, which this maps onto.
+type DatabaseConfig struct {
+	Driver string `mapstructure:"driver"` // "postgres" (default), "sqlite", "bolt", or "mongo"
+	URL    string `mapstructure:"url"`    // postgres: connection string with credentials
+
+	SQLitePath string `mapstructure:"sqlite_path"` // sqlite: path to the database file
+	BoltPath   string `mapstructure:"bolt_path"`   // bolt: path to the single-file embedded database
+
+	MongoURI      string `mapstructure:"mongo_uri"`      // mongo: connection URI with credentials
+	MongoDatabase string `mapstructure:"mongo_database"` // mongo: database name within the cluster
+}
+
+// EncryptionConfig holds Database-Vault's field-level encryption key material.
+type EncryptionConfig struct {
+	Key string `mapstructure:"key"` // Hex-encoded 32-byte AES-256 key. In envelope mode (WrappedDEKFile set) this wraps the DEK instead of being used directly - see database-vault/crypto.ConfiguredKeyWrapper
+
+	// WrappedDEKFile, when set, switches Database-Vault to envelope encryption:
+	// the field encryption key is unwrapped from this file via the
+	// RAMUSB_KEY_WRAPPER-selected KeyWrapper instead of read directly from Key.
+	// See database-vault/keyprovider.EnvelopeKeyProvider.
+	WrappedDEKFile string `mapstructure:"wrapped_dek_file"`
+}
+
+// RateLimitConfig holds a service's token-bucket rate limiter backend
+// selection, used by Entry-Hub's per-IP/per-account limiter and
+// Security-Switch's per-identity limiter alike.
+type RateLimitConfig struct {
+	Backend   string `mapstructure:"backend"` // "memory" or "redis"
+	RedisAddr string `mapstructure:"redis_addr"`
+}
+
+// KeyProviderConfig holds Security-Switch's pluggable encryption-key backend selection.
+type KeyProviderConfig struct {
+	Backend string `mapstructure:"backend"` // "env", "file", "vault", or "awskms"
+
+	EnvName string `mapstructure:"env_name"`
+	File    string `mapstructure:"file"`
+
+	VaultAddr     string `mapstructure:"vault_addr"`
+	VaultRoleID   string `mapstructure:"vault_role_id"`
+	VaultSecretID string `mapstructure:"vault_secret_id"`
+	VaultToken    string `mapstructure:"vault_token"`
+	VaultKVMount  string `mapstructure:"vault_kv_mount"`
+	VaultKVPath   string `mapstructure:"vault_kv_path"`
+
+	AWSKMSKeyID      string `mapstructure:"aws_kms_key_id"`
+	AWSKMSCiphertext string `mapstructure:"aws_kms_ciphertext"`
+}
+
+// PublicTLSConfig holds Entry-Hub's Let's-Encrypt-managed public-facing TLS
+// parameters. Internal service-to-service hops never use this: they keep
+// using Client/Server's private-CA mTLS material.
+type PublicTLSConfig struct {
+	Enabled       bool     `mapstructure:"enabled"`
+	HostWhitelist []string `mapstructure:"host_whitelist"` // Exact hostnames autocert is allowed to request certs for
+	Email         string   `mapstructure:"email"`          // Contact address registered with the ACME account
+	Staging       bool     `mapstructure:"staging"`        // Use Let's Encrypt's staging directory to avoid production rate limits
+
+	CacheBackend string `mapstructure:"cache_backend"` // "disk", "s3", or "vault"
+	CacheDir     string `mapstructure:"cache_dir"`     // Used when CacheBackend is "disk"
+
+	S3Bucket string `mapstructure:"s3_bucket"` // Used when CacheBackend is "s3"
+	S3Region string `mapstructure:"s3_region"`
+
+	VaultAddr    string `mapstructure:"vault_addr"` // Used when CacheBackend is "vault"
+	VaultToken   string `mapstructure:"vault_token"`
+	VaultKVMount string `mapstructure:"vault_kv_mount"`
+	VaultKVPath  string `mapstructure:"vault_kv_path"`
+}
+
+// SSHCAConfig holds Database-Vault's OpenSSH user certificate authority
+// parameters, used to sign short-lived certificates in place of accepting a
+// client's raw public key as a long-lived credential (see ramusb/sshca).
+type SSHCAConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	SigningKeyFile string        `mapstructure:"signing_key_file"` // PEM-encoded SSH CA private key
+	TTL            time.Duration `mapstructure:"ttl"`              // Certificate validity window
+}
+
+// UserCAConfig holds Database-Vault's x509 user-certificate authority
+// parameters, distinct from SSHCAConfig: this CA signs short-lived mTLS
+// client certificates over a CSR a user submits via POST /api/issue-cert,
+// for Storage-Service authentication, rather than an OpenSSH certificate
+// over an uploaded public key (see database-vault/pki).
+type UserCAConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	CertFile string        `mapstructure:"cert_file"` // PEM-encoded user-CA certificate
+	KeyFile  string        `mapstructure:"key_file"`  // PEM-encoded user-CA private key
+	TTL      time.Duration `mapstructure:"ttl"`       // Certificate validity window
+}
+
+// SSHTrustStoreConfig holds Database-Vault's known_hosts-style trust store
+// parameters, used during SSH key ingestion to reject revoked keys and to
+// validate certificates against trusted @cert-authority entries (see
+// ramusb/database-vault/utils/knownhosts).
+type SSHTrustStoreConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Path    string `mapstructure:"path"` // known_hosts-format trust store file
+}
+
+// SessionConfig holds Database-Vault's login session-token signing
+// parameters, used by LoginUserHandler to issue a short-lived JWT that
+// Security-Switch and Storage-Service can later validate (see
+// database-vault/session).
+type SessionConfig struct {
+	SigningKey string        `mapstructure:"signing_key"` // Hex-encoded HMAC-SHA256 signing key for session JWTs
+	TTL        time.Duration `mapstructure:"ttl"`         // Session token validity window
+}
+
+// WorkloadConfig holds Entry-Hub's cloud workload identity verifier
+// configuration for POST /api/register/workload (see ramusb/workloadid). A
+// provider is active only once its own required fields are populated, so a
+// deployment can enable just the clouds it actually runs on.
+type WorkloadConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	AzureTenantID              string   `mapstructure:"azure_tenant_id"`
+	AzureAudience              string   `mapstructure:"azure_audience"`
+	AzureAllowedSubscriptions  []string `mapstructure:"azure_allowed_subscriptions"`
+	AzureAllowedResourceGroups []string `mapstructure:"azure_allowed_resource_groups"` // Regex patterns
+
+	AWSSigningCertFile   string   `mapstructure:"aws_signing_cert_file"`
+	AWSAllowedAccountIDs []string `mapstructure:"aws_allowed_account_ids"`
+	AWSAllowedRegions    []string `mapstructure:"aws_allowed_regions"`
+
+	GCPAudience          string   `mapstructure:"gcp_audience"`
+	GCPAllowedProjectIDs []string `mapstructure:"gcp_allowed_project_ids"`
+}
+
+// MTLSAuthzConfig holds Security-Switch and Database-Vault's client
+// certificate authorization policy for middleware.VerifyMTLS, replacing the
+// hardcoded Subject.Organization check each service used to perform inline
+// (see ramusb/mtlsauth).
+type MTLSAuthzConfig struct {
+	Mode          string   `mapstructure:"mode"`           // "org" (default), "spiffe", or "san"
+	SPIFFEAllowed []string `mapstructure:"spiffe_allowed"` // e.g. "spiffe://ramusb.local/security-switch/*", used when mode is "spiffe"
+
+	SANAllowedDNS  []string `mapstructure:"san_allowed_dns"`  // e.g. "*.security-switch.ramusb.internal", used when mode is "san"
+	SANAllowedURIs []string `mapstructure:"san_allowed_uris"` // e.g. "spiffe://ramusb.local/security-switch/*", used when mode is "san"
+
+	RevocationEnabled   bool     `mapstructure:"revocation_enabled"`
+	IssuerCertFile      string   `mapstructure:"issuer_cert_file"`      // Issuing CA certificate, needed to verify OCSP responses
+	CRLDistributionURLs []string `mapstructure:"crl_distribution_urls"` // Fallback CRLs, consulted when OCSP is unreachable
+
+	// StoreUserIdentities, when non-empty, layers a per-route identity check
+	// (see middleware.RequireIdentity) on top of the process-wide policy
+	// above, narrowing /api/store-user to these SPIFFE IDs or CommonNames
+	// specifically even when Mode authorizes a broader set of callers.
+	StoreUserIdentities []string `mapstructure:"store_user_identities"`
+
+	// StoreUserFingerprints, when non-empty, additionally pins /api/store-user
+	// to these exact hex-encoded SHA-256 certificate fingerprints (see
+	// middleware.RequireFingerprint) - the stricter mode that survives even a
+	// same-identity reissued certificate not being the intended one.
+	StoreUserFingerprints []string `mapstructure:"store_user_fingerprints"`
+}
+
+// OPAConfig holds Security-Switch's forward-auth OPA sidecar parameters,
+// used by middleware.ForwardAuth to delegate the actual allow/deny decision
+// to OPA instead of leaving it hardcoded in VerifyMTLS.
+type OPAConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	TargetAddress string `mapstructure:"target_address"` // Tailscale IP:port of the OPA sidecar
+	CertFile      string `mapstructure:"cert_file"`
+	KeyFile       string `mapstructure:"key_file"`
+	CACertFile    string `mapstructure:"ca_cert_file"`
+	TLSMode       string `mapstructure:"tls_mode"` // "mutual_tls" (default), "tls", or "disabled" - see ramusb/tlsprofile
+
+	CacheSize int           `mapstructure:"cache_size"` // Max decisions held in ForwardAuth's LRU cache
+	CacheTTL  time.Duration `mapstructure:"cache_ttl"`  // How long a cached allow/deny decision is trusted
+}
+
+// PasswordPolicyConfig holds Entry-Hub's and Security-Switch's weak-password
+// detection parameters, used by utils.PasswordValidator to decide between the
+// Have I Been Pwned range API (utils.HTTPPwnedChecker) and the static
+// breached-password list (utils.StaticPwnedChecker).
+type PasswordPolicyConfig struct {
+	PwnedCheckEnabled    bool          `mapstructure:"pwned_check_enabled"`     // false falls back to the static list alone
+	PwnedCheckFailClosed bool          `mapstructure:"pwned_check_fail_closed"` // true rejects a password outright when the HIBP API is unreachable, instead of admitting it unchecked
+	PwnedCacheSize       int           `mapstructure:"pwned_cache_size"`        // Max range-response prefixes held in the LRU cache
+	PwnedCacheTTL        time.Duration `mapstructure:"pwned_cache_ttl"`         // How long a cached range response is trusted
+}
+
+// Config is the full layered configuration hierarchy shared by every R.A.M.-U.S.B. service.
+//
+// Security features:
+// - Every field resolves through Viper's layered precedence (explicit config file, then RAMUSB_* env var, then default), so no secret need be hardcoded in source
+// - Validate walks the whole struct and reports every problem at once, rather than failing on the first bad field
+//
+// Not every service populates every section: Entry-Hub leaves Database,
+// Encryption, and KeyProvider zero-valued, for example. Load with
+// Load(serviceName), then call Validate before use.
+type Config struct {
+	Server         ServerConfig         `mapstructure:"server"`
+	Client         ClientConfig         `mapstructure:"client"`
+	Database       DatabaseConfig       `mapstructure:"database"`
+	Encryption     EncryptionConfig     `mapstructure:"encryption"`
+	RateLimit      RateLimitConfig      `mapstructure:"rate_limit"`
+	KeyProvider    KeyProviderConfig    `mapstructure:"key_provider"`
+	PublicTLS      PublicTLSConfig      `mapstructure:"public_tls"`
+	SSHCA          SSHCAConfig          `mapstructure:"ssh_ca"`
+	SSHTrustStore  SSHTrustStoreConfig  `mapstructure:"ssh_trust_store"`
+	UserCA         UserCAConfig         `mapstructure:"user_ca"`
+	Session        SessionConfig        `mapstructure:"session"`
+	Workload       WorkloadConfig       `mapstructure:"workload"`
+	MTLSAuthz      MTLSAuthzConfig      `mapstructure:"mtls_authz"`
+	OPA            OPAConfig            `mapstructure:"opa"`
+	PasswordPolicy PasswordPolicyConfig `mapstructure:"password_policy"`
+}
+
+// Load resolves a Config for serviceName ("entry-hub", "security-switch", or
+// "database-vault") by layering, in increasing priority: this package's
+// defaults, an optional config file (HCL/YAML/JSON, discovered by Viper or
+// pointed to explicitly via RAMUSB_CONFIG_FILE), and RAMUSB_* environment
+// variable overrides.
+//
+// Security features:
+// - Environment variables always win over file contents, so an orchestrator-injected secret (e.g. RAMUSB_ENCRYPTION_KEY) cannot be shadowed by a checked-in config file
+//
+// Returns the resolved Config, or error if an explicitly configured config
+// file exists but cannot be parsed.
+func Load(serviceName string) (*Config, error) {
+	v := viper.New()
+
+	applyDefaults(v, serviceName)
+
+	v.SetEnvPrefix("RAMUSB")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	bindEnvOverrides(v)
+
+	if configFile := v.GetString("config_file"); configFile != "" {
+		v.SetConfigFile(configFile)
+	} else {
+		v.SetConfigName("config")
+		v.AddConfigPath(".")
+		v.AddConfigPath("./config")
+		v.AddConfigPath("/etc/ramusb")
+	}
+
+	if err := v.ReadInConfig(); err != nil {
+		if _, notFound := err.(viper.ConfigFileNotFoundError); !notFound {
+			return nil, fmt.Errorf("failed to read config file: %v", err)
+		}
+		// No config file present - defaults and env vars alone are a valid configuration.
+	}
+
+	var cfg Config
+	decodeHook := mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToSliceHookFunc(","), // RAMUSB_PUBLIC_TLS_HOST_WHITELIST, etc. arrive as comma-separated env strings
+		mapstructure.StringToTimeDurationHookFunc(),
+	)
+	if err := v.Unmarshal(&cfg, viper.DecodeHook(decodeHook)); err != nil {
+		return nil, fmt.Errorf("failed to decode configuration: %v", err)
+	}
+
+	return &cfg, nil
+}
+
+// bindEnvOverrides explicitly binds the RAMUSB_* environment variable names
+// operators already use (e.g. RAMUSB_SERVER_PORT), since Viper's
+// AutomaticEnv only resolves env vars looked up by key, not ones nested
+// under Unmarshal targets it never queries directly.
+func bindEnvOverrides(v *viper.Viper) {
+	bindings := map[string]string{
+		"server.bind_address":      "RAMUSB_SERVER_BIND_ADDRESS",
+		"server.port":              "RAMUSB_SERVER_PORT",
+		"server.cert_file":         "RAMUSB_SERVER_CERT_FILE",
+		"server.key_file":          "RAMUSB_SERVER_KEY_FILE",
+		"server.ca_cert_file":      "RAMUSB_SERVER_CA_CERT_FILE",
+		"server.health_check_port": "RAMUSB_SERVER_HEALTH_CHECK_PORT",
+		"server.tls_mode":          "RAMUSB_SERVER_TLS_MODE",
+		"server.routes_file":       "RAMUSB_SERVER_ROUTES_FILE",
+		"server.max_body_bytes":    "RAMUSB_SERVER_MAX_BODY_BYTES",
+
+		"client.target_address": "RAMUSB_CLIENT_TARGET_ADDRESS",
+		"client.cert_file":      "RAMUSB_CLIENT_CERT_FILE",
+		"client.key_file":       "RAMUSB_CLIENT_KEY_FILE",
+		"client.ca_cert_file":   "RAMUSB_CLIENT_CA_CERT_FILE",
+		"client.tls_mode":       "RAMUSB_CLIENT_TLS_MODE",
+
+		"database.url": "RAMUSB_DATABASE_URL",
+
+		"encryption.key": "RAMUSB_ENCRYPTION_KEY",
+
+		"rate_limit.backend":    "RAMUSB_RATE_LIMIT_BACKEND",
+		"rate_limit.redis_addr": "RAMUSB_RATE_LIMIT_REDIS_ADDR",
+
+		"key_provider.backend":            "RAMUSB_KEY_PROVIDER",
+		"key_provider.env_name":           "RAMUSB_KEY_PROVIDER_ENV_NAME",
+		"key_provider.file":               "RAMUSB_KEY_FILE",
+		"key_provider.vault_addr":         "RAMUSB_VAULT_ADDR",
+		"key_provider.vault_role_id":      "RAMUSB_VAULT_ROLE_ID",
+		"key_provider.vault_secret_id":    "RAMUSB_VAULT_SECRET_ID",
+		"key_provider.vault_token":        "RAMUSB_VAULT_TOKEN",
+		"key_provider.vault_kv_mount":     "RAMUSB_VAULT_KV_MOUNT",
+		"key_provider.vault_kv_path":      "RAMUSB_VAULT_KV_PATH",
+		"key_provider.aws_kms_key_id":     "RAMUSB_AWS_KMS_KEY_ID",
+		"key_provider.aws_kms_ciphertext": "RAMUSB_AWS_KMS_CIPHERTEXT",
+
+		"public_tls.enabled":        "RAMUSB_PUBLIC_TLS_ENABLED",
+		"public_tls.host_whitelist": "RAMUSB_PUBLIC_TLS_HOST_WHITELIST",
+		"public_tls.email":          "RAMUSB_PUBLIC_TLS_EMAIL",
+		"public_tls.staging":        "RAMUSB_PUBLIC_TLS_STAGING",
+		"public_tls.cache_backend":  "RAMUSB_PUBLIC_TLS_CACHE_BACKEND",
+		"public_tls.cache_dir":      "RAMUSB_PUBLIC_TLS_CACHE_DIR",
+		"public_tls.s3_bucket":      "RAMUSB_PUBLIC_TLS_S3_BUCKET",
+		"public_tls.s3_region":      "RAMUSB_PUBLIC_TLS_S3_REGION",
+		"public_tls.vault_addr":     "RAMUSB_PUBLIC_TLS_VAULT_ADDR",
+		"public_tls.vault_token":    "RAMUSB_PUBLIC_TLS_VAULT_TOKEN",
+		"public_tls.vault_kv_mount": "RAMUSB_PUBLIC_TLS_VAULT_KV_MOUNT",
+		"public_tls.vault_kv_path":  "RAMUSB_PUBLIC_TLS_VAULT_KV_PATH",
+
+		"ssh_ca.enabled":          "RAMUSB_SSH_CA_ENABLED",
+		"ssh_ca.signing_key_file": "RAMUSB_SSH_CA_SIGNING_KEY_FILE",
+		"ssh_ca.ttl":              "RAMUSB_SSH_CA_TTL",
+
+		"ssh_trust_store.enabled": "RAMUSB_SSH_TRUST_STORE_ENABLED",
+		"ssh_trust_store.path":    "RAMUSB_SSH_TRUST_STORE_PATH",
+
+		"user_ca.enabled":   "RAMUSB_USER_CA_ENABLED",
+		"user_ca.cert_file": "RAMUSB_USER_CA_CERT_FILE",
+		"user_ca.key_file":  "RAMUSB_USER_CA_KEY_FILE",
+		"user_ca.ttl":       "RAMUSB_USER_CA_TTL",
+
+		"session.signing_key": "RAMUSB_SESSION_SIGNING_KEY",
+
+		"workload.enabled":                       "RAMUSB_WORKLOAD_ENABLED",
+		"workload.azure_tenant_id":               "RAMUSB_WORKLOAD_AZURE_TENANT_ID",
+		"workload.azure_audience":                "RAMUSB_WORKLOAD_AZURE_AUDIENCE",
+		"workload.azure_allowed_subscriptions":   "RAMUSB_WORKLOAD_AZURE_ALLOWED_SUBSCRIPTIONS",
+		"workload.azure_allowed_resource_groups": "RAMUSB_WORKLOAD_AZURE_ALLOWED_RESOURCE_GROUPS",
+		"workload.aws_signing_cert_file":         "RAMUSB_WORKLOAD_AWS_SIGNING_CERT_FILE",
+		"workload.aws_allowed_account_ids":       "RAMUSB_WORKLOAD_AWS_ALLOWED_ACCOUNT_IDS",
+		"workload.aws_allowed_regions":           "RAMUSB_WORKLOAD_AWS_ALLOWED_REGIONS",
+		"workload.gcp_audience":                  "RAMUSB_WORKLOAD_GCP_AUDIENCE",
+		"workload.gcp_allowed_project_ids":       "RAMUSB_WORKLOAD_GCP_ALLOWED_PROJECT_IDS",
+
+		"password_policy.pwned_check_enabled":     "RAMUSB_PASSWORD_POLICY_PWNED_CHECK_ENABLED",
+		"password_policy.pwned_check_fail_closed": "RAMUSB_PASSWORD_POLICY_PWNED_CHECK_FAIL_CLOSED",
+		"password_policy.pwned_cache_size":        "RAMUSB_PASSWORD_POLICY_PWNED_CACHE_SIZE",
+		"password_policy.pwned_cache_ttl":         "RAMUSB_PASSWORD_POLICY_PWNED_CACHE_TTL",
+	}
+
+	for key, env := range bindings {
+		// Viper's BindEnv error only reports a programmer mistake (empty key/env), never a runtime condition.
+		_ = v.BindEnv(key, env)
+	}
+}
@@ -0,0 +1,434 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+
+	"ramusb/tlsprofile"
+)
+
+// resolveTimeout bounds how long Validate waits for each Tailscale address
+// resolution check, so a stalled DNS lookup cannot hang a config dry-run.
+const resolveTimeout = 2 * time.Second
+
+// Validate walks every section of Config and collects every problem found,
+// rather than stopping at the first one, so an operator fixing a deployment
+// sees the complete list in one pass.
+//
+// Security features:
+// - Treats a missing or unreadable certificate/key file as a reportable error rather than deferring the failure to the TLS handshake at request time
+// - Confirms the encryption key, if set, is exactly 32 bytes of valid hex before Database-Vault ever attempts to use it for AES-256-GCM
+//
+// Returns nil if every populated field is valid, or a *multierror.Error
+// (via errors.As) enumerating every problem found. Zero-valued sections
+// (e.g. Database on Entry-Hub) are skipped, since not every service
+// populates every section.
+func Validate(cfg *Config) error {
+	var result *multierror.Error
+
+	validateServer(cfg.Server, &result)
+	validateClient(cfg.Client, &result)
+	validateDatabase(cfg.Database, &result)
+	validateEncryption(cfg.Encryption, &result)
+	validateRateLimit(cfg.RateLimit, &result)
+	validateKeyProvider(cfg.KeyProvider, &result)
+	validatePublicTLS(cfg.PublicTLS, &result)
+	validateSSHCA(cfg.SSHCA, &result)
+	validateSSHTrustStore(cfg.SSHTrustStore, &result)
+	validateUserCA(cfg.UserCA, &result)
+	validateSession(cfg.Session, &result)
+	validateWorkload(cfg.Workload, &result)
+	validateMTLSAuthz(cfg.MTLSAuthz, &result)
+	validateOPA(cfg.OPA, &result)
+	validatePasswordPolicy(cfg.PasswordPolicy, &result)
+
+	return result.ErrorOrNil()
+}
+
+// validateServer checks certificate readability and address resolvability for ServerConfig.
+func validateServer(s ServerConfig, result **multierror.Error) {
+	if s.Port == "" {
+		return // Section not populated by this service
+	}
+	checkTLSMode(s.TLSMode, "server.tls_mode", result)
+	if s.TLSMode != tlsprofile.Disabled.String() {
+		checkReadableFile(s.CertFile, "server.cert_file", result)
+		checkReadableFile(s.KeyFile, "server.key_file", result)
+		if s.CACertFile != "" {
+			checkReadableFile(s.CACertFile, "server.ca_cert_file", result)
+		}
+	}
+	if s.RoutesFile != "" {
+		checkReadableFile(s.RoutesFile, "server.routes_file", result)
+	}
+	if s.MaxBodyBytes <= 0 {
+		*result = multierror.Append(*result, fmt.Errorf("server.max_body_bytes: must be positive, got %d", s.MaxBodyBytes))
+	}
+	if s.BindAddress != "" {
+		checkResolvable(net.JoinHostPort(s.BindAddress, s.Port), "server.bind_address", result)
+	}
+}
+
+// validateClient checks certificate readability and address resolvability for ClientConfig.
+func validateClient(c ClientConfig, result **multierror.Error) {
+	if c.TargetAddress == "" {
+		return // Section not populated by this service
+	}
+	checkTLSMode(c.TLSMode, "client.tls_mode", result)
+	checkResolvable(c.TargetAddress, "client.target_address", result)
+	mode, _ := tlsprofile.ParseMode(c.TLSMode) // An empty tls_mode selects mutual_tls
+	if mode != tlsprofile.Disabled {
+		if mode == tlsprofile.MutualTLS {
+			checkReadableFile(c.CertFile, "client.cert_file", result)
+			checkReadableFile(c.KeyFile, "client.key_file", result)
+		}
+		if c.CACertFile != "" {
+			checkReadableFile(c.CACertFile, "client.ca_cert_file", result)
+		}
+	}
+}
+
+// validateEncryption checks that a populated encryption key decodes to exactly
+// 32 bytes, and that a configured wrapped DEK file is readable.
+func validateEncryption(e EncryptionConfig, result **multierror.Error) {
+	if e.WrappedDEKFile != "" {
+		checkReadableFile(e.WrappedDEKFile, "encryption.wrapped_dek_file", result)
+	}
+
+	if e.Key == "" {
+		return // Database-Vault requires this unless WrappedDEKFile is set; Entry-Hub and Security-Switch leave it unset
+	}
+	key, err := hex.DecodeString(e.Key)
+	if err != nil {
+		*result = multierror.Append(*result, fmt.Errorf("encryption.key: invalid hex encoding: %v", err))
+		return
+	}
+	if len(key) != 32 {
+		*result = multierror.Append(*result, fmt.Errorf("encryption.key: AES-256 requires 32 bytes, got %d", len(key)))
+	}
+}
+
+// validateDatabase checks that the selected storage driver has the
+// connection fields it needs; an empty Driver defaults to "postgres" in
+// database-vault/config.storageConfig, so it is accepted here too.
+func validateDatabase(d DatabaseConfig, result **multierror.Error) {
+	switch d.Driver {
+	case "", "postgres":
+		return
+	case "sqlite":
+		if d.SQLitePath == "" {
+			*result = multierror.Append(*result, fmt.Errorf("database.sqlite_path: required when database.driver is \"sqlite\""))
+		}
+	case "bolt":
+		if d.BoltPath == "" {
+			*result = multierror.Append(*result, fmt.Errorf("database.bolt_path: required when database.driver is \"bolt\""))
+		}
+	case "mongo":
+		if d.MongoURI == "" {
+			*result = multierror.Append(*result, fmt.Errorf("database.mongo_uri: required when database.driver is \"mongo\""))
+		}
+		if d.MongoDatabase == "" {
+			*result = multierror.Append(*result, fmt.Errorf("database.mongo_database: required when database.driver is \"mongo\""))
+		}
+	default:
+		*result = multierror.Append(*result, fmt.Errorf("database.driver: unknown driver %q, expected \"postgres\", \"sqlite\", \"bolt\", or \"mongo\"", d.Driver))
+	}
+}
+
+// validateRateLimit checks that a configured rate limit backend is one this repo implements.
+func validateRateLimit(r RateLimitConfig, result **multierror.Error) {
+	switch r.Backend {
+	case "", "memory":
+		return
+	case "redis":
+		if r.RedisAddr == "" {
+			*result = multierror.Append(*result, fmt.Errorf("rate_limit.redis_addr: required when rate_limit.backend is \"redis\""))
+			return
+		}
+		checkResolvable(r.RedisAddr, "rate_limit.redis_addr", result)
+	default:
+		*result = multierror.Append(*result, fmt.Errorf("rate_limit.backend: unknown backend %q, expected \"memory\" or \"redis\"", r.Backend))
+	}
+}
+
+// validateKeyProvider checks that the selected key provider backend has the fields it needs.
+func validateKeyProvider(k KeyProviderConfig, result **multierror.Error) {
+	switch k.Backend {
+	case "", "env":
+		return
+	case "file":
+		if k.File == "" {
+			*result = multierror.Append(*result, fmt.Errorf("key_provider.file: required when key_provider.backend is \"file\""))
+			return
+		}
+		checkReadableFile(k.File, "key_provider.file", result)
+	case "vault":
+		if k.VaultAddr == "" {
+			*result = multierror.Append(*result, fmt.Errorf("key_provider.vault_addr: required when key_provider.backend is \"vault\""))
+		}
+		if k.VaultKVPath == "" {
+			*result = multierror.Append(*result, fmt.Errorf("key_provider.vault_kv_path: required when key_provider.backend is \"vault\""))
+		}
+		hasAppRole := k.VaultRoleID != "" && k.VaultSecretID != ""
+		if !hasAppRole && k.VaultToken == "" {
+			*result = multierror.Append(*result, fmt.Errorf("key_provider: vault backend needs either vault_role_id+vault_secret_id or vault_token"))
+		}
+	case "awskms":
+		if k.AWSKMSCiphertext == "" {
+			*result = multierror.Append(*result, fmt.Errorf("key_provider.aws_kms_ciphertext: required when key_provider.backend is \"awskms\""))
+		}
+	default:
+		*result = multierror.Append(*result, fmt.Errorf("key_provider.backend: unknown backend %q, expected \"env\", \"file\", \"vault\", or \"awskms\"", k.Backend))
+	}
+}
+
+// validatePublicTLS checks that an enabled PublicTLSConfig has the fields its selected cache backend needs.
+func validatePublicTLS(p PublicTLSConfig, result **multierror.Error) {
+	if !p.Enabled {
+		return
+	}
+	if len(p.HostWhitelist) == 0 {
+		*result = multierror.Append(*result, fmt.Errorf("public_tls.host_whitelist: required when public_tls.enabled is true"))
+	}
+	if p.Email == "" {
+		*result = multierror.Append(*result, fmt.Errorf("public_tls.email: required when public_tls.enabled is true"))
+	}
+	switch p.CacheBackend {
+	case "disk":
+		if p.CacheDir == "" {
+			*result = multierror.Append(*result, fmt.Errorf("public_tls.cache_dir: required when public_tls.cache_backend is \"disk\""))
+		}
+	case "s3":
+		if p.S3Bucket == "" {
+			*result = multierror.Append(*result, fmt.Errorf("public_tls.s3_bucket: required when public_tls.cache_backend is \"s3\""))
+		}
+	case "vault":
+		if p.VaultAddr == "" {
+			*result = multierror.Append(*result, fmt.Errorf("public_tls.vault_addr: required when public_tls.cache_backend is \"vault\""))
+		}
+		if p.VaultKVPath == "" {
+			*result = multierror.Append(*result, fmt.Errorf("public_tls.vault_kv_path: required when public_tls.cache_backend is \"vault\""))
+		}
+		if p.VaultToken == "" {
+			*result = multierror.Append(*result, fmt.Errorf("public_tls.vault_token: required when public_tls.cache_backend is \"vault\""))
+		}
+	default:
+		*result = multierror.Append(*result, fmt.Errorf("public_tls.cache_backend: unknown backend %q, expected \"disk\", \"s3\", or \"vault\"", p.CacheBackend))
+	}
+}
+
+// validateSSHCA checks that an enabled SSHCAConfig has a readable signing key and a positive TTL.
+func validateSSHCA(s SSHCAConfig, result **multierror.Error) {
+	if !s.Enabled {
+		return
+	}
+	checkReadableFile(s.SigningKeyFile, "ssh_ca.signing_key_file", result)
+	if s.TTL <= 0 {
+		*result = multierror.Append(*result, fmt.Errorf("ssh_ca.ttl: must be positive when ssh_ca.enabled is true"))
+	}
+}
+
+// validateSSHTrustStore checks that an enabled SSHTrustStoreConfig points at
+// a readable known_hosts-format file.
+func validateSSHTrustStore(s SSHTrustStoreConfig, result **multierror.Error) {
+	if !s.Enabled {
+		return
+	}
+	checkReadableFile(s.Path, "ssh_trust_store.path", result)
+}
+
+// validateUserCA checks that an enabled UserCAConfig has a readable
+// certificate and key and a positive TTL.
+func validateUserCA(u UserCAConfig, result **multierror.Error) {
+	if !u.Enabled {
+		return
+	}
+	checkReadableFile(u.CertFile, "user_ca.cert_file", result)
+	checkReadableFile(u.KeyFile, "user_ca.key_file", result)
+	if u.TTL <= 0 {
+		*result = multierror.Append(*result, fmt.Errorf("user_ca.ttl: must be positive when user_ca.enabled is true"))
+	}
+}
+
+// validateSession checks that a populated SessionConfig's signing key is
+// valid hex and that TTL is positive. An empty SigningKey is accepted here
+// (Entry-Hub and Security-Switch never populate it) - LoginUserHandler
+// itself fails closed if asked to issue a token with no key configured.
+func validateSession(s SessionConfig, result **multierror.Error) {
+	if s.SigningKey != "" {
+		if _, err := hex.DecodeString(s.SigningKey); err != nil {
+			*result = multierror.Append(*result, fmt.Errorf("session.signing_key: invalid hex encoding: %v", err))
+		}
+	}
+	if s.TTL < 0 {
+		*result = multierror.Append(*result, fmt.Errorf("session.ttl: must not be negative"))
+	}
+}
+
+// validateWorkload checks that an enabled WorkloadConfig has at least one
+// cloud provider configured, and that each populated provider has the fields
+// it needs. A provider is considered configured by the presence of its
+// tenant/account-scoping field, so a deployment can enable only the clouds it
+// actually runs on.
+func validateWorkload(w WorkloadConfig, result **multierror.Error) {
+	if !w.Enabled {
+		return
+	}
+
+	azureConfigured := w.AzureTenantID != ""
+	awsConfigured := w.AWSSigningCertFile != ""
+	gcpConfigured := w.GCPAudience != ""
+	if !azureConfigured && !awsConfigured && !gcpConfigured {
+		*result = multierror.Append(*result, fmt.Errorf("workload: at least one of azure_tenant_id, aws_signing_cert_file, or gcp_audience is required when workload.enabled is true"))
+		return
+	}
+
+	if azureConfigured {
+		if w.AzureAudience == "" {
+			*result = multierror.Append(*result, fmt.Errorf("workload.azure_audience: required when workload.azure_tenant_id is set"))
+		}
+		if len(w.AzureAllowedSubscriptions) == 0 {
+			*result = multierror.Append(*result, fmt.Errorf("workload.azure_allowed_subscriptions: required when workload.azure_tenant_id is set"))
+		}
+		for _, pattern := range w.AzureAllowedResourceGroups {
+			if _, err := regexp.Compile(pattern); err != nil {
+				*result = multierror.Append(*result, fmt.Errorf("workload.azure_allowed_resource_groups: invalid pattern %q: %v", pattern, err))
+			}
+		}
+	}
+
+	if awsConfigured {
+		checkReadableFile(w.AWSSigningCertFile, "workload.aws_signing_cert_file", result)
+		if len(w.AWSAllowedAccountIDs) == 0 {
+			*result = multierror.Append(*result, fmt.Errorf("workload.aws_allowed_account_ids: required when workload.aws_signing_cert_file is set"))
+		}
+		if len(w.AWSAllowedRegions) == 0 {
+			*result = multierror.Append(*result, fmt.Errorf("workload.aws_allowed_regions: required when workload.aws_signing_cert_file is set"))
+		}
+	}
+
+	if gcpConfigured && len(w.GCPAllowedProjectIDs) == 0 {
+		*result = multierror.Append(*result, fmt.Errorf("workload.gcp_allowed_project_ids: required when workload.gcp_audience is set"))
+	}
+}
+
+// validateMTLSAuthz checks that mode is recognized, that "spiffe" mode has
+// an allowlist to match against, and that an enabled revocation check has an
+// issuer certificate to verify OCSP responses against.
+func validateMTLSAuthz(m MTLSAuthzConfig, result **multierror.Error) {
+	switch m.Mode {
+	case "", "org":
+	case "spiffe":
+		if len(m.SPIFFEAllowed) == 0 {
+			*result = multierror.Append(*result, fmt.Errorf("mtls_authz.spiffe_allowed: required when mtls_authz.mode is \"spiffe\""))
+		}
+	case "san":
+		if len(m.SANAllowedDNS) == 0 && len(m.SANAllowedURIs) == 0 {
+			*result = multierror.Append(*result, fmt.Errorf("mtls_authz.san_allowed_dns or mtls_authz.san_allowed_uris: at least one is required when mtls_authz.mode is \"san\""))
+		}
+	default:
+		*result = multierror.Append(*result, fmt.Errorf("mtls_authz.mode: unknown mode %q, expected \"org\", \"spiffe\", or \"san\"", m.Mode))
+	}
+
+	for _, fp := range m.StoreUserFingerprints {
+		raw, err := hex.DecodeString(fp)
+		if err != nil || len(raw) != sha256.Size {
+			*result = multierror.Append(*result, fmt.Errorf("mtls_authz.store_user_fingerprints: %q is not a hex-encoded SHA-256 digest", fp))
+		}
+	}
+
+	if !m.RevocationEnabled {
+		return
+	}
+	checkReadableFile(m.IssuerCertFile, "mtls_authz.issuer_cert_file", result)
+}
+
+// validateOPA checks a populated OPA target's TLS material and cache bounds.
+func validateOPA(o OPAConfig, result **multierror.Error) {
+	if !o.Enabled {
+		return
+	}
+	checkTLSMode(o.TLSMode, "opa.tls_mode", result)
+	checkResolvable(o.TargetAddress, "opa.target_address", result)
+	if o.TLSMode != tlsprofile.Disabled.String() {
+		if o.TLSMode == tlsprofile.MutualTLS.String() {
+			checkReadableFile(o.CertFile, "opa.cert_file", result)
+			checkReadableFile(o.KeyFile, "opa.key_file", result)
+		}
+		if o.CACertFile != "" {
+			checkReadableFile(o.CACertFile, "opa.ca_cert_file", result)
+		}
+	}
+	if o.CacheSize <= 0 {
+		*result = multierror.Append(*result, fmt.Errorf("opa.cache_size: must be positive, got %d", o.CacheSize))
+	}
+	if o.CacheTTL <= 0 {
+		*result = multierror.Append(*result, fmt.Errorf("opa.cache_ttl: must be positive, got %s", o.CacheTTL))
+	}
+}
+
+// validatePasswordPolicy checks that an enabled PasswordPolicyConfig has
+// positive cache bounds; PwnedCheckFailClosed has no wrong value, so it isn't checked.
+func validatePasswordPolicy(p PasswordPolicyConfig, result **multierror.Error) {
+	if !p.PwnedCheckEnabled {
+		return
+	}
+	if p.PwnedCacheSize <= 0 {
+		*result = multierror.Append(*result, fmt.Errorf("password_policy.pwned_cache_size: must be positive, got %d", p.PwnedCacheSize))
+	}
+	if p.PwnedCacheTTL <= 0 {
+		*result = multierror.Append(*result, fmt.Errorf("password_policy.pwned_cache_ttl: must be positive, got %s", p.PwnedCacheTTL))
+	}
+}
+
+// checkTLSMode appends an error to result if value names no known
+// tlsprofile.Mode, or selects "disabled" outside RAMUSB_ENV=dev.
+func checkTLSMode(value, field string, result **multierror.Error) {
+	mode, err := tlsprofile.ParseMode(value)
+	if err != nil {
+		*result = multierror.Append(*result, fmt.Errorf("%s: %v", field, err))
+		return
+	}
+	if mode == tlsprofile.Disabled && os.Getenv("RAMUSB_ENV") != "dev" {
+		*result = multierror.Append(*result, fmt.Errorf("%s: \"disabled\" is only permitted when RAMUSB_ENV=dev", field))
+	}
+}
+
+// checkReadableFile appends an error to result if path is empty or cannot be opened for reading.
+func checkReadableFile(path, field string, result **multierror.Error) {
+	if path == "" {
+		*result = multierror.Append(*result, fmt.Errorf("%s: not set", field))
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		*result = multierror.Append(*result, fmt.Errorf("%s: %v", field, err))
+		return
+	}
+	f.Close()
+}
+
+// checkResolvable appends an error to result if addr is not a valid host:port
+// or its host does not resolve.
+func checkResolvable(addr, field string, result **multierror.Error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		*result = multierror.Append(*result, fmt.Errorf("%s: invalid host:port %q: %v", field, addr, err))
+		return
+	}
+
+	resolver := net.Resolver{}
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+	if _, err := resolver.LookupHost(ctx, host); err != nil {
+		*result = multierror.Append(*result, fmt.Errorf("%s: %q did not resolve: %v", field, addr, err))
+	}
+}
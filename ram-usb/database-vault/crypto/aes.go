@@ -6,16 +6,34 @@ with cryptographically secure nonce generation and base64 encoding for
 safe database storage. Provides confidentiality and authenticity for
 email data while enabling encrypted primary key functionality for
 zero-knowledge user identification in the R.A.M.-U.S.B. storage system.
+
+EncryptEmailDeterministic derives its nonce from HMAC-SHA256(key, email)
+rather than from the key alone, so distinct emails under the same key never
+share a (key, nonce) pair - reusing a GCM nonce across different plaintexts
+is a catastrophic authentication break, not merely a determinism concern.
+The derived nonce is stored alongside a format-version byte in the output
+(see emailCiphertextVersion), so DecryptEmailDeterministic still transparently
+reads a ciphertext produced under the original, pre-versioning fixed-nonce
+scheme.
 */
 package crypto
 
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
 )
 
+// emailCiphertextVersion marks a ciphertext produced by the current,
+// per-email-nonce EncryptEmailDeterministic scheme. A ciphertext lacking
+// this leading byte (or failing to authenticate under it) predates
+// versioning and is decrypted via the original fixed-nonce derivation
+// instead (see DecryptEmailDeterministic).
+const emailCiphertextVersion = 0x02
+
 // ValidateEncryptionKey performs comprehensive encryption key validation.
 //
 // Security features:
@@ -52,10 +70,10 @@ func ValidateEncryptionKey(key []byte) error {
 // Security features:
 // - Deterministic encryption enables consistent database queries
 // - Key derivation ensures operation-specific encryption keys
-// - Fixed nonce derived from master key for repeatability
+// - Nonce derived from HMAC-SHA256(email key, email) rather than fixed per key, so two different emails under the same key never collide on (key, nonce)
 // - Maintains AES-256-GCM authentication and confidentiality
 //
-// Returns consistent base64-encoded string for same email input.
+// Returns consistent base64-encoded string for same (email, masterKey) input.
 func EncryptEmailDeterministic(email string, masterKey []byte) (string, error) {
 	// KEY VALIDATION
 	if err := ValidateEncryptionKey(masterKey); err != nil {
@@ -72,15 +90,10 @@ func EncryptEmailDeterministic(email string, masterKey []byte) (string, error) {
 		return "", fmt.Errorf("failed to derive email key: %v", err)
 	}
 
-	// FIXED NONCE DERIVATION
-	emailNonce, err := DeriveKey(KeyDerivationInfo{
-		MasterKey: masterKey,
-		Context:   "email-nonce-v1",
-		Length:    12,
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to derive email nonce: %v", err)
-	}
+	// PER-EMAIL NONCE DERIVATION
+	// HMAC over the plaintext, not a fixed context string, so every distinct
+	// email gets its own nonce under the same key while staying deterministic
+	nonce := deriveEmailNonce(emailKey, email)
 
 	// AES-GCM DETERMINISTIC ENCRYPTION
 	block, err := aes.NewCipher(emailKey)
@@ -94,10 +107,27 @@ func EncryptEmailDeterministic(email string, masterKey []byte) (string, error) {
 	}
 
 	// DETERMINISTIC ENCRYPTION
-	ciphertext := gcm.Seal(nil, emailNonce, []byte(email), nil)
+	ciphertext := gcm.Seal(nil, nonce, []byte(email), nil)
+
+	// VERSIONED WIRE FORMAT: [1-byte version] || [12-byte nonce] || ciphertext+tag
+	blob := make([]byte, 0, 1+len(nonce)+len(ciphertext))
+	blob = append(blob, emailCiphertextVersion)
+	blob = append(blob, nonce...)
+	blob = append(blob, ciphertext...)
 
 	// BASE64 ENCODING
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
+	return base64.StdEncoding.EncodeToString(blob), nil
+}
+
+// deriveEmailNonce derives a 12-byte GCM nonce from emailKey and email via
+// HMAC-SHA256, truncated to the nonce size. Deterministic per (emailKey,
+// email) pair, so re-encrypting the same email under the same key reproduces
+// the same nonce (and therefore the same ciphertext) without ever reusing
+// that nonce for a different email.
+func deriveEmailNonce(emailKey []byte, email string) []byte {
+	mac := hmac.New(sha256.New, emailKey)
+	mac.Write([]byte(email))
+	return mac.Sum(nil)[:12]
 }
 
 // DecryptEmailDeterministic decrypts deterministically encrypted email.
@@ -107,7 +137,12 @@ func EncryptEmailDeterministic(email string, masterKey []byte) (string, error) {
 // - AES-256-GCM authenticated decryption verifies data integrity
 // - Recovers original email from deterministic encryption
 //
-// Returns plaintext email address or error if decryption fails.
+// Transparently decrypts ciphertext produced by either the current
+// per-email-nonce scheme (see emailCiphertextVersion) or the original
+// fixed-nonce scheme it replaced, so rows written before this versioning
+// existed keep decrypting without a migration.
+//
+// Returns plaintext email address or error if decryption fails under both schemes.
 func DecryptEmailDeterministic(encryptedEmail string, masterKey []byte) (string, error) {
 	// KEY VALIDATION
 	if err := ValidateEncryptionKey(masterKey); err != nil {
@@ -124,22 +159,12 @@ func DecryptEmailDeterministic(encryptedEmail string, masterKey []byte) (string,
 		return "", fmt.Errorf("failed to derive email key: %v", err)
 	}
 
-	emailNonce, err := DeriveKey(KeyDerivationInfo{
-		MasterKey: masterKey,
-		Context:   "email-nonce-v1",
-		Length:    12,
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to derive email nonce: %v", err)
-	}
-
 	// BASE64 DECODING
-	ciphertext, err := base64.StdEncoding.DecodeString(encryptedEmail)
+	blob, err := base64.StdEncoding.DecodeString(encryptedEmail)
 	if err != nil {
 		return "", fmt.Errorf("failed to decode base64: %v", err)
 	}
 
-	// AES-GCM DECRYPTION
 	block, err := aes.NewCipher(emailKey)
 	if err != nil {
 		return "", fmt.Errorf("failed to create AES cipher: %v", err)
@@ -150,11 +175,47 @@ func DecryptEmailDeterministic(encryptedEmail string, masterKey []byte) (string,
 		return "", fmt.Errorf("failed to create GCM: %v", err)
 	}
 
-	// AUTHENTICATED DECRYPTION
-	plaintext, err := gcm.Open(nil, emailNonce, ciphertext, nil)
+	// CURRENT SCHEME: versioned, nonce embedded in blob
+	if plaintext, ok := decryptVersionedEmail(gcm, blob); ok {
+		return string(plaintext), nil
+	}
+
+	// LEGACY FALLBACK: pre-versioning rows used one nonce fixed per master
+	// key, reused across every email that key ever encrypted
+	legacyNonce, err := DeriveKey(KeyDerivationInfo{
+		MasterKey: masterKey,
+		Context:   "email-nonce-v1",
+		Length:    12,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to derive legacy email nonce: %v", err)
+	}
+
+	plaintext, err := gcm.Open(nil, legacyNonce, blob, nil)
 	if err != nil {
 		return "", fmt.Errorf("decryption failed: %v", err)
 	}
 
 	return string(plaintext), nil
 }
+
+// decryptVersionedEmail attempts to open blob as a current-scheme ciphertext
+// ([1-byte version] || [12-byte nonce] || ciphertext+tag).
+//
+// Returns false (not the blob's error) if blob is too short, carries a
+// different version byte, or fails GCM authentication - any of which means
+// blob was produced by the legacy fixed-nonce scheme instead.
+func decryptVersionedEmail(gcm cipher.AEAD, blob []byte) ([]byte, bool) {
+	nonceSize := gcm.NonceSize()
+	if len(blob) < 1+nonceSize || blob[0] != emailCiphertextVersion {
+		return nil, false
+	}
+
+	nonce := blob[1 : 1+nonceSize]
+	ciphertext := blob[1+nonceSize:]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, false
+	}
+	return plaintext, true
+}
@@ -0,0 +1,284 @@
+/*
+Non-deterministic field-level encryption with a separate blind index for Database-Vault.
+
+Implements FieldCipher, which encrypts values (email addresses) with AES-256-GCM
+under a fresh random nonce per call, so two encryptions of the same value are
+unlinkable in storage - unlike EncryptEmailDeterministic's deterministic per-email nonce scheme.
+Because a random nonce breaks equality-based lookup, FieldCipher separately
+derives a blind-index key and computes an HMAC-SHA256 over the normalized
+value, giving storage an indexable column for `WHERE email_bi = ?` queries
+without ever comparing ciphertexts or decrypting rows. Rewrap re-derives both
+keys under a new master key during key rotation.
+
+EncryptWithKeyring/DecryptWithKeyring wrap a FieldCipher per Keyring.KeyID and
+prefix the stored field with it, the same way EncryptEmailWithKeyring does for
+the deterministic scheme in keyring.go, so the rotation package's KeyID-prefix
+scan works against FieldCipher-encrypted rows too. BlindIndexCandidates gives
+lookup the matching flexibility on the blind-index side, since that HMAC
+rotates with the master key but carries no prefix of its own.
+*/
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// fieldEncryptContext and fieldBlindIndexContext provide HKDF domain separation
+// between the encryption key and the blind-index key derived from the same master key.
+const (
+	fieldEncryptContext    = "email-encrypt"
+	fieldBlindIndexContext = "email-blind-index"
+)
+
+// FieldCipher provides non-deterministic field encryption alongside a deterministic blind index for lookup.
+//
+// Security features:
+// - encryptKey and blindIndexKey are derived via DeriveKey with distinct contexts, so a compromise of one does not expose the other
+// - Random per-call nonce in Encrypt prevents ciphertext correlation across rows with the same plaintext
+// - BlindIndex never reveals the plaintext value, only equality
+//
+// Construct with NewFieldCipher; one instance should be reused for all
+// operations under a given master key.
+type FieldCipher struct {
+	encryptKey    []byte
+	blindIndexKey []byte
+}
+
+// NewFieldCipher derives the encryption and blind-index keys for a master key.
+//
+// Security features:
+// - Validates the master key the same way ValidateEncryptionKey does before deriving from it
+// - Both derived keys are 32 bytes, suitable for AES-256-GCM and HMAC-SHA256
+//
+// Returns a ready-to-use FieldCipher or error if the master key is invalid or derivation fails.
+func NewFieldCipher(masterKey []byte) (*FieldCipher, error) {
+	if err := ValidateEncryptionKey(masterKey); err != nil {
+		return nil, fmt.Errorf("invalid master key: %v", err)
+	}
+
+	encryptKey, err := DeriveKey(KeyDerivationInfo{MasterKey: masterKey, Context: fieldEncryptContext, Length: 32})
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive field encryption key: %v", err)
+	}
+
+	blindIndexKey, err := DeriveKey(KeyDerivationInfo{MasterKey: masterKey, Context: fieldBlindIndexContext, Length: 32})
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive blind index key: %v", err)
+	}
+
+	return &FieldCipher{encryptKey: encryptKey, blindIndexKey: blindIndexKey}, nil
+}
+
+// Encrypt encrypts plaintext with AES-256-GCM under a fresh random 96-bit nonce.
+//
+// Security features:
+// - Random nonce per call means repeated plaintexts never produce matching ciphertext, unlike EncryptEmailDeterministic
+// - Stores nonce||ciphertext||tag together so decryption needs nothing but this string and the master key
+//
+// Returns a base64-encoded `nonce||ciphertext||tag` string, or error if encryption fails.
+func (fc *FieldCipher) Encrypt(plaintext string) (string, error) {
+	block, err := aes.NewCipher(fc.encryptKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to create AES cipher: %v", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("failed to create GCM: %v", err)
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %v", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt reverses Encrypt, recovering the plaintext from a `nonce||ciphertext||tag` string.
+//
+// Returns the plaintext value, or error if the encoding is malformed or authentication fails.
+func (fc *FieldCipher) Decrypt(encoded string) (string, error) {
+	block, err := aes.NewCipher(fc.encryptKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to create AES cipher: %v", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("failed to create GCM: %v", err)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode base64: %v", err)
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", fmt.Errorf("ciphertext too short to contain a nonce")
+	}
+
+	nonce, ciphertext := sealed[:nonceSize], sealed[nonceSize:]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("decryption failed: %v", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// BlindIndex computes a deterministic HMAC-SHA256 over the normalized value for indexable lookup.
+//
+// Security features:
+// - Normalization (lowercase, trim) ensures the same logical email always produces the same index regardless of formatting
+// - HMAC-SHA256 makes the index infeasible to invert without the blind-index key
+//
+// Returns a hex-encoded HMAC, suitable for storage in a dedicated indexed column.
+func (fc *FieldCipher) BlindIndex(value string) string {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	mac := hmac.New(sha256.New, fc.blindIndexKey)
+	mac.Write([]byte(normalized))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Rewrap decrypts a value under the old master key and re-encrypts it under the new one.
+//
+// Security features:
+// - Builds two short-lived FieldCipher instances rather than mutating a shared one, so the old and new keys are never both attached to the same instance
+// - Callers must separately recompute BlindIndex under the new FieldCipher, since Rewrap only re-encrypts the value itself
+//
+// Returns the re-encrypted value, or error if decryption under the old key or
+// encryption under the new key fails.
+func Rewrap(oldMasterKey, newMasterKey []byte, encryptedValue string) (string, error) {
+	oldCipher, err := NewFieldCipher(oldMasterKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize old field cipher: %v", err)
+	}
+
+	plaintext, err := oldCipher.Decrypt(encryptedValue)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt under old key: %v", err)
+	}
+
+	newCipher, err := NewFieldCipher(newMasterKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize new field cipher: %v", err)
+	}
+
+	reencrypted, err := newCipher.Encrypt(plaintext)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt under new key: %v", err)
+	}
+
+	return reencrypted, nil
+}
+
+// EncryptWithKeyring encrypts plaintext under kr's active primary key and
+// prefixes the result with its KeyID, mirroring EncryptEmailWithKeyring's
+// wire format so the rotation package's KeyID-prefix scan
+// (storage.UserStorage.ListUsersByKeyPrefix) matches rows FieldCipher wrote.
+//
+// Returns a "<key_id>:<base64 nonce||ciphertext||tag>" string, or error if
+// deriving the field cipher or encryption fails.
+func EncryptWithKeyring(plaintext string, kr *Keyring) (string, error) {
+	id, key := kr.Primary()
+
+	fc, err := NewFieldCipher(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize field cipher for key %q: %v", id, err)
+	}
+
+	ciphertext, err := fc.Encrypt(plaintext)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt under key %q: %v", id, err)
+	}
+
+	return string(id) + keyringFieldSeparator + ciphertext, nil
+}
+
+// DecryptWithKeyring decrypts a "<key_id>:<base64 ...>" field produced by
+// EncryptWithKeyring, selecting the matching key from kr so a row written
+// under a since-retired key still decrypts correctly.
+//
+// Returns the plaintext, or error if the field is malformed, the key id is
+// unknown to kr, or decryption fails.
+func DecryptWithKeyring(encoded string, kr *Keyring) (string, error) {
+	id, ciphertext, ok := strings.Cut(encoded, keyringFieldSeparator)
+	if !ok {
+		return "", fmt.Errorf("encrypted field missing key id prefix")
+	}
+
+	key, err := kr.Key(KeyID(id))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve key for encrypted field: %v", err)
+	}
+
+	fc, err := NewFieldCipher(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize field cipher for key %q: %v", id, err)
+	}
+
+	return fc.Decrypt(ciphertext)
+}
+
+// BlindIndexWithKeyring computes value's blind index under kr's active
+// primary key, for StoreUserHandler's write path. Callers resolving an
+// existing row should use BlindIndexCandidates instead, since a row blind-
+// indexed under a retired key no longer matches this.
+//
+// Returns the hex-encoded HMAC, or error if deriving the field cipher fails.
+func BlindIndexWithKeyring(value string, kr *Keyring) (string, error) {
+	id, key := kr.Primary()
+
+	fc, err := NewFieldCipher(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize field cipher for key %q: %v", id, err)
+	}
+
+	return fc.BlindIndex(value), nil
+}
+
+// BlindIndexCandidates computes value's blind index under every key
+// registered in kr, primary first, then retired. BlindIndex's HMAC key is
+// derived from the master key, so it rotates along with it - a row indexed
+// before a rotation no longer matches the primary key's index. Callers
+// resolving an existing row should probe storage with each candidate in
+// order until one matches, rather than assuming the primary key's index is
+// the one on file.
+//
+// Returns the candidates in try-order (always at least the primary key's),
+// or error if deriving any key's field cipher fails.
+func BlindIndexCandidates(value string, kr *Keyring) ([]string, error) {
+	primaryID, primaryKey := kr.Primary()
+	ids := append([]KeyID{primaryID}, kr.RetiredKeyIDs()...)
+
+	candidates := make([]string, 0, len(ids))
+	for _, id := range ids {
+		key := primaryKey
+		if id != primaryID {
+			var err error
+			key, err = kr.Key(id)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve key %q: %v", id, err)
+			}
+		}
+
+		fc, err := NewFieldCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize field cipher for key %q: %v", id, err)
+		}
+		candidates = append(candidates, fc.BlindIndex(value))
+	}
+
+	return candidates, nil
+}
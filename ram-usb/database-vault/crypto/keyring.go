@@ -0,0 +1,144 @@
+/*
+Multi-key envelope encryption for Database-Vault email field encryption.
+
+Extends the single-key model in aes.go and keys.go with a Keyring holding one
+active primary key plus any number of retired, decrypt-only keys, identified
+by KeyID. Encrypted fields are prefixed with the KeyID of the key that
+produced them, so RotateKeys can promote a new primary without breaking
+decryption of rows written under an older key. Supports the gradual,
+online re-encryption workflow implemented in the rotation package.
+*/
+package crypto
+
+import (
+	"fmt"
+	"sync"
+)
+
+// KeyID identifies a specific encryption key version within a Keyring (e.g. "v1", "v2").
+type KeyID string
+
+// keyringFieldSeparator joins a KeyID prefix to its ciphertext in encoded fields.
+//
+// Chosen to avoid collision with base64.StdEncoding's alphabet.
+const keyringFieldSeparator = ":"
+
+// Keyring holds one active primary key and zero or more retired, decrypt-only keys.
+//
+// Security features:
+// - Exactly one primary key is used for new encryption; retired keys are decrypt-only so a compromised old key cannot be used to create new data
+// - RotateKeys promotes a new primary and demotes the previous one atomically under a single lock, so no encryption ever mixes two keys
+// - KeyID prefixing on stored fields (see EncryptWithKeyring in fieldcipher.go) lets DecryptWithKeyring pick the right key without guessing
+//
+// Built by LoadKeyringFromSources; shared across goroutines via its mutex.
+type Keyring struct {
+	mu        sync.RWMutex
+	primaryID KeyID
+	keys      map[KeyID][]byte
+}
+
+// NewKeyring builds a Keyring with a single active primary key.
+//
+// Returns an error if primaryKey fails AES-256 validation.
+func NewKeyring(primaryID KeyID, primaryKey []byte) (*Keyring, error) {
+	if err := ValidateEncryptionKey(primaryKey); err != nil {
+		return nil, fmt.Errorf("invalid primary key: %v", err)
+	}
+
+	return &Keyring{
+		primaryID: primaryID,
+		keys:      map[KeyID][]byte{primaryID: primaryKey},
+	}, nil
+}
+
+// AddRetiredKey registers a decrypt-only key so existing ciphertext under that
+// KeyID keeps decrypting after rotation.
+//
+// Returns an error if key fails AES-256 validation or id collides with the
+// current primary key.
+func (kr *Keyring) AddRetiredKey(id KeyID, key []byte) error {
+	if err := ValidateEncryptionKey(key); err != nil {
+		return fmt.Errorf("invalid retired key %q: %v", id, err)
+	}
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	if id == kr.primaryID {
+		return fmt.Errorf("key id %q is already the active primary", id)
+	}
+
+	kr.keys[id] = key
+	return nil
+}
+
+// Primary returns the active primary KeyID and its key material.
+func (kr *Keyring) Primary() (KeyID, []byte) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	return kr.primaryID, kr.keys[kr.primaryID]
+}
+
+// Key returns the key material registered under id, whether primary or retired.
+//
+// Returns an error if no key is registered under id.
+func (kr *Keyring) Key(id KeyID) ([]byte, error) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	key, ok := kr.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("no key registered for key id %q", id)
+	}
+	return key, nil
+}
+
+// RotateKeys promotes newPrimary under newID to active primary, demoting the
+// previous primary to a retired, decrypt-only key.
+//
+// Security features:
+// - Previous primary remains available for decrypting rows not yet re-encrypted by the rotation worker
+// - Single lock acquisition makes the promotion atomic from callers' view
+//
+// Returns an error if newPrimary fails AES-256 validation or newID is already in use.
+func (kr *Keyring) RotateKeys(newID KeyID, newPrimary []byte) error {
+	if err := ValidateEncryptionKey(newPrimary); err != nil {
+		return fmt.Errorf("invalid new primary key: %v", err)
+	}
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	if _, exists := kr.keys[newID]; exists {
+		return fmt.Errorf("key id %q is already registered", newID)
+	}
+
+	// DEMOTION
+	// Previous primary stays registered as a retired, decrypt-only key
+	kr.keys[newID] = newPrimary
+	kr.primaryID = newID
+
+	return nil
+}
+
+// RetiredKeyIDs returns every non-primary KeyID currently registered, for use
+// by the rotation worker when scanning rows that still need re-encryption.
+func (kr *Keyring) RetiredKeyIDs() []KeyID {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	var ids []KeyID
+	for id := range kr.keys {
+		if id != kr.primaryID {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Email-specific KeyID-prefixed encrypt/decrypt/re-encrypt built on this
+// Keyring live in fieldcipher.go (EncryptWithKeyring, DecryptWithKeyring),
+// wrapping FieldCipher rather than EncryptEmailDeterministic - FieldCipher's
+// random-nonce scheme plus separate blind index is what StoreUserHandler
+// actually persists to EncryptedEmail, so the rotation package's migration
+// worker must speak the same format it wrote.
@@ -5,21 +5,33 @@ Provides centralized key derivation, loading, validation, and lifecycle manageme
 for AES-256-GCM encryption keys used in email field-level encryption. Implements
 secure key handling practices including HKDF derivation, multiple source loading,
 entropy validation, and memory cleanup to support zero-knowledge storage
-principles in the R.A.M.-U.S.B. distributed authentication system.
-
-TO-DO: Implement key rotation mechanism with graceful fallback
+principles in the R.A.M.-U.S.B. distributed authentication system. Key material
+is held in a crypto.SecureBuffer (mlock'd and zeroized on Destroy) for as long
+as it stays in this package, before a plain copy is handed back to callers.
+Multi-key rotation is available via LoadKeyringFromSources and crypto.Keyring;
+see the rotation package for the online re-encryption worker that migrates
+rows from a retired key to the new primary.
 */
 package crypto
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"io"
+	"log"
 	"os"
+	"strings"
 
 	"golang.org/x/crypto/hkdf"
+
+	vaultapi "github.com/hashicorp/vault/api"
+
+	awskms "github.com/aws/aws-sdk-go-v2/service/kms"
+
+	gcpkms "cloud.google.com/go/kms/apiv1"
 )
 
 // KeyDerivationInfo contains parameters for HKDF key derivation operations.
@@ -45,6 +57,7 @@ type KeyDerivationInfo struct {
 // - Context-based separation prevents key reuse across operations
 // - Configurable salt for additional entropy and security
 // - Deterministic output enables consistent key regeneration
+// - Derived key material is held in a mlock'd SecureBuffer until copied out, and zeroized immediately afterward rather than left for garbage collection
 //
 // Returns derived key of specified length or error if derivation fails.
 func DeriveKey(info KeyDerivationInfo) ([]byte, error) {
@@ -62,12 +75,19 @@ func DeriveKey(info KeyDerivationInfo) ([]byte, error) {
 	hkdfReader := hkdf.New(sha256.New, info.MasterKey, info.Salt, []byte(info.Context))
 
 	// KEY DERIVATION
-	// Generate derived key of specified length
-	derivedKey := make([]byte, info.Length)
-	if _, err := io.ReadFull(hkdfReader, derivedKey); err != nil {
+	// Generate derived key of specified length directly into a locked buffer
+	buf, err := NewSecureBuffer(make([]byte, info.Length))
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate secure buffer for derived key: %v", err)
+	}
+	defer buf.Destroy()
+
+	if _, err := io.ReadFull(hkdfReader, buf.Bytes()); err != nil {
 		return nil, fmt.Errorf("key derivation failed: %v", err)
 	}
 
+	derivedKey := make([]byte, info.Length)
+	copy(derivedKey, buf.Bytes())
 	return derivedKey, nil
 }
 
@@ -81,8 +101,8 @@ func DeriveKey(info KeyDerivationInfo) ([]byte, error) {
 //
 // Returns 32-byte AES-256 key or error if no valid key source available.
 //
-// TO-DO: Add HashiCorp Vault and AWS KMS integration
-// TO-DO: Implement key rotation with multiple key support
+// For multi-key rotation support, see LoadKeyringFromSources, which wraps
+// this function's result as the active primary key of a Keyring.
 func LoadEncryptionKeyFromSources() ([]byte, error) {
 	// PRIMARY SOURCE: Environment Variable
 	// Preferred method for containerized deployments
@@ -96,7 +116,16 @@ func LoadEncryptionKeyFromSources() ([]byte, error) {
 		return key, nil
 	}
 
-	// TERTIARY SOURCE: Development Fallback
+	// TERTIARY SOURCE: Managed Secret Store
+	// HashiCorp Vault (KV v2 / Transit), AWS KMS, or GCP KMS, configured via
+	// RAMUSB_VAULT_ADDR / RAMUSB_AWS_KMS_KEY_ID / RAMUSB_GCP_KMS_KEY_NAME
+	if sources := configuredRemoteKeySources(); len(sources) > 0 {
+		if key, err := LoadEncryptionKeyFromRemoteSources(context.Background(), sources); err == nil {
+			return key, nil
+		}
+	}
+
+	// QUATERNARY SOURCE: Development Fallback
 	// Only for development environments - not production safe
 	if isDevelopmentEnvironment() {
 		return generateDevelopmentKey()
@@ -105,12 +134,129 @@ func LoadEncryptionKeyFromSources() ([]byte, error) {
 	return nil, fmt.Errorf("no valid encryption key source found - set RAMUSB_ENCRYPTION_KEY or provide key file")
 }
 
+// LoadKeyringFromSources loads the active encryption key via LoadEncryptionKeyFromSources
+// and wraps it in a single-key Keyring, additionally registering any retired keys
+// configured for decrypt-only access during a rotation.
+//
+// Security features:
+// - Primary key id and retired key ids are explicit, never inferred, so a misconfigured RAMUSB_RETIRED_KEYS cannot silently become the primary
+// - Retired keys are only ever used for decryption (see Keyring.RotateKeys)
+//
+// Returns a ready-to-use Keyring, or error if no primary key source is available.
+//
+// TO-DO: Switch config.GetConfig() to build its Config.EncryptionKey from this Keyring
+func LoadKeyringFromSources() (*Keyring, error) {
+	primaryKey, err := LoadEncryptionKeyFromSources()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load primary key: %v", err)
+	}
+
+	primaryID := KeyID(os.Getenv("RAMUSB_KEY_ID"))
+	if primaryID == "" {
+		primaryID = "v1"
+	}
+
+	keyring, err := NewKeyring(primaryID, primaryKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize keyring: %v", err)
+	}
+
+	// RETIRED KEY REGISTRATION
+	// RAMUSB_RETIRED_KEYS is a comma-separated "id=hexkey" list, e.g. "v1=deadbeef...,v2=..."
+	if retired := os.Getenv("RAMUSB_RETIRED_KEYS"); retired != "" {
+		for _, entry := range strings.Split(retired, ",") {
+			id, hexKey, ok := strings.Cut(entry, "=")
+			if !ok {
+				log.Printf("Skipping malformed RAMUSB_RETIRED_KEYS entry: %q", entry)
+				continue
+			}
+
+			key, err := hex.DecodeString(hexKey)
+			if err != nil {
+				log.Printf("Skipping retired key %q with invalid hex encoding: %v", id, err)
+				continue
+			}
+
+			if err := keyring.AddRetiredKey(KeyID(id), key); err != nil {
+				log.Printf("Skipping retired key %q: %v", id, err)
+			}
+		}
+	}
+
+	return keyring, nil
+}
+
+// configuredRemoteKeySources builds the list of remote KeySource backends enabled via
+// environment configuration, in priority order (Vault, then AWS KMS, then GCP KMS).
+//
+// Security features:
+// - Sources are only constructed when their required configuration is present
+// - Construction failures are logged here and the source skipped, never fatal at this layer
+//
+// Returns an empty slice if no remote backend is configured.
+//
+// TO-DO: Replace environment-variable wiring with config.KeyProvider selection
+func configuredRemoteKeySources() []KeySource {
+	var sources []KeySource
+
+	if addr := os.Getenv("RAMUSB_VAULT_ADDR"); addr != "" {
+		vaultConfig := vaultapi.DefaultConfig()
+		vaultConfig.Address = addr
+		client, err := vaultapi.NewClient(vaultConfig)
+		if err != nil {
+			log.Printf("Vault client initialization failed: %v", err)
+		} else {
+			if token := os.Getenv("RAMUSB_VAULT_TOKEN"); token != "" {
+				client.SetToken(token)
+			}
+			source, err := NewVaultKeySource(client,
+				os.Getenv("RAMUSB_VAULT_KV_MOUNT"),
+				os.Getenv("RAMUSB_VAULT_KV_PATH"),
+				os.Getenv("RAMUSB_VAULT_TRANSIT_MOUNT"),
+				os.Getenv("RAMUSB_VAULT_TRANSIT_KEY"),
+				os.Getenv("RAMUSB_VAULT_WRAPPED_DEK"))
+			if err != nil {
+				log.Printf("Vault key source initialization failed: %v", err)
+			} else {
+				sources = append(sources, source)
+			}
+		}
+	}
+
+	if ciphertext := os.Getenv("RAMUSB_AWS_KMS_CIPHERTEXT"); ciphertext != "" {
+		awsClient := awskms.New(awskms.Options{})
+		source, err := NewAWSKMSKeySource(awsClient, ciphertext, os.Getenv("RAMUSB_AWS_KMS_KEY_ID"))
+		if err != nil {
+			log.Printf("AWS KMS key source initialization failed: %v", err)
+		} else {
+			sources = append(sources, source)
+		}
+	}
+
+	if ciphertext := os.Getenv("RAMUSB_GCP_KMS_CIPHERTEXT"); ciphertext != "" {
+		gcpClient, err := gcpkms.NewKeyManagementClient(context.Background())
+		if err != nil {
+			log.Printf("GCP KMS client initialization failed: %v", err)
+		} else {
+			source, err := NewGCPKMSKeySource(gcpClient, os.Getenv("RAMUSB_GCP_KMS_KEY_NAME"), ciphertext)
+			if err != nil {
+				log.Printf("GCP KMS key source initialization failed: %v", err)
+			} else {
+				sources = append(sources, source)
+			}
+		}
+	}
+
+	return sources
+}
+
 // loadKeyFromEnvironment loads and validates encryption key from environment variable.
 //
 // Security features:
 // - Hex decoding validation ensures proper key format
 // - Length validation for AES-256 compliance
 // - Entropy validation prevents weak or predictable keys
+// - Decoded key bytes are held in a mlock'd SecureBuffer for the lifetime of this call, zeroized before returning a fresh copy to the caller
 //
 // Returns validated 32-byte key or error if environment key is invalid.
 func loadKeyFromEnvironment() ([]byte, error) {
@@ -121,16 +267,25 @@ func loadKeyFromEnvironment() ([]byte, error) {
 	}
 
 	// HEX DECODING
-	key, err := hex.DecodeString(keyHex)
+	decoded, err := hex.DecodeString(keyHex)
 	if err != nil {
 		return nil, fmt.Errorf("invalid hex format in RAMUSB_ENCRYPTION_KEY: %v", err)
 	}
 
+	buf, err := NewSecureBuffer(decoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock environment key in memory: %v", err)
+	}
+	defer buf.Destroy()
+	SecureKeyCleanup(decoded)
+
 	// KEY VALIDATION
-	if err := ValidateKeyStrength(key); err != nil {
+	if err := ValidateKeyStrength(buf.Bytes()); err != nil {
 		return nil, fmt.Errorf("environment key validation failed: %v", err)
 	}
 
+	key := make([]byte, len(buf.Bytes()))
+	copy(key, buf.Bytes())
 	return key, nil
 }
 
@@ -141,6 +296,7 @@ func loadKeyFromEnvironment() ([]byte, error) {
 // - Binary key loading for maximum entropy
 // - Length and strength validation
 // - Secure error handling without file content disclosure
+// - Decoded key bytes are held in a mlock'd SecureBuffer for the lifetime of this call, zeroized before returning a fresh copy to the caller
 //
 // Returns validated key or error if file key is invalid or inaccessible.
 func loadKeyFromFile(keyPath string) ([]byte, error) {
@@ -181,12 +337,21 @@ func loadKeyFromFile(keyPath string) ([]byte, error) {
 		return nil, fmt.Errorf("key file has invalid length: expected 32 or 64 bytes, got %d", len(keyData))
 	}
 
+	buf, err := NewSecureBuffer(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock file key in memory: %v", err)
+	}
+	defer buf.Destroy()
+	SecureKeyCleanup(key)
+
 	// KEY VALIDATION
-	if err := ValidateKeyStrength(key); err != nil {
+	if err := ValidateKeyStrength(buf.Bytes()); err != nil {
 		return nil, fmt.Errorf("file key validation failed: %v", err)
 	}
 
-	return key, nil
+	result := make([]byte, len(buf.Bytes()))
+	copy(result, buf.Bytes())
+	return result, nil
 }
 
 // ValidateKeyStrength performs comprehensive cryptographic key strength validation.
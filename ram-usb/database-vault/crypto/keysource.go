@@ -0,0 +1,351 @@
+/*
+Pluggable remote key source backends for Database-Vault encryption key loading.
+
+Extends LoadEncryptionKeyFromSources with KeySource implementations backed by
+HashiCorp Vault (KV v2 and Transit) and cloud KMS providers (AWS KMS and GCP
+KMS), so the master AES-256-GCM key can be retrieved from a managed secret
+store instead of a local environment variable or file. Each backend performs
+an authenticated fetch against its provider, unwraps envelope-encrypted key
+material where applicable, and tracks lease/TTL metadata so callers can
+schedule a Refresh (and subsequent SecureKeyCleanup of the stale key) before
+the lease expires.
+
+TO-DO: Wire KeySource selection into config.GetConfig() via RAMUSB_KEY_SOURCE
+TO-DO: Add automatic background refresh scheduling driven by lease duration
+*/
+package crypto
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awskms "github.com/aws/aws-sdk-go-v2/service/kms"
+
+	gcpkms "cloud.google.com/go/kms/apiv1"
+	gcpkmspb "cloud.google.com/go/kms/apiv1/kmspb"
+)
+
+// KeySource retrieves master encryption key material from a remote or managed secret store.
+//
+// Security features:
+// - Authenticated fetch ensures only authorized callers can retrieve key material
+// - Envelope decryption support for providers that store a wrapped DEK
+// - Lease/TTL awareness enables proactive rotation before expiry
+// - Provenance-only logging (Name) prevents key material from reaching log output
+//
+// Implementations back LoadEncryptionKeyFromSources for production key management.
+type KeySource interface {
+	// Name returns a short identifier used for provenance logging only.
+	Name() string
+
+	// Fetch retrieves and validates current key material from the backing store.
+	Fetch(ctx context.Context) ([]byte, error)
+
+	// Refresh re-fetches key material. Implementations here re-fetch
+	// unconditionally; they do not wait for a lease/TTL to expire.
+	//
+	// Returns the current key so the caller can SecureKeyCleanup the previous
+	// key once the new one has been installed.
+	Refresh(ctx context.Context) ([]byte, error)
+}
+
+// VaultKeySource retrieves the master key from HashiCorp Vault's KV v2 or Transit engine.
+//
+// Security features:
+// - Token or AppRole authentication via the standard Vault API client
+// - Transit envelope decryption keeps the DEK unwrapped only in memory
+// - Lease duration tracking drives Refresh scheduling
+//
+// Configure either KVPath (KV v2 secret holding a hex-encoded key) or
+// TransitKeyName + WrappedDEK (Transit-wrapped data encryption key), not both.
+type VaultKeySource struct {
+	client        *vaultapi.Client
+	mountPath     string // KV v2 mount, e.g. "secret"
+	kvPath        string // Secret path within the mount, e.g. "ramusb/encryption-key"
+	transitMount  string // Transit mount, e.g. "transit"
+	transitKey    string // Transit key name used to unwrap the DEK
+	wrappedDEK    string // Base64 Transit ciphertext ("vault:v1:...") when using envelope mode
+	leaseDuration time.Duration
+}
+
+// NewVaultKeySource constructs a Vault-backed KeySource using token or AppRole authentication.
+//
+// Security features:
+// - Delegates authentication entirely to the supplied *vaultapi.Client
+// - No key material is cached outside of the returned byte slice
+//
+// Returns configured VaultKeySource or error if client is nil.
+func NewVaultKeySource(client *vaultapi.Client, mountPath, kvPath, transitMount, transitKey, wrappedDEK string) (*VaultKeySource, error) {
+	if client == nil {
+		return nil, fmt.Errorf("vault client must not be nil")
+	}
+	return &VaultKeySource{
+		client:       client,
+		mountPath:    mountPath,
+		kvPath:       kvPath,
+		transitMount: transitMount,
+		transitKey:   transitKey,
+		wrappedDEK:   wrappedDEK,
+	}, nil
+}
+
+// Name returns the provenance label logged by LoadEncryptionKeyFromSources.
+func (v *VaultKeySource) Name() string {
+	return "vault"
+}
+
+// Fetch retrieves the master key from Vault, preferring Transit unwrap over a raw KV v2 read.
+//
+// Security features:
+// - Transit envelope decryption never exposes the wrapping key, only the DEK
+// - KV v2 fallback validates hex encoding and 32-byte AES-256 length
+//
+// Returns 32-byte AES-256 key or error if Vault is unreachable or the secret is malformed.
+func (v *VaultKeySource) Fetch(ctx context.Context) ([]byte, error) {
+	if v.transitKey != "" && v.wrappedDEK != "" {
+		return v.unwrapViaTransit(ctx)
+	}
+	return v.readFromKV(ctx)
+}
+
+// unwrapViaTransit decrypts the wrapped DEK via Vault's Transit secrets engine.
+//
+// Returns plaintext DEK bytes or error if the Transit decrypt call fails.
+func (v *VaultKeySource) unwrapViaTransit(ctx context.Context) ([]byte, error) {
+	secret, err := v.client.Logical().WriteWithContext(ctx,
+		fmt.Sprintf("%s/decrypt/%s", v.transitMount, v.transitKey),
+		map[string]interface{}{"ciphertext": v.wrappedDEK})
+	if err != nil {
+		return nil, fmt.Errorf("transit decrypt failed: %v", err)
+	}
+	if secret == nil || secret.Data["plaintext"] == nil {
+		return nil, fmt.Errorf("transit decrypt returned no plaintext")
+	}
+	plaintextB64, ok := secret.Data["plaintext"].(string)
+	if !ok {
+		return nil, fmt.Errorf("transit decrypt returned unexpected plaintext type")
+	}
+	key, err := decodeTransitPlaintext(plaintextB64)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateKeyStrength(key); err != nil {
+		return nil, fmt.Errorf("transit-unwrapped key validation failed: %v", err)
+	}
+	if leaseStr, ok := secret.Data["lease_duration"].(string); ok {
+		if d, err := time.ParseDuration(leaseStr); err == nil {
+			v.leaseDuration = d
+		}
+	}
+	return key, nil
+}
+
+// readFromKV reads a hex-encoded key from Vault's KV v2 secrets engine.
+//
+// Returns 32-byte AES-256 key or error if the secret is missing or malformed.
+func (v *VaultKeySource) readFromKV(ctx context.Context) ([]byte, error) {
+	secret, err := v.client.Logical().ReadWithContext(ctx,
+		fmt.Sprintf("%s/data/%s", v.mountPath, v.kvPath))
+	if err != nil {
+		return nil, fmt.Errorf("vault KV v2 read failed: %v", err)
+	}
+	if secret == nil || secret.Data["data"] == nil {
+		return nil, fmt.Errorf("vault KV v2 secret not found: %s/%s", v.mountPath, v.kvPath)
+	}
+	data, ok := secret.Data["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("vault KV v2 secret has unexpected shape")
+	}
+	keyHex, ok := data["key"].(string)
+	if !ok {
+		return nil, fmt.Errorf("vault KV v2 secret missing 'key' field")
+	}
+	key, err := decodeHexKey(keyHex)
+	if err != nil {
+		return nil, err
+	}
+	if lease, ok := secret.Data["lease_duration"].(int); ok {
+		v.leaseDuration = time.Duration(lease) * time.Second
+	}
+	return key, nil
+}
+
+// Refresh re-fetches the key, logging Vault's last reported lease duration.
+//
+// Security features:
+// - Refetches on every call; the previous lease's remaining time is not checked
+// - Returns the latest key so the caller can SecureKeyCleanup the previous one
+//
+// Returns the current 32-byte key or error if the refresh fetch fails.
+func (v *VaultKeySource) Refresh(ctx context.Context) ([]byte, error) {
+	log.Printf("Refreshing encryption key from Vault (lease_duration=%s)", v.leaseDuration)
+	return v.Fetch(ctx)
+}
+
+// AWSKMSKeySource retrieves the master key by decrypting a KMS-wrapped ciphertext blob.
+//
+// Security features:
+// - IAM-authenticated Decrypt call, credentials resolved by the AWS SDK default chain
+// - Ciphertext blob is configured out-of-band (env/file), never the plaintext key
+//
+// Used when RAMUSB_KEY_PROVIDER selects awskms-backed envelope encryption.
+type AWSKMSKeySource struct {
+	client         *awskms.Client
+	ciphertextBlob []byte
+	keyID          string // Optional KMS key ARN/alias for audit trail validation
+}
+
+// NewAWSKMSKeySource constructs an AWS KMS-backed KeySource from a base64 ciphertext blob.
+//
+// Returns configured AWSKMSKeySource or error if the ciphertext cannot be decoded.
+func NewAWSKMSKeySource(client *awskms.Client, ciphertextB64, keyID string) (*AWSKMSKeySource, error) {
+	blob, err := decodeBase64Blob(ciphertextB64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid AWS KMS ciphertext blob: %v", err)
+	}
+	return &AWSKMSKeySource{client: client, ciphertextBlob: blob, keyID: keyID}, nil
+}
+
+// Name returns the provenance label logged by LoadEncryptionKeyFromSources.
+func (a *AWSKMSKeySource) Name() string {
+	return "aws-kms"
+}
+
+// Fetch decrypts the configured ciphertext blob via the AWS KMS Decrypt API.
+//
+// Returns 32-byte AES-256 key or error if decryption fails or the key length is wrong.
+func (a *AWSKMSKeySource) Fetch(ctx context.Context) ([]byte, error) {
+	input := &awskms.DecryptInput{CiphertextBlob: a.ciphertextBlob}
+	if a.keyID != "" {
+		input.KeyId = aws.String(a.keyID)
+	}
+	out, err := a.client.Decrypt(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("AWS KMS decrypt failed: %v", err)
+	}
+	if err := ValidateKeyStrength(out.Plaintext); err != nil {
+		return nil, fmt.Errorf("AWS KMS decrypted key validation failed: %v", err)
+	}
+	return out.Plaintext, nil
+}
+
+// Refresh re-decrypts the ciphertext blob. AWS KMS has no lease concept, so this
+// simply repeats Fetch — useful when the underlying CMK has been rotated.
+//
+// Returns the current 32-byte key or error if the decrypt call fails.
+func (a *AWSKMSKeySource) Refresh(ctx context.Context) ([]byte, error) {
+	return a.Fetch(ctx)
+}
+
+// GCPKMSKeySource retrieves the master key by decrypting a Cloud KMS ciphertext blob.
+//
+// Security features:
+// - Service-account-authenticated Decrypt call via the GCP KMS client library
+// - Ciphertext blob configured out-of-band, never the plaintext key
+//
+// Used when RAMUSB_KEY_PROVIDER selects gcpkms-backed envelope encryption.
+type GCPKMSKeySource struct {
+	client         *gcpkms.KeyManagementClient
+	keyName        string // Fully-qualified CryptoKey resource name
+	ciphertextBlob []byte
+}
+
+// NewGCPKMSKeySource constructs a GCP KMS-backed KeySource from a base64 ciphertext blob.
+//
+// Returns configured GCPKMSKeySource or error if the ciphertext cannot be decoded.
+func NewGCPKMSKeySource(client *gcpkms.KeyManagementClient, keyName, ciphertextB64 string) (*GCPKMSKeySource, error) {
+	blob, err := decodeBase64Blob(ciphertextB64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GCP KMS ciphertext blob: %v", err)
+	}
+	return &GCPKMSKeySource{client: client, keyName: keyName, ciphertextBlob: blob}, nil
+}
+
+// Name returns the provenance label logged by LoadEncryptionKeyFromSources.
+func (g *GCPKMSKeySource) Name() string {
+	return "gcp-kms"
+}
+
+// Fetch decrypts the configured ciphertext blob via the Cloud KMS Decrypt API.
+//
+// Returns 32-byte AES-256 key or error if decryption fails or the key length is wrong.
+func (g *GCPKMSKeySource) Fetch(ctx context.Context) ([]byte, error) {
+	resp, err := g.client.Decrypt(ctx, &gcpkmspb.DecryptRequest{
+		Name:       g.keyName,
+		Ciphertext: g.ciphertextBlob,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GCP KMS decrypt failed: %v", err)
+	}
+	if err := ValidateKeyStrength(resp.Plaintext); err != nil {
+		return nil, fmt.Errorf("GCP KMS decrypted key validation failed: %v", err)
+	}
+	return resp.Plaintext, nil
+}
+
+// Refresh re-decrypts the ciphertext blob against the current primary CryptoKeyVersion.
+//
+// Returns the current 32-byte key or error if the decrypt call fails.
+func (g *GCPKMSKeySource) Refresh(ctx context.Context) ([]byte, error) {
+	return g.Fetch(ctx)
+}
+
+// LoadEncryptionKeyFromRemoteSources iterates configured KeySource backends in priority
+// order, returning the first successfully fetched and validated key.
+//
+// Security features:
+// - Provenance-only logging (source.Name()) never exposes key material
+// - Per-source errors are logged and do not abort the remaining sources
+// - First successful, validated key wins; no silent fallback to weaker sources
+//
+// Returns 32-byte AES-256 key or error if every configured source fails.
+func LoadEncryptionKeyFromRemoteSources(ctx context.Context, sources []KeySource) ([]byte, error) {
+	for _, source := range sources {
+		key, err := source.Fetch(ctx)
+		if err != nil {
+			log.Printf("Key source %q failed: %v", source.Name(), err)
+			continue
+		}
+		log.Printf("Encryption key loaded from remote source %q", source.Name())
+		return key, nil
+	}
+	return nil, fmt.Errorf("no configured remote key source succeeded")
+}
+
+// decodeTransitPlaintext decodes the base64 plaintext returned by Vault Transit's decrypt endpoint.
+func decodeTransitPlaintext(b64 string) ([]byte, error) {
+	return decodeBase64Blob(b64)
+}
+
+// decodeBase64Blob decodes a base64-encoded blob. It does not check the
+// decoded length; callers that expect key material validate it themselves.
+//
+// Returns the decoded bytes or error if decoding fails.
+func decodeBase64Blob(b64 string) ([]byte, error) {
+	blob, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 encoding: %v", err)
+	}
+	return blob, nil
+}
+
+// decodeHexKey decodes and validates a hex-encoded 32-byte AES-256 key.
+//
+// Returns 32-byte key material or error if decoding fails or the length is wrong.
+func decodeHexKey(keyHex string) ([]byte, error) {
+	key, err := hex.DecodeString(keyHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex encoding: %v", err)
+	}
+	if err := ValidateKeyStrength(key); err != nil {
+		return nil, fmt.Errorf("key validation failed: %v", err)
+	}
+	return key, nil
+}
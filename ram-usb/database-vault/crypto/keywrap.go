@@ -0,0 +1,420 @@
+/*
+Pluggable envelope-wrapping backends for Database-Vault's data-encryption key (DEK).
+
+KeySource (see keysource.go) only fetches key material - it has no way to
+wrap a freshly generated DEK for persistence, which RotateDEK needs. KeyWrapper
+adds that missing Wrap direction, mirroring the KMS-envelope approach used by
+Vault's own seal/unseal: a small DEK is wrapped by a KMS/HSM-held key-encryption
+key, and only the wrapped blob - never the plaintext DEK - is persisted.
+
+LocalFileKeyWrapper covers the current, no-managed-KMS deployment; the
+remaining backends wrap/unwrap through the same provider clients keysource.go
+already authenticates against, so a deployment that already trusts its KMS
+for decrypt-only key loading can reuse that trust for DEK rotation too.
+
+ConfiguredKeyWrapper selects a backend from RAMUSB_KEY_WRAPPER; database-vault/config
+calls it when encryption.wrapped_dek_file is set (see keyprovider.EnvelopeKeyProvider).
+*/
+package crypto
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+
+	vaultapi "github.com/hashicorp/vault/api"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awskms "github.com/aws/aws-sdk-go-v2/service/kms"
+
+	gcpkms "cloud.google.com/go/kms/apiv1"
+	gcpkmspb "cloud.google.com/go/kms/apiv1/kmspb"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
+)
+
+// KeyWrapper wraps and unwraps a data-encryption key (DEK) under a
+// key-encryption key held by a KMS/HSM/local backend.
+//
+// Security features:
+// - Unwrap is the only path that ever produces plaintext DEK bytes, and only in memory
+// - Provenance-only logging (Name) keeps both the DEK and the wrapping key out of logs
+//
+// Implementations back keyprovider.EnvelopeKeyProvider's startup unwrap and RotateDEK's wrap of a freshly generated DEK.
+type KeyWrapper interface {
+	// Name returns a short identifier used for provenance logging only.
+	Name() string
+
+	// Wrap encrypts dek under the backend's key-encryption key.
+	Wrap(ctx context.Context, dek []byte) ([]byte, error)
+
+	// Unwrap decrypts a blob previously produced by Wrap, returning the plaintext DEK.
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// LocalFileKeyWrapper wraps the DEK with AES-256-GCM under a local master key,
+// the no-managed-KMS equivalent of today's single static encryption key.
+//
+// Security features:
+// - Random 96-bit nonce per Wrap call, prepended to the returned blob so Unwrap needs no side channel for it
+// - Master key never leaves process memory; only the wrapped blob is meant to be persisted
+type LocalFileKeyWrapper struct {
+	masterKey []byte
+}
+
+// NewLocalFileKeyWrapper constructs a KeyWrapper backed by a local master key,
+// typically loaded the same way as today's single encryption key (env var or file).
+//
+// Returns error if masterKey fails AES-256 key strength validation.
+func NewLocalFileKeyWrapper(masterKey []byte) (*LocalFileKeyWrapper, error) {
+	if err := ValidateEncryptionKey(masterKey); err != nil {
+		return nil, fmt.Errorf("invalid local wrap key: %v", err)
+	}
+	return &LocalFileKeyWrapper{masterKey: masterKey}, nil
+}
+
+// Name returns the provenance label logged around wrap/unwrap operations.
+func (l *LocalFileKeyWrapper) Name() string {
+	return "local-file"
+}
+
+// Wrap seals dek with AES-256-GCM under the local master key.
+//
+// Returns a "nonce || ciphertext || tag" blob, or error if sealing fails.
+func (l *LocalFileKeyWrapper) Wrap(_ context.Context, dek []byte) ([]byte, error) {
+	block, err := aes.NewCipher(l.masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize cipher: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize GCM mode: %v", err)
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %v", err)
+	}
+
+	return gcm.Seal(nonce, nonce, dek, nil), nil
+}
+
+// Unwrap reverses Wrap, recovering the plaintext DEK.
+//
+// Returns error if the blob is too short or authentication fails.
+func (l *LocalFileKeyWrapper) Unwrap(_ context.Context, wrapped []byte) ([]byte, error) {
+	block, err := aes.NewCipher(l.masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize cipher: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize GCM mode: %v", err)
+	}
+
+	if len(wrapped) < gcm.NonceSize() {
+		return nil, fmt.Errorf("wrapped DEK blob is shorter than the nonce size")
+	}
+	nonce, ciphertext := wrapped[:gcm.NonceSize()], wrapped[gcm.NonceSize():]
+
+	dek, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap DEK: %v", err)
+	}
+	return dek, nil
+}
+
+// VaultTransitKeyWrapper wraps/unwraps the DEK through Vault's Transit secrets engine.
+//
+// Security features:
+// - The transit key never leaves Vault; only encrypt/decrypt operations cross the wire
+// - Token or AppRole authentication via the standard Vault API client
+type VaultTransitKeyWrapper struct {
+	client       *vaultapi.Client
+	transitMount string // Transit mount, e.g. "transit"
+	transitKey   string // Transit key name used to wrap/unwrap the DEK
+}
+
+// NewVaultTransitKeyWrapper constructs a Vault Transit-backed KeyWrapper.
+//
+// Returns error if client is nil.
+func NewVaultTransitKeyWrapper(client *vaultapi.Client, transitMount, transitKey string) (*VaultTransitKeyWrapper, error) {
+	if client == nil {
+		return nil, fmt.Errorf("vault client must not be nil")
+	}
+	return &VaultTransitKeyWrapper{client: client, transitMount: transitMount, transitKey: transitKey}, nil
+}
+
+// Name returns the provenance label logged around wrap/unwrap operations.
+func (v *VaultTransitKeyWrapper) Name() string {
+	return "vault-transit"
+}
+
+// Wrap encrypts dek via Transit's encrypt endpoint.
+//
+// Returns the "vault:v1:..." ciphertext Transit returns, as raw bytes, or
+// error if the encrypt call fails.
+func (v *VaultTransitKeyWrapper) Wrap(ctx context.Context, dek []byte) ([]byte, error) {
+	secret, err := v.client.Logical().WriteWithContext(ctx,
+		fmt.Sprintf("%s/encrypt/%s", v.transitMount, v.transitKey),
+		map[string]interface{}{"plaintext": encodeBase64Blob(dek)})
+	if err != nil {
+		return nil, fmt.Errorf("transit encrypt failed: %v", err)
+	}
+	if secret == nil || secret.Data["ciphertext"] == nil {
+		return nil, fmt.Errorf("transit encrypt returned no ciphertext")
+	}
+	ciphertext, ok := secret.Data["ciphertext"].(string)
+	if !ok {
+		return nil, fmt.Errorf("transit encrypt returned unexpected ciphertext type")
+	}
+	return []byte(ciphertext), nil
+}
+
+// Unwrap decrypts a blob previously produced by Wrap via Transit's decrypt endpoint.
+//
+// Returns plaintext DEK bytes, or error if the decrypt call fails.
+func (v *VaultTransitKeyWrapper) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	secret, err := v.client.Logical().WriteWithContext(ctx,
+		fmt.Sprintf("%s/decrypt/%s", v.transitMount, v.transitKey),
+		map[string]interface{}{"ciphertext": string(wrapped)})
+	if err != nil {
+		return nil, fmt.Errorf("transit decrypt failed: %v", err)
+	}
+	if secret == nil || secret.Data["plaintext"] == nil {
+		return nil, fmt.Errorf("transit decrypt returned no plaintext")
+	}
+	plaintextB64, ok := secret.Data["plaintext"].(string)
+	if !ok {
+		return nil, fmt.Errorf("transit decrypt returned unexpected plaintext type")
+	}
+	return decodeTransitPlaintext(plaintextB64)
+}
+
+// AWSKMSKeyWrapper wraps/unwraps the DEK via AWS KMS's Encrypt/Decrypt APIs.
+//
+// Security features:
+// - IAM-authenticated Encrypt/Decrypt calls, credentials resolved by the AWS SDK default chain
+// - The customer master key never leaves KMS; only ciphertext crosses the wire
+type AWSKMSKeyWrapper struct {
+	client *awskms.Client
+	keyID  string // KMS key ARN/alias
+}
+
+// NewAWSKMSKeyWrapper constructs an AWS KMS-backed KeyWrapper.
+//
+// Returns error if keyID is empty, since AWS KMS Encrypt requires it.
+func NewAWSKMSKeyWrapper(client *awskms.Client, keyID string) (*AWSKMSKeyWrapper, error) {
+	if keyID == "" {
+		return nil, fmt.Errorf("AWS KMS key id must not be empty")
+	}
+	return &AWSKMSKeyWrapper{client: client, keyID: keyID}, nil
+}
+
+// Name returns the provenance label logged around wrap/unwrap operations.
+func (a *AWSKMSKeyWrapper) Name() string {
+	return "aws-kms"
+}
+
+// Wrap encrypts dek via the AWS KMS Encrypt API.
+//
+// Returns the ciphertext blob, or error if the encrypt call fails.
+func (a *AWSKMSKeyWrapper) Wrap(ctx context.Context, dek []byte) ([]byte, error) {
+	out, err := a.client.Encrypt(ctx, &awskms.EncryptInput{KeyId: aws.String(a.keyID), Plaintext: dek})
+	if err != nil {
+		return nil, fmt.Errorf("AWS KMS encrypt failed: %v", err)
+	}
+	return out.CiphertextBlob, nil
+}
+
+// Unwrap decrypts a blob previously produced by Wrap via the AWS KMS Decrypt API.
+//
+// Returns plaintext DEK bytes, or error if decryption fails or the key length is wrong.
+func (a *AWSKMSKeyWrapper) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	out, err := a.client.Decrypt(ctx, &awskms.DecryptInput{CiphertextBlob: wrapped, KeyId: aws.String(a.keyID)})
+	if err != nil {
+		return nil, fmt.Errorf("AWS KMS decrypt failed: %v", err)
+	}
+	if err := ValidateKeyStrength(out.Plaintext); err != nil {
+		return nil, fmt.Errorf("AWS KMS unwrapped DEK validation failed: %v", err)
+	}
+	return out.Plaintext, nil
+}
+
+// GCPKMSKeyWrapper wraps/unwraps the DEK via Cloud KMS's Encrypt/Decrypt APIs.
+//
+// Security features:
+// - Service-account-authenticated Encrypt/Decrypt calls via the GCP KMS client library
+// - The CryptoKey never leaves Cloud KMS; only ciphertext crosses the wire
+type GCPKMSKeyWrapper struct {
+	client  *gcpkms.KeyManagementClient
+	keyName string // Fully-qualified CryptoKey resource name
+}
+
+// NewGCPKMSKeyWrapper constructs a GCP KMS-backed KeyWrapper.
+//
+// Returns error if keyName is empty.
+func NewGCPKMSKeyWrapper(client *gcpkms.KeyManagementClient, keyName string) (*GCPKMSKeyWrapper, error) {
+	if keyName == "" {
+		return nil, fmt.Errorf("GCP KMS key name must not be empty")
+	}
+	return &GCPKMSKeyWrapper{client: client, keyName: keyName}, nil
+}
+
+// Name returns the provenance label logged around wrap/unwrap operations.
+func (g *GCPKMSKeyWrapper) Name() string {
+	return "gcp-kms"
+}
+
+// Wrap encrypts dek via the Cloud KMS Encrypt API.
+//
+// Returns the ciphertext blob, or error if the encrypt call fails.
+func (g *GCPKMSKeyWrapper) Wrap(ctx context.Context, dek []byte) ([]byte, error) {
+	resp, err := g.client.Encrypt(ctx, &gcpkmspb.EncryptRequest{Name: g.keyName, Plaintext: dek})
+	if err != nil {
+		return nil, fmt.Errorf("GCP KMS encrypt failed: %v", err)
+	}
+	return resp.Ciphertext, nil
+}
+
+// Unwrap decrypts a blob previously produced by Wrap via the Cloud KMS Decrypt API.
+//
+// Returns plaintext DEK bytes, or error if decryption fails or the key length is wrong.
+func (g *GCPKMSKeyWrapper) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	resp, err := g.client.Decrypt(ctx, &gcpkmspb.DecryptRequest{Name: g.keyName, Ciphertext: wrapped})
+	if err != nil {
+		return nil, fmt.Errorf("GCP KMS decrypt failed: %v", err)
+	}
+	if err := ValidateKeyStrength(resp.Plaintext); err != nil {
+		return nil, fmt.Errorf("GCP KMS unwrapped DEK validation failed: %v", err)
+	}
+	return resp.Plaintext, nil
+}
+
+// AzureKeyVaultKeyWrapper wraps/unwraps the DEK via Azure Key Vault's WrapKey/UnwrapKey operations.
+//
+// Security features:
+// - Managed-identity or service-principal authentication via azidentity, never a static shared key
+// - The Key Vault key never leaves the HSM-backed vault; only ciphertext crosses the wire
+type AzureKeyVaultKeyWrapper struct {
+	client     *azkeys.Client
+	keyName    string
+	keyVersion string // Empty selects the vault's current key version
+}
+
+// NewAzureKeyVaultKeyWrapper constructs an Azure Key Vault-backed KeyWrapper.
+//
+// Returns error if client is nil or keyName is empty.
+func NewAzureKeyVaultKeyWrapper(client *azkeys.Client, keyName, keyVersion string) (*AzureKeyVaultKeyWrapper, error) {
+	if client == nil {
+		return nil, fmt.Errorf("azure key vault client must not be nil")
+	}
+	if keyName == "" {
+		return nil, fmt.Errorf("azure key vault key name must not be empty")
+	}
+	return &AzureKeyVaultKeyWrapper{client: client, keyName: keyName, keyVersion: keyVersion}, nil
+}
+
+// Name returns the provenance label logged around wrap/unwrap operations.
+func (z *AzureKeyVaultKeyWrapper) Name() string {
+	return "azure-keyvault"
+}
+
+// Wrap encrypts dek via Key Vault's WrapKey operation using RSA-OAEP-256.
+//
+// Returns the ciphertext (Azure calls it the "result"), or error if the wrap call fails.
+func (z *AzureKeyVaultKeyWrapper) Wrap(ctx context.Context, dek []byte) ([]byte, error) {
+	resp, err := z.client.WrapKey(ctx, z.keyName, z.keyVersion, azkeys.KeyOperationParameters{
+		Algorithm: to.Ptr(azkeys.EncryptionAlgorithmRSAOAEP256),
+		Value:     dek,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("azure key vault wrap failed: %v", err)
+	}
+	return resp.Result, nil
+}
+
+// Unwrap decrypts a blob previously produced by Wrap via Key Vault's UnwrapKey operation.
+//
+// Returns plaintext DEK bytes, or error if the unwrap call fails or the key length is wrong.
+func (z *AzureKeyVaultKeyWrapper) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	resp, err := z.client.UnwrapKey(ctx, z.keyName, z.keyVersion, azkeys.KeyOperationParameters{
+		Algorithm: to.Ptr(azkeys.EncryptionAlgorithmRSAOAEP256),
+		Value:     wrapped,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("azure key vault unwrap failed: %v", err)
+	}
+	if err := ValidateKeyStrength(resp.Result); err != nil {
+		return nil, fmt.Errorf("azure key vault unwrapped DEK validation failed: %v", err)
+	}
+	return resp.Result, nil
+}
+
+// encodeBase64Blob base64-encodes key material for transport to Vault's Transit API.
+func encodeBase64Blob(blob []byte) string {
+	return base64.StdEncoding.EncodeToString(blob)
+}
+
+// ConfiguredKeyWrapper builds the KeyWrapper selected by RAMUSB_KEY_WRAPPER
+// ("local-file", "vault-transit", "aws-kms", "gcp-kms", or "azure-keyvault"),
+// reading each backend's connection details from the same RAMUSB_* variables
+// configuredRemoteKeySources uses for the equivalent KeySource.
+//
+// Security features:
+// - Unset RAMUSB_KEY_WRAPPER defaults to "local-file", preserving today's single-static-key behavior
+// - Azure credentials resolve via azidentity's DefaultAzureCredential chain (managed identity first), never a static shared key
+//
+// Returns error if the selected backend is unrecognized, missing required
+// variables, or fails to initialize its client.
+//
+// TO-DO: Replace environment-variable wiring with config.KeyProvider selection
+func ConfiguredKeyWrapper(localMasterKey []byte) (KeyWrapper, error) {
+	switch mode := os.Getenv("RAMUSB_KEY_WRAPPER"); mode {
+	case "", "local-file":
+		return NewLocalFileKeyWrapper(localMasterKey)
+
+	case "vault-transit":
+		vaultConfig := vaultapi.DefaultConfig()
+		vaultConfig.Address = os.Getenv("RAMUSB_VAULT_ADDR")
+		client, err := vaultapi.NewClient(vaultConfig)
+		if err != nil {
+			return nil, fmt.Errorf("vault client initialization failed: %v", err)
+		}
+		if token := os.Getenv("RAMUSB_VAULT_TOKEN"); token != "" {
+			client.SetToken(token)
+		}
+		return NewVaultTransitKeyWrapper(client, os.Getenv("RAMUSB_VAULT_TRANSIT_MOUNT"), os.Getenv("RAMUSB_VAULT_TRANSIT_KEY"))
+
+	case "aws-kms":
+		return NewAWSKMSKeyWrapper(awskms.New(awskms.Options{}), os.Getenv("RAMUSB_AWS_KMS_KEY_ID"))
+
+	case "gcp-kms":
+		gcpClient, err := gcpkms.NewKeyManagementClient(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("GCP KMS client initialization failed: %v", err)
+		}
+		return NewGCPKMSKeyWrapper(gcpClient, os.Getenv("RAMUSB_GCP_KMS_KEY_NAME"))
+
+	case "azure-keyvault":
+		cred, err := azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			return nil, fmt.Errorf("azure credential initialization failed: %v", err)
+		}
+		client, err := azkeys.NewClient(os.Getenv("RAMUSB_AZURE_KEYVAULT_URL"), cred, nil)
+		if err != nil {
+			return nil, fmt.Errorf("azure key vault client initialization failed: %v", err)
+		}
+		return NewAzureKeyVaultKeyWrapper(client, os.Getenv("RAMUSB_AZURE_KEYVAULT_KEY_NAME"), os.Getenv("RAMUSB_AZURE_KEYVAULT_KEY_VERSION"))
+
+	default:
+		return nil, fmt.Errorf("unknown RAMUSB_KEY_WRAPPER %q", mode)
+	}
+}
@@ -4,87 +4,304 @@ Password hashing utilities for secure Database-Vault credential storage.
 Implements Argon2id password hashing with cryptographically secure salt
 generation to defend against rainbow table attacks and GPU-based brute force.
 Uses memory-hard algorithm parameters to resist specialized hardware attacks.
-Provides the final cryptographic layer before permanent credential storage
-in the Database-Vault secure storage system.
+Hashes are emitted and parsed as standard PHC-style strings, extended with a
+`pepper=v{n}` field (`$argon2id$v=19$m=32768,t=1,p=4,pepper=v1$<salt_b64>$<hash_b64>`)
+so salt, parameters, and pepper version all travel with the hash, allowing
+in-place parameter upgrades and pepper rotation without a schema change.
+Peppered passwords and candidate hashes are held in mlock'd SecureBuffers
+during verification, and compared with crypto/subtle.ConstantTimeCompare so
+hash comparison time does not leak where the first differing byte falls.
+Provides the final cryptographic layer before permanent credential storage in
+the Database-Vault secure storage system.
 
-TO-DO in HashPassword()
+HashPassword only ever produces Argon2id, but VerifyPassword dispatches on
+StoredUser.PasswordHash's self-describing scheme prefix (see schemes.go), so
+rows imported from a system that hashed with bcrypt, PBKDF2-SHA256, or
+sha512_crypt keep verifying without a bulk re-hash migration. A successful
+verification against any of those legacy schemes reports needsRehash so the
+caller (see handlers.LoginUserHandler) transparently upgrades the stored hash
+to Argon2id on the user's next login, the same heterogeneous-import pattern
+SFTPGo's dataprovider uses.
 */
 package crypto
 
 import (
 	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"strings"
 
 	"golang.org/x/crypto/argon2"
 )
 
-// GenerateSalt creates cryptographically secure random salt for password hashing.
+// argon2Params captures the tunable Argon2id cost parameters embedded in a PHC hash string.
+//
+// Security features:
+// - Self-describing parameters allow VerifyPassword to recompute with the exact settings a hash was created with, even after currentParams changes
+// - Enables detection of under-strength hashes via needsRehash
+// - pepperVersion of 0 means no pepper was mixed in (legacy-migrated hashes)
+//
+// Stored inline in every PHC-encoded hash; never persisted separately.
+type argon2Params struct {
+	memory        uint32 // Memory cost in KiB
+	time          uint32 // Number of iterations
+	threads       uint8  // Degree of parallelism
+	pepperVersion int    // PepperProvider version mixed into the password, 0 if none
+}
+
+// currentParams defines the Argon2id cost parameters used for all newly created hashes.
+//
+// Security features:
+// - Single source of truth for "current" strength, compared against stored hashes to flag weaker legacy parameters for rehash
+// - Chosen to resist GPU attacks while maintaining reasonable server performance
+var currentParams = argon2Params{memory: 32 * 1024, time: 1, threads: 4}
+
+// GenerateSalt creates cryptographically secure random salt for legacy hash migration.
 //
 // Security features:
 // - Uses crypto/rand for unpredictable entropy source
 // - 16-byte length provides sufficient uniqueness against collisions
-// - Hexadecimal encoding prevents binary storage issues
-// - Unique salt per user prevents rainbow table attacks across user base
+// - Hexadecimal encoding matches the pre-PHC on-disk representation
 //
+// Retained for MigrateLegacyHash; HashPassword generates its own salt internally.
 // Returns hex-encoded salt string and error if entropy source fails.
 func GenerateSalt() (string, error) {
-	// SALT GENERATION
-	// Create 16-byte buffer for cryptographically secure randomness
 	salt := make([]byte, 16)
-	_, err := rand.Read(salt)
-	if err != nil {
+	if _, err := rand.Read(salt); err != nil {
 		// Entropy source failure - critical security error
 		return "", err
 	}
-
-	// ENCODING
-	// Convert to hexadecimal for safe database storage and transmission
 	return fmt.Sprintf("%x", salt), nil
 }
 
-// HashPassword generates Argon2id hash with provided salt for secure database storage.
+// HashPassword generates a PHC-encoded Argon2id hash for secure database storage.
 //
 // Security features:
 // - Argon2id algorithm resists both time-memory and side-channel attacks
 // - Memory-hard parameters (32MB) defend against GPU acceleration attacks
-// - Single iteration with medium-high memory usage balances security and performance
-// - Deterministic output with same password+salt for login verification
-//
-// Returns hex-encoded hash suitable for Database-Vault permanent storage.
+// - Fresh 16-byte random salt per call prevents rainbow table attacks
+// - Pepper mixed in before hashing via the default PepperProvider, so a database-only compromise is not enough to brute-force stored passwords
+// - Self-describing PHC string keeps salt, parameters, and pepper version alongside the hash, enabling upgrades without a separate salt column
 //
-// TO-DO: Add pepper integration - passwordWithPepper := password + config.GetPepper()
-// TO-DO: This prevents offline attacks even if database is compromised
-func HashPassword(password, salt string) string {
-	// PARAMETER CONVERSION
-	// Convert salt to bytes for Argon2id algorithm requirements
-	saltBytes := []byte(salt)
+// Returns a PHC-encoded string (`$argon2id$v=19$m=...,t=...,p=...,pepper=v...$salt$hash`)
+// suitable for Database-Vault permanent storage, or error if salt generation
+// or pepper lookup fails.
+func HashPassword(password string) (string, error) {
+	// PEPPER LOOKUP
+	// Mix in the current pepper version so the hash depends on a secret
+	// never stored in the database
+	pepperVersion, pepper, err := currentPepper()
+	if err != nil {
+		return "", fmt.Errorf("failed to load pepper: %v", err)
+	}
+
+	// SALT GENERATION
+	// Fresh cryptographically secure salt for this hash only
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %v", err)
+	}
 
 	// ARGON2ID HASHING
 	// Parameters: 1 iteration, 32MB memory, 4 threads, 32-byte output
-	// Chosen to resist GPU attacks while maintaining reasonable server performance
-	hash := argon2.IDKey([]byte(password), saltBytes, 1, 32*1024, 4, 32)
+	peppered := append([]byte(password), pepper...)
+	hash := argon2.IDKey(peppered, salt, currentParams.time, currentParams.memory, currentParams.threads, 32)
+
+	// PHC ENCODING
+	// Embed version, parameters, pepper version, salt, and hash in the PHC string format
+	params := currentParams
+	params.pepperVersion = pepperVersion
+	return encodePHC(params, salt, hash), nil
+}
 
-	// ENCODING
-	// Convert hash to hexadecimal for consistent database storage format
-	return fmt.Sprintf("%x", hash)
+// VerifyPassword compares a password against storedHash, dispatching to the
+// scheme its self-describing prefix names (see schemes.go).
+//
+// Security features:
+// - Scheme dispatch by prefix means a row imported from another system verifies under its original algorithm, never silently treated as Argon2id
+// - needsRehash is unconditionally true for any successful verification under a non-Argon2id scheme, since Argon2id is the only one HashPassword ever produces
+// - No password storage or logging during verification process
+//
+// Returns whether the password matches, whether the hash should be upgraded
+// to Argon2id, and an error if storedHash's prefix names no recognized scheme
+// or the hash is malformed for that scheme.
+func VerifyPassword(password, storedHash string) (valid bool, needsRehash bool, err error) {
+	switch {
+	case strings.HasPrefix(storedHash, "$argon2id$"):
+		return verifyArgon2id(password, storedHash)
+	case strings.HasPrefix(storedHash, "$2a$"), strings.HasPrefix(storedHash, "$2b$"), strings.HasPrefix(storedHash, "$2y$"):
+		return verifyBcrypt(password, storedHash)
+	case strings.HasPrefix(storedHash, "$pbkdf2-sha256$"):
+		return verifyPBKDF2SHA256(password, storedHash)
+	case strings.HasPrefix(storedHash, "$6$"):
+		return verifySHA512Crypt(password, storedHash)
+	default:
+		return false, false, fmt.Errorf("unrecognized password hash scheme")
+	}
 }
 
-// VerifyPassword compares provided password against stored hash and salt.
+// verifyArgon2id compares a password against a PHC-encoded Argon2id hash.
 //
 // Security features:
-// - Constant-time comparison prevents timing attacks on password verification
-// - Uses same Argon2id parameters as HashPassword for consistency
+// - Recomputes the hash using the exact parameters embedded in the stored string, so legacy hashes created under weaker parameters still verify
+// - Looks up the pepper by the version stamped in the stored hash, so verification keeps working for users hashed before a pepper rotation
+// - Reports needsRehash so callers can transparently upgrade users whose stored parameters or pepper version are weaker/older than current
 // - No password storage or logging during verification process
-// - Secure hash regeneration with provided salt for comparison
 //
-// Returns true if password matches stored credentials, false otherwise.
-func VerifyPassword(password, storedHash, storedSalt string) bool {
+// Returns whether the password matches, whether the hash should be upgraded,
+// and an error if the stored string is not a well-formed PHC argon2id hash.
+func verifyArgon2id(password, storedHash string) (valid bool, needsRehash bool, err error) {
+	params, salt, hash, err := decodePHC(storedHash)
+	if err != nil {
+		return false, false, fmt.Errorf("malformed stored hash: %v", err)
+	}
+
+	// PEPPER LOOKUP
+	// Resolve the pepper bytes for the version stamped in the stored hash
+	var pepper []byte
+	if params.pepperVersion > 0 {
+		pepper, err = lookupPepper(params.pepperVersion)
+		if err != nil {
+			return false, false, fmt.Errorf("failed to load pepper version %d: %v", params.pepperVersion, err)
+		}
+	}
+
 	// HASH REGENERATION
-	// Generate hash with same parameters used during storage
-	candidateHash := HashPassword(password, storedSalt)
+	// Recompute using the parameters embedded in the stored hash, holding both
+	// the peppered password and the candidate hash in mlock'd SecureBuffers
+	// for the duration of the comparison
+	pepperedBuf, err := NewSecureBuffer(append([]byte(password), pepper...))
+	if err != nil {
+		return false, false, fmt.Errorf("failed to lock peppered password in memory: %v", err)
+	}
+	defer pepperedBuf.Destroy()
+
+	candidateHash := argon2.IDKey(pepperedBuf.Bytes(), salt, params.time, params.memory, params.threads, uint32(len(hash)))
+	candidateBuf, err := NewSecureBuffer(candidateHash)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to lock candidate hash in memory: %v", err)
+	}
+	defer candidateBuf.Destroy()
+	SecureKeyCleanup(candidateHash)
+
+	// COMPARISON
+	// subtle.ConstantTimeCompare runs in time independent of where the first
+	// differing byte falls, unlike Go string/byte-slice equality
+	valid = len(candidateBuf.Bytes()) == len(hash) && subtle.ConstantTimeCompare(candidateBuf.Bytes(), hash) == 1
+
+	// UPGRADE CHECK
+	// Flag for rehash if the stored hash used weaker-than-current parameters
+	// or an outdated pepper version
+	currentPepperVersion, _, pepperErr := currentPepper()
+	needsRehash = valid && (params.memory < currentParams.memory ||
+		params.time < currentParams.time ||
+		params.threads < currentParams.threads ||
+		(pepperErr == nil && params.pepperVersion < currentPepperVersion))
+
+	return valid, needsRehash, nil
+}
+
+// currentPepper resolves the active pepper version and bytes from the
+// process-wide default PepperProvider.
+//
+// Returns the active version and derived pepper, or error if no provider is configured.
+func currentPepper() (int, []byte, error) {
+	provider, err := defaultPepper()
+	if err != nil {
+		return 0, nil, err
+	}
+	return provider.Current()
+}
+
+// lookupPepper resolves the derived pepper bytes for a specific version from
+// the process-wide default PepperProvider.
+//
+// Returns the derived pepper, or error if no provider is configured.
+func lookupPepper(version int) ([]byte, error) {
+	provider, err := defaultPepper()
+	if err != nil {
+		return nil, err
+	}
+	return provider.Pepper(version)
+}
+
+// MigrateLegacyHash converts a pre-PHC hex hash + hex salt pair into the PHC-encoded form.
+//
+// Security features:
+// - Uses the same Argon2id parameters the legacy hex format was always hashed with
+// - Produces an encoding VerifyPassword can parse directly, no schema change required
+//
+// Returns PHC-encoded hash string or error if the legacy hash is not valid hex.
+func MigrateLegacyHash(legacyHash, legacySalt string) (string, error) {
+	hashBytes, err := hex.DecodeString(legacyHash)
+	if err != nil {
+		return "", fmt.Errorf("invalid legacy hash encoding: %v", err)
+	}
+
+	// Legacy rows were always hashed with these parameters (see git history of HashPassword)
+	// and predate pepper support, so pepperVersion stays 0
+	legacyParams := argon2Params{memory: 32 * 1024, time: 1, threads: 4}
+	return encodePHC(legacyParams, []byte(legacySalt), hashBytes), nil
+}
+
+// encodePHC formats Argon2id parameters, pepper version, salt, and hash as a PHC string.
+//
+// Returns a string of the form `$argon2id$v=19$m=...,t=...,p=...,pepper=v...$salt_b64$hash_b64`,
+// omitting the pepper field entirely when pepperVersion is 0 (no pepper).
+func encodePHC(p argon2Params, salt, hash []byte) string {
+	params := fmt.Sprintf("m=%d,t=%d,p=%d", p.memory, p.time, p.threads)
+	if p.pepperVersion > 0 {
+		params = fmt.Sprintf("%s,pepper=v%d", params, p.pepperVersion)
+	}
+	return fmt.Sprintf("$argon2id$v=%d$%s$%s$%s",
+		argon2.Version, params,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash))
+}
+
+// decodePHC parses a standard Argon2id PHC string into its component parts.
+//
+// Returns the embedded parameters (with pepperVersion 0 if the hash predates
+// pepper support), salt, and hash, or error if the string is malformed.
+func decodePHC(encoded string) (argon2Params, []byte, []byte, error) {
+	// EXPECTED SHAPE: "", "argon2id", "v=19", "m=...,t=...,p=...[,pepper=v...]", salt, hash
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return argon2Params{}, nil, nil, fmt.Errorf("not a recognized argon2id PHC string")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return argon2Params{}, nil, nil, fmt.Errorf("invalid version field: %v", err)
+	}
+
+	var p argon2Params
+	var threads uint32
+	paramFields := strings.Split(parts[3], ",")
+	if len(paramFields) < 3 {
+		return argon2Params{}, nil, nil, fmt.Errorf("invalid parameter field: %q", parts[3])
+	}
+	if _, err := fmt.Sscanf(strings.Join(paramFields[:3], ","), "m=%d,t=%d,p=%d", &p.memory, &p.time, &threads); err != nil {
+		return argon2Params{}, nil, nil, fmt.Errorf("invalid parameter field: %v", err)
+	}
+	p.threads = uint8(threads)
+	if len(paramFields) >= 4 {
+		if _, err := fmt.Sscanf(paramFields[3], "pepper=v%d", &p.pepperVersion); err != nil {
+			return argon2Params{}, nil, nil, fmt.Errorf("invalid pepper field: %v", err)
+		}
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return argon2Params{}, nil, nil, fmt.Errorf("invalid salt encoding: %v", err)
+	}
+
+	hash, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return argon2Params{}, nil, nil, fmt.Errorf("invalid hash encoding: %v", err)
+	}
 
-	// CONSTANT-TIME COMPARISON
-	// Prevent timing attacks by comparing full hash strings
-	// Go's string comparison is not guaranteed constant-time, but hash length is fixed
-	return candidateHash == storedHash
+	return p, salt, hash, nil
 }
@@ -0,0 +1,132 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/GehirnInc/crypt/sha512_crypt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// These fixtures each encode the same password under one of the four schemes
+// VerifyPassword dispatches on by prefix (see schemes.go), so the dispatch
+// itself - not just the individual verifiers - is exercised.
+const testPassword = "correct horse battery staple"
+
+func TestVerifyPasswordArgon2id(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	hash := argon2.IDKey([]byte(testPassword), salt, currentParams.time, currentParams.memory, currentParams.threads, 32)
+	storedHash := encodePHC(currentParams, salt, hash)
+
+	valid, needsRehash, err := VerifyPassword(testPassword, storedHash)
+	if err != nil {
+		t.Fatalf("VerifyPassword: %v", err)
+	}
+	if !valid {
+		t.Fatal("VerifyPassword: got valid=false, want true for matching password")
+	}
+	if needsRehash {
+		t.Fatal("VerifyPassword: got needsRehash=true, want false for a hash already at current parameters")
+	}
+
+	valid, _, err = VerifyPassword("wrong password", storedHash)
+	if err != nil {
+		t.Fatalf("VerifyPassword(wrong password): %v", err)
+	}
+	if valid {
+		t.Fatal("VerifyPassword(wrong password): got valid=true, want false")
+	}
+}
+
+func TestVerifyPasswordBcrypt(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.DefaultCost)
+	if err != nil {
+		t.Fatalf("bcrypt.GenerateFromPassword: %v", err)
+	}
+
+	valid, needsRehash, err := VerifyPassword(testPassword, string(hash))
+	if err != nil {
+		t.Fatalf("VerifyPassword: %v", err)
+	}
+	if !valid {
+		t.Fatal("VerifyPassword: got valid=false, want true for matching password")
+	}
+	if !needsRehash {
+		t.Fatal("VerifyPassword: got needsRehash=false, want true - bcrypt is never the current scheme")
+	}
+
+	valid, _, err = VerifyPassword("wrong password", string(hash))
+	if err != nil {
+		t.Fatalf("VerifyPassword(wrong password): %v", err)
+	}
+	if valid {
+		t.Fatal("VerifyPassword(wrong password): got valid=true, want false")
+	}
+}
+
+func TestVerifyPasswordPBKDF2SHA256(t *testing.T) {
+	salt := []byte("fedcba9876543210")
+	iterations := 29000
+	derived := pbkdf2.Key([]byte(testPassword), salt, iterations, 32, sha256.New)
+	storedHash := fmt.Sprintf("$pbkdf2-sha256$%d$%s$%s",
+		iterations,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(derived))
+
+	valid, needsRehash, err := VerifyPassword(testPassword, storedHash)
+	if err != nil {
+		t.Fatalf("VerifyPassword: %v", err)
+	}
+	if !valid {
+		t.Fatal("VerifyPassword: got valid=false, want true for matching password")
+	}
+	if !needsRehash {
+		t.Fatal("VerifyPassword: got needsRehash=false, want true - pbkdf2-sha256 is never the current scheme")
+	}
+
+	valid, _, err = VerifyPassword("wrong password", storedHash)
+	if err != nil {
+		t.Fatalf("VerifyPassword(wrong password): %v", err)
+	}
+	if valid {
+		t.Fatal("VerifyPassword(wrong password): got valid=true, want false")
+	}
+}
+
+func TestVerifyPasswordSHA512Crypt(t *testing.T) {
+	c := sha512_crypt.New()
+	storedHash, err := c.Generate([]byte(testPassword), nil)
+	if err != nil {
+		t.Fatalf("sha512_crypt Generate: %v", err)
+	}
+
+	valid, needsRehash, err := VerifyPassword(testPassword, storedHash)
+	if err != nil {
+		t.Fatalf("VerifyPassword: %v", err)
+	}
+	if !valid {
+		t.Fatal("VerifyPassword: got valid=false, want true for matching password")
+	}
+	if !needsRehash {
+		t.Fatal("VerifyPassword: got needsRehash=false, want true - sha512_crypt is never the current scheme")
+	}
+
+	valid, _, err = VerifyPassword("wrong password", storedHash)
+	if err != nil {
+		t.Fatalf("VerifyPassword(wrong password): %v", err)
+	}
+	if valid {
+		t.Fatal("VerifyPassword(wrong password): got valid=true, want false")
+	}
+}
+
+func TestVerifyPasswordUnrecognizedScheme(t *testing.T) {
+	_, _, err := VerifyPassword(testPassword, "$unknown$scheme$hash")
+	if err == nil {
+		t.Fatal("VerifyPassword(unrecognized scheme): got nil error, want an error")
+	}
+}
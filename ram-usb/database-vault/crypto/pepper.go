@@ -0,0 +1,196 @@
+/*
+Pepper management utilities for Database-Vault password hashing.
+
+Implements versioned pepper derivation so HashPassword and VerifyPassword can
+mix a secret not stored alongside the database into every Argon2id hash.
+Peppers are never persisted directly; instead, each version is derived from a
+single master pepper secret via HKDF-SHA256, so old versions remain
+reproducible - and therefore verifiable - after rotation. Provides the
+defense-in-depth layer that keeps credentials safe even if the database
+(including salts and parameters) is fully compromised.
+
+TO-DO: Load the master pepper secret from Vault/KMS instead of environment only
+*/
+package crypto
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// PepperProvider supplies versioned pepper material for password hashing.
+//
+// Security features:
+// - Versioned lookup allows old hashes to keep verifying across rotations
+// - Implementations keep the master secret out of the database entirely
+//
+// Used by HashPassword and VerifyPassword to mix a database-independent
+// secret into every Argon2id computation.
+type PepperProvider interface {
+	// Current returns the active pepper version and its derived pepper bytes.
+	Current() (version int, pepper []byte, err error)
+	// Pepper returns the derived pepper bytes for a specific version.
+	Pepper(version int) ([]byte, error)
+}
+
+// hkdfPepperProvider derives per-version peppers from a single master secret
+// using HKDF-SHA256, caching derived versions to avoid redundant derivation.
+//
+// Security features:
+// - Per-version HKDF context ("ramusb-pepper-v{n}") provides domain separation so peppers cannot be confused or replayed across versions
+// - Master secret never leaves this provider; only derived bytes are used
+//
+// Satisfies the PepperProvider interface for environment-sourced master secrets.
+type hkdfPepperProvider struct {
+	mu           sync.Mutex
+	masterSecret []byte
+	currentVer   int
+	derivedCache map[int][]byte
+}
+
+// defaultPepperProvider is the process-wide PepperProvider used by HashPassword
+// and VerifyPassword, initialized lazily from environment configuration.
+var (
+	defaultPepperProvider     PepperProvider
+	defaultPepperProviderOnce sync.Once
+	defaultPepperProviderErr  error
+)
+
+// pepperDerivedLength is the byte length of each derived per-version pepper.
+const pepperDerivedLength = 32
+
+// NewHKDFPepperProvider builds a PepperProvider that derives per-version
+// peppers from masterSecret via HKDF-SHA256, starting at currentVersion.
+//
+// Security features:
+// - Requires a non-empty master secret; refuses to operate on empty input
+// - currentVersion becomes the version HashPassword stamps onto new hashes
+//
+// Returns a ready-to-use PepperProvider or error if masterSecret is empty.
+func NewHKDFPepperProvider(masterSecret []byte, currentVersion int) (PepperProvider, error) {
+	if len(masterSecret) == 0 {
+		return nil, fmt.Errorf("master pepper secret cannot be empty")
+	}
+	if currentVersion < 1 {
+		return nil, fmt.Errorf("pepper version must be >= 1, got %d", currentVersion)
+	}
+
+	return &hkdfPepperProvider{
+		masterSecret: masterSecret,
+		currentVer:   currentVersion,
+		derivedCache: make(map[int][]byte),
+	}, nil
+}
+
+// Current returns the active pepper version and its derived pepper bytes.
+//
+// Returns the configured current version and derived pepper, or error if
+// derivation fails.
+func (p *hkdfPepperProvider) Current() (int, []byte, error) {
+	pepper, err := p.Pepper(p.currentVer)
+	if err != nil {
+		return 0, nil, err
+	}
+	return p.currentVer, pepper, nil
+}
+
+// Pepper returns the derived pepper bytes for a specific version, deriving
+// and caching it on first use.
+//
+// Returns derived pepper bytes or error if HKDF derivation fails.
+func (p *hkdfPepperProvider) Pepper(version int) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if cached, ok := p.derivedCache[version]; ok {
+		return cached, nil
+	}
+
+	// PER-VERSION DERIVATION
+	// Context string binds the derived pepper to this exact version
+	derived, err := DeriveKey(KeyDerivationInfo{
+		MasterKey: p.masterSecret,
+		Context:   fmt.Sprintf("ramusb-pepper-v%d", version),
+		Length:    pepperDerivedLength,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive pepper version %d: %v", version, err)
+	}
+
+	p.derivedCache[version] = derived
+	return derived, nil
+}
+
+// RotatePepper advances the active pepper version for all future hashes.
+//
+// Security features:
+// - Previously issued hashes keep verifying via Pepper(oldVersion) lookup
+// - Callers should flag existing users for rehash so they migrate to the new version on next successful login (see VerifyPassword's needsRehash)
+//
+// Returns error if newVersion is not strictly greater than the current one.
+func (p *hkdfPepperProvider) RotatePepper(newVersion int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if newVersion <= p.currentVer {
+		return fmt.Errorf("new pepper version %d must be greater than current version %d", newVersion, p.currentVer)
+	}
+
+	p.currentVer = newVersion
+	return nil
+}
+
+// RotatePepper advances the process-wide default PepperProvider to newVersion.
+//
+// Returns error if the default provider is unavailable or rotation fails.
+//
+// TO-DO: Persist the new version to shared config so other instances pick it up
+func RotatePepper(newVersion int) error {
+	provider, err := defaultPepper()
+	if err != nil {
+		return err
+	}
+
+	rotator, ok := provider.(*hkdfPepperProvider)
+	if !ok {
+		return fmt.Errorf("configured pepper provider does not support rotation")
+	}
+
+	return rotator.RotatePepper(newVersion)
+}
+
+// PepperHealthCheck verifies the process-wide default PepperProvider is configured
+// and can resolve its current pepper version, for use by health check endpoints.
+//
+// Returns nil if the pepper subsystem is ready, or error describing why it isn't.
+func PepperHealthCheck() error {
+	_, _, err := currentPepper()
+	return err
+}
+
+// defaultPepper lazily initializes the process-wide PepperProvider from the
+// RAMUSB_PEPPER_SECRET and RAMUSB_PEPPER_VERSION environment variables.
+//
+// Returns the shared PepperProvider or error if the master secret is missing.
+func defaultPepper() (PepperProvider, error) {
+	defaultPepperProviderOnce.Do(func() {
+		secretHex := os.Getenv("RAMUSB_PEPPER_SECRET")
+		if secretHex == "" {
+			defaultPepperProviderErr = fmt.Errorf("RAMUSB_PEPPER_SECRET environment variable not set")
+			return
+		}
+
+		version := 1
+		if verStr := os.Getenv("RAMUSB_PEPPER_VERSION"); verStr != "" {
+			if _, err := fmt.Sscanf(verStr, "%d", &version); err != nil {
+				defaultPepperProviderErr = fmt.Errorf("invalid RAMUSB_PEPPER_VERSION: %v", err)
+				return
+			}
+		}
+
+		defaultPepperProvider, defaultPepperProviderErr = NewHKDFPepperProvider([]byte(secretHex), version)
+	})
+
+	return defaultPepperProvider, defaultPepperProviderErr
+}
@@ -0,0 +1,53 @@
+/*
+Password-reset token generation and hashing for Database-Vault.
+
+Reset tokens are high-entropy random values, not user-chosen secrets, so
+unlike HashPassword's Argon2id they are hashed with a fast, unsalted
+SHA-256: the token's own randomness is what resists guessing, and a fast
+hash lets ConsumePasswordResetToken look one up by hash in a single indexed
+query instead of scanning every pending token to run a slow KDF against it.
+*/
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// resetTokenBytes is the amount of randomness in a generated reset token,
+// matching the blind-index HMAC key size used elsewhere in this package.
+const resetTokenBytes = 32
+
+// GenerateResetToken returns a new random, hex-encoded password-reset token.
+//
+// Security features:
+// - 32 bytes (256 bits) of crypto/rand output makes guessing or enumerating
+//   a live token infeasible regardless of ConsumePasswordResetToken's lookup cost
+//
+// The plaintext token is the caller's sole responsibility to deliver
+// out-of-band; only HashResetToken's digest of it should ever be persisted.
+//
+// Returns error if the system's CSPRNG fails to produce randomness.
+func GenerateResetToken() (string, error) {
+	buf := make([]byte, resetTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate reset token: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// HashResetToken returns the SHA-256 hex digest of token, for storage and
+// lookup in place of the plaintext value.
+//
+// Security features:
+// - Database compromise yields only hashes; replaying a reset requires the
+//   original high-entropy token, not just read access to the row
+//
+// Used identically by CreatePasswordResetToken (to persist) and
+// ConsumePasswordResetToken (to look up) so the two always agree.
+func HashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
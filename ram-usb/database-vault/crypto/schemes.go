@@ -0,0 +1,96 @@
+/*
+Legacy password-hash scheme verifiers for Database-Vault migration support.
+
+HashPassword (see password.go) only ever produces Argon2id, but VerifyPassword
+dispatches on a stored hash's PHC-style scheme prefix, so accounts imported
+from a system that hashed with bcrypt, PBKDF2-SHA256, or sha512_crypt keep
+authenticating under their original algorithm rather than requiring a bulk
+re-hash migration before Database-Vault can serve them. Every verifier here
+reports needsRehash unconditionally on a successful match, since Argon2id is
+the only scheme HashPassword ever writes back.
+*/
+package crypto
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/GehirnInc/crypt"
+	"github.com/GehirnInc/crypt/sha512_crypt"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// verifyBcrypt compares a password against a bcrypt hash
+// (`$2a$`/`$2b$`/`$2y$` prefixed).
+//
+// Returns whether the password matches, true for needsRehash on any match,
+// and an error only if storedHash is malformed rather than simply mismatched.
+func verifyBcrypt(password, storedHash string) (valid bool, needsRehash bool, err error) {
+	err = bcrypt.CompareHashAndPassword([]byte(storedHash), []byte(password))
+	switch {
+	case err == nil:
+		return true, true, nil
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return false, false, nil
+	default:
+		return false, false, fmt.Errorf("malformed bcrypt hash: %v", err)
+	}
+}
+
+// verifyPBKDF2SHA256 compares a password against a PBKDF2-HMAC-SHA256 hash
+// encoded as `$pbkdf2-sha256$<iterations>$<salt_b64>$<hash_b64>`, our own
+// encoding of the widely-used pbkdf2_sha256 scheme, using unpadded standard
+// base64 rather than passlib's custom alphabet.
+//
+// Returns whether the password matches, true for needsRehash on any match,
+// and an error if storedHash does not parse as that format.
+func verifyPBKDF2SHA256(password, storedHash string) (valid bool, needsRehash bool, err error) {
+	parts := strings.Split(storedHash, "$")
+	if len(parts) != 5 || parts[0] != "" || parts[1] != "pbkdf2-sha256" {
+		return false, false, fmt.Errorf("malformed pbkdf2-sha256 hash")
+	}
+
+	iterations, err := strconv.Atoi(parts[2])
+	if err != nil || iterations <= 0 {
+		return false, false, fmt.Errorf("invalid pbkdf2-sha256 iteration count: %q", parts[2])
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false, false, fmt.Errorf("invalid pbkdf2-sha256 salt encoding: %v", err)
+	}
+
+	want, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false, fmt.Errorf("invalid pbkdf2-sha256 hash encoding: %v", err)
+	}
+
+	got := pbkdf2.Key([]byte(password), salt, iterations, len(want), sha256.New)
+	valid = subtle.ConstantTimeCompare(got, want) == 1
+	return valid, valid, nil
+}
+
+// verifySHA512Crypt compares a password against a glibc crypt(3) sha512_crypt
+// hash (`$6$` prefixed), via the same library SFTPGo's dataprovider uses for
+// this scheme.
+//
+// Returns whether the password matches, true for needsRehash on any match,
+// and an error only if storedHash is malformed rather than simply mismatched.
+func verifySHA512Crypt(password, storedHash string) (valid bool, needsRehash bool, err error) {
+	c := sha512_crypt.New()
+	err = c.Verify(storedHash, []byte(password))
+	switch {
+	case err == nil:
+		return true, true, nil
+	case errors.Is(err, crypt.ErrKeyMismatch):
+		return false, false, nil
+	default:
+		return false, false, fmt.Errorf("malformed sha512_crypt hash: %v", err)
+	}
+}
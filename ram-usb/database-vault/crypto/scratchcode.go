@@ -0,0 +1,71 @@
+/*
+MFA scratch-code generation and hashing for Database-Vault.
+
+Scratch codes are the fallback a user redeems when their TOTP device is
+unavailable; like GenerateResetToken they are high-entropy random values
+rather than user-chosen secrets, so HashScratchCode uses the same fast,
+unsalted SHA-256 as crypto.HashResetToken - the code's own randomness is
+what resists guessing, not the hash's cost.
+*/
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"fmt"
+)
+
+// scratchCodeBytes is the amount of randomness in one generated scratch
+// code, before base32 encoding.
+const scratchCodeBytes = 10
+
+// scratchCodeCount is how many single-use scratch codes ConfirmMFAHandler
+// issues alongside enabling MFA.
+const scratchCodeCount = 10
+
+// GenerateScratchCodes returns scratchCodeCount freshly generated, single-use
+// MFA backup codes, base32-encoded (Crockford-free, RFC 4648 alphabet) for
+// easy manual transcription.
+//
+// Returns error if the system's CSPRNG fails to produce randomness.
+func GenerateScratchCodes() ([]string, error) {
+	codes := make([]string, scratchCodeCount)
+	for i := range codes {
+		buf := make([]byte, scratchCodeBytes)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to generate scratch code: %v", err)
+		}
+		codes[i] = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf)
+	}
+	return codes, nil
+}
+
+// HashScratchCode returns the SHA-256 hex digest of code, for storage and
+// lookup in place of the plaintext value - see crypto.HashResetToken, which
+// this mirrors exactly.
+func HashScratchCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return fmt.Sprintf("%x", sum)
+}
+
+// VerifyScratchCode reports whether code hashes to one of hashes, comparing
+// in constant time so a timing side-channel can't reveal how close a guess
+// came to a real hash.
+//
+// Returns the index of the matched hash (so the caller can remove it,
+// enforcing single use) and true, or (-1, false) if no hash matched.
+func VerifyScratchCode(code string, hashes []string) (int, bool) {
+	candidate := []byte(HashScratchCode(code))
+	matched := -1
+	for i, h := range hashes {
+		if subtle.ConstantTimeCompare(candidate, []byte(h)) == 1 {
+			matched = i
+		}
+	}
+	if matched == -1 {
+		return -1, false
+	}
+	return matched, true
+}
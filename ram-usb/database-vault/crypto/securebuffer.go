@@ -0,0 +1,98 @@
+/*
+Secure in-memory handling for sensitive key material in Database-Vault.
+
+Implements SecureBuffer, a wrapper around sensitive byte slices that locks
+their backing memory against swapping via mlock (see securebuffer_unix.go and
+securebuffer_other.go for the platform-specific implementation) and guarantees
+zeroization through both an explicit Destroy call and a runtime finalizer, so
+key material cleanup isn't solely dependent on every caller remembering to
+call SecureKeyCleanup. Used wherever encryption keys and derived key material
+are loaded or computed, reducing the exposure window for process memory dumps.
+*/
+package crypto
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// SecureBuffer wraps sensitive byte slices with memory locking and guaranteed zeroization.
+//
+// Security features:
+// - mlock'd backing memory resists being swapped to disk while in use
+// - Destroy zeroizes and munlocks; a finalizer calls Destroy automatically if a caller forgets, so key material never outlives garbage collection
+// - Bytes() panics after Destroy to catch use-after-destroy bugs during development
+//
+// Construct with NewSecureBuffer; always defer Destroy() once done with the key material.
+type SecureBuffer struct {
+	mu        sync.Mutex
+	data      []byte
+	destroyed bool
+}
+
+// NewSecureBuffer copies data into a newly allocated, mlock'd SecureBuffer.
+//
+// Security features:
+// - Copies rather than adopts the input slice, so the caller's copy can be independently zeroized without affecting the SecureBuffer's own lifetime
+// - Registers a finalizer so Destroy runs even if the caller never calls it
+//
+// Returns the SecureBuffer, or error if the backing memory cannot be locked.
+func NewSecureBuffer(data []byte) (*SecureBuffer, error) {
+	buf := &SecureBuffer{data: make([]byte, len(data))}
+	copy(buf.data, data)
+
+	if len(buf.data) > 0 {
+		if err := mlock(buf.data); err != nil {
+			return nil, fmt.Errorf("failed to lock secure buffer memory: %v", err)
+		}
+	}
+
+	// FINALIZER REGISTRATION
+	// Guarantees zeroization even if the caller never calls Destroy explicitly
+	runtime.SetFinalizer(buf, func(b *SecureBuffer) {
+		b.Destroy()
+	})
+
+	return buf, nil
+}
+
+// Bytes returns the buffer's current contents for read-only use.
+//
+// Security features:
+// - Panics if called after Destroy, surfacing use-after-destroy bugs immediately rather than silently returning zeroed or freed memory
+//
+// Returns the underlying byte slice; callers must not retain it past Destroy.
+func (b *SecureBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.destroyed {
+		panic("crypto: use of SecureBuffer after Destroy")
+	}
+	return b.data
+}
+
+// Destroy overwrites the buffer's memory, releases its memory lock, and marks
+// it destroyed. Safe to call multiple times or from a finalizer.
+//
+// Security features:
+// - Multi-pass overwrite (0x00, then 0xFF, then 0x00) matches SecureKeyCleanup
+// - Idempotent, so both an explicit Destroy and the finalizer's Destroy are safe
+func (b *SecureBuffer) Destroy() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.destroyed {
+		return
+	}
+
+	if len(b.data) > 0 {
+		SecureKeyCleanup(b.data)
+		munlock(b.data)
+	}
+
+	b.data = nil
+	b.destroyed = true
+	runtime.SetFinalizer(b, nil)
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package crypto
+
+// mlock is a no-op on non-unix platforms, which lack the mlock syscall.
+//
+// Returns nil always; SecureBuffer still zeroizes on Destroy even without
+// memory locking.
+func mlock(data []byte) error {
+	return nil
+}
+
+// munlock is a no-op on non-unix platforms, matching mlock.
+//
+// Returns nil always.
+func munlock(data []byte) error {
+	return nil
+}
@@ -0,0 +1,20 @@
+//go:build unix
+
+package crypto
+
+import "golang.org/x/sys/unix"
+
+// mlock locks data's backing memory against swapping on unix platforms.
+//
+// Returns nil on success, or the underlying mlock syscall error.
+func mlock(data []byte) error {
+	return unix.Mlock(data)
+}
+
+// munlock releases a memory lock previously acquired by mlock.
+//
+// Errors are intentionally ignored by callers: Destroy must proceed with
+// zeroization regardless of whether the unlock succeeds.
+func munlock(data []byte) error {
+	return unix.Munlock(data)
+}
@@ -0,0 +1,123 @@
+/*
+RFC 6238 TOTP generation and verification for Database-Vault multi-factor enrollment.
+
+Implements HOTP (RFC 4226) over HMAC-SHA1 as its counter primitive, then TOTP
+on top by deriving the counter from the current Unix time and totpStepSeconds
+- the same construction every mainstream authenticator app (Google
+Authenticator, Authy, 1Password, etc.) expects from a provisioning URI.
+*/
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// totpSecretBytes is the amount of randomness in a generated TOTP seed (160
+// bits), matching the output size of the HMAC-SHA1 this package's HOTP step uses.
+const totpSecretBytes = 20
+
+// totpStepSeconds is the RFC 6238 time step: how many seconds one generated
+// code remains valid for before the counter advances.
+const totpStepSeconds = 30
+
+// totpDigits is the length of a generated TOTP code, the near-universal
+// default authenticator apps assume absent an explicit "digits" URI parameter.
+const totpDigits = 6
+
+// totpWindowSteps is how many steps before and after the current one
+// VerifyTOTPCode accepts, tolerating clock drift between the server and the
+// user's device without materially widening the guessing window.
+const totpWindowSteps = 1
+
+// GenerateTOTPSecret returns a new random TOTP seed, suitable for
+// base32-encoding into a provisioning URI or persisting (encrypted) alongside the account.
+//
+// Returns error if the system's CSPRNG fails to produce randomness.
+func GenerateTOTPSecret() ([]byte, error) {
+	secret := make([]byte, totpSecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate TOTP secret: %v", err)
+	}
+	return secret, nil
+}
+
+// EncodeTOTPSecret base32-encodes secret (no padding) for manual entry into
+// an authenticator app, or for embedding in a provisioning URI.
+func EncodeTOTPSecret(secret []byte) string {
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret)
+}
+
+// TOTPProvisioningURI builds the otpauth://totp URI an authenticator app
+// scans (as a QR code rendered client-side from this string) to enroll secret.
+//
+// accountEmail identifies the account within issuer in the app's UI; issuer
+// should be a stable, human-recognizable service name.
+func TOTPProvisioningURI(secret []byte, accountEmail, issuer string) string {
+	label := url.PathEscape(fmt.Sprintf("%s:%s", issuer, accountEmail))
+	query := url.Values{
+		"secret":    {EncodeTOTPSecret(secret)},
+		"issuer":    {issuer},
+		"algorithm": {"SHA1"},
+		"digits":    {strconv.Itoa(totpDigits)},
+		"period":    {strconv.Itoa(totpStepSeconds)},
+	}
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, query.Encode())
+}
+
+// hotp computes the RFC 4226 HMAC-based one-time password for secret at counter.
+func hotp(secret []byte, counter uint64) string {
+	var counterBytes [8]byte
+	binary.BigEndian.PutUint64(counterBytes[:], counter)
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(counterBytes[:])
+	sum := mac.Sum(nil)
+
+	// DYNAMIC TRUNCATION (RFC 4226 section 5.3)
+	offset := sum[len(sum)-1] & 0x0f
+	truncated := (uint32(sum[offset])&0x7f)<<24 |
+		uint32(sum[offset+1])<<16 |
+		uint32(sum[offset+2])<<8 |
+		uint32(sum[offset+3])
+
+	code := truncated % 1000000 // 10^totpDigits
+	return fmt.Sprintf("%0*d", totpDigits, code)
+}
+
+// VerifyTOTPCode checks code against secret for the time step containing at,
+// tolerating up to totpWindowSteps of clock drift in either direction.
+//
+// Security features:
+// - Constant-time comparison (crypto/subtle) against each candidate code, so timing doesn't reveal how much of a wrong code matched; the loop still stops at the first matching step
+// - A matching step's counter must exceed lastAcceptedCounter, so a code cannot be replayed once accepted - see the returned counter
+//
+// Returns whether code is valid and, if so, the counter it matched (for the
+// caller to persist as the new StoredUser.MFALastAcceptedCounter); returns
+// (false, lastAcceptedCounter) on no match or a replayed counter.
+func VerifyTOTPCode(secret []byte, code string, at time.Time, lastAcceptedCounter int64) (bool, int64) {
+	if len(code) != totpDigits {
+		return false, lastAcceptedCounter
+	}
+
+	current := at.Unix() / totpStepSeconds
+	for step := -totpWindowSteps; step <= totpWindowSteps; step++ {
+		counter := current + int64(step)
+		if counter < 0 || counter <= lastAcceptedCounter {
+			continue // already-consumed or stale step, reject reuse
+		}
+		candidate := hotp(secret, uint64(counter))
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(code)) == 1 {
+			return true, counter
+		}
+	}
+	return false, lastAcceptedCounter
+}
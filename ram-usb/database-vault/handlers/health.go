@@ -1,75 +1,239 @@
 /*
-Health check handler for Database-Vault secure storage service monitoring.
+Health check handlers for Database-Vault secure storage service monitoring.
 
-Provides lightweight status verification for load balancers, monitoring systems,
-and service discovery within the zero-trust architecture. Enables automated
-detection of Database-Vault availability including database connectivity status
-without exposing sensitive storage configuration or credential information
-to Security-Switch monitoring requests.
+Provides two tiers of status verification within the zero-trust architecture:
+a cheap, unauthenticated liveness probe for load balancers, and a detailed,
+mTLS-gated report with per-dependency latency and error diagnostics for
+Security-Switch and operator monitoring. Neither tier discloses sensitive
+storage configuration or credential information.
 */
 package handlers
 
 import (
+	"context"
+	"database-vault/config"
+	"database-vault/crypto"
+	"database-vault/storage"
 	"database-vault/types"
 	"encoding/json"
 	"net/http"
+	"time"
 )
 
-// HealthHandler provides Database-Vault status verification for monitoring systems.
+// dependencyCheckTimeout bounds how long a single dependency probe may take,
+// so a stalled backend cannot make health checks themselves hang.
+const dependencyCheckTimeout = 500 * time.Millisecond
+
+// userStorage is the storage backend behind every handler in this package
+// that persists or reads user credentials (HealthHandler,
+// DetailedHealthHandler, StoreUserHandler, the password-reset handlers),
+// injected via SetUserStorage once main.go has opened a concrete backend.
+var userStorage storage.UserStorage
+
+// SetUserStorage injects the UserStorage backend used by this package's handlers.
 //
 // Security features:
-// - mTLS middleware ensures only authenticated Security-Switch clients can access
-// - No sensitive storage configuration disclosure in response
-// - Database connectivity verification without exposing connection details
-// - Minimal resource usage for frequent monitoring requests
-// - JSON response format ensures consistent monitoring integration
+// - No-op until called, so dependent handlers fail closed (reported/returned unavailable) rather than silently skipping the database dependency
 //
-// Returns HTTP 200 with success status indicating Database-Vault operational state.
+// Called once during startup after the concrete storage backend is constructed.
+func SetUserStorage(s storage.UserStorage) {
+	userStorage = s
+}
+
+// LivenessHandler provides a minimal, unauthenticated liveness probe for load balancers.
 //
-// TO-DO: Add database connectivity check when storage interface is implemented
-// TO-DO: Include storage capacity metrics in extended health response
-func HealthHandler(w http.ResponseWriter, r *http.Request) {
-	// JSON RESPONSE SETUP
-	// Ensure consistent content type for monitoring tools
+// Security features:
+// - No mTLS requirement keeps it cheap enough for frequent LB health checks
+// - No dependency checks or configuration disclosure of any kind
+// - Constant-time response independent of storage or KMS availability
+//
+// Returns HTTP 200 with a bare success indicator; never reports degraded or unavailable.
+func LivenessHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-
-	// STATUS RESPONSE
-	// Simple success indicator for automated health monitoring
 	json.NewEncoder(w).Encode(types.Response{
 		Success: true,
-		Message: "Database-Vault operational!",
+		Message: "Database-Vault alive",
 	})
+}
+
+// HealthHandler provides Database-Vault status verification for Security-Switch monitoring.
+//
+// Security features:
+// - mTLS middleware ensures only authenticated Security-Switch clients can access
+// - Per-dependency timeout prevents a stalled backend from hanging the check
+// - No sensitive storage configuration disclosure in response
+//
+// Returns HTTP 200 when all dependencies are healthy, HTTP 200 with status
+// "degraded" when only non-critical dependencies fail, and HTTP 503 when the
+// database (the only critical dependency) is unavailable.
+func HealthHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx, cancel := context.WithTimeout(r.Context(), dependencyCheckTimeout)
+	defer cancel()
+
+	dependencies := map[string]string{
+		"database": checkDatabase(ctx).Status,
+		"kms":      checkKMS().Status,
+		"pepper":   checkPepper().Status,
+	}
+
+	status := overallStatus(dependencies)
+
+	response := types.HealthResponse{
+		Success:        status != "unavailable",
+		Message:        "Database-Vault operational",
+		Service:        "database-vault",
+		Status:         status,
+		DatabaseStatus: dependencies["database"],
+		Dependencies:   dependencies,
+	}
+
+	if status == "unavailable" {
+		response.Message = "Database-Vault degraded: critical dependency unavailable"
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// DetailedHealthHandler provides per-dependency latency and error diagnostics for monitoring.
+//
+// Security features:
+// - mTLS middleware gate keeps detailed diagnostics (latency, last error) away from unauthenticated callers, unlike the cheap /healthz liveness probe
+// - Per-dependency timeout prevents a stalled backend from hanging the check
+//
+// Returns HTTP 200 when healthy or degraded, HTTP 503 when the database
+// (the only critical dependency) is unavailable.
+func DetailedHealthHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx, cancel := context.WithTimeout(r.Context(), dependencyCheckTimeout)
+	defer cancel()
+
+	dependencies := map[string]types.DependencyDetail{
+		"database": checkDatabase(ctx),
+		"kms":      checkKMS(),
+		"pepper":   checkPepper(),
+	}
+
+	statusStrings := make(map[string]string, len(dependencies))
+	for name, detail := range dependencies {
+		statusStrings[name] = detail.Status
+	}
+	status := overallStatus(statusStrings)
+
+	response := types.DetailedHealthResponse{
+		Success:      status != "unavailable",
+		Message:      "Database-Vault detailed health report",
+		Service:      "database-vault",
+		Status:       status,
+		Dependencies: dependencies,
+	}
+
+	if status == "unavailable" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// overallStatus derives a single service-wide status from per-dependency statuses.
+//
+// Security features:
+// - "database" is the only dependency that can make the service unavailable; kms/pepper failures only degrade, since existing hashes/keys may still work
+//
+// Returns "unavailable" if the database is down, "degraded" if any other
+// dependency is not "ok", or "healthy" otherwise.
+func overallStatus(dependencies map[string]string) string {
+	if dependencies["database"] != "ok" {
+		return "unavailable"
+	}
+	for name, status := range dependencies {
+		if name != "database" && status != "ok" {
+			return "degraded"
+		}
+	}
+	return "healthy"
+}
+
+// checkDatabase probes the configured storage backend with a bounded timeout.
+//
+// Returns a DependencyDetail with status "ok" if the backend is reachable,
+// "unavailable" if it times out or errors, or "unavailable" if no backend is configured yet.
+func checkDatabase(ctx context.Context) types.DependencyDetail {
+	start := time.Now()
+
+	if userStorage == nil {
+		return types.DependencyDetail{
+			Status:        "unavailable",
+			LatencyMS:     0,
+			LastError:     "no storage backend configured",
+			LastCheckedAt: start,
+		}
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := userStorage.HealthCheck()
+		result <- err
+	}()
 
-	// TO-DO: Implement extended health check with database connectivity
-	// TO-DO: Add storage capacity and performance metrics
-	/*
-		// DATABASE CONNECTIVITY CHECK
-		// Verify database connectivity if storage interface is available
-		if storageInstance != nil {
-			healthStatus, err := storageInstance.HealthCheck()
-			if err != nil {
-				// Database connectivity issue - return degraded status
-				w.WriteHeader(http.StatusServiceUnavailable)
-				json.NewEncoder(w).Encode(types.HealthResponse{
-					Success:        false,
-					Message:        "Database-Vault operational with database connectivity issues",
-					Service:        "database-vault",
-					Status:         "degraded",
-					DatabaseStatus: "disconnected",
-				})
-				return
-			}
-
-			// EXTENDED HEALTH RESPONSE
-			// Include database status for comprehensive monitoring
-			json.NewEncoder(w).Encode(types.HealthResponse{
-				Success:         true,
-				Message:         "Database-Vault fully operational",
-				Service:         "database-vault",
-				Status:          "healthy",
-				DatabaseStatus:  "connected",
-				StorageCapacity: healthStatus.StorageCapacity,
-			})
+	select {
+	case err := <-result:
+		detail := types.DependencyDetail{LatencyMS: time.Since(start).Milliseconds(), LastCheckedAt: time.Now()}
+		if err != nil {
+			detail.Status = "unavailable"
+			detail.LastError = err.Error()
+		} else {
+			detail.Status = "ok"
 		}
-	*/
+		return detail
+	case <-ctx.Done():
+		return types.DependencyDetail{
+			Status:        "unavailable",
+			LatencyMS:     time.Since(start).Milliseconds(),
+			LastError:     "database health check timed out",
+			LastCheckedAt: time.Now(),
+		}
+	}
+}
+
+// checkKMS validates the currently configured encryption key, as a proxy for
+// the reachability of whichever key source (environment, file, Vault, KMS) produced it.
+//
+// Returns a DependencyDetail with status "ok" if the key passes validation,
+// "degraded" otherwise.
+func checkKMS() types.DependencyDetail {
+	start := time.Now()
+	detail := types.DependencyDetail{LastCheckedAt: start}
+
+	if err := crypto.ValidateEncryptionKey(config.GetConfig().EncryptionKey); err != nil {
+		detail.Status = "degraded"
+		detail.LastError = err.Error()
+	} else {
+		detail.Status = "ok"
+	}
+
+	detail.LatencyMS = time.Since(start).Milliseconds()
+	return detail
+}
+
+// checkPepper validates that the default PepperProvider can resolve its current version.
+//
+// Returns a DependencyDetail with status "ok" if the pepper subsystem is
+// ready, "degraded" otherwise (e.g. RAMUSB_PEPPER_SECRET not configured).
+func checkPepper() types.DependencyDetail {
+	start := time.Now()
+	detail := types.DependencyDetail{LastCheckedAt: start}
+
+	if err := crypto.PepperHealthCheck(); err != nil {
+		detail.Status = "degraded"
+		detail.LastError = err.Error()
+	} else {
+		detail.Status = "ok"
+	}
+
+	detail.LatencyMS = time.Since(start).Milliseconds()
+	return detail
 }
@@ -0,0 +1,239 @@
+/*
+Login handler for Database-Vault secure storage service.
+
+Implements credential verification against AES-256-GCM-sealed, blind-indexed
+storage: LoginUserHandler resolves the submitted email to its blind index,
+verifies the password with Argon2id (see crypto.VerifyPassword), and issues a
+short-lived signed session token (see session.Issuer) that Security-Switch and
+Storage-Service can later validate without a further round trip here.
+*/
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"database-vault/config"
+	"database-vault/crypto"
+	"database-vault/logging"
+	"database-vault/session"
+	"database-vault/storage"
+	"database-vault/types"
+	"database-vault/utils"
+
+	"ramusb/errs"
+)
+
+// dummyPasswordHash is a syntactically valid, pepper-free Argon2id PHC
+// string with an all-zero salt and hash, verified against the submitted
+// password when no account matches the login's email blind index. It
+// never validates, but it carries crypto.VerifyPassword through the same
+// argon2.IDKey computation a real lookup would, so "no such account" and
+// "wrong password" take the same amount of time.
+const dummyPasswordHash = "$argon2id$v=19$m=32768,t=1,p=4$AAAAAAAAAAAAAAAAAAAAAA$AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+// maxFailedLoginAttempts is the number of consecutive failed logins a
+// session.Issuer's token issuance is withheld after; left unenforced for
+// now since StoredUser.FailedLoginAttempts currently only informs audit
+// logging, not lockout decisions - see TO-DO below.
+//
+// TO-DO: Reject login attempts once FailedLoginAttempts reaches this
+// threshold until some cooldown or administrative reset clears it.
+const maxFailedLoginAttempts = 10
+
+// LoginUserHandler authenticates a user against stored credentials and
+// issues a short-lived session token.
+//
+// Security features:
+// - mTLS middleware ensures only authenticated Security-Switch instances can access
+// - Looked up via the email blind index, never a direct EncryptedEmail comparison
+// - Argon2id verification via crypto.VerifyPassword, constant-time at the hash-comparison level
+// - Constant-time dummy hashing against dummyPasswordHash when no account matches, so
+//   timing does not distinguish "no such account" from "wrong password"
+// - Generic "invalid email or password" error on any authentication failure
+// - FailedLoginAttempts/LastFailedLogin updated on every attempt for lockout tracking/auditing
+//
+// Returns HTTP 200 with a session token on success, 401 on authentication
+// failure, 4xx on validation errors, 5xx on storage or configuration errors.
+func LoginUserHandler(w http.ResponseWriter, r *http.Request) {
+	if !utils.EnforcePOST(w, r) {
+		return
+	}
+
+	body, ok := utils.ReadRequestBody(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.LoginRequest
+	if !utils.ParseJSONBody(body, &req, w, r) {
+		return
+	}
+
+	if req.Email == "" || req.Password == "" {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Email and password are required.")
+		return
+	}
+	if !utils.IsValidEmail(req.Email) || strings.Count(req.Email, "@") != 1 {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid email format.")
+		return
+	}
+
+	cfg := config.GetConfig()
+	keyring, err := currentKeyring(cfg)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("encryption_key_invalid", "Encryption configuration error.", err))
+		return
+	}
+	if len(cfg.SessionSigningKey) == 0 {
+		utils.WriteError(w, r, errs.ServiceUnavailable("session_issuer_unavailable", "Login is not enabled on this server.", nil))
+		return
+	}
+	issuer, err := session.NewIssuer(cfg.SessionSigningKey, cfg.SessionTTL)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("session_issuer_init_failed", "Session configuration error.", err))
+		return
+	}
+
+	// Must resolve the same keyring as StoreUserHandler (see currentKeyring)
+	// rather than cfg.EncryptionKey's startup snapshot - the blind index is an
+	// HMAC over a key derived from it, so a key mismatch makes
+	// GetUserByEmailBlindIndex miss every row StoreUserHandler wrote under a
+	// rotated secret. fieldCipher is still built from the keyring's current
+	// primary key alone: MFASecretEncrypted isn't part of the keyring rotation
+	// scheme, so it is only ever encrypted and decrypted under the live key.
+	_, primaryKey := keyring.Primary()
+	fieldCipher, err := crypto.NewFieldCipher(primaryKey)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("field_cipher_init_failed", "Encryption configuration error.", err))
+		return
+	}
+
+	if userStorage == nil {
+		utils.WriteError(w, r, errs.ServiceUnavailable("storage_unavailable", "Storage backend unavailable.", nil))
+		return
+	}
+
+	// Probes every key in the keyring (see lookupUserByEmail), not just the
+	// current primary's blind index, so login still resolves a row registered
+	// before a completed rotation and not yet re-encrypted by the rotation worker.
+	user, emailBlindIndex, err := lookupUserByEmail(req.Email, keyring)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("login_lookup_failed", "Database error during login.", err))
+		return
+	}
+
+	// CONSTANT-TIME PASSWORD VERIFICATION
+	// Verify against the real stored hash when the account exists, or
+	// dummyPasswordHash otherwise, so a missing account and a wrong
+	// password take the same amount of time to reject
+	storedHash := dummyPasswordHash
+	if user != nil {
+		storedHash = user.PasswordHash
+	}
+	valid, needsRehash, err := crypto.VerifyPassword(req.Password, storedHash)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("password_verify_failed", "Password processing error.", err))
+		return
+	}
+	valid = valid && user != nil
+
+	if !valid {
+		if user != nil {
+			recordFailedLogin(r, user.EncryptedEmail, user.FailedLoginAttempts)
+		}
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "Invalid email or password.")
+		return
+	}
+
+	// PASSWORD REHASH (DEFERRED UPGRADE)
+	// Transparently upgrade a hash created under weaker-than-current
+	// parameters or an outdated pepper version, without requiring the user
+	// to change their password
+	if needsRehash {
+		if rehashed, err := crypto.HashPassword(req.Password); err == nil {
+			if err := userStorage.UpdateUser(user.EncryptedEmail, storage.UserUpdateRequest{NewPasswordHash: &rehashed}); err != nil {
+				logging.FromContext(r.Context()).Warn("failed to persist rehashed password", "email_blind_index", emailBlindIndex, "error", err)
+			}
+		}
+	}
+
+	// LOCKOUT COUNTER RESET
+	// A successful login clears the consecutive-failure count it was tracking
+	zero := 0
+	if err := userStorage.UpdateUser(user.EncryptedEmail, storage.UserUpdateRequest{NewFailedLoginAttempts: &zero}); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to reset failed-login counter", "email_blind_index", emailBlindIndex, "error", err)
+	}
+
+	// MFA CHALLENGE
+	// A password match alone is not a completed login once MFAEnabled is
+	// set; require a valid RFC 6238 code (or an unused scratch code) before
+	// issuing a session token
+	if user.MFAEnabled {
+		if !verifyMFACode(r, fieldCipher, user, req.MFACode) {
+			utils.SendMFARequiredResponse(w, "A valid MFA code is required.")
+			return
+		}
+	}
+
+	token, err := issuer.Issue(emailBlindIndex)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("session_issue_failed", "Failed to issue session token.", err))
+		return
+	}
+
+	logging.FromContext(r.Context()).Info("audit: user login succeeded",
+		"email_blind_index", emailBlindIndex, "timestamp", time.Now().Format(time.RFC3339))
+	utils.SendSuccessResponseWithSessionToken(w, http.StatusOK, "Login successful.", token)
+}
+
+// verifyMFACode checks code against user's enrolled TOTP secret, falling
+// back to an unused scratch code, and persists whichever state the match
+// consumed (the new TOTP counter, or the redeemed scratch code's removal)
+// so neither can be replayed.
+//
+// Returns whether code verified. A persistence failure after a successful
+// verification is logged but does not turn a valid code invalid - the user
+// already proved possession of the second factor.
+func verifyMFACode(r *http.Request, fieldCipher *crypto.FieldCipher, user *types.StoredUser, code string) bool {
+	if code == "" {
+		return false
+	}
+
+	if user.MFASecretEncrypted != "" {
+		if secret, err := fieldCipher.Decrypt(user.MFASecretEncrypted); err == nil {
+			if valid, counter := crypto.VerifyTOTPCode([]byte(secret), code, time.Now(), user.MFALastAcceptedCounter); valid {
+				if err := userStorage.UpdateUser(user.EncryptedEmail, storage.UserUpdateRequest{NewMFALastAcceptedCounter: &counter}); err != nil {
+					logging.FromContext(r.Context()).Warn("failed to persist MFA counter", "error", err)
+				}
+				return true
+			}
+		}
+	}
+
+	if index, ok := crypto.VerifyScratchCode(code, user.MFAScratchCodeHashes); ok {
+		remaining := append(append([]string{}, user.MFAScratchCodeHashes[:index]...), user.MFAScratchCodeHashes[index+1:]...)
+		if err := userStorage.UpdateUser(user.EncryptedEmail, storage.UserUpdateRequest{NewMFAScratchCodeHashes: &remaining}); err != nil {
+			logging.FromContext(r.Context()).Warn("failed to persist scratch code redemption", "error", err)
+		}
+		return true
+	}
+
+	return false
+}
+
+// recordFailedLogin increments FailedLoginAttempts and stamps
+// LastFailedLogin for encryptedEmail, logging but not failing the request
+// if the update itself errors - a lockout-tracking write failure should
+// not mask the real "invalid email or password" response.
+func recordFailedLogin(r *http.Request, encryptedEmail string, currentAttempts int) {
+	attempts := currentAttempts + 1
+	now := time.Now()
+	if err := userStorage.UpdateUser(encryptedEmail, storage.UserUpdateRequest{
+		NewFailedLoginAttempts: &attempts,
+		NewLastFailedLogin:     &now,
+	}); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to record failed login attempt", "error", err)
+	}
+}
@@ -0,0 +1,232 @@
+/*
+Multi-factor enrollment handlers for Database-Vault secure storage service.
+
+Implements the two-step TOTP enrollment flow RFC 6238 requires to avoid
+locking a user out on a typo: EnrollMFAHandler generates and persists a new
+secret without activating it, and ConfirmMFAHandler only sets
+StoredUser.MFAEnabled once the caller proves possession of that secret by
+submitting a code it produces. See handlers/login.go for the corresponding
+login-time challenge.
+*/
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"database-vault/config"
+	"database-vault/crypto"
+	"database-vault/storage"
+	"database-vault/types"
+	"database-vault/utils"
+
+	"ramusb/errs"
+)
+
+// mfaIssuer is the service name embedded in every TOTP provisioning URI,
+// shown to the user inside their authenticator app.
+const mfaIssuer = "R.A.M.-U.S.B."
+
+// EnrollMFAHandler generates a new TOTP secret for an existing account and
+// persists it (encrypted) without yet enabling MFA.
+//
+// Security features:
+// - mTLS middleware ensures only authenticated Security-Switch instances can access
+// - Looked up via the email blind index, never a direct EncryptedEmail comparison
+// - The secret is sealed with crypto.FieldCipher under the same master key as EncryptedEmail before storage
+// - Re-enrolling an account that already has MFAEnabled is rejected, so a stolen session token alone cannot swap out a victim's enrolled secret
+//
+// Returns HTTP 200 with the new secret and provisioning URI on success, 404
+// if no account matches the email, 409 if MFA is already enabled, 4xx on
+// validation errors, 5xx on storage or configuration errors.
+func EnrollMFAHandler(w http.ResponseWriter, r *http.Request) {
+	if !utils.EnforcePOST(w, r) {
+		return
+	}
+
+	body, ok := utils.ReadRequestBody(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.MFAEnrollRequest
+	if !utils.ParseJSONBody(body, &req, w, r) {
+		return
+	}
+
+	if req.Email == "" || !utils.IsValidEmail(req.Email) {
+		utils.WriteError(w, r, errs.BadRequest("invalid_email", "Invalid email format.", nil))
+		return
+	}
+
+	cfg := config.GetConfig()
+	keyring, err := currentKeyring(cfg)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("encryption_key_invalid", "Encryption configuration error.", err))
+		return
+	}
+
+	// MFASecretEncrypted isn't part of the keyring rotation scheme, so it is
+	// only ever sealed under the keyring's current primary key.
+	_, primaryKey := keyring.Primary()
+	fieldCipher, err := crypto.NewFieldCipher(primaryKey)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("field_cipher_init_failed", "Encryption configuration error.", err))
+		return
+	}
+
+	if userStorage == nil {
+		utils.WriteError(w, r, errs.ServiceUnavailable("storage_unavailable", "Storage backend unavailable.", nil))
+		return
+	}
+
+	user, _, err := lookupUserByEmail(req.Email, keyring)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("mfa_enroll_lookup_failed", "Database error during MFA enrollment.", err))
+		return
+	}
+	if user == nil {
+		utils.WriteError(w, r, errs.NotFound("user_not_found", "No account found for that email.", nil))
+		return
+	}
+	if user.MFAEnabled {
+		utils.WriteError(w, r, errs.Conflict("mfa_already_enabled", "Multi-factor authentication is already enabled for this account.", nil))
+		return
+	}
+
+	secret, err := crypto.GenerateTOTPSecret()
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("mfa_secret_generate_failed", "Failed to generate MFA secret.", err))
+		return
+	}
+
+	encryptedSecret, err := fieldCipher.Encrypt(string(secret))
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("mfa_secret_encryption_failed", "Failed to secure MFA secret.", err))
+		return
+	}
+
+	if err := userStorage.UpdateUser(user.EncryptedEmail, storage.UserUpdateRequest{NewMFASecretEncrypted: &encryptedSecret}); err != nil {
+		writeStorageError(w, r, err, "Failed to persist MFA enrollment.")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(types.MFAEnrollResponse{
+		Success:         true,
+		Secret:          crypto.EncodeTOTPSecret(secret),
+		ProvisioningURI: crypto.TOTPProvisioningURI(secret, req.Email, mfaIssuer),
+	})
+}
+
+// ConfirmMFAHandler verifies the first code generated from an in-progress
+// enrollment and, only if it checks out, activates MFA and issues scratch codes.
+//
+// Security features:
+// - mTLS middleware ensures only authenticated Security-Switch instances can access
+// - crypto.VerifyTOTPCode rejects a replayed counter, so the same confirmation code cannot be reused as the first login challenge
+// - Scratch codes are generated and hashed (crypto.HashScratchCode) here and returned exactly once; only their hashes are persisted
+//
+// Returns HTTP 200 with the scratch codes on success, 404 if no account
+// matches the email, 409 if MFA is already enabled, 401 if the code does not
+// verify, 4xx on validation errors, 5xx on storage or configuration errors.
+func ConfirmMFAHandler(w http.ResponseWriter, r *http.Request) {
+	if !utils.EnforcePOST(w, r) {
+		return
+	}
+
+	body, ok := utils.ReadRequestBody(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.MFAConfirmRequest
+	if !utils.ParseJSONBody(body, &req, w, r) {
+		return
+	}
+
+	if req.Email == "" || !utils.IsValidEmail(req.Email) || req.Code == "" {
+		utils.WriteError(w, r, errs.BadRequest("invalid_request", "Email and a TOTP code are required.", nil))
+		return
+	}
+
+	cfg := config.GetConfig()
+	keyring, err := currentKeyring(cfg)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("encryption_key_invalid", "Encryption configuration error.", err))
+		return
+	}
+
+	// MFASecretEncrypted isn't part of the keyring rotation scheme, so it is
+	// only ever sealed under the keyring's current primary key.
+	_, primaryKey := keyring.Primary()
+	fieldCipher, err := crypto.NewFieldCipher(primaryKey)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("field_cipher_init_failed", "Encryption configuration error.", err))
+		return
+	}
+
+	if userStorage == nil {
+		utils.WriteError(w, r, errs.ServiceUnavailable("storage_unavailable", "Storage backend unavailable.", nil))
+		return
+	}
+
+	user, _, err := lookupUserByEmail(req.Email, keyring)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("mfa_confirm_lookup_failed", "Database error during MFA confirmation.", err))
+		return
+	}
+	if user == nil {
+		utils.WriteError(w, r, errs.NotFound("user_not_found", "No account found for that email.", nil))
+		return
+	}
+	if user.MFAEnabled {
+		utils.WriteError(w, r, errs.Conflict("mfa_already_enabled", "Multi-factor authentication is already enabled for this account.", nil))
+		return
+	}
+	if user.MFASecretEncrypted == "" {
+		utils.WriteError(w, r, errs.BadRequest("mfa_not_enrolled", "No MFA enrollment is in progress for this account.", nil))
+		return
+	}
+
+	secret, err := fieldCipher.Decrypt(user.MFASecretEncrypted)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("mfa_secret_decryption_failed", "Failed to verify MFA secret.", err))
+		return
+	}
+
+	valid, counter := crypto.VerifyTOTPCode([]byte(secret), req.Code, time.Now(), user.MFALastAcceptedCounter)
+	if !valid {
+		utils.WriteError(w, r, errs.Unauthorized("mfa_code_invalid", "Invalid MFA code.", nil))
+		return
+	}
+
+	scratchCodes, err := crypto.GenerateScratchCodes()
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("mfa_scratch_codes_generate_failed", "Failed to generate scratch codes.", err))
+		return
+	}
+	scratchCodeHashes := make([]string, len(scratchCodes))
+	for i, code := range scratchCodes {
+		scratchCodeHashes[i] = crypto.HashScratchCode(code)
+	}
+
+	mfaEnabled := true
+	if err := userStorage.UpdateUser(user.EncryptedEmail, storage.UserUpdateRequest{
+		NewMFAEnabled:             &mfaEnabled,
+		NewMFAScratchCodeHashes:   &scratchCodeHashes,
+		NewMFALastAcceptedCounter: &counter,
+	}); err != nil {
+		writeStorageError(w, r, err, "Failed to activate MFA.")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(types.MFAConfirmResponse{
+		Success:      true,
+		ScratchCodes: scratchCodes,
+	})
+}
@@ -0,0 +1,163 @@
+/*
+Password-reset handlers for Database-Vault secure storage service.
+
+Implements issue/consume around storage.UserStorage's password-reset token
+primitives: IssuePasswordResetHandler mints a single-use token for an
+existing user, ConsumePasswordResetHandler redeems one for a new Argon2id
+password hash. Neither endpoint ever sees or stores a reset token in
+plaintext past the HTTP response that carries it to Security-Switch.
+*/
+package handlers
+
+import (
+	"database-vault/config"
+	"database-vault/crypto"
+	"database-vault/storage"
+	"database-vault/types"
+	"database-vault/utils"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"ramusb/errs"
+)
+
+// passwordResetTTL bounds how long an issued token remains redeemable
+// before storage.UserStorage.ConsumePasswordResetToken starts rejecting it.
+const passwordResetTTL = 30 * time.Minute
+
+// IssuePasswordResetHandler mints a single-use password-reset token for an existing user.
+//
+// Security features:
+// - mTLS middleware ensures only authenticated Security-Switch instances can access
+// - Looked up via the email blind index, never EncryptedEmail comparison
+// - The returned token is the only place its plaintext ever appears; storage.UserStorage
+//   persists only crypto.HashResetToken(token) - see storage.UserStorage.CreatePasswordResetToken
+//
+// Returns HTTP 201 with the token on success, 404 if no account matches the email,
+// 4xx on validation errors, 5xx on storage errors.
+func IssuePasswordResetHandler(w http.ResponseWriter, r *http.Request) {
+	if !utils.EnforcePOST(w, r) {
+		return
+	}
+
+	body, ok := utils.ReadRequestBody(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.PasswordResetIssueRequest
+	if !utils.ParseJSONBody(body, &req, w, r) {
+		return
+	}
+
+	if req.Email == "" || !utils.IsValidEmail(req.Email) {
+		utils.WriteError(w, r, errs.BadRequest("invalid_email", "Invalid email format.", nil))
+		return
+	}
+
+	cfg := config.GetConfig()
+	keyring, err := currentKeyring(cfg)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("encryption_key_invalid", "Encryption configuration error.", err))
+		return
+	}
+
+	if userStorage == nil {
+		utils.WriteError(w, r, errs.ServiceUnavailable("storage_unavailable", "Storage backend unavailable.", nil))
+		return
+	}
+
+	// Must resolve the same keyring as StoreUserHandler (see currentKeyring)
+	// rather than cfg.EncryptionKey's startup snapshot, and probe every key in
+	// it (see lookupUserByEmail) - the blind index is an HMAC over a key
+	// derived from one of the keyring's keys, so a key mismatch or a row
+	// registered before a completed rotation makes a single-key lookup miss
+	// a real account.
+	user, _, err := lookupUserByEmail(req.Email, keyring)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("reset_lookup_failed", "Database error during password reset.", err))
+		return
+	}
+	if user == nil {
+		utils.WriteError(w, r, errs.NotFound("user_not_found", "No account found for that email.", nil))
+		return
+	}
+
+	token, err := userStorage.CreatePasswordResetToken(user.EncryptedEmail, passwordResetTTL)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("reset_token_issue_failed", "Failed to issue password reset token.", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(types.PasswordResetIssueResponse{
+		Success:    true,
+		ResetToken: token,
+		ExpiresAt:  time.Now().Add(passwordResetTTL),
+	})
+}
+
+// ConsumePasswordResetHandler redeems a password-reset token for a new password.
+//
+// Security features:
+// - mTLS middleware ensures only authenticated Security-Switch instances can access
+// - storage.UserStorage.ConsumePasswordResetToken enforces single use and expiry atomically,
+//   so a token cannot be replayed even under concurrent requests
+// - Same validation (length, entropy, weak-password list) as initial registration applies
+//
+// Returns HTTP 200 on success, 400 on validation errors, 404 if the token is
+// unrecognized/expired/already used, 5xx on storage errors.
+func ConsumePasswordResetHandler(w http.ResponseWriter, r *http.Request) {
+	if !utils.EnforcePOST(w, r) {
+		return
+	}
+
+	body, ok := utils.ReadRequestBody(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.PasswordResetConsumeRequest
+	if !utils.ParseJSONBody(body, &req, w, r) {
+		return
+	}
+
+	if req.ResetToken == "" || len(req.NewPassword) < 8 {
+		utils.WriteError(w, r, errs.BadRequest("invalid_request", "Reset token and a password of at least 8 characters are required.", nil))
+		return
+	}
+	if !utils.HasSufficientEntropy(req.NewPassword) {
+		utils.WriteError(w, r, errs.BadRequest("insufficient_entropy", "Password is too predictable; choose a longer or more varied password.", nil))
+		return
+	}
+	if utils.IsWeakPassword(req.NewPassword) {
+		utils.WriteError(w, r, errs.BadRequest("weak_password", "Password is too common, please choose a stronger password.", nil))
+		return
+	}
+
+	if userStorage == nil {
+		utils.WriteError(w, r, errs.ServiceUnavailable("storage_unavailable", "Storage backend unavailable.", nil))
+		return
+	}
+
+	encryptedEmail, err := userStorage.ConsumePasswordResetToken(req.ResetToken)
+	if err != nil {
+		utils.WriteError(w, r, errs.NotFound("reset_token_invalid", "Password reset token is invalid or has expired.", err))
+		return
+	}
+
+	passwordHash, err := crypto.HashPassword(req.NewPassword)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("password_hash_failed", "Password processing error.", err))
+		return
+	}
+
+	if err := userStorage.UpdateUser(encryptedEmail, storage.UserUpdateRequest{NewPasswordHash: &passwordHash}); err != nil {
+		utils.WriteError(w, r, errs.Internal("password_reset_update_failed", "Failed to update password.", err))
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Password reset successfully.")
+}
@@ -0,0 +1,242 @@
+/*
+x509 user-certificate issuance and revocation handlers for Database-Vault
+secure storage service.
+
+Implements the CSR-based mTLS client certificate flow described in
+database-vault/pki: IssueCertHandler signs a certificate over a CSR a client
+builds itself (so its private key never leaves that machine), RevokeCertHandler
+retires a previously issued certificate by serial, and OCSPHandler answers
+RFC 6960 OCSP requests so Storage-Service can reject a compromised certificate
+before its TTL naturally expires. Distinct from the OpenSSH certificate
+authority in handlers/store.go (see ramusb/sshca), which covers a different
+trust domain.
+*/
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"database-vault/config"
+	"database-vault/pki"
+	"database-vault/storage"
+	"database-vault/types"
+	"database-vault/utils"
+
+	"ramusb/errs"
+)
+
+// ocspRequestBodyLimit bounds how large a raw OCSP request body OCSPHandler
+// will read, since it is read directly rather than through utils.ReadRequestBody's
+// JSON-oriented path.
+const ocspRequestBodyLimit = 4096
+
+// IssueCertHandler signs a client certificate over a CSR submitted by an
+// already-registered account, for Storage-Service mTLS authentication.
+//
+// Security features:
+// - mTLS middleware ensures only authenticated Security-Switch instances can access
+// - The CSR's own signature is verified (pki.CA.IssueCertificate) before anything is signed, so a tampered request is rejected
+// - Subject.CommonName is always the server-resolved EncryptedEmail, never a value taken from the CSR, preserving zero-knowledge identity binding
+// - The issued certificate's serial and expiry are persisted before the response is sent, so RevokeCertHandler and the OCSP responder can act on it immediately
+//
+// Returns HTTP 200 with the signed certificate on success, 404 if no account
+// matches the email, 4xx on validation errors, 5xx on CA, storage, or
+// configuration errors.
+func IssueCertHandler(w http.ResponseWriter, r *http.Request) {
+	if !utils.EnforcePOST(w, r) {
+		return
+	}
+
+	body, ok := utils.ReadRequestBody(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.CertIssueRequest
+	if !utils.ParseJSONBody(body, &req, w, r) {
+		return
+	}
+
+	if req.Email == "" || !utils.IsValidEmail(req.Email) || req.CSR == "" {
+		utils.WriteError(w, r, errs.BadRequest("invalid_request", "Email and a CSR are required.", nil))
+		return
+	}
+
+	cfg := config.GetConfig()
+	if !cfg.UserCAEnabled {
+		utils.WriteError(w, r, errs.BadRequest("user_ca_disabled", "Certificate issuance is not enabled on this server.", nil))
+		return
+	}
+
+	keyring, err := currentKeyring(cfg)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("encryption_key_invalid", "Encryption configuration error.", err))
+		return
+	}
+
+	if userStorage == nil {
+		utils.WriteError(w, r, errs.ServiceUnavailable("storage_unavailable", "Storage backend unavailable.", nil))
+		return
+	}
+
+	user, _, err := lookupUserByEmail(req.Email, keyring)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("cert_issue_lookup_failed", "Database error during certificate issuance.", err))
+		return
+	}
+	if user == nil {
+		utils.WriteError(w, r, errs.NotFound("user_not_found", "No account found for that email.", nil))
+		return
+	}
+
+	ca, err := pki.NewCA(cfg.UserCACertFile, cfg.UserCAKeyFile, cfg.UserCATTL)
+	if err != nil {
+		utils.WriteError(w, r, errs.CertificateError("user_ca_load_failed", "Certificate authority configuration error.", err))
+		return
+	}
+
+	certPEM, serial, notAfter, err := ca.IssueCertificate([]byte(req.CSR), user.EncryptedEmail)
+	if err != nil {
+		utils.WriteError(w, r, errs.BadRequest("csr_invalid", "Certificate signing request is invalid.", err))
+		return
+	}
+
+	if err := userStorage.StoreIssuedCertificate(types.IssuedCertificate{
+		Serial:         serial,
+		EncryptedEmail: user.EncryptedEmail,
+		NotAfter:       notAfter,
+		CreatedAt:      notAfter.Add(-cfg.UserCATTL),
+	}); err != nil {
+		writeStorageError(w, r, err, "Failed to persist issued certificate.")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(types.CertIssueResponse{
+		Success:     true,
+		Certificate: string(certPEM),
+		Serial:      serial,
+		NotAfter:    notAfter,
+	})
+}
+
+// RevokeCertHandler retires a previously issued client certificate by serial,
+// e.g. after its private key is reported compromised.
+//
+// Security features:
+// - mTLS middleware ensures only authenticated Security-Switch instances can access
+// - Revocation is idempotent (storage.UserStorage.RevokeCertificate): re-revoking an already-revoked serial succeeds without overwriting its original RevokedAt/RevocationReason
+//
+// Returns HTTP 200 on success, 404 if no certificate matches the serial,
+// 4xx on validation errors, 5xx on storage errors.
+func RevokeCertHandler(w http.ResponseWriter, r *http.Request) {
+	if !utils.EnforcePOST(w, r) {
+		return
+	}
+
+	body, ok := utils.ReadRequestBody(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.CertRevokeRequest
+	if !utils.ParseJSONBody(body, &req, w, r) {
+		return
+	}
+
+	if req.Serial == "" {
+		utils.WriteError(w, r, errs.BadRequest("invalid_request", "A certificate serial is required.", nil))
+		return
+	}
+
+	if userStorage == nil {
+		utils.WriteError(w, r, errs.ServiceUnavailable("storage_unavailable", "Storage backend unavailable.", nil))
+		return
+	}
+
+	if err := userStorage.RevokeCertificate(req.Serial, req.Reason); err != nil {
+		if storageErr, ok := err.(*storage.StorageError); ok && storageErr.Type == storage.ErrorUserNotFound {
+			utils.WriteError(w, r, errs.NotFound("certificate_not_found", "No certificate found with that serial.", err))
+			return
+		}
+		writeStorageError(w, r, err, "Failed to revoke certificate.")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(types.CertRevokeResponse{Success: true})
+}
+
+// OCSPHandler answers an RFC 6960 OCSP request for a user client
+// certificate, so Storage-Service can reject a revoked certificate without
+// waiting for its TTL to expire.
+//
+// Security features:
+// - The CA signs every OCSP response itself (pki.CA.RespondOCSP), so a compromised database alone cannot forge a "good" status
+// - An unknown serial is reported revoked rather than good, so a database row deleted or never persisted does not fail open
+//
+// Returns HTTP 200 with a DER-encoded OCSP response, or 4xx/5xx on a
+// malformed request, configuration error, or storage failure.
+func OCSPHandler(w http.ResponseWriter, r *http.Request) {
+	if !utils.EnforcePOST(w, r) {
+		return
+	}
+
+	requestDER, err := io.ReadAll(io.LimitReader(r.Body, ocspRequestBodyLimit))
+	if err != nil {
+		utils.WriteError(w, r, errs.BadRequest("body_read_failed", "Failed to read OCSP request.", err))
+		return
+	}
+
+	serial, err := pki.ParseOCSPRequest(requestDER)
+	if err != nil {
+		utils.WriteError(w, r, errs.BadRequest("ocsp_request_invalid", "Malformed OCSP request.", err))
+		return
+	}
+
+	cfg := config.GetConfig()
+	if !cfg.UserCAEnabled {
+		utils.WriteError(w, r, errs.BadRequest("user_ca_disabled", "Certificate issuance is not enabled on this server.", nil))
+		return
+	}
+
+	if userStorage == nil {
+		utils.WriteError(w, r, errs.ServiceUnavailable("storage_unavailable", "Storage backend unavailable.", nil))
+		return
+	}
+
+	record, err := userStorage.GetCertificateBySerial(serial.Text(16))
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("ocsp_lookup_failed", "Database error during OCSP lookup.", err))
+		return
+	}
+
+	status := pki.OCSPStatus{Revoked: true} // Fail closed: an unknown serial is treated as revoked, not good
+	if record != nil {
+		status.Revoked = record.Revoked
+		if record.RevokedAt != nil {
+			status.RevokedAt = *record.RevokedAt
+		}
+		status.Reason = record.RevocationReason
+	}
+
+	ca, err := pki.NewCA(cfg.UserCACertFile, cfg.UserCAKeyFile, cfg.UserCATTL)
+	if err != nil {
+		utils.WriteError(w, r, errs.CertificateError("user_ca_load_failed", "Certificate authority configuration error.", err))
+		return
+	}
+
+	responseDER, err := ca.RespondOCSP(serial, status)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("ocsp_response_failed", "Failed to build OCSP response.", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/ocsp-response")
+	w.WriteHeader(http.StatusOK)
+	w.Write(responseDER)
+}
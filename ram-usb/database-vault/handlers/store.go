@@ -2,25 +2,33 @@
 User credential storage handler for Database-Vault secure persistence service.
 
 Implements the final layer of the R.A.M.-U.S.B. distributed authentication system
-with comprehensive defense-in-depth validation, AES-256-GCM email encryption,
+with comprehensive defense-in-depth validation, AES-256-GCM email encryption via
+crypto.FieldCipher (random nonce per row plus a separate blind index for lookup),
 Argon2id password hashing, and secure database storage. Receives mTLS-authenticated
 storage requests from Security-Switch instances, performs ultimate security validation,
 and persists encrypted credentials with zero-knowledge principles.
-
-TO-DO in StoreUserHandler
 */
 package handlers
 
 import (
 	"database-vault/config"
 	"database-vault/crypto"
+	"database-vault/logging"
+	"database-vault/secrets"
+	"database-vault/storage"
 	"database-vault/types"
 	"database-vault/utils"
+	"database-vault/utils/knownhosts"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"strings"
 	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"ramusb/errs"
+	"ramusb/sshca"
 )
 
 // StoreUserHandler processes user credential storage requests with comprehensive security validation.
@@ -56,7 +64,7 @@ func StoreUserHandler(w http.ResponseWriter, r *http.Request) {
 	// JSON DESERIALIZATION
 	// Convert raw JSON bytes into structured RegisterRequest object
 	var req types.RegisterRequest
-	if !utils.ParseJSONBody(body, &req, w) {
+	if !utils.ParseJSONBody(body, &req, w, r) {
 		return // Sends HTTP 400 Bad Request if JSON parsing fails
 	}
 
@@ -88,17 +96,20 @@ func StoreUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// WEAK PASSWORD DETECTION (DEFENSE-IN-DEPTH)
-	// Check against database of commonly used weak passwords
-	if utils.IsWeakPassword(req.Password) {
-		utils.SendErrorResponse(w, http.StatusBadRequest, "Password is too common, please choose a stronger password.")
+	// PASSWORD ENTROPY VALIDATION (DEFENSE-IN-DEPTH)
+	// Primary strength gate: a pool-based entropy estimate admits long,
+	// low-complexity passphrases instead of demanding specific character
+	// categories (see utils.PasswordEntropyBits)
+	if !utils.HasSufficientEntropy(req.Password) {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Password is too predictable; choose a longer or more varied password.")
 		return
 	}
 
-	// PASSWORD COMPLEXITY VALIDATION (DEFENSE-IN-DEPTH)
-	// Ensure password contains at least 3 out of 4 character categories
-	if !utils.HasPasswordComplexity(req.Password) {
-		utils.SendErrorResponse(w, http.StatusBadRequest, "Password must contain at least 3 of: uppercase, lowercase, numbers, special characters.")
+	// WEAK PASSWORD DETECTION (DEFENSE-IN-DEPTH)
+	// Second gate: reject known-breached passwords even if they happen to
+	// score above the entropy threshold
+	if utils.IsWeakPassword(req.Password) {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Password is too common, please choose a stronger password.")
 		return
 	}
 
@@ -116,132 +127,268 @@ func StoreUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// CONFIGURATION AND ENCRYPTION KEY LOADING
-	// Load encryption key for AES-256-GCM email encryption
+	// CONFIGURATION AND ENCRYPTION KEYRING LOADING
+	// currentKeyring resolves cfg.Envelope's live, rotation-aware Keyring when
+	// configured, otherwise wraps cfg.SecretsProvider (see secrets.CachedProvider)
+	// or cfg.EncryptionKey's static key in an ad-hoc single-key Keyring, so
+	// rotating the secret at its backing store (e.g. Vault KV v2) takes effect
+	// within one cache TTL window instead of requiring a restart
 	cfg := config.GetConfig()
-	if err := crypto.ValidateEncryptionKey(cfg.EncryptionKey); err != nil {
-		log.Printf("Encryption key validation failed: %v", err)
-		utils.SendErrorResponse(w, http.StatusInternalServerError, "Encryption configuration error.")
-		return
-	}
-
-	// EMAIL ENCRYPTION
-	// Encrypt email with deterministic AES-256-GCM for consistent database lookup
-	encryptedEmail, err := crypto.EncryptEmailDeterministic(req.Email, cfg.EncryptionKey)
+	keyring, err := currentKeyring(cfg)
 	if err != nil {
-		errorMsg := fmt.Sprintf("Failed to encrypt email for %s: %v", req.Email, err)
-		log.Printf("Error: %s", errorMsg)
-		utils.SendErrorResponse(w, http.StatusInternalServerError, "Email encryption failed.")
+		utils.WriteError(w, r, errs.Internal("encryption_key_invalid", "Encryption configuration error.", err))
 		return
 	}
 
-	// TO-DO: Initialize storage interface when PostgreSQL implementation is available
-	// var userStorage storage.UserStorage
-	// userStorage = postgresql.NewUserStorage(cfg.DatabaseURL)
-
-	// DUPLICATE EMAIL DETECTION
-	// Check if encrypted email already exists in database
-	// TO-DO: Uncomment when storage interface is implemented
-	/*
-		emailExists, err := userStorage.EmailExists(encryptedEmail)
+	// SSH KEY REVOCATION CHECK (DEFENSE-IN-DEPTH, OPT-IN)
+	// Reject registration only if the presented key is explicitly marked
+	// @revoked for this principal in the known_hosts-style trust store.
+	// ErrUnknownHost/ErrKeyMismatch are not blocking here: this is a
+	// first-registration flow, not a pre-populated allowlist, so a principal
+	// absent from the trust store (the common case) must still be able to
+	// register.
+	if cfg.SSHTrustStoreEnabled {
+		trustStore, err := knownhosts.Load(cfg.SSHTrustStorePath)
 		if err != nil {
-			errorMsg := fmt.Sprintf("Failed to check email existence for %s: %v", req.Email, err)
-			log.Printf("Error: %s", errorMsg)
-			utils.SendErrorResponse(w, http.StatusInternalServerError, "Database error during duplicate check.")
-			return
-		}
-		if emailExists {
-			log.Printf("Registration attempt with existing email: %s", req.Email)
-			utils.SendErrorResponse(w, http.StatusConflict, "Email address already registered.")
+			utils.WriteError(w, r, errs.Internal("trust_store_load_failed", "SSH trust store configuration error.", err))
 			return
 		}
-	*/
 
-	// DUPLICATE SSH KEY DETECTION
-	// Check if SSH public key already exists in database
-	// TO-DO: Uncomment when storage interface is implemented
-	/*
-		sshKeyExists, err := userStorage.SSHKeyExists(req.SSHPubKey)
+		parsedKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(req.SSHPubKey))
 		if err != nil {
-			errorMsg := fmt.Sprintf("Failed to check SSH key existence: %v", err)
-			log.Printf("Error: %s", errorMsg)
-			utils.SendErrorResponse(w, http.StatusInternalServerError, "Database error during SSH key check.")
+			utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid SSH public key format.")
 			return
 		}
-		if sshKeyExists {
-			log.Printf("Registration attempt with existing SSH key")
-			utils.SendErrorResponse(w, http.StatusConflict, "SSH public key already in use.")
+
+		if err := trustStore.Authorize(req.Email, parsedKey); errors.Is(err, knownhosts.ErrRevoked) {
+			utils.SendErrorResponse(w, http.StatusForbidden, "This SSH public key has been revoked.")
 			return
 		}
-	*/
+	}
 
-	// PASSWORD SALT GENERATION
-	// Generate cryptographically secure salt for Argon2id hashing
-	passwordSalt, err := crypto.GenerateSalt()
+	// EMAIL ENCRYPTION
+	// Encrypt email with AES-256-GCM under a random nonce per row, prefixed
+	// with the keyring's active KeyID (crypto.EncryptWithKeyring) so the
+	// rotation package's migration worker can find and re-encrypt this row
+	// after a future RotateKeys; a separate blind index (see crypto.FieldCipher)
+	// carries the indexable lookup value that the random nonce removes from
+	// EncryptedEmail itself
+	encryptedEmail, err := crypto.EncryptWithKeyring(req.Email, keyring)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("email_encryption_failed", "Email encryption failed.", err))
+		return
+	}
+	emailBlindIndex, err := crypto.BlindIndexWithKeyring(req.Email, keyring)
 	if err != nil {
-		errorMsg := fmt.Sprintf("Failed to generate password salt: %v", err)
-		log.Printf("Error: %s", errorMsg)
-		utils.SendErrorResponse(w, http.StatusInternalServerError, "Password processing error.")
+		utils.WriteError(w, r, errs.Internal("field_cipher_init_failed", "Encryption configuration error.", err))
+		return
+	}
+
+	if userStorage == nil {
+		utils.WriteError(w, r, errs.ServiceUnavailable("storage_unavailable", "Storage backend unavailable.", nil))
+		return
+	}
+
+	// DUPLICATE EMAIL DETECTION
+	// Probe every key in the keyring (see lookupUserByEmail), not just the
+	// current primary's blind index: EncryptedEmail itself cannot be compared
+	// across rows now that it carries a random nonce, and a row registered
+	// before a completed rotation is still indexed under a retired key until
+	// the rotation worker re-encrypts it
+	existingUser, _, err := lookupUserByEmail(req.Email, keyring)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("duplicate_check_failed", "Database error during duplicate check.", err))
+		return
+	}
+	if existingUser != nil {
+		utils.SendErrorResponse(w, http.StatusConflict, "Email address already registered.")
+		return
+	}
+
+	// DUPLICATE SSH KEY DETECTION
+	// Check if SSH public key already exists in database
+	sshKeyExists, err := userStorage.SSHKeyExists(req.SSHPubKey)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("ssh_key_check_failed", "Database error during SSH key check.", err))
+		return
+	}
+	if sshKeyExists {
+		utils.SendErrorResponse(w, http.StatusConflict, "SSH public key already in use.")
 		return
 	}
 
 	// PASSWORD HASHING
-	// Hash password with Argon2id using generated salt
-	passwordHash := crypto.HashPassword(req.Password, passwordSalt)
+	// Hash password with Argon2id; salt and parameters are embedded in the
+	// returned PHC-encoded string, so no separate salt column is needed
+	passwordHash, err := crypto.HashPassword(req.Password)
+	if err != nil {
+		utils.WriteError(w, r, errs.Internal("password_hash_failed", "Password processing error.", err))
+		return
+	}
 
 	// USER RECORD CREATION
 	// Prepare complete user record for database storage
 	now := time.Now()
 	user := types.StoredUser{
-		EncryptedEmail: encryptedEmail,
-		PasswordHash:   passwordHash,
-		PasswordSalt:   passwordSalt,
-		SSHPubKey:      req.SSHPubKey,
-		CreatedAt:      now,
-		UpdatedAt:      now,
+		EncryptedEmail:  encryptedEmail,
+		EmailBlindIndex: emailBlindIndex,
+		PasswordHash:    passwordHash,
+		SSHPubKey:       req.SSHPubKey,
+		CreatedAt:       now,
+		UpdatedAt:       now,
 	}
 
-	// TO-DO: Remove this blank identifier when storage interface is implemented
-	_ = user // Suppress unused variable warning until PostgreSQL implementation is available
+	// SSH CERTIFICATE ISSUANCE (CERT_MODE)
+	// Sign a short-lived OpenSSH user certificate over the client's public key
+	// instead of relying on it as a long-lived credential, when requested
+	var signedCertificate string
+	if req.CertMode {
+		if !cfg.SSHCAEnabled {
+			utils.SendErrorResponse(w, http.StatusBadRequest, "Certificate mode is not enabled on this server.")
+			return
+		}
 
-	// DATABASE STORAGE
-	// Store user credentials in secure database
-	// TO-DO: Uncomment when storage interface is implemented
-	/*
-		if err := userStorage.StoreUser(user); err != nil {
-			errorMsg := fmt.Sprintf("Failed to store user credentials for %s: %v", req.Email, err)
-			log.Printf("Error: %s", errorMsg)
-
-			// STORAGE ERROR CATEGORIZATION
-			// Provide specific guidance based on storage failure type
-			if storageErr, ok := err.(*storage.StorageError); ok {
-				switch storageErr.Type {
-				case storage.ErrorUserExists:
-					utils.SendErrorResponse(w, http.StatusConflict, storageErr.UserMessage)
-				case storage.ErrorSSHKeyExists:
-					utils.SendErrorResponse(w, http.StatusConflict, storageErr.UserMessage)
-				case storage.ErrorDatabaseConnection:
-					utils.SendErrorResponse(w, http.StatusServiceUnavailable, "Database service unavailable.")
-				case storage.ErrorValidationFailed:
-					utils.SendErrorResponse(w, http.StatusBadRequest, storageErr.UserMessage)
-				default:
-					utils.SendErrorResponse(w, http.StatusInternalServerError, "Storage operation failed.")
-				}
-			} else {
-				// Generic storage error - system issue
-				utils.SendErrorResponse(w, http.StatusInternalServerError, "Unable to store user credentials.")
-			}
+		ca, err := sshca.NewCA(cfg.SSHCASigningKeyFile, cfg.SSHCATTL)
+		if err != nil {
+			utils.WriteError(w, r, errs.CertificateError("ssh_ca_load_failed", "SSH certificate authority configuration error.", err))
+			return
+		}
+
+		certBytes, err := ca.SignUserKey([]byte(req.SSHPubKey), req.Email)
+		if err != nil {
+			utils.WriteError(w, r, errs.Internal("ssh_cert_sign_failed", "SSH certificate signing failed.", err))
 			return
 		}
-	*/
+		signedCertificate = string(certBytes)
+	}
+
+	// DATABASE STORAGE
+	// Store user credentials in secure database
+	if err := userStorage.StoreUser(user); err != nil {
+		writeStorageError(w, r, err, "Unable to store user credentials.")
+		return
+	}
 
 	// SUCCESS RESPONSE
 	// Log successful registration and send confirmation to Security-Switch
-	log.Printf("User credentials successfully stored: %s", req.Email)
-	utils.SendSuccessResponse(w, http.StatusCreated, "User credentials stored successfully!")
+	logging.FromContext(r.Context()).Info("user credentials successfully stored", "email_blind_index", emailBlindIndex)
+	if signedCertificate != "" {
+		utils.SendSuccessResponseWithCertificate(w, http.StatusCreated, "User credentials stored successfully!", signedCertificate)
+	} else {
+		utils.SendSuccessResponse(w, http.StatusCreated, "User credentials stored successfully!")
+	}
 
 	// AUDIT LOGGING
-	// Record successful storage operation for security monitoring
-	log.Printf("Audit: User registration completed - Email: %s, Timestamp: %s",
-		req.Email, time.Now().Format(time.RFC3339))
+	// Record successful storage operation for security monitoring, keyed by
+	// the blind index rather than the plaintext email
+	logging.FromContext(r.Context()).Info("audit: user registration completed",
+		"email_blind_index", emailBlindIndex, "timestamp", time.Now().Format(time.RFC3339))
+}
+
+// currentEncryptionKey resolves the AES-256-GCM key StoreUserHandler encrypts
+// under. Envelope encryption (cfg.Envelope) already tracks its own live
+// Keyring independent of this package, so it takes priority when configured;
+// otherwise cfg.SecretsProvider (see secrets.Provider) is consulted so a
+// secret rotated at its backing store takes effect without a restart,
+// falling back to cfg.EncryptionKey's startup snapshot if no provider is configured.
+//
+// Returns the validated 32-byte key, or error if the lookup or key
+// validation fails.
+func currentEncryptionKey(cfg *config.Config) ([]byte, error) {
+	if cfg.Envelope != nil {
+		_, key := cfg.Envelope.Keyring().Primary()
+		return key, crypto.ValidateEncryptionKey(key)
+	}
+
+	if cfg.SecretsProvider == nil {
+		return cfg.EncryptionKey, crypto.ValidateEncryptionKey(cfg.EncryptionKey)
+	}
+
+	key, err := cfg.SecretsProvider.Get(secrets.EncryptionKeyName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch encryption key from secrets provider: %v", err)
+	}
+	if err := crypto.ValidateEncryptionKey(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// currentKeyringStaticID labels the ad-hoc single-key Keyring currentKeyring
+// builds when cfg has no Envelope configured, so EncryptedEmail written under
+// a bare SecretsProvider or cfg.EncryptionKey key still carries a recognizable
+// KeyID prefix rather than an empty one.
+const currentKeyringStaticID = crypto.KeyID("static")
+
+// currentKeyring resolves the live *crypto.Keyring every email encryption,
+// blind-index, and lookup call in this package goes through.
+// cfg.Envelope already tracks a real multi-key Keyring with RotateKeys
+// support, so it takes priority when configured; otherwise the single key
+// currentEncryptionKey resolves (SecretsProvider, or cfg.EncryptionKey as a
+// last resort) is wrapped in a fresh single-key Keyring under
+// currentKeyringStaticID, so callers never need to special-case the
+// no-envelope configuration.
+//
+// Returns the live or ad-hoc Keyring, or error if no usable key is configured.
+func currentKeyring(cfg *config.Config) (*crypto.Keyring, error) {
+	if cfg.Envelope != nil {
+		return cfg.Envelope.Keyring(), nil
+	}
+
+	key, err := currentEncryptionKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.NewKeyring(currentKeyringStaticID, key)
+}
+
+// lookupUserByEmail resolves email to its stored user by trying the blind
+// index computed under every key in kr, primary first (see
+// crypto.BlindIndexCandidates), so a row blind-indexed under a key retired by
+// a completed RotateKeys still resolves before the rotation worker gets to
+// re-encrypting it.
+//
+// Returns the matching user (nil if none matched), the blind index the
+// caller should use going forward (the first candidate tried, i.e. the one
+// under the current primary key), or error if no candidate could be derived
+// or a storage lookup failed.
+func lookupUserByEmail(email string, kr *crypto.Keyring) (*types.StoredUser, string, error) {
+	candidates, err := crypto.BlindIndexCandidates(email, kr)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, candidate := range candidates {
+		user, err := userStorage.GetUserByEmailBlindIndex(candidate)
+		if err != nil {
+			return nil, "", err
+		}
+		if user != nil {
+			return user, candidates[0], nil
+		}
+	}
+	return nil, candidates[0], nil
+}
+
+// writeStorageError maps a storage.UserStorage error onto the appropriate
+// HTTP response, using its StorageErrorType when err is a
+// *storage.StorageError for a precise status/message, or genericMessage
+// with a 500 otherwise.
+func writeStorageError(w http.ResponseWriter, r *http.Request, err error, genericMessage string) {
+	storageErr, ok := err.(*storage.StorageError)
+	if !ok {
+		utils.WriteError(w, r, errs.Internal("storage_operation_failed", genericMessage, err))
+		return
+	}
+
+	switch storageErr.Type {
+	case storage.ErrorUserExists, storage.ErrorSSHKeyExists:
+		utils.WriteError(w, r, errs.Conflict("storage_conflict", storageErr.UserMessage, storageErr))
+	case storage.ErrorDatabaseConnection:
+		utils.WriteError(w, r, errs.ServiceUnavailable("storage_unavailable", "Database service unavailable.", storageErr))
+	case storage.ErrorValidationFailed, storage.ErrorInvalidUserData:
+		utils.WriteError(w, r, errs.BadRequest("storage_validation_failed", storageErr.UserMessage, storageErr))
+	default:
+		utils.WriteError(w, r, errs.Internal("storage_operation_failed", "Storage operation failed.", storageErr))
+	}
 }
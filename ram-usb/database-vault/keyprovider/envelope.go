@@ -0,0 +1,181 @@
+/*
+Envelope-encryption wiring for Database-Vault's data-encryption key (DEK).
+
+EnvelopeKeyProvider is the production-preferred counterpart to KeyringAdapter:
+instead of assuming the plaintext key is already available (crypto.LoadKeyringFromSources'
+env/file/remote-fetch fallbacks), it persists only a KeyWrapper-wrapped DEK blob
+(stored alongside config, per a WrappedDEKStore) and unwraps it into memory once
+at startup. RotateDEK generates a new DEK, wraps and persists it, and promotes it
+via the same crypto.Keyring.RotateKeys the existing rotation.Worker already
+migrates rows away from - row re-encryption itself stays that worker's job,
+not duplicated here.
+*/
+package keyprovider
+
+import (
+	"context"
+	"crypto/rand"
+	"database-vault/crypto"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WrappedDEKBlob is the on-disk (or in-DB) representation of a KeyWrapper-wrapped DEK.
+type WrappedDEKBlob struct {
+	KeyID      string `json:"key_id"`     // Keyring KeyID this DEK is promoted to, e.g. "v2"
+	Wrapper    string `json:"wrapper"`    // KeyWrapper.Name() that produced Ciphertext, for operator visibility on mismatch
+	Ciphertext []byte `json:"ciphertext"` // KeyWrapper.Wrap output
+}
+
+// WrappedDEKStore persists the current wrapped DEK blob.
+//
+// Implementations must make Save atomic: a crash mid-write must never leave
+// behind a blob RotateDEK started writing but didn't finish, since that would
+// strand the vault between two key versions at next startup.
+type WrappedDEKStore interface {
+	// Load retrieves the current wrapped DEK blob.
+	Load() (*WrappedDEKBlob, error)
+
+	// Save atomically replaces the current wrapped DEK blob.
+	Save(blob *WrappedDEKBlob) error
+}
+
+// LocalFileWrappedDEKStore persists the wrapped DEK blob as JSON alongside config.
+//
+// Security features:
+// - Save writes to a temp file in the same directory and renames over the target, so Load never observes a partially written blob
+type LocalFileWrappedDEKStore struct {
+	path string
+}
+
+// NewLocalFileWrappedDEKStore constructs a WrappedDEKStore backed by a local file path.
+//
+// Returns error if path is empty.
+func NewLocalFileWrappedDEKStore(path string) (*LocalFileWrappedDEKStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("wrapped DEK file path must not be empty")
+	}
+	return &LocalFileWrappedDEKStore{path: path}, nil
+}
+
+// Load reads and decodes the wrapped DEK blob from disk.
+//
+// Returns error if the file is missing or not valid JSON.
+func (s *LocalFileWrappedDEKStore) Load() (*WrappedDEKBlob, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wrapped DEK file %q: %v", s.path, err)
+	}
+
+	var blob WrappedDEKBlob
+	if err := json.Unmarshal(data, &blob); err != nil {
+		return nil, fmt.Errorf("wrapped DEK file %q is not valid JSON: %v", s.path, err)
+	}
+	return &blob, nil
+}
+
+// Save writes blob to a temp file in the same directory, then renames it over
+// the target path so a reader never observes a half-written file.
+//
+// Returns error if encoding or either filesystem operation fails.
+func (s *LocalFileWrappedDEKStore) Save(blob *WrappedDEKBlob) error {
+	data, err := json.Marshal(blob)
+	if err != nil {
+		return fmt.Errorf("failed to encode wrapped DEK blob: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file for wrapped DEK blob: %v", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once the rename below succeeds
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write wrapped DEK blob: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close wrapped DEK temp file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to atomically replace wrapped DEK file: %v", err)
+	}
+	return nil
+}
+
+// EnvelopeKeyProvider loads and rotates Database-Vault's encryption Keyring
+// through a KeyWrapper instead of a statically configured plaintext key.
+//
+// Security features:
+// - The plaintext DEK exists only inside the in-memory crypto.Keyring returned by Keyring, never on disk
+// - RotateDEK's wrap-then-persist-then-promote order means a failed wrap or Save leaves the previous DEK fully in place, never a half-rotated Keyring
+type EnvelopeKeyProvider struct {
+	wrapper crypto.KeyWrapper
+	store   WrappedDEKStore
+	keyring *crypto.Keyring
+}
+
+// LoadEnvelopeKeyProvider reads the current wrapped DEK blob from store, unwraps
+// it via wrapper, and builds the crypto.Keyring callers use for field encryption.
+//
+// Returns error if the blob cannot be loaded, the configured wrapper can't
+// unwrap it, or the unwrapped DEK fails key strength validation.
+func LoadEnvelopeKeyProvider(ctx context.Context, wrapper crypto.KeyWrapper, store WrappedDEKStore) (*EnvelopeKeyProvider, error) {
+	blob, err := store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load wrapped DEK blob: %v", err)
+	}
+
+	dek, err := wrapper.Unwrap(ctx, blob.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap DEK via %q: %v", wrapper.Name(), err)
+	}
+
+	keyring, err := crypto.NewKeyring(crypto.KeyID(blob.KeyID), dek)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build keyring from unwrapped DEK: %v", err)
+	}
+
+	return &EnvelopeKeyProvider{wrapper: wrapper, store: store, keyring: keyring}, nil
+}
+
+// Keyring returns the provider's live crypto.Keyring, for handlers to encrypt
+// and decrypt fields against and for rotation.Worker to migrate retired keys from.
+func (p *EnvelopeKeyProvider) Keyring() *crypto.Keyring {
+	return p.keyring
+}
+
+// RotateDEK generates a fresh DEK, wraps it via the configured KeyWrapper,
+// persists the wrapped blob, and promotes the new DEK to primary.
+//
+// Security features:
+// - New DEK is wrapped and durably persisted before Keyring.RotateKeys ever promotes it, so a crash between generation and promotion leaves the old primary untouched
+// - Previous primary demotes to a retired, decrypt-only key (see crypto.Keyring.RotateKeys), so rows not yet migrated by rotation.Worker keep decrypting
+//
+// Returns error if DEK generation, wrapping, persistence, or promotion fails.
+// Callers should follow a successful RotateDEK with a rotation.Worker.Run
+// pass to migrate rows off the newly retired key.
+func (p *EnvelopeKeyProvider) RotateDEK(ctx context.Context) error {
+	newDEK := make([]byte, 32)
+	if _, err := rand.Read(newDEK); err != nil {
+		return fmt.Errorf("failed to generate new DEK: %v", err)
+	}
+
+	newID := nextKeyID(p.keyring)
+
+	wrapped, err := p.wrapper.Wrap(ctx, newDEK)
+	if err != nil {
+		return fmt.Errorf("failed to wrap new DEK via %q: %v", p.wrapper.Name(), err)
+	}
+
+	if err := p.store.Save(&WrappedDEKBlob{KeyID: string(newID), Wrapper: p.wrapper.Name(), Ciphertext: wrapped}); err != nil {
+		return fmt.Errorf("failed to persist wrapped DEK blob: %v", err)
+	}
+
+	if err := p.keyring.RotateKeys(newID, newDEK); err != nil {
+		return fmt.Errorf("failed to promote new DEK: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+/*
+Structured slog-based logging for Database-Vault.
+
+Replaces the ad-hoc log.Printf/log.Fatalf audit trail (main.go startup,
+utils.LogAndSendError/WriteError, middleware) with a configured *slog.Logger
+so every log line is machine-parseable: op, status, and identity fields
+become structured attributes instead of being interpolated into a free-text
+message. New builds a base logger from RAMUSB_LOG_LEVEL/RAMUSB_LOG_FORMAT;
+Middleware attaches a request-scoped child (carrying a correlation id) to
+each request's context, retrieved downstream via FromContext.
+*/
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ctxKey is an unexported type for this package's context key, so it can
+// never collide with a key set by another package.
+type ctxKey int
+
+// loggerKey is the context key WithLogger stores a request-scoped logger under.
+const loggerKey ctxKey = iota
+
+// New builds the base *slog.Logger for the process, reading level and
+// output format from RAMUSB_LOG_LEVEL ("debug", "info", "warn", "error";
+// default "info") and RAMUSB_LOG_FORMAT ("json" or "text"; default "json").
+//
+// Security features:
+// - JSON output by default, so every field (including ones a future caller adds) is escaped and machine-parseable rather than free-text interpolated
+//
+// Returns a ready-to-use logger; an unrecognized level or format falls back
+// to its default rather than failing startup.
+func New() *slog.Logger {
+	opts := &slog.HandlerOptions{Level: parseLevel(os.Getenv("RAMUSB_LOG_LEVEL"))}
+
+	var handler slog.Handler
+	if strings.ToLower(os.Getenv("RAMUSB_LOG_FORMAT")) == "text" {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	} else {
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	}
+	return slog.New(handler)
+}
+
+// parseLevel maps a level name to its slog.Level, defaulting to Info for an
+// empty or unrecognized value.
+func parseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// WithLogger returns a context carrying logger, retrievable via FromContext.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// FromContext returns the logger attached by Middleware, or slog.Default()
+// if ctx carries none (e.g. a call site that hasn't been threaded through
+// the HTTP middleware chain yet).
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// Middleware attaches a request-scoped child of base - carrying a request
+// id, the request method, and the request path - to each request's
+// context, so every log line FromContext emits downstream can be tied back
+// to the request that produced it.
+//
+// Security features:
+// - Request id lets an operator tie a reported failure back to the matching server log line without exposing internal error text to the caller
+//
+// Returns middleware wrapping next.
+func Middleware(base *slog.Logger) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			requestLogger := RequestLogger(base, r)
+			next(w, r.WithContext(WithLogger(r.Context(), requestLogger)))
+		}
+	}
+}
+
+// RequestLogger builds the per-request child of base that Middleware
+// attaches to every request's context, carrying a freshly generated
+// request id plus r's method and path. Exposed directly (rather than only
+// through Middleware) for middleware.Verifier.VerifyMTLS, which must attach
+// a request-scoped logger before it can log its own TLS/certificate checks.
+func RequestLogger(base *slog.Logger, r *http.Request) *slog.Logger {
+	return base.With(
+		"request_id", NewRequestID(),
+		"method", r.Method,
+		"path", r.URL.Path,
+	)
+}
+
+// NewRequestID generates a short random hex token identifying one request
+// across every log line it produces.
+func NewRequestID() string {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(buf)
+}
+
+// RedactDatabaseURL replaces a database connection string's userinfo with a
+// fixed placeholder via net/url, so a connection string can be logged
+// without leaking its embedded credentials.
+//
+// Returns the sanitized URL, or "***MASKED***" if rawURL doesn't parse as a URL.
+func RedactDatabaseURL(rawURL string) string {
+	if rawURL == "" {
+		return ""
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "***MASKED***"
+	}
+	if u.User != nil {
+		u.User = url.User("***")
+	}
+	return u.String()
+}
+
+// RedactKeyMaterial summarizes key bytes as their length, for logging
+// confirmation that a key loaded without ever logging the key itself.
+func RedactKeyMaterial(key []byte) string {
+	return "[redacted " + strconv.Itoa(len(key)) + "-byte key]"
+}
+
+// RedactSSHKey reduces an SSH public key to its algorithm prefix, for
+// logging enough to distinguish key types without the key material itself.
+//
+// Returns "[empty]" for an empty key, "[redacted <algo> key]" otherwise.
+func RedactSSHKey(sshKey string) string {
+	if sshKey == "" {
+		return "[empty]"
+	}
+	algo, _, found := strings.Cut(sshKey, " ")
+	if !found {
+		return "[redacted key]"
+	}
+	return "[redacted " + algo + " key]"
+}
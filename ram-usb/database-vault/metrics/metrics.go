@@ -0,0 +1,101 @@
+/*
+Prometheus collectors for Database-Vault's storage layer.
+
+storage.WithMetrics is the only intended writer of these collectors, keeping
+the UserStorage interface itself free of any metrics-specific parameters.
+The /metrics route main.go registers serves them via promhttp.Handler.
+*/
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// OpsTotal counts every storage.UserStorage call, labeled by operation
+	// name and result - "ok" or, when the returned error is a
+	// *storage.StorageError, its StorageErrorType (e.g. "constraint_violation",
+	// "database_connection") so operators can alert on a spike of one
+	// category without the others drowning it out in an aggregate rate.
+	OpsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_ops_total",
+		Help: "Total storage operations, labeled by operation and result.",
+	}, []string{"op", "result"})
+
+	// OpDuration records per-operation latency for Prometheus-side quantile
+	// queries (histogram_quantile over db_op_duration_seconds_bucket).
+	OpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_op_duration_seconds",
+		Help:    "Storage operation latency in seconds, labeled by operation.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"op"})
+
+	// ConnectionsOpen reports the active storage backend's open connection
+	// count, refreshed on every HealthCheck.
+	ConnectionsOpen = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "db_connections_open",
+		Help: "Open connections reported by the active storage backend.",
+	})
+
+	// UsersTotal reports the total registered user count, refreshed on every
+	// GetUserStats call.
+	UsersTotal = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "users_total",
+		Help: "Total registered users, refreshed on every GetUserStats call.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(OpsTotal, OpDuration, ConnectionsOpen, UsersTotal)
+}
+
+// RecentQuantile tracks a small sliding window of recent operation
+// durations so HealthCheck can report a representative ResponseTime instead
+// of a single ping's latency. Prometheus's client library is write-only
+// from the instrumented process's own point of view - computing "the p50 of
+// db_op_duration_seconds" requires a Prometheus server to query, which
+// HealthCheck doesn't have access to - so this keeps the window itself.
+//
+// Safe for concurrent use.
+type RecentQuantile struct {
+	mu       sync.Mutex
+	samples  []time.Duration
+	capacity int
+}
+
+// NewRecentQuantile returns a RecentQuantile retaining the most recent
+// capacity observations.
+func NewRecentQuantile(capacity int) *RecentQuantile {
+	return &RecentQuantile{capacity: capacity}
+}
+
+// Observe records d, evicting the oldest sample once capacity is exceeded.
+func (q *RecentQuantile) Observe(d time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.samples = append(q.samples, d)
+	if len(q.samples) > q.capacity {
+		q.samples = q.samples[len(q.samples)-q.capacity:]
+	}
+}
+
+// Quantile returns the p-th percentile (0 <= p <= 1) of the current window,
+// or zero if no samples have been observed yet.
+func (q *RecentQuantile) Quantile(p float64) time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.samples) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), q.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(p * float64(len(sorted)))
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
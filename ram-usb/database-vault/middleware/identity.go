@@ -0,0 +1,98 @@
+/*
+Per-route client-certificate authorization for Database-Vault.
+
+Verifier.VerifyMTLS already authorizes every request against one
+process-wide mtlsauth.AuthzPolicy (organization, SPIFFE ID, or SAN). The
+wrappers here sit inside VerifyMTLS and narrow that further on a per-route
+basis, either by resolved identity or by pinning the exact certificate
+fingerprint, without touching the process-wide policy:
+
+	mux.HandleFunc("/api/store-user",
+		verifier.VerifyMTLS(middleware.RequireIdentity([]string{"spiffe://ramusb.local/security-switch"}, handlers.StoreUserHandler)))
+*/
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"database-vault/logging"
+	"database-vault/utils"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"ramusb/mtlsauth"
+)
+
+// RequireIdentity wraps next so that, in addition to whatever
+// Verifier.VerifyMTLS has already authorized, the client certificate's
+// resolved identity (its spiffe:// URI SAN if present, else its
+// Subject.CommonName - see mtlsauth.IdentityOf) must also appear in
+// allowed. Must run behind VerifyMTLS, which is what populates
+// r.TLS.PeerCertificates in the first place.
+func RequireIdentity(allowed []string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cert, ok := peerCert(w, r)
+		if !ok {
+			return
+		}
+
+		id := mtlsauth.IdentityOf(cert)
+		if !mtlsauth.MatchesAny(id, allowed) {
+			utils.LogAndSendError(w, r, http.StatusForbidden,
+				fmt.Sprintf("identity %q is not permitted on %s (allowed=%v)", id, r.URL.Path, allowed),
+				"Unauthorized client")
+			return
+		}
+
+		logging.FromContext(r.Context()).Info("route identity authorized", "identity", id, "path", r.URL.Path)
+		next(w, r)
+	}
+}
+
+// RequireFingerprint wraps next with certificate-fingerprint pinning: the
+// hex-encoded SHA-256 digest of the peer certificate's DER encoding (the
+// same value certwatch.Watcher.Fingerprint reports for the server's own
+// leaf) must appear in allowed. Stricter than RequireIdentity - reissuing a
+// certificate under the same identity, or a compromised CA signing a new
+// one, is not enough to pass; only the exact pinned certificate is - at the
+// cost of every permitted certificate's fingerprint needing to be tracked
+// and updated here across rotations.
+func RequireFingerprint(allowed []string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cert, ok := peerCert(w, r)
+		if !ok {
+			return
+		}
+
+		fp := fingerprintOf(cert)
+		if !slices.Contains(allowed, fp) {
+			utils.LogAndSendError(w, r, http.StatusForbidden,
+				fmt.Sprintf("certificate fingerprint %s is not pinned for %s", fp, r.URL.Path),
+				"Unauthorized client")
+			return
+		}
+
+		logging.FromContext(r.Context()).Info("route fingerprint authorized", "fingerprint", fp, "path", r.URL.Path)
+		next(w, r)
+	}
+}
+
+// peerCert returns the request's leaf client certificate, writing an
+// unauthorized response and returning ok=false if VerifyMTLS somehow didn't
+// run first.
+func peerCert(w http.ResponseWriter, r *http.Request) (*x509.Certificate, bool) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "Client certificate required")
+		return nil, false
+	}
+	return r.TLS.PeerCertificates[0], true
+}
+
+// fingerprintOf returns the hex-encoded SHA-256 digest of cert's DER
+// encoding.
+func fingerprintOf(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
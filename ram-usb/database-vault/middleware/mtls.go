@@ -4,28 +4,52 @@ mTLS middleware for Database-Vault request validation and authentication.
 Implements certificate-based client authentication to ensure only authorized
 Security-Switch instances can communicate with Database-Vault endpoints. Provides
 comprehensive certificate validation including TLS connection verification,
-client certificate presence, and organizational authorization checks within
-the zero-trust inter-service architecture for secure credential storage operations.
+client certificate presence, and delegated authorization (organization,
+SPIFFE identity, or SAN, plus optional OCSP/CRL revocation checking) within the
+zero-trust inter-service architecture for secure credential storage operations.
 */
 package middleware
 
 import (
+	"database-vault/logging"
 	"database-vault/utils"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+
+	"ramusb/mtlsauth"
 )
 
+// Verifier wraps a shared ramusb/mtlsauth.Verifier as HTTP middleware,
+// keeping Database-Vault's request handling and logging conventions while
+// delegating revocation checking and identity authorization to the policy
+// configured in NewVerifier.
+//
+// Construct with NewVerifier.
+type Verifier struct {
+	authz      *mtlsauth.Verifier
+	baseLogger *slog.Logger
+}
+
+// NewVerifier returns a Verifier that authorizes inbound client certificates
+// via authz, attaching a request-scoped child of baseLogger (see
+// database-vault/logging) to every request's context before it reaches the
+// wrapped handler.
+func NewVerifier(authz *mtlsauth.Verifier, baseLogger *slog.Logger) *Verifier {
+	return &Verifier{authz: authz, baseLogger: baseLogger}
+}
+
 // VerifyMTLS creates middleware function for mTLS client certificate validation.
 //
 // Security features:
 // - TLS connection state verification prevents non-encrypted requests
 // - Client certificate presence validation ensures mutual authentication
-// - Organizational authorization restricts access to SecuritySwitch services only
+// - Delegated authorization (via mtlsauth.AuthzPolicy) restricts access to SecuritySwitch, by organization, SPIFFE ID, or SAN
+// - Optional OCSP/CRL revocation checking rejects certificates issued to since-compromised instances
 // - Comprehensive logging provides audit trail for security monitoring
 //
 // Returns wrapped handler function with mTLS authentication or error response for unauthorized requests.
-func VerifyMTLS(next http.HandlerFunc) http.HandlerFunc {
+func (v *Verifier) VerifyMTLS(next http.HandlerFunc) http.HandlerFunc {
 	// MIDDLEWARE WRAPPER FUNCTION
 	// Returns anonymous function that performs mTLS verification before calling next handler
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -33,11 +57,18 @@ func VerifyMTLS(next http.HandlerFunc) http.HandlerFunc {
 		// Ensure consistent content type for error responses
 		w.Header().Set("Content-Type", "application/json")
 
+		// REQUEST-SCOPED LOGGER ATTACHMENT
+		// Every downstream utils.LogAndSendError/WriteError call, and every
+		// log line this middleware itself emits from here on, is tagged with
+		// the same request_id
+		reqLogger := logging.RequestLogger(v.baseLogger, r).With("remote_addr", r.RemoteAddr)
+		r = r.WithContext(logging.WithLogger(r.Context(), reqLogger))
+
 		// TLS CONNECTION VERIFICATION
 		// Ensure request uses encrypted TLS transport
 		if r.TLS == nil {
 			// Non-TLS connection attempt - security violation
-			log.Printf("Request without TLS from %s", r.RemoteAddr)
+			reqLogger.Warn("request without TLS")
 			utils.SendErrorResponse(w, http.StatusUnauthorized, "TLS required")
 			return
 		}
@@ -46,7 +77,7 @@ func VerifyMTLS(next http.HandlerFunc) http.HandlerFunc {
 		// Verify that client presented certificate for mutual authentication
 		if len(r.TLS.PeerCertificates) == 0 {
 			// Missing client certificate - authentication failure
-			log.Printf("Request without client certificate from %s", r.RemoteAddr)
+			reqLogger.Warn("request without client certificate")
 			utils.SendErrorResponse(w, http.StatusUnauthorized, "Client certificate required")
 			return
 		}
@@ -57,24 +88,24 @@ func VerifyMTLS(next http.HandlerFunc) http.HandlerFunc {
 
 		// AUTHENTICATION SUCCESS LOGGING
 		// Log successful mTLS authentication with certificate details
-		log.Printf("mTLS authenticated request from %s (CN=%s, O=%s)",
-			r.RemoteAddr,
-			clientCert.Subject.CommonName,
-			clientCert.Subject.Organization)
+		reqLogger.Info("mTLS authenticated request",
+			"common_name", clientCert.Subject.CommonName,
+			"organization", clientCert.Subject.Organization)
 
-		// ORGANIZATIONAL AUTHORIZATION
-		// Verify client belongs to authorized SecuritySwitch organization
-		if len(clientCert.Subject.Organization) == 0 || clientCert.Subject.Organization[0] != "SecuritySwitch" {
-			// Unauthorized organization - access denied
-			log.Printf("Unauthorized client organization: %v", clientCert.Subject.Organization)
-			utils.SendErrorResponse(w, http.StatusForbidden, "Unauthorized client")
+		// DELEGATED AUTHORIZATION
+		// Revocation status and identity authorization, per the configured AuthzPolicy
+		if err := v.authz.Authorize(clientCert); err != nil {
+			// Revoked certificate or unauthorized identity - access denied
+			utils.LogAndSendError(w, r, http.StatusForbidden,
+				fmt.Sprintf("unauthorized client certificate (CN=%s, DNS=%v, URIs=%v): %v",
+					clientCert.Subject.CommonName, clientCert.DNSNames, clientCert.URIs, err),
+				"Unauthorized client")
 			return
 		}
 
 		// REQUEST AUDIT LOGGING
 		// Log authenticated request details for security monitoring
-		fmt.Printf("Authenticated request: \n\tfrom:\t%s \n\tmethod:\t%s\n\tpath:\t%s\n",
-			r.RemoteAddr, r.Method, r.URL.Path)
+		reqLogger.Info("authenticated request", "method", r.Method, "path", r.URL.Path)
 
 		// AUTHORIZED REQUEST FORWARDING
 		// Call original handler after successful mTLS verification
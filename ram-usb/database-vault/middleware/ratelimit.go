@@ -0,0 +1,146 @@
+/*
+Per-identity request-rate limiting for Database-Vault endpoints.
+
+Security-Switch's own ratelimit.Limiter already throttles requests by mTLS
+identity before they ever reach Database-Vault, but that check runs on the
+trusted side of the connection - a compromised Security-Switch instance, or
+a bug in its limiter configuration, would otherwise have unthrottled access
+to the storage layer. RateLimiter is Database-Vault's own independent
+backstop, keyed the same way (verified peer certificate identity) but
+enforced at the final hop rather than relying solely on the one before it.
+Database-Vault carries its own go.mod, unlike Entry-Hub and Security-Switch,
+so this uses golang.org/x/time/rate directly instead of hand-rolling a token
+bucket the way security_switch/ratelimit does.
+*/
+package middleware
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"database-vault/utils"
+
+	"ramusb/mtlsauth"
+)
+
+// gcInterval is how often RateLimiter sweeps idle entries out of its map.
+const gcInterval = 10 * time.Minute
+
+// idleTTL is how long an identity's bucket may sit unused before GC reclaims
+// it - well past any plausible gap between Security-Switch requests.
+const idleTTL = 30 * time.Minute
+
+// peerBucket pairs a token bucket with the time it was last consulted, so
+// GC can distinguish an idle entry from an active one.
+type peerBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimiter enforces a per-identity rate limit keyed on the request's
+// verified peer certificate (its SPIFFE URI SAN when present, its
+// CommonName otherwise), independent of whatever limiting Security-Switch
+// applies upstream.
+//
+// Security features:
+// - Identity is derived from the verified peer certificate, never a caller-supplied header
+// - Idle buckets are reclaimed by a background GC goroutine instead of accumulating for the life of the process
+//
+// Construct with NewRateLimiter.
+type RateLimiter struct {
+	rps   float64
+	burst int
+
+	mu      sync.Mutex
+	buckets map[string]*peerBucket
+}
+
+// NewRateLimiter builds a RateLimiter allowing rps requests per second per
+// identity, with bursts up to burst, and starts its idle-entry GC goroutine.
+//
+// Returns a ready-to-use RateLimiter.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	rl := &RateLimiter{
+		rps:     rps,
+		burst:   burst,
+		buckets: make(map[string]*peerBucket),
+	}
+	go rl.gcLoop()
+	return rl
+}
+
+// gcLoop periodically removes entries idle for longer than idleTTL. Runs for
+// the lifetime of the process.
+func (rl *RateLimiter) gcLoop() {
+	ticker := time.NewTicker(gcInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		cutoff := time.Now().Add(-idleTTL)
+		rl.mu.Lock()
+		for key, bucket := range rl.buckets {
+			if bucket.lastSeen.Before(cutoff) {
+				delete(rl.buckets, key)
+			}
+		}
+		rl.mu.Unlock()
+	}
+}
+
+// Middleware enforces RateLimiter's per-identity limit before calling next,
+// rejecting with HTTP 429 once an identity's bucket is exhausted.
+//
+// Security features:
+// - Runs after VerifyMTLS in the handler chain, so identity extraction always sees a verified certificate
+// - Rejections are logged with the offending identity for audit and incident response
+//
+// Returns middleware wrapping next.
+func (rl *RateLimiter) Middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			// Should not happen downstream of VerifyMTLS - checked here as defense-in-depth
+			utils.SendErrorResponse(w, http.StatusUnauthorized, "Client certificate required")
+			return
+		}
+
+		identity := peerIdentity(r.TLS.PeerCertificates[0])
+		if !rl.allow(identity) {
+			utils.LogAndSendError(w, r, http.StatusTooManyRequests,
+				fmt.Sprintf("rate limit exceeded for identity %s", identity),
+				"Rate limit exceeded. Please try again later.")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// allow consumes one token from identity's bucket, creating a fresh one
+// pre-filled to rl.burst if this is the first request seen for it.
+func (rl *RateLimiter) allow(identity string) bool {
+	rl.mu.Lock()
+	bucket, ok := rl.buckets[identity]
+	if !ok {
+		bucket = &peerBucket{limiter: rate.NewLimiter(rate.Limit(rl.rps), rl.burst)}
+		rl.buckets[identity] = bucket
+	}
+	bucket.lastSeen = time.Now()
+	rl.mu.Unlock()
+
+	return bucket.limiter.Allow()
+}
+
+// peerIdentity derives a rate limit key from cert: its SPIFFE URI SAN when
+// present (see ramusb/mtlsauth.SPIFFEID), falling back to its CommonName
+// otherwise - CN alone covers mtls_authz.mode "org" deployments, the URI SAN
+// covers "spiffe"/"san" ones.
+func peerIdentity(cert *x509.Certificate) string {
+	if spiffeID, ok := mtlsauth.SPIFFEID(cert); ok {
+		return spiffeID
+	}
+	return cert.Subject.CommonName
+}
@@ -0,0 +1,133 @@
+/*
+x509 user-certificate authority for mTLS client authentication to
+Storage-Service.
+
+Storage-Service access has historically relied on a long-lived raw SSH
+public key, or (see ramusb/sshca) a short-lived SSH certificate over one -
+while every other internal hop in R.A.M.-U.S.B. is mTLS. CA closes that gap:
+it signs a short-lived x509 client certificate over a CSR the user submits
+after registration, bound to their StoredUser.EncryptedEmail rather than a
+long-lived key, so the private key never leaves the client. Distinct from
+the service-mesh CA at ../certificates/certification-authority, which
+authenticates Entry-Hub/Security-Switch/Database-Vault to each other rather
+than individual users.
+*/
+package pki
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// clockSkewAllowance backdates NotBefore so a certificate issued seconds ago
+// is not rejected by a Storage-Service host whose clock runs slightly behind.
+const clockSkewAllowance = 1 * time.Minute
+
+// serialBits sizes the random serial number space large enough that a
+// collision across the lifetime of the CA is not a practical concern.
+const serialBits = 128
+
+// CA signs short-lived x509 client certificates for Storage-Service mTLS
+// authentication, distinct from the service-mesh CA used between Entry-Hub,
+// Security-Switch, and Database-Vault.
+//
+// Security features:
+// - Certificates are valid for TTL only, bounding the blast radius of a leaked private key
+// - Subject.CommonName pins the certificate to a single StoredUser.EncryptedEmail, never the plaintext email
+// - ExtKeyUsage is ClientAuth only, so an issued certificate cannot be repurposed as a server certificate
+// - The private key is never seen by Database-Vault: IssueCertificate only ever signs a client-supplied CSR
+//
+// Construct with NewCA.
+type CA struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+	ttl  time.Duration
+}
+
+// NewCA loads a PEM-encoded user-CA certificate and private key from disk
+// and returns a CA issuing certificates valid for ttl.
+//
+// Returns error if either file cannot be read or parsed, or the key does
+// not implement crypto.Signer.
+func NewCA(caCertFile, caKeyFile string, ttl time.Duration) (*CA, error) {
+	pair, err := tls.LoadX509KeyPair(caCertFile, caKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user CA certificate/key: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse user CA certificate: %v", err)
+	}
+
+	signer, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("user CA private key does not support signing")
+	}
+
+	return &CA{cert: cert, key: signer, ttl: ttl}, nil
+}
+
+// CACertPEM returns the user-CA certificate, PEM-encoded, for a client or
+// the OCSP responder to validate an issued certificate's chain against.
+func (c *CA) CACertPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+}
+
+// IssueCertificate parses csrPEM - a PKCS#10 certificate signing request the
+// client built over a private key that never leaves its own machine - and
+// returns a short-lived client certificate over that key, bound to identity
+// via Subject.CommonName.
+//
+// Security features:
+// - csr.CheckSignature rejects a CSR whose public key does not match the signature, refusing to sign a request the caller doesn't actually hold the private key for
+// - identity (StoredUser.EncryptedEmail) is taken from the server-side lookup, never from the CSR itself, so a client cannot request a certificate for another account's identity
+// - SerialNumber is a fresh random value per certificate, for unambiguous revocation and OCSP lookup
+//
+// Returns the signed certificate (PEM-encoded), its serial number (hex), and
+// its expiry, or error if the CSR is malformed, its signature does not
+// verify, or signing fails.
+func (c *CA) IssueCertificate(csrPEM []byte, identity string) (certPEM []byte, serial string, notAfter time.Time, err error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, "", time.Time{}, fmt.Errorf("csr is not a PEM-encoded CERTIFICATE REQUEST")
+	}
+
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, "", time.Time{}, fmt.Errorf("failed to parse csr: %v", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, "", time.Time{}, fmt.Errorf("csr signature does not verify: %v", err)
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), serialBits))
+	if err != nil {
+		return nil, "", time.Time{}, fmt.Errorf("failed to generate certificate serial: %v", err)
+	}
+
+	now := time.Now()
+	expiry := now.Add(c.ttl)
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: identity},
+		NotBefore:    now.Add(-clockSkewAllowance),
+		NotAfter:     expiry,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, c.cert, csr.PublicKey, c.key)
+	if err != nil {
+		return nil, "", time.Time{}, fmt.Errorf("failed to sign user certificate: %v", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), serialNumber.Text(16), expiry, nil
+}
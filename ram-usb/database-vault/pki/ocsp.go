@@ -0,0 +1,67 @@
+package pki
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// ocspValidity bounds how long a signed OCSP response may be cached by the
+// caller before it must be re-checked, per RFC 6960's thisUpdate/nextUpdate.
+const ocspValidity = 1 * time.Hour
+
+// OCSPStatus describes what RespondOCSP should report for the serial number
+// a request asks about, resolved by the caller from storage.UserStorage's
+// certificate record.
+type OCSPStatus struct {
+	Revoked   bool
+	RevokedAt time.Time
+	Reason    int // ocsp revocation reason code (ocsp.Unspecified, ocsp.KeyCompromise, ...); meaningful only when Revoked
+}
+
+// ParseOCSPRequest extracts the queried certificate's serial number from a
+// DER-encoded RFC 6960 OCSP request.
+//
+// Returns the serial number, or error if requestDER is not a valid OCSP request.
+func ParseOCSPRequest(requestDER []byte) (*big.Int, error) {
+	req, err := ocsp.ParseRequest(requestDER)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ocsp request: %v", err)
+	}
+	return req.SerialNumber, nil
+}
+
+// RespondOCSP signs an RFC 6960 OCSP response for serial, reporting status,
+// using the same CA certificate and key that issued the certificate.
+//
+// Security features:
+// - Signed by the issuing CA, so a verifier needs only the CA certificate it already trusts to validate the response
+// - NextUpdate bounds how long a caller may treat a cached Good response as still current
+//
+// Returns the DER-encoded OCSP response, or error if signing fails.
+func (c *CA) RespondOCSP(serial *big.Int, status OCSPStatus) ([]byte, error) {
+	ocspStatus := ocsp.Good
+	var revokedAt time.Time
+	if status.Revoked {
+		ocspStatus = ocsp.Revoked
+		revokedAt = status.RevokedAt
+	}
+
+	now := time.Now()
+	template := ocsp.Response{
+		Status:           ocspStatus,
+		SerialNumber:     serial,
+		ThisUpdate:       now,
+		NextUpdate:       now.Add(ocspValidity),
+		RevokedAt:        revokedAt,
+		RevocationReason: status.Reason,
+	}
+
+	der, err := ocsp.CreateResponse(c.cert, c.cert, template, c.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign ocsp response: %v", err)
+	}
+	return der, nil
+}
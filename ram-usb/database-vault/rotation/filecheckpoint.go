@@ -0,0 +1,87 @@
+package rotation
+
+import (
+	"database-vault/crypto"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileCheckpointStore persists one Checkpoint per KeyID as JSON in a local
+// directory, the rotation-worker analog of keyprovider.LocalFileWrappedDEKStore.
+//
+// Security features:
+// - Save writes to a temp file in the same directory and renames over the target, so Load never observes a partially written checkpoint
+type FileCheckpointStore struct {
+	dir string
+}
+
+// NewFileCheckpointStore constructs a CheckpointStore backed by dir, creating
+// it (mode 0700) if it doesn't already exist.
+//
+// Returns error if dir is empty or cannot be created.
+func NewFileCheckpointStore(dir string) (*FileCheckpointStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("checkpoint directory must not be empty")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create checkpoint directory %q: %v", dir, err)
+	}
+	return &FileCheckpointStore{dir: dir}, nil
+}
+
+// Load returns the saved Checkpoint for keyID, or a fresh zero-value
+// Checkpoint if no checkpoint file exists yet.
+//
+// Returns error if the file exists but is not valid JSON.
+func (s *FileCheckpointStore) Load(keyID crypto.KeyID) (*Checkpoint, error) {
+	data, err := os.ReadFile(s.path(keyID))
+	if os.IsNotExist(err) {
+		return &Checkpoint{KeyID: keyID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint for key %q: %v", keyID, err)
+	}
+
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("checkpoint file for key %q is not valid JSON: %v", keyID, err)
+	}
+	return &checkpoint, nil
+}
+
+// Save writes checkpoint to a temp file in the checkpoint directory, then
+// renames it over the target path so a reader never observes a half-written file.
+//
+// Returns error if encoding or either filesystem operation fails.
+func (s *FileCheckpointStore) Save(checkpoint *Checkpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %v", err)
+	}
+
+	path := s.path(checkpoint.KeyID)
+	tmp, err := os.CreateTemp(s.dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file for checkpoint: %v", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once the rename below succeeds
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close checkpoint temp file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to atomically replace checkpoint file: %v", err)
+	}
+	return nil
+}
+
+// path returns the on-disk path for keyID's checkpoint file.
+func (s *FileCheckpointStore) path(keyID crypto.KeyID) string {
+	return filepath.Join(s.dir, fmt.Sprintf("checkpoint-%s.json", keyID))
+}
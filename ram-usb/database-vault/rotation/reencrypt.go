@@ -0,0 +1,186 @@
+/*
+Online key-rotation re-encryption worker for Database-Vault.
+
+Implements the background migration that accompanies crypto.Keyring.RotateKeys:
+it scans stored users still encrypted under a retired key, decrypts with that
+key, re-encrypts with the current primary, and atomically updates each row.
+Progress is checkpointed after every batch so the worker can resume from where
+it left off after a restart instead of rescanning already-migrated rows.
+Runs independently of request handling, so a slow or interrupted migration
+never blocks normal Database-Vault traffic.
+
+main.go drives Worker.Run on a fixed interval (see runRotationWorker) when
+envelope encryption is configured.
+*/
+package rotation
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"database-vault/crypto"
+	"database-vault/storage"
+	"database-vault/types"
+)
+
+// defaultBatchSize bounds how many rows the worker loads and re-encrypts per iteration.
+const defaultBatchSize = 100
+
+// Checkpoint records re-encryption progress for a single retired KeyID, so a
+// restarted Worker can resume instead of rescanning already-migrated rows.
+//
+// Security features:
+// - Cursor is the last successfully migrated EncryptedEmail, never a raw offset, so resuming is correct even if rows are concurrently inserted
+// - Persisted outside the worker process via CheckpointStore
+//
+// Produced by Worker.Run after every successfully processed batch.
+type Checkpoint struct {
+	KeyID     crypto.KeyID `json:"key_id"`    // Retired key being migrated away from
+	Cursor    string       `json:"cursor"`    // Last migrated EncryptedEmail value
+	Processed int          `json:"processed"` // Total rows migrated for this KeyID so far
+	Done      bool         `json:"done"`      // True once no rows remain under KeyID
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// CheckpointStore persists and retrieves re-encryption progress across restarts.
+//
+// Security features:
+// - Keyed by KeyID so concurrent migrations for different retired keys don't collide
+//
+// Implementations may back this with the database itself, a local file, or a
+// distributed key-value store; Worker only depends on this interface.
+type CheckpointStore interface {
+	// Load returns the last saved Checkpoint for keyID, or a fresh zero-value
+	// Checkpoint if none has been saved yet.
+	Load(keyID crypto.KeyID) (*Checkpoint, error)
+	// Save persists checkpoint, overwriting any previous value for its KeyID.
+	Save(checkpoint *Checkpoint) error
+}
+
+// Worker migrates rows encrypted under a Keyring's retired keys to its current primary.
+//
+// Security features:
+// - Never holds plaintext email longer than a single decrypt/re-encrypt pair
+// - Compare-and-swap update (UserStorage.UpdateEncryptedEmail) prevents lost updates if a row is concurrently modified elsewhere
+// - Checkpointing after every batch bounds how much work is repeated on crash
+//
+// Construct with NewWorker and drive with Run from a long-lived background goroutine.
+type Worker struct {
+	storage    storage.UserStorage
+	keyring    *crypto.Keyring
+	checkpoint CheckpointStore
+	batchSize  int
+}
+
+// NewWorker builds a re-encryption Worker over the given storage backend, Keyring,
+// and checkpoint store, using defaultBatchSize for its scan batches.
+func NewWorker(userStorage storage.UserStorage, keyring *crypto.Keyring, checkpoints CheckpointStore) *Worker {
+	return &Worker{
+		storage:    userStorage,
+		keyring:    keyring,
+		checkpoint: checkpoints,
+		batchSize:  defaultBatchSize,
+	}
+}
+
+// Run migrates every row still encrypted under any of the Keyring's retired keys
+// to its current primary key, one retired KeyID at a time, resuming from the
+// last saved Checkpoint for each.
+//
+// Security features:
+// - Stops promptly if ctx is cancelled, leaving the checkpoint at the last completed batch so the next Run resumes cleanly
+// - Re-reads the Keyring's retired key list each call, so a key freshly retired by RotateKeys is picked up without restarting the worker
+//
+// Returns error if a batch fails to list or migrate; partial progress up to
+// that point remains checkpointed.
+func (w *Worker) Run(ctx context.Context) error {
+	for _, retiredID := range w.keyring.RetiredKeyIDs() {
+		if err := w.migrateKey(ctx, retiredID); err != nil {
+			return fmt.Errorf("failed to migrate key %q: %v", retiredID, err)
+		}
+	}
+	return nil
+}
+
+// migrateKey re-encrypts every row under a single retired KeyID to the
+// Keyring's current primary key, resuming from its last saved Checkpoint.
+//
+// Returns error if checkpoint loading, batch listing, or migration fails.
+func (w *Worker) migrateKey(ctx context.Context, retiredID crypto.KeyID) error {
+	cp, err := w.checkpoint.Load(retiredID)
+	if err != nil {
+		return fmt.Errorf("failed to load checkpoint: %v", err)
+	}
+	if cp.Done {
+		return nil
+	}
+	cp.KeyID = retiredID
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		// BATCH RETRIEVAL
+		// Scoped to the retired key id so already-migrated rows never reappear
+		users, err := w.storage.ListUsersByKeyPrefix(string(retiredID), cp.Cursor, w.batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to list users for key %q: %v", retiredID, err)
+		}
+
+		if len(users) == 0 {
+			cp.Done = true
+			cp.UpdatedAt = time.Now()
+			return w.checkpoint.Save(cp)
+		}
+
+		for _, user := range users {
+			if err := w.migrateRow(user); err != nil {
+				// CHECKPOINT ON FAILURE
+				// Persist progress made before this row so a retry doesn't redo completed work
+				cp.UpdatedAt = time.Now()
+				if saveErr := w.checkpoint.Save(cp); saveErr != nil {
+					log.Printf("Key rotation: failed to save checkpoint after error: %v", saveErr)
+				}
+				return fmt.Errorf("failed to re-encrypt user: %v", err)
+			}
+			cp.Cursor = user.EncryptedEmail
+			cp.Processed++
+		}
+
+		// BATCH CHECKPOINT
+		// Save progress after every successfully migrated batch
+		cp.UpdatedAt = time.Now()
+		if err := w.checkpoint.Save(cp); err != nil {
+			return fmt.Errorf("failed to save checkpoint: %v", err)
+		}
+
+		log.Printf("Key rotation: migrated %d rows from key %q so far", cp.Processed, retiredID)
+	}
+}
+
+// migrateRow decrypts a single user's email under the retired key and
+// re-encrypts it under the Keyring's current primary key.
+//
+// Returns error if decryption, re-encryption, or the atomic update fails.
+func (w *Worker) migrateRow(user types.StoredUser) error {
+	email, err := crypto.DecryptWithKeyring(user.EncryptedEmail, w.keyring)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt email: %v", err)
+	}
+
+	newEncryptedEmail, err := crypto.EncryptWithKeyring(email, w.keyring)
+	if err != nil {
+		return fmt.Errorf("failed to re-encrypt email: %v", err)
+	}
+
+	if err := w.storage.UpdateEncryptedEmail(user.EncryptedEmail, newEncryptedEmail); err != nil {
+		return fmt.Errorf("failed to update encrypted email: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package secrets
+
+import (
+	"sync"
+	"time"
+)
+
+// cacheEntry holds a previously fetched secret value and when it expires.
+type cacheEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// CachedProvider wraps a Provider with a short in-process TTL, so a handler
+// that calls Get on every request doesn't hit the backing store (a network
+// round trip to Vault, in the KV v2 case) on every single one.
+//
+// Security features:
+// - TTL bounds how stale a served key can be after rotation in the backing store, without requiring a restart
+// - Watch is passed straight through to the wrapped Provider; a push notification is not itself cached
+//
+// Construct with NewCachedProvider, typically wrapping the Provider selected
+// by config.GetConfig for the lifetime of the process.
+type CachedProvider struct {
+	inner Provider
+	ttl   time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+// NewCachedProvider wraps inner with a cache that re-fetches a given secret
+// name at most once per ttl.
+func NewCachedProvider(inner Provider, ttl time.Duration) *CachedProvider {
+	return &CachedProvider{
+		inner: inner,
+		ttl:   ttl,
+		cache: make(map[string]cacheEntry),
+	}
+}
+
+// Get returns the cached value for name if it was fetched within the last
+// ttl, otherwise fetches a fresh value from the wrapped Provider and caches it.
+//
+// Returns error if the underlying fetch fails; a stale cache entry is not
+// served past its expiry on failure, matching how cfg.EncryptionKey already
+// fails closed rather than silently keeping an old key.
+func (c *CachedProvider) Get(name string) ([]byte, error) {
+	c.mu.Lock()
+	entry, ok := c.cache[name]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.value, nil
+	}
+
+	value, err := c.inner.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.cache[name] = cacheEntry{value: value, expiresAt: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+
+	return value, nil
+}
+
+// Watch delegates to the wrapped Provider's Watch.
+func (c *CachedProvider) Watch(name string) <-chan []byte {
+	return c.inner.Watch(name)
+}
@@ -0,0 +1,73 @@
+/*
+Pluggable secret retrieval for Database-Vault, decoupling handlers from any
+one secret backend.
+
+Provider generalizes crypto.LoadEncryptionKeyFromSources/crypto.KeySource
+behind a narrower, request-time interface: Get fetches a named secret's
+current bytes, and Watch pushes updated bytes when the backing store reports
+a change, so a handler can pick up a rotated secret without restarting the
+process. FileEnvProvider covers today's file/env behavior; VaultKV2Provider
+(see vault.go) adds HashiCorp Vault's KV v2 engine over AppRole auth.
+CachedProvider (see cache.go) wraps either behind a short in-process TTL so
+per-request Get calls don't hit the backing store on every call.
+*/
+package secrets
+
+import (
+	"fmt"
+
+	"database-vault/crypto"
+)
+
+// EncryptionKeyName is the secret name StoreUserHandler requests for the
+// AES-256-GCM field-encryption key, passed to Provider.Get/Watch.
+const EncryptionKeyName = "database-vault/encryption-key"
+
+// Provider retrieves named secret material, with optional push notification
+// when the value changes.
+//
+// Security features:
+// - Get never caches on its own; callers that want a TTL wrap it in CachedProvider
+// - Watch lets a caller react to rotation without polling Get in a busy loop
+//
+// Implementations back StoreUserHandler's per-call encryption key lookup.
+type Provider interface {
+	// Get returns the current bytes of the secret named name.
+	Get(name string) ([]byte, error)
+
+	// Watch returns a channel that receives the secret's bytes each time the
+	// backing store reports a new value. Implementations that cannot detect
+	// changes (e.g. a static file/env value) return a channel that never
+	// fires; callers must not assume delivery.
+	Watch(name string) <-chan []byte
+}
+
+// FileEnvProvider serves the encryption key from the same file/environment
+// sources Database-Vault has always used, so deployments that don't run
+// Vault keep today's exact behavior under the new Provider interface.
+//
+// Security features:
+// - Delegates entirely to crypto.LoadEncryptionKeyFromSources, so file permission and entropy validation are unchanged
+// - Watch never fires: a file/env secret requires a restart to change, same as before this package existed
+type FileEnvProvider struct{}
+
+// NewFileEnvProvider constructs a Provider backed by file/environment key sources.
+func NewFileEnvProvider() *FileEnvProvider {
+	return &FileEnvProvider{}
+}
+
+// Get returns the encryption key loaded via crypto.LoadEncryptionKeyFromSources.
+//
+// Returns error if name is not EncryptionKeyName, or if no valid key source is configured.
+func (p *FileEnvProvider) Get(name string) ([]byte, error) {
+	if name != EncryptionKeyName {
+		return nil, fmt.Errorf("file/env secrets provider has no secret named %q", name)
+	}
+	return crypto.LoadEncryptionKeyFromSources()
+}
+
+// Watch returns a channel that never fires, since file/env key material only
+// changes on restart.
+func (p *FileEnvProvider) Watch(name string) <-chan []byte {
+	return make(chan []byte)
+}
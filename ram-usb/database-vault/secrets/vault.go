@@ -0,0 +1,188 @@
+/*
+HashiCorp Vault KV v2 secret Provider for Database-Vault.
+
+Unlike crypto.VaultKeySource/crypto.VaultTransitKeyWrapper (which authenticate
+with a pre-supplied *vaultapi.Client, typically carrying a static token),
+VaultKV2Provider owns the whole client lifecycle including AppRole login, so a
+deployment can grant Database-Vault a least-privilege AppRole role/secret ID
+pair instead of a long-lived root or admin token. The connection to Vault
+itself runs over mTLS via the supplied *tls.Config, matching how every other
+inter-service connection in the R.A.M.-U.S.B. system authenticates.
+*/
+package secrets
+
+import (
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// vaultWatchPollInterval is how often Watch re-checks the secret for a
+// changed value, since Vault's KV v2 engine has no server-push API.
+const vaultWatchPollInterval = 30 * time.Second
+
+// VaultKV2Provider retrieves secrets from Vault's KV v2 engine, authenticating
+// via AppRole over an mTLS-secured connection to Vault.
+//
+// Security features:
+// - AppRole role_id/secret_id authentication instead of a static root/admin token
+// - mTLS to Vault itself via the *tls.Config passed to NewVaultKV2Provider
+// - Get re-logs in once three quarters of the AppRole token's lease has elapsed (see tokenNearingExpiry), rather than caching a token indefinitely
+//
+// Construct with NewVaultKV2Provider; one instance should be reused for the
+// life of the process, typically wrapped in CachedProvider by config.GetConfig.
+type VaultKV2Provider struct {
+	client     *vaultapi.Client
+	mount      string // KV v2 mount, e.g. "secret"
+	roleID     string
+	secretID   string
+	tokenTTL   time.Duration
+	loggedInAt time.Time
+}
+
+// NewVaultKV2Provider constructs a Vault KV v2-backed Provider, logging in via
+// AppRole immediately so configuration errors surface at startup rather than
+// on the first request.
+//
+// Security features:
+// - tlsConfig is applied to the HTTP client used for every Vault request, including the initial AppRole login
+// - secretID is read from secretIDFile rather than taken as a literal, so it can be distributed as a wrapped or rotated file without appearing in process arguments or environment
+//
+// Returns error if addr/mount/roleID/secretIDFile are empty, the secret ID
+// file cannot be read, or the initial AppRole login fails.
+func NewVaultKV2Provider(addr, mount, roleID, secretIDFile string, tlsConfig *tls.Config) (*VaultKV2Provider, error) {
+	if addr == "" || mount == "" || roleID == "" || secretIDFile == "" {
+		return nil, fmt.Errorf("vault address, KV mount, AppRole role id, and secret id file are all required")
+	}
+
+	secretIDBytes, err := os.ReadFile(secretIDFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read AppRole secret id file: %v", err)
+	}
+	secretID := strings.TrimSpace(string(secretIDBytes))
+
+	vaultConfig := vaultapi.DefaultConfig()
+	vaultConfig.Address = addr
+	if tlsConfig != nil {
+		vaultConfig.HttpClient = &http.Client{
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		}
+	}
+
+	client, err := vaultapi.NewClient(vaultConfig)
+	if err != nil {
+		return nil, fmt.Errorf("vault client initialization failed: %v", err)
+	}
+
+	p := &VaultKV2Provider{client: client, mount: mount, roleID: roleID, secretID: secretID}
+	if err := p.login(); err != nil {
+		return nil, fmt.Errorf("AppRole login failed: %v", err)
+	}
+	return p, nil
+}
+
+// login authenticates to Vault via the AppRole auth method and installs the
+// resulting client token on the shared *vaultapi.Client.
+//
+// Returns error if the login call fails or returns no auth token.
+func (p *VaultKV2Provider) login() error {
+	secret, err := p.client.Logical().Write("auth/approle/login", map[string]interface{}{
+		"role_id":   p.roleID,
+		"secret_id": p.secretID,
+	})
+	if err != nil {
+		return fmt.Errorf("approle login request failed: %v", err)
+	}
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return fmt.Errorf("approle login returned no client token")
+	}
+
+	p.client.SetToken(secret.Auth.ClientToken)
+	p.loggedInAt = time.Now()
+	p.tokenTTL = time.Duration(secret.Auth.LeaseDuration) * time.Second
+	return nil
+}
+
+// tokenNearingExpiry reports whether the current AppRole token is close
+// enough to its lease expiry that Get should re-login before using it.
+func (p *VaultKV2Provider) tokenNearingExpiry() bool {
+	if p.tokenTTL == 0 {
+		return false // non-expiring (or unknown) token, e.g. under a dev Vault
+	}
+	return time.Since(p.loggedInAt) > p.tokenTTL*3/4
+}
+
+// Get reads name as a path within the configured KV v2 mount, returning the
+// hex-decoded bytes stored under its "value" field.
+//
+// Security features:
+// - Re-logs in via AppRole first if the current token is nearing its lease expiry
+//
+// Returns error if re-login, the read, or hex decoding fails, or the secret
+// has no "value" field.
+func (p *VaultKV2Provider) Get(name string) ([]byte, error) {
+	if p.tokenNearingExpiry() {
+		if err := p.login(); err != nil {
+			return nil, fmt.Errorf("AppRole re-login failed: %v", err)
+		}
+	}
+
+	secret, err := p.client.Logical().Read(fmt.Sprintf("%s/data/%s", p.mount, name))
+	if err != nil {
+		return nil, fmt.Errorf("vault KV v2 read failed: %v", err)
+	}
+	if secret == nil || secret.Data["data"] == nil {
+		return nil, fmt.Errorf("vault KV v2 secret not found: %s/%s", p.mount, name)
+	}
+	data, ok := secret.Data["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("vault KV v2 secret has unexpected shape")
+	}
+	valueHex, ok := data["value"].(string)
+	if !ok {
+		return nil, fmt.Errorf("vault KV v2 secret %q missing 'value' field", name)
+	}
+
+	value, err := hex.DecodeString(valueHex)
+	if err != nil {
+		return nil, fmt.Errorf("vault KV v2 secret %q has invalid hex encoding: %v", name, err)
+	}
+	return value, nil
+}
+
+// Watch polls Get every vaultWatchPollInterval and pushes name's value to the
+// returned channel whenever it differs from the last observed value, since
+// KV v2 has no server-push change notification.
+//
+// Security features:
+// - A failed poll is dropped silently rather than closing the channel, so a transient Vault outage doesn't make Watch look like a permanent rotation signal
+//
+// Returns a channel that is never closed; its polling goroutine runs until
+// the process exits, and callers are not expected to stop watching explicitly.
+func (p *VaultKV2Provider) Watch(name string) <-chan []byte {
+	updates := make(chan []byte, 1)
+	go func() {
+		var last []byte
+		ticker := time.NewTicker(vaultWatchPollInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			current, err := p.Get(name)
+			if err != nil {
+				continue
+			}
+			if last != nil && string(current) == string(last) {
+				continue
+			}
+			last = current
+			updates <- current
+		}
+	}()
+	return updates
+}
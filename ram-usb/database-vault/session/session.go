@@ -0,0 +1,85 @@
+/*
+Session-token issuance and verification for Database-Vault login.
+
+LoginUserHandler mints a short-lived, HMAC-SHA256-signed JWT on successful
+authentication so Security-Switch (and, downstream, Storage-Service) can
+validate a caller's session without a further round trip to Database-Vault.
+The signing key is a symmetric HMAC secret configured via session.signing_key,
+matching the rest of Database-Vault's locally-held-secret conventions (see
+config.Config.EncryptionKey) rather than an asymmetric keypair, since every
+verifier here lives inside the same trust boundary.
+*/
+package session
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// claims holds the registered JWT fields issued for a logged-in user.
+//
+// Security features:
+// - Subject carries EmailBlindIndex, never the plaintext email or EncryptedEmail, so a leaked token discloses no more than the storage layer's own indexable lookup value
+// - ExpiresAt bounds how long a token remains valid, enforced by jwt.Parse itself
+type claims struct {
+	jwt.RegisteredClaims
+}
+
+// Issuer mints and verifies session tokens under a single HMAC-SHA256 signing key.
+//
+// Construct with NewIssuer.
+type Issuer struct {
+	key []byte
+	ttl time.Duration
+}
+
+// NewIssuer returns an Issuer signing and verifying tokens with key, valid
+// for ttl from the moment of issuance. Returns an error if key is empty -
+// callers should treat this as "session tokens are not configured" and fail
+// the login request closed rather than issuing an unsigned or zero-key token.
+func NewIssuer(key []byte, ttl time.Duration) (*Issuer, error) {
+	if len(key) == 0 {
+		return nil, errors.New("session: signing key is empty")
+	}
+	if ttl <= 0 {
+		return nil, errors.New("session: ttl must be positive")
+	}
+	return &Issuer{key: key, ttl: ttl}, nil
+}
+
+// Issue mints a signed session token for subject (the user's EmailBlindIndex).
+//
+// Returns the compact JWT string, or error if signing fails.
+func (i *Issuer) Issue(subject string) (string, error) {
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(i.ttl)),
+			Issuer:    "database-vault",
+		},
+	})
+	return token.SignedString(i.key)
+}
+
+// Verify parses and validates tokenString, returning its subject
+// (EmailBlindIndex) if the signature and expiry both check out.
+//
+// Returns the subject, or error if the token is malformed, expired, or not
+// signed by this Issuer's key.
+func (i *Issuer) Verify(tokenString string) (subject string, err error) {
+	parsed, err := jwt.ParseWithClaims(tokenString, &claims{}, func(t *jwt.Token) (interface{}, error) {
+		return i.key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}), jwt.WithIssuer("database-vault"))
+	if err != nil {
+		return "", err
+	}
+	c, ok := parsed.Claims.(*claims)
+	if !ok || !parsed.Valid {
+		return "", errors.New("session: invalid token claims")
+	}
+	return c.Subject, nil
+}
@@ -0,0 +1,590 @@
+/*
+BoltDB-backed storage.UserStorage, registered under driver name "bolt".
+
+Single-file embedded storage for dev and small single-node deployments,
+alongside storage/sqlite - bolt trades SQL's secondary-index support for a
+simpler single-writer key/value model, so this package maintains its own
+index buckets (by blind index, by SSH key) instead of relying on the
+database engine for uniqueness checks.
+*/
+package bolt
+
+import (
+	"database-vault/crypto"
+	"database-vault/storage"
+	"database-vault/storage/driver"
+	"database-vault/types"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func init() {
+	driver.Register("bolt", Open)
+}
+
+var (
+	usersBucket             = []byte("users")                 // encrypted_email -> JSON record
+	blindIndexBucket        = []byte("blind_index")           // email_blind_index -> encrypted_email
+	sshKeyBucket            = []byte("ssh_key_index")         // ssh_public_key -> encrypted_email
+	resetTokensBucket       = []byte("password_reset_tokens") // token_hash -> JSON resetTokenRecord
+	issuedCertificateBucket = []byte("issued_certificates")   // serial -> JSON types.IssuedCertificate
+)
+
+// resetTokenRecord is the password_reset_tokens bucket value for one token.
+type resetTokenRecord struct {
+	EncryptedEmail string     `json:"encrypted_email"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	UsedAt         *time.Time `json:"used_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// record is the bucket value for one user - types.StoredUser plus the
+// soft-delete marker that type has no field for.
+type record struct {
+	types.StoredUser
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Storage is a storage.UserStorage backed by a single BoltDB file.
+//
+// Construct with Open.
+type Storage struct {
+	db *bolt.DB
+}
+
+// Open opens (creating if absent) the BoltDB file at cfg.BoltPath and
+// ensures its buckets exist.
+//
+// Returns error if the file cannot be opened or bucket creation fails.
+func Open(cfg storage.StorageConfig) (storage.UserStorage, error) {
+	if cfg.BoltPath == "" {
+		return nil, fmt.Errorf("bolt: storage.bolt_path must not be empty")
+	}
+
+	db, err := bolt.Open(cfg.BoltPath, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("bolt: failed to open %q: %v", cfg.BoltPath, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{usersBucket, blindIndexBucket, sshKeyBucket, resetTokensBucket, issuedCertificateBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("bolt: failed to create buckets: %v", err)
+	}
+
+	return &Storage{db: db}, nil
+}
+
+// StoreUser inserts user, returning ErrorUserExists/ErrorSSHKeyExists if its
+// EncryptedEmail, EmailBlindIndex, or SSHPubKey is already indexed.
+func (s *Storage) StoreUser(user types.StoredUser) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		users := tx.Bucket(usersBucket)
+		blindIdx := tx.Bucket(blindIndexBucket)
+		sshIdx := tx.Bucket(sshKeyBucket)
+
+		if users.Get([]byte(user.EncryptedEmail)) != nil || blindIdx.Get([]byte(user.EmailBlindIndex)) != nil {
+			return storage.NewStorageError(storage.ErrorUserExists, "StoreUser", "encrypted_email or email_blind_index already exists", "Email address already registered.")
+		}
+		if sshIdx.Get([]byte(user.SSHPubKey)) != nil {
+			return storage.NewStorageError(storage.ErrorSSHKeyExists, "StoreUser", "ssh_public_key already exists", "SSH public key already in use.")
+		}
+
+		data, err := json.Marshal(record{StoredUser: user})
+		if err != nil {
+			return storage.NewStorageError(storage.ErrorInvalidUserData, "StoreUser", err.Error(), "Invalid user data.")
+		}
+		if err := users.Put([]byte(user.EncryptedEmail), data); err != nil {
+			return err
+		}
+		if err := blindIdx.Put([]byte(user.EmailBlindIndex), []byte(user.EncryptedEmail)); err != nil {
+			return err
+		}
+		return sshIdx.Put([]byte(user.SSHPubKey), []byte(user.EncryptedEmail))
+	})
+	return wrapTxError(err, "StoreUser")
+}
+
+// GetUserByEncryptedEmail returns the matching, non-deleted row or nil.
+func (s *Storage) GetUserByEncryptedEmail(encryptedEmail string) (*types.StoredUser, error) {
+	var result *types.StoredUser
+	err := s.db.View(func(tx *bolt.Tx) error {
+		rec, err := getRecord(tx, []byte(encryptedEmail))
+		if err != nil || rec == nil {
+			return err
+		}
+		result = &rec.StoredUser
+		return nil
+	})
+	return result, wrapTxError(err, "GetUserByEncryptedEmail")
+}
+
+// EmailExists reports whether a non-deleted row with encryptedEmail exists.
+func (s *Storage) EmailExists(encryptedEmail string) (bool, error) {
+	user, err := s.GetUserByEncryptedEmail(encryptedEmail)
+	return user != nil, err
+}
+
+// GetUserByEmailBlindIndex returns the matching, non-deleted row or nil.
+func (s *Storage) GetUserByEmailBlindIndex(blindIndex string) (*types.StoredUser, error) {
+	var result *types.StoredUser
+	err := s.db.View(func(tx *bolt.Tx) error {
+		encryptedEmail := tx.Bucket(blindIndexBucket).Get([]byte(blindIndex))
+		if encryptedEmail == nil {
+			return nil
+		}
+		rec, err := getRecord(tx, encryptedEmail)
+		if err != nil || rec == nil {
+			return err
+		}
+		result = &rec.StoredUser
+		return nil
+	})
+	return result, wrapTxError(err, "GetUserByEmailBlindIndex")
+}
+
+// SSHKeyExists reports whether a non-deleted row with sshKey exists.
+func (s *Storage) SSHKeyExists(sshKey string) (bool, error) {
+	var exists bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		encryptedEmail := tx.Bucket(sshKeyBucket).Get([]byte(sshKey))
+		if encryptedEmail == nil {
+			return nil
+		}
+		rec, err := getRecord(tx, encryptedEmail)
+		if err != nil {
+			return err
+		}
+		exists = rec != nil
+		return nil
+	})
+	return exists, wrapTxError(err, "SSHKeyExists")
+}
+
+// UpdateUser applies the non-nil fields in updates to the row identified by encryptedEmail.
+func (s *Storage) UpdateUser(encryptedEmail string, updates storage.UserUpdateRequest) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		rec, err := getRecord(tx, []byte(encryptedEmail))
+		if err != nil {
+			return err
+		}
+		if rec == nil {
+			return storage.NewStorageError(storage.ErrorUserNotFound, "UpdateUser", "no matching row", "User not found.")
+		}
+
+		sshIdx := tx.Bucket(sshKeyBucket)
+		if updates.NewSSHPubKey != nil && *updates.NewSSHPubKey != rec.SSHPubKey {
+			if sshIdx.Get([]byte(*updates.NewSSHPubKey)) != nil {
+				return storage.NewStorageError(storage.ErrorSSHKeyExists, "UpdateUser", "ssh key already in use", "SSH public key already in use.")
+			}
+			if err := sshIdx.Delete([]byte(rec.SSHPubKey)); err != nil {
+				return err
+			}
+			if err := sshIdx.Put([]byte(*updates.NewSSHPubKey), []byte(encryptedEmail)); err != nil {
+				return err
+			}
+			rec.SSHPubKey = *updates.NewSSHPubKey
+		}
+		if updates.NewPasswordHash != nil {
+			rec.PasswordHash = *updates.NewPasswordHash
+		}
+		if updates.NewFailedLoginAttempts != nil {
+			rec.FailedLoginAttempts = *updates.NewFailedLoginAttempts
+		}
+		if updates.NewLastFailedLogin != nil {
+			rec.LastFailedLogin = updates.NewLastFailedLogin
+		}
+		if updates.NewMFAEnabled != nil {
+			rec.MFAEnabled = *updates.NewMFAEnabled
+		}
+		if updates.NewMFASecretEncrypted != nil {
+			rec.MFASecretEncrypted = *updates.NewMFASecretEncrypted
+		}
+		if updates.NewMFAScratchCodeHashes != nil {
+			rec.MFAScratchCodeHashes = *updates.NewMFAScratchCodeHashes
+		}
+		if updates.NewMFALastAcceptedCounter != nil {
+			rec.MFALastAcceptedCounter = *updates.NewMFALastAcceptedCounter
+		}
+		rec.UpdatedAt = time.Now()
+
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return storage.NewStorageError(storage.ErrorInvalidUserData, "UpdateUser", err.Error(), "Invalid user data.")
+		}
+		return tx.Bucket(usersBucket).Put([]byte(encryptedEmail), data)
+	})
+	return wrapTxError(err, "UpdateUser")
+}
+
+// DeleteUser soft-deletes by setting DeletedAt, or permanently removes the
+// row (and its index entries) when permanent is true.
+func (s *Storage) DeleteUser(encryptedEmail string, permanent bool) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		rec, err := getRecordIncludingDeleted(tx, []byte(encryptedEmail))
+		if err != nil {
+			return err
+		}
+		if rec == nil {
+			return storage.NewStorageError(storage.ErrorUserNotFound, "DeleteUser", "no matching row", "User not found.")
+		}
+
+		if permanent {
+			if err := tx.Bucket(blindIndexBucket).Delete([]byte(rec.EmailBlindIndex)); err != nil {
+				return err
+			}
+			if err := tx.Bucket(sshKeyBucket).Delete([]byte(rec.SSHPubKey)); err != nil {
+				return err
+			}
+			return tx.Bucket(usersBucket).Delete([]byte(encryptedEmail))
+		}
+
+		if rec.DeletedAt != nil {
+			return storage.NewStorageError(storage.ErrorUserNotFound, "DeleteUser", "no matching row", "User not found.")
+		}
+
+		now := time.Now()
+		rec.DeletedAt = &now
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return storage.NewStorageError(storage.ErrorInvalidUserData, "DeleteUser", err.Error(), "Invalid user data.")
+		}
+		return tx.Bucket(usersBucket).Put([]byte(encryptedEmail), data)
+	})
+	return wrapTxError(err, "DeleteUser")
+}
+
+// GetUserStats returns aggregate counts across non-deleted rows.
+func (s *Storage) GetUserStats() (*storage.UserStats, error) {
+	stats := &storage.UserStats{}
+	cutoff := time.Now().Add(-24 * time.Hour)
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(usersBucket).ForEach(func(_, data []byte) error {
+			var rec record
+			if err := json.Unmarshal(data, &rec); err != nil {
+				return err
+			}
+			if rec.DeletedAt != nil {
+				return nil
+			}
+			stats.TotalUsers++
+			if rec.CreatedAt.After(cutoff) {
+				stats.RegistrationsToday++
+			}
+			if rec.CreatedAt.After(stats.LastRegistration) {
+				stats.LastRegistration = rec.CreatedAt
+			}
+			return nil
+		})
+	})
+	stats.ActiveUsers = stats.TotalUsers
+	if err != nil {
+		return stats, wrapTxError(err, "GetUserStats")
+	}
+
+	now := time.Now()
+	err = s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(resetTokensBucket).ForEach(func(_, data []byte) error {
+			var rec resetTokenRecord
+			if err := json.Unmarshal(data, &rec); err != nil {
+				return err
+			}
+			if rec.UsedAt == nil && rec.ExpiresAt.After(now) {
+				stats.PendingResets++
+			}
+			return nil
+		})
+	})
+	return stats, wrapTxError(err, "GetUserStats")
+}
+
+// CreatePasswordResetToken generates and persists a new single-use token for
+// encryptedEmail, storing only crypto.HashResetToken(token).
+func (s *Storage) CreatePasswordResetToken(encryptedEmail string, ttl time.Duration) (string, error) {
+	token, err := crypto.GenerateResetToken()
+	if err != nil {
+		return "", storage.NewStorageError(storage.ErrorUnknown, "CreatePasswordResetToken", err.Error(), "Failed to issue password reset token.")
+	}
+
+	now := time.Now()
+	rec := resetTokenRecord{
+		EncryptedEmail: encryptedEmail,
+		ExpiresAt:      now.Add(ttl),
+		CreatedAt:      now,
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return "", storage.NewStorageError(storage.ErrorInvalidUserData, "CreatePasswordResetToken", err.Error(), "Invalid password reset token data.")
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(resetTokensBucket).Put([]byte(crypto.HashResetToken(token)), data)
+	})
+	if err != nil {
+		return "", wrapTxError(err, "CreatePasswordResetToken")
+	}
+	return token, nil
+}
+
+// ConsumePasswordResetToken redeems tokenID within a single write
+// transaction, so a concurrent redemption attempt either sees the record
+// already marked UsedAt or blocks behind this one - bolt allows only one
+// writer at a time, giving single-use for free without a separate CAS.
+func (s *Storage) ConsumePasswordResetToken(tokenID string) (string, error) {
+	tokenHash := []byte(crypto.HashResetToken(tokenID))
+	var encryptedEmail string
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(resetTokensBucket)
+		data := bucket.Get(tokenHash)
+		if data == nil {
+			return storage.NewStorageError(storage.ErrorUserNotFound, "ConsumePasswordResetToken", "no matching token", "Password reset token is invalid or has expired.")
+		}
+
+		var rec resetTokenRecord
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return storage.NewStorageError(storage.ErrorInvalidUserData, "ConsumePasswordResetToken", err.Error(), "Invalid password reset token data.")
+		}
+		if rec.UsedAt != nil || !rec.ExpiresAt.After(time.Now()) {
+			return storage.NewStorageError(storage.ErrorUserNotFound, "ConsumePasswordResetToken", "token already used or expired", "Password reset token is invalid or has expired.")
+		}
+
+		now := time.Now()
+		rec.UsedAt = &now
+		updated, err := json.Marshal(rec)
+		if err != nil {
+			return storage.NewStorageError(storage.ErrorInvalidUserData, "ConsumePasswordResetToken", err.Error(), "Invalid password reset token data.")
+		}
+		encryptedEmail = rec.EncryptedEmail
+		return bucket.Put(tokenHash, updated)
+	})
+	if err != nil {
+		return "", wrapTxError(err, "ConsumePasswordResetToken")
+	}
+	return encryptedEmail, nil
+}
+
+// DeleteExpiredPasswordResetTokens removes every token past its expiry,
+// regardless of whether it was ever consumed.
+func (s *Storage) DeleteExpiredPasswordResetTokens() (int, error) {
+	now := time.Now()
+	removed := 0
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(resetTokensBucket)
+		c := bucket.Cursor()
+		var expiredKeys [][]byte
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var rec resetTokenRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return err
+			}
+			if !rec.ExpiresAt.After(now) {
+				expiredKeys = append(expiredKeys, append([]byte(nil), k...))
+			}
+		}
+		for _, k := range expiredKeys {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	return removed, wrapTxError(err, "DeleteExpiredPasswordResetTokens")
+}
+
+// StoreIssuedCertificate persists a record of a freshly signed user certificate.
+func (s *Storage) StoreIssuedCertificate(cert types.IssuedCertificate) error {
+	data, err := json.Marshal(cert)
+	if err != nil {
+		return storage.NewStorageError(storage.ErrorInvalidUserData, "StoreIssuedCertificate", err.Error(), "Invalid certificate record data.")
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(issuedCertificateBucket).Put([]byte(cert.Serial), data)
+	})
+	return wrapTxError(err, "StoreIssuedCertificate")
+}
+
+// GetCertificateBySerial retrieves an issued certificate's record by serial.
+func (s *Storage) GetCertificateBySerial(serial string) (*types.IssuedCertificate, error) {
+	var cert *types.IssuedCertificate
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(issuedCertificateBucket).Get([]byte(serial))
+		if data == nil {
+			return nil
+		}
+		var rec types.IssuedCertificate
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return storage.NewStorageError(storage.ErrorInvalidUserData, "GetCertificateBySerial", err.Error(), "Invalid certificate record data.")
+		}
+		cert = &rec
+		return nil
+	})
+	if err != nil {
+		return nil, wrapTxError(err, "GetCertificateBySerial")
+	}
+	return cert, nil
+}
+
+// RevokeCertificate marks an issued certificate as revoked. Idempotent: an
+// already-revoked record's RevokedAt is left untouched.
+func (s *Storage) RevokeCertificate(serial string, reason int) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(issuedCertificateBucket)
+		data := bucket.Get([]byte(serial))
+		if data == nil {
+			return storage.NewStorageError(storage.ErrorUserNotFound, "RevokeCertificate", "no certificate with that serial", "No certificate found with that serial.")
+		}
+
+		var rec types.IssuedCertificate
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return storage.NewStorageError(storage.ErrorInvalidUserData, "RevokeCertificate", err.Error(), "Invalid certificate record data.")
+		}
+		if rec.Revoked {
+			return nil
+		}
+
+		now := time.Now()
+		rec.Revoked = true
+		rec.RevokedAt = &now
+		rec.RevocationReason = reason
+		updated, err := json.Marshal(rec)
+		if err != nil {
+			return storage.NewStorageError(storage.ErrorInvalidUserData, "RevokeCertificate", err.Error(), "Invalid certificate record data.")
+		}
+		return bucket.Put([]byte(serial), updated)
+	})
+	return wrapTxError(err, "RevokeCertificate")
+}
+
+// ListUsersByKeyPrefix returns up to limit non-deleted rows whose
+// EncryptedEmail is prefixed with keyID+":", after cursor in lexicographic order.
+func (s *Storage) ListUsersByKeyPrefix(keyID string, cursor string, limit int) ([]types.StoredUser, error) {
+	prefix := []byte(keyID + ":")
+	var users []types.StoredUser
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(usersBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && len(users) < limit; k, v = c.Next() {
+			if !hasPrefix(k, prefix) {
+				break
+			}
+			if string(k) <= cursor {
+				continue
+			}
+			var rec record
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return err
+			}
+			if rec.DeletedAt == nil {
+				users = append(users, rec.StoredUser)
+			}
+		}
+		return nil
+	})
+	return users, wrapTxError(err, "ListUsersByKeyPrefix")
+}
+
+func hasPrefix(b, prefix []byte) bool {
+	return len(b) >= len(prefix) && string(b[:len(prefix)]) == string(prefix)
+}
+
+// UpdateEncryptedEmail compare-and-swaps EncryptedEmail from oldEncryptedEmail
+// to newEncryptedEmail, moving the users-bucket entry and its index pointers.
+func (s *Storage) UpdateEncryptedEmail(oldEncryptedEmail, newEncryptedEmail string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		rec, err := getRecordIncludingDeleted(tx, []byte(oldEncryptedEmail))
+		if err != nil {
+			return err
+		}
+		if rec == nil {
+			return storage.NewStorageError(storage.ErrorUserNotFound, "UpdateEncryptedEmail", "row changed between read and write", "Row no longer matches expected state.")
+		}
+
+		rec.EncryptedEmail = newEncryptedEmail
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return storage.NewStorageError(storage.ErrorInvalidUserData, "UpdateEncryptedEmail", err.Error(), "Invalid user data.")
+		}
+
+		users := tx.Bucket(usersBucket)
+		if err := users.Delete([]byte(oldEncryptedEmail)); err != nil {
+			return err
+		}
+		if err := users.Put([]byte(newEncryptedEmail), data); err != nil {
+			return err
+		}
+		if err := tx.Bucket(blindIndexBucket).Put([]byte(rec.EmailBlindIndex), []byte(newEncryptedEmail)); err != nil {
+			return err
+		}
+		return tx.Bucket(sshKeyBucket).Put([]byte(rec.SSHPubKey), []byte(newEncryptedEmail))
+	})
+	return wrapTxError(err, "UpdateEncryptedEmail")
+}
+
+// HealthCheck confirms the BoltDB file is still reachable.
+func (s *Storage) HealthCheck() (*storage.StorageHealth, error) {
+	start := time.Now()
+	err := s.db.View(func(tx *bolt.Tx) error { return nil })
+	health := &storage.StorageHealth{
+		Connected:       err == nil,
+		ResponseTime:    time.Since(start),
+		ConnectionCount: 1,
+		LastHealthCheck: time.Now(),
+	}
+	if err != nil {
+		return health, storage.NewStorageError(storage.ErrorDatabaseConnection, "HealthCheck", err.Error(), "Database service unavailable.")
+	}
+	return health, nil
+}
+
+// getRecord returns the non-deleted record for encryptedEmail, or nil if
+// absent or soft-deleted.
+func getRecord(tx *bolt.Tx, encryptedEmail []byte) (*record, error) {
+	rec, err := getRecordIncludingDeleted(tx, encryptedEmail)
+	if err != nil || rec == nil || rec.DeletedAt != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// getRecordIncludingDeleted returns the record for encryptedEmail regardless
+// of its DeletedAt state, or nil if absent.
+func getRecordIncludingDeleted(tx *bolt.Tx, encryptedEmail []byte) (*record, error) {
+	data := tx.Bucket(usersBucket).Get(encryptedEmail)
+	if data == nil {
+		return nil, nil
+	}
+	var rec record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, storage.NewStorageError(storage.ErrorInvalidUserData, "getRecord", err.Error(), "Invalid user data.")
+	}
+	return &rec, nil
+}
+
+// wrapTxError passes a *storage.StorageError returned from inside a bolt
+// transaction straight through, and classifies anything else (a bolt
+// transport error) as ErrorDatabaseConnection.
+func wrapTxError(err error, operation string) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*storage.StorageError); ok {
+		return err
+	}
+	return storage.NewStorageError(storage.ErrorDatabaseConnection, operation, err.Error(), "Database error.")
+}
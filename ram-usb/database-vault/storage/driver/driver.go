@@ -0,0 +1,67 @@
+/*
+Storage backend registry for Database-Vault's UserStorage.
+
+Mirrors the database/sql driver.Register pattern (and dex/identifo's own
+pluggable storage backends): each concrete implementation package
+(storage/postgres, storage/sqlite, storage/bolt, storage/mongo) registers a
+Factory under its own name from an init() func, and main.go opens whichever
+one cfg.Storage.Driver names without ever importing a concrete backend
+package directly. Adding a fifth backend means adding a fifth package, not
+touching this one or main.go's selection logic.
+*/
+package driver
+
+import (
+	"database-vault/storage"
+	"fmt"
+	"sync"
+)
+
+// Factory constructs a storage.UserStorage from its driver-specific
+// StorageConfig fields.
+type Factory func(cfg storage.StorageConfig) (storage.UserStorage, error)
+
+var (
+	mu        sync.RWMutex
+	factories = make(map[string]Factory)
+)
+
+// Register adds factory under name, for main.go to later select via Open.
+//
+// Intended to be called from a backend package's init() func; panics on a
+// duplicate name since that indicates two backend packages were built in
+// under the same driver name, a programming error rather than a runtime condition.
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, exists := factories[name]; exists {
+		panic(fmt.Sprintf("storage/driver: Register called twice for driver %q", name))
+	}
+	factories[name] = factory
+}
+
+// Open builds the UserStorage registered under cfg.Driver.
+//
+// Returns error if no backend package registered that driver name - typically
+// because its package was never imported for side effect, e.g. missing
+// `_ "database-vault/storage/postgres"` in main.go.
+func Open(cfg storage.StorageConfig) (storage.UserStorage, error) {
+	mu.RLock()
+	factory, ok := factories[cfg.Driver]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("storage/driver: no backend registered for driver %q (missing blank import?)", cfg.Driver)
+	}
+	return factory(cfg)
+}
+
+// Registered reports whether a driver is currently registered, for
+// diagnostics (e.g. admin/health endpoints listing build-time capabilities).
+func Registered(name string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := factories[name]
+	return ok
+}
@@ -0,0 +1,185 @@
+/*
+Structured-logging decorator for Database-Vault's storage.UserStorage.
+
+Wraps whichever concrete backend storage/driver.Open selects (see
+storage/postgres, storage/sqlite, storage/bolt, storage/mongo) for
+observability, without any of those packages needing to know about
+database-vault/logging.
+*/
+package storage
+
+import (
+	"database-vault/types"
+	"log/slog"
+	"time"
+)
+
+// InstrumentedUserStorage wraps any UserStorage implementation with
+// structured slog logging around every call: operation name, duration, and
+// - on failure - the error (via StorageError's LogValue when applicable).
+// Never logs EncryptedEmail, SSHPubKey, or PasswordHash; callers needing to
+// correlate a logged operation with a specific row should cross-reference
+// the blind index instead.
+//
+// UserStorage methods take no context.Context, so logging here is tied to a
+// fixed base logger supplied at construction rather than a per-request one
+// (see database-vault/logging for the per-request equivalent used in the
+// HTTP handler chain).
+//
+// Construct with NewInstrumentedUserStorage.
+type InstrumentedUserStorage struct {
+	next   UserStorage
+	logger *slog.Logger
+}
+
+// NewInstrumentedUserStorage wraps next with logging against logger.
+func NewInstrumentedUserStorage(next UserStorage, logger *slog.Logger) *InstrumentedUserStorage {
+	return &InstrumentedUserStorage{next: next, logger: logger}
+}
+
+// logOp records op's duration and outcome, logging err via its StorageError
+// LogValue when next returned one.
+func (s *InstrumentedUserStorage) logOp(op string, start time.Time, err error) {
+	duration := time.Since(start)
+	if err != nil {
+		s.logger.Error("storage operation failed", "op", op, "duration_ms", duration.Milliseconds(), "error", err)
+		return
+	}
+	s.logger.Info("storage operation completed", "op", op, "duration_ms", duration.Milliseconds())
+}
+
+// StoreUser delegates to next, logging the outcome.
+func (s *InstrumentedUserStorage) StoreUser(user types.StoredUser) error {
+	start := time.Now()
+	err := s.next.StoreUser(user)
+	s.logOp("StoreUser", start, err)
+	return err
+}
+
+// GetUserByEncryptedEmail delegates to next, logging the outcome.
+func (s *InstrumentedUserStorage) GetUserByEncryptedEmail(encryptedEmail string) (*types.StoredUser, error) {
+	start := time.Now()
+	user, err := s.next.GetUserByEncryptedEmail(encryptedEmail)
+	s.logOp("GetUserByEncryptedEmail", start, err)
+	return user, err
+}
+
+// EmailExists delegates to next, logging the outcome.
+func (s *InstrumentedUserStorage) EmailExists(encryptedEmail string) (bool, error) {
+	start := time.Now()
+	exists, err := s.next.EmailExists(encryptedEmail)
+	s.logOp("EmailExists", start, err)
+	return exists, err
+}
+
+// GetUserByEmailBlindIndex delegates to next, logging the outcome.
+func (s *InstrumentedUserStorage) GetUserByEmailBlindIndex(blindIndex string) (*types.StoredUser, error) {
+	start := time.Now()
+	user, err := s.next.GetUserByEmailBlindIndex(blindIndex)
+	s.logOp("GetUserByEmailBlindIndex", start, err)
+	return user, err
+}
+
+// SSHKeyExists delegates to next, logging the outcome.
+func (s *InstrumentedUserStorage) SSHKeyExists(sshKey string) (bool, error) {
+	start := time.Now()
+	exists, err := s.next.SSHKeyExists(sshKey)
+	s.logOp("SSHKeyExists", start, err)
+	return exists, err
+}
+
+// UpdateUser delegates to next, logging the outcome.
+func (s *InstrumentedUserStorage) UpdateUser(encryptedEmail string, updates UserUpdateRequest) error {
+	start := time.Now()
+	err := s.next.UpdateUser(encryptedEmail, updates)
+	s.logOp("UpdateUser", start, err)
+	return err
+}
+
+// DeleteUser delegates to next, logging the outcome.
+func (s *InstrumentedUserStorage) DeleteUser(encryptedEmail string, permanent bool) error {
+	start := time.Now()
+	err := s.next.DeleteUser(encryptedEmail, permanent)
+	s.logOp("DeleteUser", start, err)
+	return err
+}
+
+// GetUserStats delegates to next, logging the outcome.
+func (s *InstrumentedUserStorage) GetUserStats() (*UserStats, error) {
+	start := time.Now()
+	stats, err := s.next.GetUserStats()
+	s.logOp("GetUserStats", start, err)
+	return stats, err
+}
+
+// ListUsersByKeyPrefix delegates to next, logging the outcome.
+func (s *InstrumentedUserStorage) ListUsersByKeyPrefix(keyID string, cursor string, limit int) ([]types.StoredUser, error) {
+	start := time.Now()
+	users, err := s.next.ListUsersByKeyPrefix(keyID, cursor, limit)
+	s.logOp("ListUsersByKeyPrefix", start, err)
+	return users, err
+}
+
+// UpdateEncryptedEmail delegates to next, logging the outcome.
+func (s *InstrumentedUserStorage) UpdateEncryptedEmail(oldEncryptedEmail, newEncryptedEmail string) error {
+	start := time.Now()
+	err := s.next.UpdateEncryptedEmail(oldEncryptedEmail, newEncryptedEmail)
+	s.logOp("UpdateEncryptedEmail", start, err)
+	return err
+}
+
+// HealthCheck delegates to next, logging the outcome.
+func (s *InstrumentedUserStorage) HealthCheck() (*StorageHealth, error) {
+	start := time.Now()
+	health, err := s.next.HealthCheck()
+	s.logOp("HealthCheck", start, err)
+	return health, err
+}
+
+// CreatePasswordResetToken delegates to next, logging the outcome.
+func (s *InstrumentedUserStorage) CreatePasswordResetToken(encryptedEmail string, ttl time.Duration) (string, error) {
+	start := time.Now()
+	token, err := s.next.CreatePasswordResetToken(encryptedEmail, ttl)
+	s.logOp("CreatePasswordResetToken", start, err)
+	return token, err
+}
+
+// ConsumePasswordResetToken delegates to next, logging the outcome.
+func (s *InstrumentedUserStorage) ConsumePasswordResetToken(tokenID string) (string, error) {
+	start := time.Now()
+	encryptedEmail, err := s.next.ConsumePasswordResetToken(tokenID)
+	s.logOp("ConsumePasswordResetToken", start, err)
+	return encryptedEmail, err
+}
+
+// DeleteExpiredPasswordResetTokens delegates to next, logging the outcome.
+func (s *InstrumentedUserStorage) DeleteExpiredPasswordResetTokens() (int, error) {
+	start := time.Now()
+	removed, err := s.next.DeleteExpiredPasswordResetTokens()
+	s.logOp("DeleteExpiredPasswordResetTokens", start, err)
+	return removed, err
+}
+
+// StoreIssuedCertificate delegates to next, logging the outcome.
+func (s *InstrumentedUserStorage) StoreIssuedCertificate(cert types.IssuedCertificate) error {
+	start := time.Now()
+	err := s.next.StoreIssuedCertificate(cert)
+	s.logOp("StoreIssuedCertificate", start, err)
+	return err
+}
+
+// GetCertificateBySerial delegates to next, logging the outcome.
+func (s *InstrumentedUserStorage) GetCertificateBySerial(serial string) (*types.IssuedCertificate, error) {
+	start := time.Now()
+	cert, err := s.next.GetCertificateBySerial(serial)
+	s.logOp("GetCertificateBySerial", start, err)
+	return cert, err
+}
+
+// RevokeCertificate delegates to next, logging the outcome.
+func (s *InstrumentedUserStorage) RevokeCertificate(serial string, reason int) error {
+	start := time.Now()
+	err := s.next.RevokeCertificate(serial, reason)
+	s.logOp("RevokeCertificate", start, err)
+	return err
+}
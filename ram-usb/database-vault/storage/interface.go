@@ -11,6 +11,7 @@ package storage
 
 import (
 	"database-vault/types"
+	"log/slog"
 	"time"
 )
 
@@ -56,9 +57,22 @@ type UserStorage interface {
 	// - No user data exposure during existence check operations
 	// - Audit logging for registration attempt monitoring
 	//
+	// Deprecated: exact EncryptedEmail comparison only works for rows written
+	// before field encryption moved to a random nonce per row; prefer
+	// GetUserByEmailBlindIndex for new lookups.
+	//
 	// Returns true if encrypted email exists, false otherwise, error if check fails.
 	EmailExists(encryptedEmail string) (bool, error)
 
+	// GetUserByEmailBlindIndex retrieves a user by their HMAC-SHA256 email blind index.
+	//
+	// Security features:
+	// - Equality lookup never requires decrypting EncryptedEmail or comparing ciphertexts
+	// - Works correctly even though EncryptedEmail is sealed with a random nonce per row (see crypto.FieldCipher)
+	//
+	// Returns the user record or nil if not found, error if the database operation fails.
+	GetUserByEmailBlindIndex(blindIndex string) (*types.StoredUser, error)
+
 	// SSHKeyExists verifies SSH public key uniqueness across entire user base.
 	//
 	// Security features:
@@ -102,6 +116,30 @@ type UserStorage interface {
 	// Returns statistics summary or error if collection fails.
 	GetUserStats() (*UserStats, error)
 
+	// ListUsersByKeyPrefix retrieves a page of users whose EncryptedEmail is
+	// prefixed with keyID, for use by the key-rotation re-encryption worker.
+	//
+	// Security features:
+	// - Cursor-based pagination avoids large in-memory result sets
+	// - Scoped to a single KeyID so rotation only touches rows left on the
+	//   retired key, never rows already migrated to the new primary
+	//
+	// Returns up to limit users with EncryptedEmail after cursor (exclusive,
+	// lexicographic order), or error if the query fails.
+	ListUsersByKeyPrefix(keyID string, cursor string, limit int) ([]types.StoredUser, error)
+
+	// UpdateEncryptedEmail atomically replaces a user's EncryptedEmail, used by
+	// the re-encryption worker to migrate a row from a retired key to the new primary.
+	//
+	// Security features:
+	// - Compare-and-swap on oldEncryptedEmail prevents lost updates if the row
+	//   changed between the worker's read and write
+	// - Leaves all other user fields untouched
+	//
+	// Returns error if oldEncryptedEmail no longer matches the stored row or
+	// the update fails.
+	UpdateEncryptedEmail(oldEncryptedEmail, newEncryptedEmail string) error
+
 	// HealthCheck verifies database connectivity and storage system integrity.
 	//
 	// Security features:
@@ -111,6 +149,60 @@ type UserStorage interface {
 	//
 	// Returns health status or error if system is unavailable.
 	HealthCheck() (*StorageHealth, error)
+
+	// CreatePasswordResetToken issues a new single-use password-reset token
+	// for encryptedEmail, valid for ttl, analogous to dex's Password reset resource.
+	//
+	// Security features:
+	// - Only crypto.HashResetToken(tokenID) is ever persisted; the plaintext
+	//   token exists solely in the return value, for the caller to deliver out-of-band
+	// - ExpiresAt = now + ttl bounds how long the token remains redeemable
+	//
+	// Returns the plaintext token, or error if the record cannot be written.
+	CreatePasswordResetToken(encryptedEmail string, ttl time.Duration) (tokenID string, err error)
+
+	// ConsumePasswordResetToken atomically redeems tokenID for the
+	// EncryptedEmail it was issued to, enforcing single use and expiry.
+	//
+	// Security features:
+	// - An atomic UPDATE...WHERE used_at IS NULL enforces single use even
+	//   under concurrent redemption attempts; only the first succeeds
+	// - Expired, already-used, and unrecognized tokens all fail with
+	//   ErrorUserNotFound, so a caller can't distinguish one from another by probing
+	//
+	// Returns the EncryptedEmail the token was issued to, or error if the
+	// token is unrecognized, expired, or already used.
+	ConsumePasswordResetToken(tokenID string) (encryptedEmail string, err error)
+
+	// DeleteExpiredPasswordResetTokens removes password-reset tokens whose
+	// ExpiresAt has passed, regardless of UsedAt, for the background sweep
+	// goroutine main.go starts alongside the mTLS server.
+	//
+	// Returns the number of rows removed, or error if the delete fails.
+	DeleteExpiredPasswordResetTokens() (removed int, err error)
+
+	// StoreIssuedCertificate persists a record of a freshly signed pki.CA
+	// client certificate, so RevokeCertificate and the OCSP responder can
+	// later look it up by serial.
+	//
+	// Returns error if the record cannot be written.
+	StoreIssuedCertificate(cert types.IssuedCertificate) error
+
+	// GetCertificateBySerial retrieves an issued certificate's record by its
+	// serial number, for the OCSP responder.
+	//
+	// Returns the record, or nil if no certificate was ever issued with that
+	// serial, or error if the lookup fails.
+	GetCertificateBySerial(serial string) (*types.IssuedCertificate, error)
+
+	// RevokeCertificate marks an issued certificate as revoked, so the OCSP
+	// responder rejects it from here on.
+	//
+	// Security features:
+	// - Idempotent: revoking an already-revoked certificate leaves its original RevokedAt untouched
+	//
+	// Returns error if no certificate with that serial exists or the update fails.
+	RevokeCertificate(serial string, reason int) error
 }
 
 // UserUpdateRequest defines fields that can be modified for existing users.
@@ -118,14 +210,25 @@ type UserStorage interface {
 // Security features:
 // - Immutable encrypted email prevents primary key confusion
 // - Optional field updates allow partial credential modification
-// - New password hash validation with fresh salt generation
+// - New password hash is a self-contained PHC string (salt and parameters embedded)
 // - SSH key uniqueness verification before update acceptance
 //
 // Used by UpdateUser for secure credential modification operations.
 type UserUpdateRequest struct {
-	NewPasswordHash *string `json:"new_password_hash,omitempty"` // Updated Argon2id hash with new salt
-	NewPasswordSalt *string `json:"new_password_salt,omitempty"` // Fresh cryptographic salt for new password
+	NewPasswordHash *string `json:"new_password_hash,omitempty"` // Updated PHC-encoded Argon2id hash
+	NewPasswordSalt *string `json:"new_password_salt,omitempty"` // Deprecated: legacy hex salt, unused for PHC hashes
 	NewSSHPubKey    *string `json:"new_ssh_key,omitempty"`       // Updated SSH public key for storage access
+
+	// Lockout tracking, updated by LoginUserHandler on every login attempt
+	NewFailedLoginAttempts *int       `json:"new_failed_login_attempts,omitempty"` // Replaces StoredUser.FailedLoginAttempts outright (not incremented in-place), since the caller already knows the new count
+	NewLastFailedLogin     *time.Time `json:"new_last_failed_login,omitempty"`     // Replaces StoredUser.LastFailedLogin outright
+
+	// MFA enrollment and challenge state, updated by handlers.EnrollMFAHandler,
+	// handlers.ConfirmMFAHandler, and handlers.LoginUserHandler
+	NewMFAEnabled             *bool     `json:"new_mfa_enabled,omitempty"`               // Replaces StoredUser.MFAEnabled outright
+	NewMFASecretEncrypted     *string   `json:"new_mfa_secret_encrypted,omitempty"`      // Replaces StoredUser.MFASecretEncrypted outright
+	NewMFAScratchCodeHashes   *[]string `json:"new_mfa_scratch_code_hashes,omitempty"`   // Replaces StoredUser.MFAScratchCodeHashes outright, e.g. with one hash removed after redemption
+	NewMFALastAcceptedCounter *int64    `json:"new_mfa_last_accepted_counter,omitempty"` // Replaces StoredUser.MFALastAcceptedCounter outright
 }
 
 // UserStats provides anonymous usage statistics for operational monitoring.
@@ -142,6 +245,7 @@ type UserStats struct {
 	RegistrationsToday int       `json:"registrations_today"` // New registrations in last 24 hours
 	LastRegistration   time.Time `json:"last_registration"`   // Most recent registration timestamp
 	StorageUsageBytes  int64     `json:"storage_usage_bytes"` // Database storage consumption
+	PendingResets      int       `json:"pending_resets"`      // Unexpired, unconsumed password-reset tokens
 }
 
 // StorageHealth represents database and storage system health status.
@@ -162,6 +266,12 @@ type StorageHealth struct {
 
 // StorageConfig holds database connection and configuration parameters.
 //
+// Driver selects which storage/driver.Register'd backend main.go builds via
+// storage/driver.Open; DatabaseURL/MaxConnections apply to the
+// connection-pooled SQL backends ("postgres") (SSLMode is not read by any
+// backend), while SQLitePath/BoltPath/Mongo
+// apply only to their respective drivers and are ignored otherwise.
+//
 // Security features:
 // - Secure connection string handling with credentials protection
 // - Connection pooling configuration for performance and security
@@ -170,12 +280,19 @@ type StorageHealth struct {
 //
 // Used during UserStorage implementation initialization and configuration.
 type StorageConfig struct {
-	DatabaseURL        string        `json:"database_url"`         // PostgreSQL connection string with credentials
-	MaxConnections     int           `json:"max_connections"`      // Connection pool maximum size
+	Driver             string        `json:"driver"`               // Registered storage/driver name: "postgres", "sqlite", "bolt", or "mongo"
+	DatabaseURL        string        `json:"database_url"`         // postgres: PostgreSQL connection string with credentials
+	MaxConnections     int           `json:"max_connections"`      // postgres: connection pool maximum size
 	ConnectionTimeout  time.Duration `json:"connection_timeout"`   // Database connection timeout
 	QueryTimeout       time.Duration `json:"query_timeout"`        // Individual query execution timeout
-	SSLMode            string        `json:"ssl_mode"`             // SSL/TLS mode (require, verify-full, etc.)
+	SSLMode            string        `json:"ssl_mode"`             // SSL/TLS mode (require, verify-full, etc.); not read by the postgres backend, which takes sslmode from DatabaseURL
 	EnableQueryLogging bool          `json:"enable_query_logging"` // SQL query audit logging
+
+	SQLitePath string `json:"sqlite_path"` // sqlite: path to the database file (modernc.org/sqlite, CGO-free)
+	BoltPath   string `json:"bolt_path"`   // bolt: path to the single-file embedded database
+
+	MongoURI      string `json:"mongo_uri"`      // mongo: connection URI with credentials
+	MongoDatabase string `json:"mongo_database"` // mongo: database name within the cluster
 }
 
 // StorageError represents Database-Vault storage operation error conditions.
@@ -231,6 +348,18 @@ func (e *StorageError) Error() string {
 	return e.Message
 }
 
+// LogValue implements slog.LogValuer, so a StorageError passed directly as a
+// slog attribute value logs as a structured group (type/operation/timestamp/
+// message) instead of falling back to its flat Error() string.
+func (e *StorageError) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("type", string(e.Type)),
+		slog.String("operation", e.Operation),
+		slog.Time("timestamp", e.Timestamp),
+		slog.String("message", e.Message),
+	)
+}
+
 // NewStorageError creates a new StorageError with categorization and timestamps.
 //
 // Security features:
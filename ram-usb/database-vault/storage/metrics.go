@@ -0,0 +1,207 @@
+/*
+Prometheus-metrics decorator for Database-Vault's storage.UserStorage.
+
+Wraps whichever concrete backend storage/driver.Open selects (see
+storage/postgres, storage/sqlite, storage/bolt, storage/mongo) for
+observability: db_ops_total, db_op_duration_seconds, db_connections_open,
+and users_total (see database-vault/metrics). Composes with
+InstrumentedUserStorage - main.go wraps with both - rather than folding
+slog logging and Prometheus export into one decorator, so either can be
+dropped without touching the other.
+*/
+package storage
+
+import (
+	"database-vault/metrics"
+	"database-vault/types"
+	"time"
+)
+
+// healthQuantileWindow bounds how many recent per-operation durations
+// MetricsUserStorage.HealthCheck draws its reported ResponseTime from.
+const healthQuantileWindow = 50
+
+// MetricsUserStorage wraps any UserStorage implementation with Prometheus
+// observability around every call: a db_ops_total increment labeled by
+// operation and result, and a db_op_duration_seconds observation labeled by
+// operation.
+//
+// Construct with WithMetrics.
+type MetricsUserStorage struct {
+	next   UserStorage
+	recent *metrics.RecentQuantile // recent op durations, for HealthCheck's ResponseTime
+}
+
+// WithMetrics wraps next with Prometheus observability, returned as a
+// UserStorage so callers don't need the concrete type.
+func WithMetrics(next UserStorage) UserStorage {
+	return &MetricsUserStorage{next: next, recent: metrics.NewRecentQuantile(healthQuantileWindow)}
+}
+
+// observe records op's duration and result against the package-level
+// collectors, and folds duration into the recent window HealthCheck reads from.
+func (s *MetricsUserStorage) observe(op string, start time.Time, err error) {
+	duration := time.Since(start)
+	metrics.OpDuration.WithLabelValues(op).Observe(duration.Seconds())
+	metrics.OpsTotal.WithLabelValues(op, resultLabel(err)).Inc()
+	s.recent.Observe(duration)
+}
+
+// resultLabel returns err's StorageErrorType as the "result" label when err
+// is a *StorageError, "ok" when err is nil, or "error" otherwise.
+func resultLabel(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	if se, ok := err.(*StorageError); ok {
+		return string(se.Type)
+	}
+	return "error"
+}
+
+// StoreUser delegates to next, recording metrics.
+func (s *MetricsUserStorage) StoreUser(user types.StoredUser) error {
+	start := time.Now()
+	err := s.next.StoreUser(user)
+	s.observe("StoreUser", start, err)
+	return err
+}
+
+// GetUserByEncryptedEmail delegates to next, recording metrics.
+func (s *MetricsUserStorage) GetUserByEncryptedEmail(encryptedEmail string) (*types.StoredUser, error) {
+	start := time.Now()
+	user, err := s.next.GetUserByEncryptedEmail(encryptedEmail)
+	s.observe("GetUserByEncryptedEmail", start, err)
+	return user, err
+}
+
+// EmailExists delegates to next, recording metrics.
+func (s *MetricsUserStorage) EmailExists(encryptedEmail string) (bool, error) {
+	start := time.Now()
+	exists, err := s.next.EmailExists(encryptedEmail)
+	s.observe("EmailExists", start, err)
+	return exists, err
+}
+
+// GetUserByEmailBlindIndex delegates to next, recording metrics.
+func (s *MetricsUserStorage) GetUserByEmailBlindIndex(blindIndex string) (*types.StoredUser, error) {
+	start := time.Now()
+	user, err := s.next.GetUserByEmailBlindIndex(blindIndex)
+	s.observe("GetUserByEmailBlindIndex", start, err)
+	return user, err
+}
+
+// SSHKeyExists delegates to next, recording metrics.
+func (s *MetricsUserStorage) SSHKeyExists(sshKey string) (bool, error) {
+	start := time.Now()
+	exists, err := s.next.SSHKeyExists(sshKey)
+	s.observe("SSHKeyExists", start, err)
+	return exists, err
+}
+
+// UpdateUser delegates to next, recording metrics.
+func (s *MetricsUserStorage) UpdateUser(encryptedEmail string, updates UserUpdateRequest) error {
+	start := time.Now()
+	err := s.next.UpdateUser(encryptedEmail, updates)
+	s.observe("UpdateUser", start, err)
+	return err
+}
+
+// DeleteUser delegates to next, recording metrics.
+func (s *MetricsUserStorage) DeleteUser(encryptedEmail string, permanent bool) error {
+	start := time.Now()
+	err := s.next.DeleteUser(encryptedEmail, permanent)
+	s.observe("DeleteUser", start, err)
+	return err
+}
+
+// GetUserStats delegates to next, recording metrics and refreshing the
+// users_total gauge from the result.
+func (s *MetricsUserStorage) GetUserStats() (*UserStats, error) {
+	start := time.Now()
+	stats, err := s.next.GetUserStats()
+	s.observe("GetUserStats", start, err)
+	if stats != nil {
+		metrics.UsersTotal.Set(float64(stats.TotalUsers))
+	}
+	return stats, err
+}
+
+// ListUsersByKeyPrefix delegates to next, recording metrics.
+func (s *MetricsUserStorage) ListUsersByKeyPrefix(keyID string, cursor string, limit int) ([]types.StoredUser, error) {
+	start := time.Now()
+	users, err := s.next.ListUsersByKeyPrefix(keyID, cursor, limit)
+	s.observe("ListUsersByKeyPrefix", start, err)
+	return users, err
+}
+
+// UpdateEncryptedEmail delegates to next, recording metrics.
+func (s *MetricsUserStorage) UpdateEncryptedEmail(oldEncryptedEmail, newEncryptedEmail string) error {
+	start := time.Now()
+	err := s.next.UpdateEncryptedEmail(oldEncryptedEmail, newEncryptedEmail)
+	s.observe("UpdateEncryptedEmail", start, err)
+	return err
+}
+
+// HealthCheck delegates to next, recording metrics, refreshing the
+// db_connections_open gauge, and replacing the returned ResponseTime with
+// the recent window's median - a single ping's latency is a poor proxy for
+// what callers actually experience under load.
+func (s *MetricsUserStorage) HealthCheck() (*StorageHealth, error) {
+	start := time.Now()
+	health, err := s.next.HealthCheck()
+	s.observe("HealthCheck", start, err)
+	if health != nil {
+		health.ResponseTime = s.recent.Quantile(0.5)
+		metrics.ConnectionsOpen.Set(float64(health.ConnectionCount))
+	}
+	return health, err
+}
+
+// CreatePasswordResetToken delegates to next, recording metrics.
+func (s *MetricsUserStorage) CreatePasswordResetToken(encryptedEmail string, ttl time.Duration) (string, error) {
+	start := time.Now()
+	token, err := s.next.CreatePasswordResetToken(encryptedEmail, ttl)
+	s.observe("CreatePasswordResetToken", start, err)
+	return token, err
+}
+
+// ConsumePasswordResetToken delegates to next, recording metrics.
+func (s *MetricsUserStorage) ConsumePasswordResetToken(tokenID string) (string, error) {
+	start := time.Now()
+	encryptedEmail, err := s.next.ConsumePasswordResetToken(tokenID)
+	s.observe("ConsumePasswordResetToken", start, err)
+	return encryptedEmail, err
+}
+
+// DeleteExpiredPasswordResetTokens delegates to next, recording metrics.
+func (s *MetricsUserStorage) DeleteExpiredPasswordResetTokens() (int, error) {
+	start := time.Now()
+	removed, err := s.next.DeleteExpiredPasswordResetTokens()
+	s.observe("DeleteExpiredPasswordResetTokens", start, err)
+	return removed, err
+}
+
+// StoreIssuedCertificate delegates to next, recording metrics.
+func (s *MetricsUserStorage) StoreIssuedCertificate(cert types.IssuedCertificate) error {
+	start := time.Now()
+	err := s.next.StoreIssuedCertificate(cert)
+	s.observe("StoreIssuedCertificate", start, err)
+	return err
+}
+
+// GetCertificateBySerial delegates to next, recording metrics.
+func (s *MetricsUserStorage) GetCertificateBySerial(serial string) (*types.IssuedCertificate, error) {
+	start := time.Now()
+	cert, err := s.next.GetCertificateBySerial(serial)
+	s.observe("GetCertificateBySerial", start, err)
+	return cert, err
+}
+
+// RevokeCertificate delegates to next, recording metrics.
+func (s *MetricsUserStorage) RevokeCertificate(serial string, reason int) error {
+	start := time.Now()
+	err := s.next.RevokeCertificate(serial, reason)
+	s.observe("RevokeCertificate", start, err)
+	return err
+}
@@ -0,0 +1,543 @@
+/*
+MongoDB-backed storage.UserStorage, registered under driver name "mongo".
+
+Document-store counterpart to storage/postgres for deployments already
+standardized on Mongo; unique indexes on email_blind_index and
+ssh_public_key enforce the same duplicate-detection guarantees the SQL
+backends get from UNIQUE constraints. encrypted_email carries no unique
+index of its own.
+*/
+package mongo
+
+import (
+	"context"
+	"database-vault/crypto"
+	"database-vault/storage"
+	"database-vault/storage/driver"
+	"database-vault/types"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	driver.Register("mongo", Open)
+}
+
+// resetTokenDocument is the password_reset_tokens collection's schema.
+type resetTokenDocument struct {
+	TokenHash      string     `bson:"token_hash"`
+	EncryptedEmail string     `bson:"encrypted_email"`
+	ExpiresAt      time.Time  `bson:"expires_at"`
+	UsedAt         *time.Time `bson:"used_at,omitempty"`
+	CreatedAt      time.Time  `bson:"created_at"`
+}
+
+// issuedCertificateDocument is the issued_certificates collection's schema.
+type issuedCertificateDocument struct {
+	Serial           string     `bson:"serial"`
+	EncryptedEmail   string     `bson:"encrypted_email"`
+	NotAfter         time.Time  `bson:"not_after"`
+	Revoked          bool       `bson:"revoked"`
+	RevokedAt        *time.Time `bson:"revoked_at,omitempty"`
+	RevocationReason int        `bson:"revocation_reason,omitempty"`
+	CreatedAt        time.Time  `bson:"created_at"`
+}
+
+func (d issuedCertificateDocument) toIssuedCertificate() types.IssuedCertificate {
+	return types.IssuedCertificate{
+		Serial:           d.Serial,
+		EncryptedEmail:   d.EncryptedEmail,
+		NotAfter:         d.NotAfter,
+		Revoked:          d.Revoked,
+		RevokedAt:        d.RevokedAt,
+		RevocationReason: d.RevocationReason,
+		CreatedAt:        d.CreatedAt,
+	}
+}
+
+// document is the Mongo collection's schema - types.StoredUser plus the
+// soft-delete marker that type has no field for.
+type document struct {
+	EncryptedEmail         string     `bson:"encrypted_email"`
+	EmailBlindIndex        string     `bson:"email_blind_index"`
+	PasswordHash           string     `bson:"password_hash"`
+	SSHPubKey              string     `bson:"ssh_public_key"`
+	CreatedAt              time.Time  `bson:"created_at"`
+	UpdatedAt              time.Time  `bson:"updated_at"`
+	DeletedAt              *time.Time `bson:"deleted_at,omitempty"`
+	FailedLoginAttempts    int        `bson:"failed_login_attempts"`
+	LastFailedLogin        *time.Time `bson:"last_failed_login,omitempty"`
+	MFAEnabled             bool       `bson:"mfa_enabled"`
+	MFASecretEncrypted     string     `bson:"mfa_secret_encrypted,omitempty"`
+	MFAScratchCodeHashes   []string   `bson:"mfa_scratch_code_hashes,omitempty"`
+	MFALastAcceptedCounter int64      `bson:"mfa_last_accepted_counter,omitempty"`
+}
+
+func (d document) toStoredUser() types.StoredUser {
+	return types.StoredUser{
+		EncryptedEmail:         d.EncryptedEmail,
+		EmailBlindIndex:        d.EmailBlindIndex,
+		PasswordHash:           d.PasswordHash,
+		SSHPubKey:              d.SSHPubKey,
+		CreatedAt:              d.CreatedAt,
+		UpdatedAt:              d.UpdatedAt,
+		FailedLoginAttempts:    d.FailedLoginAttempts,
+		LastFailedLogin:        d.LastFailedLogin,
+		MFAEnabled:             d.MFAEnabled,
+		MFASecretEncrypted:     d.MFASecretEncrypted,
+		MFAScratchCodeHashes:   d.MFAScratchCodeHashes,
+		MFALastAcceptedCounter: d.MFALastAcceptedCounter,
+	}
+}
+
+// Storage is a storage.UserStorage backed by a MongoDB "users" collection.
+//
+// Construct with Open.
+type Storage struct {
+	client             *mongo.Client
+	users              *mongo.Collection
+	resetTokens        *mongo.Collection
+	issuedCertificates *mongo.Collection
+	queryTimeout       time.Duration
+}
+
+// Open connects to cfg.MongoURI, selects cfg.MongoDatabase, and ensures the
+// unique indexes StoreUser/UpdateUser rely on exist.
+//
+// Returns error if the connection fails or index creation fails.
+func Open(cfg storage.StorageConfig) (storage.UserStorage, error) {
+	if cfg.MongoURI == "" || cfg.MongoDatabase == "" {
+		return nil, fmt.Errorf("mongo: storage.mongo_uri and storage.mongo_database must not be empty")
+	}
+
+	connectTimeout := cfg.ConnectionTimeout
+	if connectTimeout <= 0 {
+		connectTimeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoURI))
+	if err != nil {
+		return nil, fmt.Errorf("mongo: failed to connect: %v", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		return nil, fmt.Errorf("mongo: failed to ping cluster: %v", err)
+	}
+
+	users := client.Database(cfg.MongoDatabase).Collection("users")
+	_, err = users.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "email_blind_index", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "ssh_public_key", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	if err != nil {
+		client.Disconnect(ctx)
+		return nil, fmt.Errorf("mongo: failed to create indexes: %v", err)
+	}
+
+	resetTokens := client.Database(cfg.MongoDatabase).Collection("password_reset_tokens")
+	_, err = resetTokens.Indexes().CreateOne(ctx,
+		mongo.IndexModel{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)})
+	if err != nil {
+		client.Disconnect(ctx)
+		return nil, fmt.Errorf("mongo: failed to create password_reset_tokens index: %v", err)
+	}
+
+	issuedCertificates := client.Database(cfg.MongoDatabase).Collection("issued_certificates")
+	_, err = issuedCertificates.Indexes().CreateOne(ctx,
+		mongo.IndexModel{Keys: bson.D{{Key: "serial", Value: 1}}, Options: options.Index().SetUnique(true)})
+	if err != nil {
+		client.Disconnect(ctx)
+		return nil, fmt.Errorf("mongo: failed to create issued_certificates index: %v", err)
+	}
+
+	qt := cfg.QueryTimeout
+	if qt <= 0 {
+		qt = 5 * time.Second
+	}
+	return &Storage{client: client, users: users, resetTokens: resetTokens, issuedCertificates: issuedCertificates, queryTimeout: qt}, nil
+}
+
+func (s *Storage) ctx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), s.queryTimeout)
+}
+
+// StoreUser inserts user, classifying a duplicate-key error on
+// email_blind_index/ssh_public_key into the matching
+// ErrorUserExists/ErrorSSHKeyExists case.
+func (s *Storage) StoreUser(user types.StoredUser) error {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	doc := document{
+		EncryptedEmail:  user.EncryptedEmail,
+		EmailBlindIndex: user.EmailBlindIndex,
+		PasswordHash:    user.PasswordHash,
+		SSHPubKey:       user.SSHPubKey,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+	}
+	_, err := s.users.InsertOne(ctx, doc)
+	if err == nil {
+		return nil
+	}
+
+	if mongo.IsDuplicateKeyError(err) {
+		if exists, _ := s.SSHKeyExists(user.SSHPubKey); exists {
+			return storage.NewStorageError(storage.ErrorSSHKeyExists, "StoreUser", err.Error(), "SSH public key already in use.")
+		}
+		return storage.NewStorageError(storage.ErrorUserExists, "StoreUser", err.Error(), "Email address already registered.")
+	}
+	return storage.NewStorageError(storage.ErrorDatabaseConnection, "StoreUser", err.Error(), "Database error during registration.")
+}
+
+// GetUserByEncryptedEmail returns the matching, non-deleted row or nil.
+func (s *Storage) GetUserByEncryptedEmail(encryptedEmail string) (*types.StoredUser, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+	return s.findOne(ctx, bson.M{"encrypted_email": encryptedEmail, "deleted_at": nil})
+}
+
+// EmailExists reports whether a non-deleted row with encryptedEmail exists.
+func (s *Storage) EmailExists(encryptedEmail string) (bool, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	count, err := s.users.CountDocuments(ctx, bson.M{"encrypted_email": encryptedEmail, "deleted_at": nil})
+	if err != nil {
+		return false, storage.NewStorageError(storage.ErrorDatabaseConnection, "EmailExists", err.Error(), "Database error during duplicate check.")
+	}
+	return count > 0, nil
+}
+
+// GetUserByEmailBlindIndex returns the matching, non-deleted row or nil.
+func (s *Storage) GetUserByEmailBlindIndex(blindIndex string) (*types.StoredUser, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+	return s.findOne(ctx, bson.M{"email_blind_index": blindIndex, "deleted_at": nil})
+}
+
+// SSHKeyExists reports whether a non-deleted row with sshKey exists.
+func (s *Storage) SSHKeyExists(sshKey string) (bool, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	count, err := s.users.CountDocuments(ctx, bson.M{"ssh_public_key": sshKey, "deleted_at": nil})
+	if err != nil {
+		return false, storage.NewStorageError(storage.ErrorDatabaseConnection, "SSHKeyExists", err.Error(), "Database error during SSH key check.")
+	}
+	return count > 0, nil
+}
+
+// UpdateUser applies the non-nil fields in updates to the row identified by encryptedEmail.
+func (s *Storage) UpdateUser(encryptedEmail string, updates storage.UserUpdateRequest) error {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	if updates.NewSSHPubKey != nil {
+		count, err := s.users.CountDocuments(ctx, bson.M{
+			"ssh_public_key":  *updates.NewSSHPubKey,
+			"encrypted_email": bson.M{"$ne": encryptedEmail},
+			"deleted_at":      nil,
+		})
+		if err != nil {
+			return storage.NewStorageError(storage.ErrorDatabaseConnection, "UpdateUser", err.Error(), "Database error during SSH key check.")
+		}
+		if count > 0 {
+			return storage.NewStorageError(storage.ErrorSSHKeyExists, "UpdateUser", "ssh key already in use", "SSH public key already in use.")
+		}
+	}
+
+	set := bson.M{"updated_at": time.Now()}
+	if updates.NewPasswordHash != nil {
+		set["password_hash"] = *updates.NewPasswordHash
+	}
+	if updates.NewSSHPubKey != nil {
+		set["ssh_public_key"] = *updates.NewSSHPubKey
+	}
+	if updates.NewFailedLoginAttempts != nil {
+		set["failed_login_attempts"] = *updates.NewFailedLoginAttempts
+	}
+	if updates.NewLastFailedLogin != nil {
+		set["last_failed_login"] = *updates.NewLastFailedLogin
+	}
+	if updates.NewMFAEnabled != nil {
+		set["mfa_enabled"] = *updates.NewMFAEnabled
+	}
+	if updates.NewMFASecretEncrypted != nil {
+		set["mfa_secret_encrypted"] = *updates.NewMFASecretEncrypted
+	}
+	if updates.NewMFAScratchCodeHashes != nil {
+		set["mfa_scratch_code_hashes"] = *updates.NewMFAScratchCodeHashes
+	}
+	if updates.NewMFALastAcceptedCounter != nil {
+		set["mfa_last_accepted_counter"] = *updates.NewMFALastAcceptedCounter
+	}
+
+	result, err := s.users.UpdateOne(ctx, bson.M{"encrypted_email": encryptedEmail, "deleted_at": nil}, bson.M{"$set": set})
+	if err != nil {
+		return storage.NewStorageError(storage.ErrorQueryExecution, "UpdateUser", err.Error(), "Database error during update.")
+	}
+	if result.MatchedCount == 0 {
+		return storage.NewStorageError(storage.ErrorUserNotFound, "UpdateUser", "no matching row", "User not found.")
+	}
+	return nil
+}
+
+// DeleteUser soft-deletes by setting deleted_at, or permanently removes the
+// document when permanent is true.
+func (s *Storage) DeleteUser(encryptedEmail string, permanent bool) error {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	if permanent {
+		result, err := s.users.DeleteOne(ctx, bson.M{"encrypted_email": encryptedEmail})
+		if err != nil {
+			return storage.NewStorageError(storage.ErrorQueryExecution, "DeleteUser", err.Error(), "Database error during deletion.")
+		}
+		if result.DeletedCount == 0 {
+			return storage.NewStorageError(storage.ErrorUserNotFound, "DeleteUser", "no matching row", "User not found.")
+		}
+		return nil
+	}
+
+	result, err := s.users.UpdateOne(ctx,
+		bson.M{"encrypted_email": encryptedEmail, "deleted_at": nil},
+		bson.M{"$set": bson.M{"deleted_at": time.Now()}})
+	if err != nil {
+		return storage.NewStorageError(storage.ErrorQueryExecution, "DeleteUser", err.Error(), "Database error during deletion.")
+	}
+	if result.MatchedCount == 0 {
+		return storage.NewStorageError(storage.ErrorUserNotFound, "DeleteUser", "no matching row", "User not found.")
+	}
+	return nil
+}
+
+// GetUserStats returns aggregate counts across non-deleted rows.
+func (s *Storage) GetUserStats() (*storage.UserStats, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	total, err := s.users.CountDocuments(ctx, bson.M{"deleted_at": nil})
+	if err != nil {
+		return nil, storage.NewStorageError(storage.ErrorQueryExecution, "GetUserStats", err.Error(), "Database error retrieving statistics.")
+	}
+	today, err := s.users.CountDocuments(ctx, bson.M{"deleted_at": nil, "created_at": bson.M{"$gt": time.Now().Add(-24 * time.Hour)}})
+	if err != nil {
+		return nil, storage.NewStorageError(storage.ErrorQueryExecution, "GetUserStats", err.Error(), "Database error retrieving statistics.")
+	}
+
+	var latest document
+	_ = s.users.FindOne(ctx, bson.M{"deleted_at": nil}, options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})).Decode(&latest)
+
+	pendingResets, err := s.resetTokens.CountDocuments(ctx, bson.M{"used_at": nil, "expires_at": bson.M{"$gt": time.Now()}})
+	if err != nil {
+		return nil, storage.NewStorageError(storage.ErrorQueryExecution, "GetUserStats", err.Error(), "Database error retrieving statistics.")
+	}
+
+	return &storage.UserStats{
+		TotalUsers:         int(total),
+		ActiveUsers:        int(total),
+		RegistrationsToday: int(today),
+		LastRegistration:   latest.CreatedAt,
+		PendingResets:      int(pendingResets),
+	}, nil
+}
+
+// CreatePasswordResetToken generates and persists a new single-use token for
+// encryptedEmail, storing only crypto.HashResetToken(token).
+func (s *Storage) CreatePasswordResetToken(encryptedEmail string, ttl time.Duration) (string, error) {
+	token, err := crypto.GenerateResetToken()
+	if err != nil {
+		return "", storage.NewStorageError(storage.ErrorUnknown, "CreatePasswordResetToken", err.Error(), "Failed to issue password reset token.")
+	}
+
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	now := time.Now()
+	_, err = s.resetTokens.InsertOne(ctx, resetTokenDocument{
+		TokenHash:      crypto.HashResetToken(token),
+		EncryptedEmail: encryptedEmail,
+		ExpiresAt:      now.Add(ttl),
+		CreatedAt:      now,
+	})
+	if err != nil {
+		return "", storage.NewStorageError(storage.ErrorQueryExecution, "CreatePasswordResetToken", err.Error(), "Database error issuing password reset token.")
+	}
+	return token, nil
+}
+
+// ConsumePasswordResetToken redeems tokenID via an atomic FindOneAndUpdate
+// filtered on used_at/expires_at, so only the first, not-yet-expired
+// redemption attempt can ever match and succeed.
+func (s *Storage) ConsumePasswordResetToken(tokenID string) (string, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	filter := bson.M{
+		"token_hash": crypto.HashResetToken(tokenID),
+		"used_at":    nil,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+	var doc resetTokenDocument
+	err := s.resetTokens.FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.M{"used_at": time.Now()}}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return "", storage.NewStorageError(storage.ErrorUserNotFound, "ConsumePasswordResetToken", "no matching unexpired, unused token", "Password reset token is invalid or has expired.")
+	}
+	if err != nil {
+		return "", storage.NewStorageError(storage.ErrorQueryExecution, "ConsumePasswordResetToken", err.Error(), "Database error redeeming password reset token.")
+	}
+	return doc.EncryptedEmail, nil
+}
+
+// DeleteExpiredPasswordResetTokens removes every token past its expiry,
+// regardless of whether it was ever consumed.
+func (s *Storage) DeleteExpiredPasswordResetTokens() (int, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	result, err := s.resetTokens.DeleteMany(ctx, bson.M{"expires_at": bson.M{"$lte": time.Now()}})
+	if err != nil {
+		return 0, storage.NewStorageError(storage.ErrorQueryExecution, "DeleteExpiredPasswordResetTokens", err.Error(), "Database error sweeping expired password reset tokens.")
+	}
+	return int(result.DeletedCount), nil
+}
+
+// StoreIssuedCertificate persists a record of a freshly signed user certificate.
+func (s *Storage) StoreIssuedCertificate(cert types.IssuedCertificate) error {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	_, err := s.issuedCertificates.InsertOne(ctx, issuedCertificateDocument{
+		Serial:         cert.Serial,
+		EncryptedEmail: cert.EncryptedEmail,
+		NotAfter:       cert.NotAfter,
+		CreatedAt:      cert.CreatedAt,
+	})
+	if err != nil {
+		return storage.NewStorageError(storage.ErrorQueryExecution, "StoreIssuedCertificate", err.Error(), "Database error recording issued certificate.")
+	}
+	return nil
+}
+
+// GetCertificateBySerial retrieves an issued certificate's record by serial.
+func (s *Storage) GetCertificateBySerial(serial string) (*types.IssuedCertificate, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	var doc issuedCertificateDocument
+	err := s.issuedCertificates.FindOne(ctx, bson.M{"serial": serial}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, storage.NewStorageError(storage.ErrorQueryExecution, "GetCertificateBySerial", err.Error(), "Database error retrieving certificate record.")
+	}
+	cert := doc.toIssuedCertificate()
+	return &cert, nil
+}
+
+// RevokeCertificate marks an issued certificate as revoked. Idempotent: an
+// already-revoked document's revoked_at is left untouched by the filter.
+func (s *Storage) RevokeCertificate(serial string, reason int) error {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	result, err := s.issuedCertificates.UpdateOne(ctx,
+		bson.M{"serial": serial, "revoked": false},
+		bson.M{"$set": bson.M{"revoked": true, "revoked_at": time.Now(), "revocation_reason": reason}})
+	if err != nil {
+		return storage.NewStorageError(storage.ErrorQueryExecution, "RevokeCertificate", err.Error(), "Database error revoking certificate.")
+	}
+	if result.MatchedCount == 0 {
+		count, err := s.issuedCertificates.CountDocuments(ctx, bson.M{"serial": serial})
+		if err != nil {
+			return storage.NewStorageError(storage.ErrorQueryExecution, "RevokeCertificate", err.Error(), "Database error revoking certificate.")
+		}
+		if count == 0 {
+			return storage.NewStorageError(storage.ErrorUserNotFound, "RevokeCertificate", "no certificate with that serial", "No certificate found with that serial.")
+		}
+	}
+	return nil
+}
+
+// ListUsersByKeyPrefix returns up to limit non-deleted rows whose
+// EncryptedEmail is prefixed with keyID, after cursor in lexicographic order.
+func (s *Storage) ListUsersByKeyPrefix(keyID string, cursor string, limit int) ([]types.StoredUser, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	filter := bson.M{
+		"encrypted_email": bson.M{"$regex": "^" + keyID + ":", "$gt": cursor},
+		"deleted_at":      nil,
+	}
+	cur, err := s.users.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "encrypted_email", Value: 1}}).SetLimit(int64(limit)))
+	if err != nil {
+		return nil, storage.NewStorageError(storage.ErrorQueryExecution, "ListUsersByKeyPrefix", err.Error(), "Database error during rotation scan.")
+	}
+	defer cur.Close(ctx)
+
+	var users []types.StoredUser
+	for cur.Next(ctx) {
+		var doc document
+		if err := cur.Decode(&doc); err != nil {
+			return nil, storage.NewStorageError(storage.ErrorQueryExecution, "ListUsersByKeyPrefix", err.Error(), "Database error during rotation scan.")
+		}
+		users = append(users, doc.toStoredUser())
+	}
+	return users, cur.Err()
+}
+
+// UpdateEncryptedEmail compare-and-swaps EncryptedEmail from oldEncryptedEmail to newEncryptedEmail.
+func (s *Storage) UpdateEncryptedEmail(oldEncryptedEmail, newEncryptedEmail string) error {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	result, err := s.users.UpdateOne(ctx,
+		bson.M{"encrypted_email": oldEncryptedEmail},
+		bson.M{"$set": bson.M{"encrypted_email": newEncryptedEmail}})
+	if err != nil {
+		return storage.NewStorageError(storage.ErrorQueryExecution, "UpdateEncryptedEmail", err.Error(), "Database error during re-encryption.")
+	}
+	if result.MatchedCount == 0 {
+		return storage.NewStorageError(storage.ErrorUserNotFound, "UpdateEncryptedEmail", "row changed between read and write", "Row no longer matches expected state.")
+	}
+	return nil
+}
+
+// HealthCheck confirms the client can still reach the cluster.
+func (s *Storage) HealthCheck() (*storage.StorageHealth, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	start := time.Now()
+	err := s.client.Ping(ctx, nil)
+	health := &storage.StorageHealth{
+		Connected:       err == nil,
+		ResponseTime:    time.Since(start),
+		LastHealthCheck: time.Now(),
+	}
+	if err != nil {
+		return health, storage.NewStorageError(storage.ErrorDatabaseConnection, "HealthCheck", err.Error(), "Database service unavailable.")
+	}
+	return health, nil
+}
+
+// findOne returns the single document matching filter, or nil if none exists.
+func (s *Storage) findOne(ctx context.Context, filter bson.M) (*types.StoredUser, error) {
+	var doc document
+	err := s.users.FindOne(ctx, filter).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, storage.NewStorageError(storage.ErrorQueryExecution, "findOne", err.Error(), "Database error during lookup.")
+	}
+	user := doc.toStoredUser()
+	return &user, nil
+}
@@ -0,0 +1,38 @@
+package mongo
+
+import (
+	"context"
+	"database-vault/storage"
+	"database-vault/storage/storagetest"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestStorage runs the shared storagetest conformance suite against a live
+// Mongo cluster reachable at RAMUSB_TEST_MONGO_URI, e.g.
+// "mongodb://localhost:27017". Skipped entirely when that variable is unset,
+// since this package has no embedded Mongo of its own to stand one up with.
+func TestStorage(t *testing.T) {
+	uri := os.Getenv("RAMUSB_TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("RAMUSB_TEST_MONGO_URI not set; skipping Mongo storage conformance test")
+	}
+
+	storagetest.Run(t, func(t *testing.T) storage.UserStorage {
+		s, err := Open(storage.StorageConfig{
+			MongoURI:      uri,
+			MongoDatabase: fmt.Sprintf("ramusb_storagetest_%d", time.Now().UnixNano()),
+		})
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		t.Cleanup(func() {
+			impl := s.(*Storage)
+			impl.users.Database().Drop(context.Background())
+			impl.client.Disconnect(context.Background())
+		})
+		return s
+	})
+}
@@ -0,0 +1,529 @@
+/*
+PostgreSQL-backed storage.UserStorage, registered under driver name "postgres".
+
+Uses pgx's connection pool directly (no database/sql shim) for the
+production deployment target; storage/sqlite and storage/bolt cover
+zero-infrastructure dev/embedded use, storage/mongo covers document-store
+deployments.
+*/
+package postgres
+
+import (
+	"context"
+	"database-vault/crypto"
+	"database-vault/storage"
+	"database-vault/storage/driver"
+	"database-vault/types"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func init() {
+	driver.Register("postgres", Open)
+}
+
+// schema creates the users table this backend expects, if it doesn't
+// already exist - deleted_at supports DeleteUser's non-permanent path
+// without a separate tombstone table.
+const schema = `
+CREATE TABLE IF NOT EXISTS users (
+	encrypted_email         TEXT PRIMARY KEY,
+	email_blind_index       TEXT NOT NULL UNIQUE,
+	password_hash           TEXT NOT NULL,
+	ssh_public_key          TEXT NOT NULL UNIQUE,
+	created_at              TIMESTAMPTZ NOT NULL,
+	updated_at              TIMESTAMPTZ NOT NULL,
+	deleted_at              TIMESTAMPTZ,
+	failed_login_attempts   INT NOT NULL DEFAULT 0,
+	last_failed_login       TIMESTAMPTZ,
+	mfa_enabled             BOOLEAN NOT NULL DEFAULT false,
+	mfa_secret_encrypted    TEXT NOT NULL DEFAULT '',
+	mfa_scratch_code_hashes TEXT NOT NULL DEFAULT '[]',
+	mfa_last_accepted_counter BIGINT NOT NULL DEFAULT 0
+)`
+
+// resetTokenSchema creates the password_reset_tokens table, keyed by the
+// token hash itself rather than a separate surrogate id, since every lookup
+// in ConsumePasswordResetToken already has the hash in hand.
+const resetTokenSchema = `
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+	token_hash      TEXT PRIMARY KEY,
+	encrypted_email TEXT NOT NULL,
+	expires_at      TIMESTAMPTZ NOT NULL,
+	used_at         TIMESTAMPTZ,
+	created_at      TIMESTAMPTZ NOT NULL
+)`
+
+// issuedCertificateSchema creates the issued_certificates table, keyed by
+// the certificate serial itself since every lookup (RevokeCertificate, the
+// OCSP responder) already has the serial in hand.
+const issuedCertificateSchema = `
+CREATE TABLE IF NOT EXISTS issued_certificates (
+	serial            TEXT PRIMARY KEY,
+	encrypted_email   TEXT NOT NULL,
+	not_after         TIMESTAMPTZ NOT NULL,
+	revoked           BOOLEAN NOT NULL DEFAULT false,
+	revoked_at        TIMESTAMPTZ,
+	revocation_reason INT NOT NULL DEFAULT 0,
+	created_at        TIMESTAMPTZ NOT NULL
+)`
+
+// Storage is a storage.UserStorage backed by a pgx connection pool.
+//
+// Construct with Open.
+type Storage struct {
+	pool         *pgxpool.Pool
+	queryTimeout time.Duration
+}
+
+// Open connects to cfg.DatabaseURL, applies cfg.MaxConnections, and
+// ensures the users table exists. cfg.SSLMode is not read; TLS settings come
+// only from the sslmode parameter in cfg.DatabaseURL.
+//
+// Returns error if the connection string is invalid, the pool cannot be
+// created, or schema creation fails.
+func Open(cfg storage.StorageConfig) (storage.UserStorage, error) {
+	poolCfg, err := pgxpool.ParseConfig(cfg.DatabaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("postgres: invalid database_url: %v", err)
+	}
+	if cfg.MaxConnections > 0 {
+		poolCfg.MaxConns = int32(cfg.MaxConnections)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout(cfg))
+	defer cancel()
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
+	if err != nil {
+		return nil, fmt.Errorf("postgres: failed to create connection pool: %v", err)
+	}
+
+	if _, err := pool.Exec(ctx, schema); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("postgres: failed to create users table: %v", err)
+	}
+	if _, err := pool.Exec(ctx, resetTokenSchema); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("postgres: failed to create password_reset_tokens table: %v", err)
+	}
+	if _, err := pool.Exec(ctx, issuedCertificateSchema); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("postgres: failed to create issued_certificates table: %v", err)
+	}
+
+	return &Storage{pool: pool, queryTimeout: queryTimeout(cfg)}, nil
+}
+
+func connectTimeout(cfg storage.StorageConfig) time.Duration {
+	if cfg.ConnectionTimeout > 0 {
+		return cfg.ConnectionTimeout
+	}
+	return 10 * time.Second
+}
+
+func queryTimeout(cfg storage.StorageConfig) time.Duration {
+	if cfg.QueryTimeout > 0 {
+		return cfg.QueryTimeout
+	}
+	return 5 * time.Second
+}
+
+func (s *Storage) ctx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), s.queryTimeout)
+}
+
+// StoreUser inserts user, returning a storage.StorageError classifying a
+// unique-constraint violation on EncryptedEmail or SSHPubKey as the matching
+// ErrorUserExists/ErrorSSHKeyExists case.
+func (s *Storage) StoreUser(user types.StoredUser) error {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO users (encrypted_email, email_blind_index, password_hash, ssh_public_key, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		user.EncryptedEmail, user.EmailBlindIndex, user.PasswordHash, user.SSHPubKey, user.CreatedAt, user.UpdatedAt)
+	// failed_login_attempts/last_failed_login are left at their column
+	// defaults (0/NULL) on insert; only UpdateUser ever touches them
+	if err == nil {
+		return nil
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+		if pgErr.ConstraintName == "users_ssh_public_key_key" {
+			return storage.NewStorageError(storage.ErrorSSHKeyExists, "StoreUser", err.Error(), "SSH public key already in use.")
+		}
+		return storage.NewStorageError(storage.ErrorUserExists, "StoreUser", err.Error(), "Email address already registered.")
+	}
+	return storage.NewStorageError(storage.ErrorDatabaseConnection, "StoreUser", err.Error(), "Database error during registration.")
+}
+
+// GetUserByEncryptedEmail returns the matching, non-deleted row or nil.
+func (s *Storage) GetUserByEncryptedEmail(encryptedEmail string) (*types.StoredUser, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	return s.scanUser(ctx, `SELECT encrypted_email, email_blind_index, password_hash, ssh_public_key, created_at, updated_at, failed_login_attempts, last_failed_login, mfa_enabled, mfa_secret_encrypted, mfa_scratch_code_hashes, mfa_last_accepted_counter
+		FROM users WHERE encrypted_email = $1 AND deleted_at IS NULL`, encryptedEmail)
+}
+
+// EmailExists reports whether a non-deleted row with encryptedEmail exists.
+func (s *Storage) EmailExists(encryptedEmail string) (bool, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	var exists bool
+	err := s.pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE encrypted_email = $1 AND deleted_at IS NULL)`, encryptedEmail).Scan(&exists)
+	if err != nil {
+		return false, storage.NewStorageError(storage.ErrorDatabaseConnection, "EmailExists", err.Error(), "Database error during duplicate check.")
+	}
+	return exists, nil
+}
+
+// GetUserByEmailBlindIndex returns the matching, non-deleted row or nil.
+func (s *Storage) GetUserByEmailBlindIndex(blindIndex string) (*types.StoredUser, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	return s.scanUser(ctx, `SELECT encrypted_email, email_blind_index, password_hash, ssh_public_key, created_at, updated_at, failed_login_attempts, last_failed_login, mfa_enabled, mfa_secret_encrypted, mfa_scratch_code_hashes, mfa_last_accepted_counter
+		FROM users WHERE email_blind_index = $1 AND deleted_at IS NULL`, blindIndex)
+}
+
+// SSHKeyExists reports whether a non-deleted row with sshKey exists.
+func (s *Storage) SSHKeyExists(sshKey string) (bool, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	var exists bool
+	err := s.pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE ssh_public_key = $1 AND deleted_at IS NULL)`, sshKey).Scan(&exists)
+	if err != nil {
+		return false, storage.NewStorageError(storage.ErrorDatabaseConnection, "SSHKeyExists", err.Error(), "Database error during SSH key check.")
+	}
+	return exists, nil
+}
+
+// UpdateUser applies the non-nil fields in updates to the row identified by encryptedEmail.
+func (s *Storage) UpdateUser(encryptedEmail string, updates storage.UserUpdateRequest) error {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	if updates.NewSSHPubKey != nil {
+		var exists bool
+		if err := s.pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE ssh_public_key = $1 AND encrypted_email != $2 AND deleted_at IS NULL)`,
+			*updates.NewSSHPubKey, encryptedEmail).Scan(&exists); err != nil {
+			return storage.NewStorageError(storage.ErrorDatabaseConnection, "UpdateUser", err.Error(), "Database error during SSH key check.")
+		}
+		if exists {
+			return storage.NewStorageError(storage.ErrorSSHKeyExists, "UpdateUser", "ssh key already in use", "SSH public key already in use.")
+		}
+	}
+
+	var scratchCodeHashesJSON *string
+	if updates.NewMFAScratchCodeHashes != nil {
+		encoded, err := json.Marshal(*updates.NewMFAScratchCodeHashes)
+		if err != nil {
+			return storage.NewStorageError(storage.ErrorInvalidUserData, "UpdateUser", err.Error(), "Invalid MFA scratch code data.")
+		}
+		s := string(encoded)
+		scratchCodeHashesJSON = &s
+	}
+
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE users SET
+			password_hash             = COALESCE($1, password_hash),
+			ssh_public_key            = COALESCE($2, ssh_public_key),
+			failed_login_attempts     = COALESCE($3, failed_login_attempts),
+			last_failed_login         = COALESCE($4, last_failed_login),
+			mfa_enabled               = COALESCE($5, mfa_enabled),
+			mfa_secret_encrypted      = COALESCE($6, mfa_secret_encrypted),
+			mfa_scratch_code_hashes   = COALESCE($7, mfa_scratch_code_hashes),
+			mfa_last_accepted_counter = COALESCE($8, mfa_last_accepted_counter),
+			updated_at                = $9
+		 WHERE encrypted_email = $10 AND deleted_at IS NULL`,
+		updates.NewPasswordHash, updates.NewSSHPubKey, updates.NewFailedLoginAttempts, updates.NewLastFailedLogin,
+		updates.NewMFAEnabled, updates.NewMFASecretEncrypted, scratchCodeHashesJSON, updates.NewMFALastAcceptedCounter,
+		time.Now(), encryptedEmail)
+	if err != nil {
+		return storage.NewStorageError(storage.ErrorQueryExecution, "UpdateUser", err.Error(), "Database error during update.")
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.NewStorageError(storage.ErrorUserNotFound, "UpdateUser", "no matching row", "User not found.")
+	}
+	return nil
+}
+
+// DeleteUser soft-deletes by setting deleted_at, or permanently removes the row when permanent is true.
+func (s *Storage) DeleteUser(encryptedEmail string, permanent bool) error {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	var tag pgconn.CommandTag
+	var err error
+	if permanent {
+		tag, err = s.pool.Exec(ctx, `DELETE FROM users WHERE encrypted_email = $1`, encryptedEmail)
+	} else {
+		tag, err = s.pool.Exec(ctx, `UPDATE users SET deleted_at = $1 WHERE encrypted_email = $2 AND deleted_at IS NULL`, time.Now(), encryptedEmail)
+	}
+	if err != nil {
+		return storage.NewStorageError(storage.ErrorQueryExecution, "DeleteUser", err.Error(), "Database error during deletion.")
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.NewStorageError(storage.ErrorUserNotFound, "DeleteUser", "no matching row", "User not found.")
+	}
+	return nil
+}
+
+// GetUserStats returns aggregate counts across non-deleted rows.
+func (s *Storage) GetUserStats() (*storage.UserStats, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	stats := &storage.UserStats{}
+	err := s.pool.QueryRow(ctx, `
+		SELECT
+			COUNT(*) FILTER (WHERE deleted_at IS NULL),
+			COUNT(*) FILTER (WHERE deleted_at IS NULL AND created_at > NOW() - INTERVAL '1 day'),
+			COALESCE(MAX(created_at), to_timestamp(0))
+		FROM users`).Scan(&stats.TotalUsers, &stats.RegistrationsToday, &stats.LastRegistration)
+	if err != nil {
+		return nil, storage.NewStorageError(storage.ErrorQueryExecution, "GetUserStats", err.Error(), "Database error retrieving statistics.")
+	}
+	stats.ActiveUsers = stats.TotalUsers
+
+	err = s.pool.QueryRow(ctx, `SELECT COUNT(*) FROM password_reset_tokens WHERE used_at IS NULL AND expires_at > NOW()`).Scan(&stats.PendingResets)
+	if err != nil {
+		return nil, storage.NewStorageError(storage.ErrorQueryExecution, "GetUserStats", err.Error(), "Database error retrieving statistics.")
+	}
+	return stats, nil
+}
+
+// CreatePasswordResetToken generates and persists a new single-use token for
+// encryptedEmail, storing only crypto.HashResetToken(token).
+func (s *Storage) CreatePasswordResetToken(encryptedEmail string, ttl time.Duration) (string, error) {
+	token, err := crypto.GenerateResetToken()
+	if err != nil {
+		return "", storage.NewStorageError(storage.ErrorUnknown, "CreatePasswordResetToken", err.Error(), "Failed to issue password reset token.")
+	}
+
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	now := time.Now()
+	_, err = s.pool.Exec(ctx,
+		`INSERT INTO password_reset_tokens (token_hash, encrypted_email, expires_at, created_at)
+		 VALUES ($1, $2, $3, $4)`,
+		crypto.HashResetToken(token), encryptedEmail, now.Add(ttl), now)
+	if err != nil {
+		return "", storage.NewStorageError(storage.ErrorQueryExecution, "CreatePasswordResetToken", err.Error(), "Database error issuing password reset token.")
+	}
+	return token, nil
+}
+
+// ConsumePasswordResetToken redeems tokenID via an atomic UPDATE guarded by
+// used_at IS NULL and expires_at > NOW(), so only the first, not-yet-expired
+// redemption attempt can ever succeed.
+func (s *Storage) ConsumePasswordResetToken(tokenID string) (string, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	var encryptedEmail string
+	err := s.pool.QueryRow(ctx,
+		`UPDATE password_reset_tokens SET used_at = $1
+		 WHERE token_hash = $2 AND used_at IS NULL AND expires_at > $1
+		 RETURNING encrypted_email`,
+		time.Now(), crypto.HashResetToken(tokenID)).Scan(&encryptedEmail)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", storage.NewStorageError(storage.ErrorUserNotFound, "ConsumePasswordResetToken", "no matching unexpired, unused token", "Password reset token is invalid or has expired.")
+	}
+	if err != nil {
+		return "", storage.NewStorageError(storage.ErrorQueryExecution, "ConsumePasswordResetToken", err.Error(), "Database error redeeming password reset token.")
+	}
+	return encryptedEmail, nil
+}
+
+// DeleteExpiredPasswordResetTokens removes every token past its expiry,
+// regardless of whether it was ever consumed.
+func (s *Storage) DeleteExpiredPasswordResetTokens() (int, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	tag, err := s.pool.Exec(ctx, `DELETE FROM password_reset_tokens WHERE expires_at <= $1`, time.Now())
+	if err != nil {
+		return 0, storage.NewStorageError(storage.ErrorQueryExecution, "DeleteExpiredPasswordResetTokens", err.Error(), "Database error sweeping expired password reset tokens.")
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// StoreIssuedCertificate persists a record of a freshly signed user certificate.
+func (s *Storage) StoreIssuedCertificate(cert types.IssuedCertificate) error {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO issued_certificates (serial, encrypted_email, not_after, created_at)
+		 VALUES ($1, $2, $3, $4)`,
+		cert.Serial, cert.EncryptedEmail, cert.NotAfter, cert.CreatedAt)
+	if err != nil {
+		return storage.NewStorageError(storage.ErrorQueryExecution, "StoreIssuedCertificate", err.Error(), "Database error recording issued certificate.")
+	}
+	return nil
+}
+
+// GetCertificateBySerial retrieves an issued certificate's record by serial.
+func (s *Storage) GetCertificateBySerial(serial string) (*types.IssuedCertificate, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	var cert types.IssuedCertificate
+	var revokedAt *time.Time
+	err := s.pool.QueryRow(ctx,
+		`SELECT serial, encrypted_email, not_after, revoked, revoked_at, revocation_reason, created_at
+		 FROM issued_certificates WHERE serial = $1`, serial).
+		Scan(&cert.Serial, &cert.EncryptedEmail, &cert.NotAfter, &cert.Revoked, &revokedAt, &cert.RevocationReason, &cert.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, storage.NewStorageError(storage.ErrorQueryExecution, "GetCertificateBySerial", err.Error(), "Database error retrieving certificate record.")
+	}
+	cert.RevokedAt = revokedAt
+	return &cert, nil
+}
+
+// RevokeCertificate marks an issued certificate as revoked. Idempotent: an
+// already-revoked row's RevokedAt is left untouched by the WHERE clause.
+func (s *Storage) RevokeCertificate(serial string, reason int) error {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE issued_certificates SET revoked = true, revoked_at = $1, revocation_reason = $2
+		 WHERE serial = $3 AND revoked = false`,
+		time.Now(), reason, serial)
+	if err != nil {
+		return storage.NewStorageError(storage.ErrorQueryExecution, "RevokeCertificate", err.Error(), "Database error revoking certificate.")
+	}
+	if tag.RowsAffected() == 0 {
+		exists, err := s.certificateExists(ctx, serial)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return storage.NewStorageError(storage.ErrorUserNotFound, "RevokeCertificate", "no certificate with that serial", "No certificate found with that serial.")
+		}
+	}
+	return nil
+}
+
+// certificateExists distinguishes "already revoked" (no error) from "no
+// such serial" (ErrorUserNotFound) after a zero-row RevokeCertificate update.
+func (s *Storage) certificateExists(ctx context.Context, serial string) (bool, error) {
+	var exists bool
+	err := s.pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM issued_certificates WHERE serial = $1)`, serial).Scan(&exists)
+	if err != nil {
+		return false, storage.NewStorageError(storage.ErrorQueryExecution, "RevokeCertificate", err.Error(), "Database error revoking certificate.")
+	}
+	return exists, nil
+}
+
+// ListUsersByKeyPrefix returns up to limit non-deleted rows whose
+// EncryptedEmail is prefixed with keyID, after cursor in lexicographic order.
+func (s *Storage) ListUsersByKeyPrefix(keyID string, cursor string, limit int) ([]types.StoredUser, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT encrypted_email, email_blind_index, password_hash, ssh_public_key, created_at, updated_at, failed_login_attempts, last_failed_login, mfa_enabled, mfa_secret_encrypted, mfa_scratch_code_hashes, mfa_last_accepted_counter
+		FROM users
+		WHERE encrypted_email LIKE $1 AND encrypted_email > $2 AND deleted_at IS NULL
+		ORDER BY encrypted_email
+		LIMIT $3`, keyID+":%", cursor, limit)
+	if err != nil {
+		return nil, storage.NewStorageError(storage.ErrorQueryExecution, "ListUsersByKeyPrefix", err.Error(), "Database error during rotation scan.")
+	}
+	defer rows.Close()
+
+	var users []types.StoredUser
+	for rows.Next() {
+		u, err := scanUserRow(rows)
+		if err != nil {
+			return nil, storage.NewStorageError(storage.ErrorQueryExecution, "ListUsersByKeyPrefix", err.Error(), "Database error during rotation scan.")
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// UpdateEncryptedEmail compare-and-swaps EncryptedEmail from oldEncryptedEmail to newEncryptedEmail.
+func (s *Storage) UpdateEncryptedEmail(oldEncryptedEmail, newEncryptedEmail string) error {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	tag, err := s.pool.Exec(ctx, `UPDATE users SET encrypted_email = $1 WHERE encrypted_email = $2`, newEncryptedEmail, oldEncryptedEmail)
+	if err != nil {
+		return storage.NewStorageError(storage.ErrorQueryExecution, "UpdateEncryptedEmail", err.Error(), "Database error during re-encryption.")
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.NewStorageError(storage.ErrorUserNotFound, "UpdateEncryptedEmail", "row changed between read and write", "Row no longer matches expected state.")
+	}
+	return nil
+}
+
+// HealthCheck confirms the pool can still reach the database.
+func (s *Storage) HealthCheck() (*storage.StorageHealth, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	start := time.Now()
+	err := s.pool.Ping(ctx)
+	health := &storage.StorageHealth{
+		Connected:       err == nil,
+		ResponseTime:    time.Since(start),
+		ConnectionCount: int(s.pool.Stat().TotalConns()),
+		LastHealthCheck: time.Now(),
+	}
+	if err != nil {
+		return health, storage.NewStorageError(storage.ErrorDatabaseConnection, "HealthCheck", err.Error(), "Database service unavailable.")
+	}
+	return health, nil
+}
+
+// rowScanner is satisfied by both pgx.Row and pgx.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanUserRow scans one row in the column order every SELECT ... FROM users
+// query in this file uses, decoding mfa_scratch_code_hashes from its JSON text column.
+func scanUserRow(r rowScanner) (types.StoredUser, error) {
+	var u types.StoredUser
+	var scratchCodeHashesJSON string
+	if err := r.Scan(&u.EncryptedEmail, &u.EmailBlindIndex, &u.PasswordHash, &u.SSHPubKey, &u.CreatedAt, &u.UpdatedAt,
+		&u.FailedLoginAttempts, &u.LastFailedLogin, &u.MFAEnabled, &u.MFASecretEncrypted, &scratchCodeHashesJSON, &u.MFALastAcceptedCounter); err != nil {
+		return u, err
+	}
+	if err := json.Unmarshal([]byte(scratchCodeHashesJSON), &u.MFAScratchCodeHashes); err != nil {
+		return u, fmt.Errorf("invalid mfa_scratch_code_hashes JSON: %v", err)
+	}
+	return u, nil
+}
+
+// scanUser runs query with args and scans a single optional row into a types.StoredUser.
+func (s *Storage) scanUser(ctx context.Context, query string, args ...interface{}) (*types.StoredUser, error) {
+	u, err := scanUserRow(s.pool.QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, storage.NewStorageError(storage.ErrorQueryExecution, "scanUser", err.Error(), "Database error during lookup.")
+	}
+	return &u, nil
+}
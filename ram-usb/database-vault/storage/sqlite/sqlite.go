@@ -0,0 +1,559 @@
+/*
+SQLite-backed storage.UserStorage, registered under driver name "sqlite".
+
+Uses modernc.org/sqlite, a CGO-free SQLite driver, so a single-binary
+Database-Vault deployment (dev, CI, small single-node installs) needs no C
+toolchain - the same zero-CGO bar workloadid and the rest of this module
+already hold to.
+*/
+package sqlite
+
+import (
+	"context"
+	"database-vault/crypto"
+	"database-vault/storage"
+	"database-vault/storage/driver"
+	"database-vault/types"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func init() {
+	driver.Register("sqlite", Open)
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS users (
+	encrypted_email           TEXT PRIMARY KEY,
+	email_blind_index         TEXT NOT NULL UNIQUE,
+	password_hash             TEXT NOT NULL,
+	ssh_public_key            TEXT NOT NULL UNIQUE,
+	created_at                TEXT NOT NULL,
+	updated_at                TEXT NOT NULL,
+	deleted_at                TEXT,
+	failed_login_attempts     INTEGER NOT NULL DEFAULT 0,
+	last_failed_login         TEXT,
+	mfa_enabled               INTEGER NOT NULL DEFAULT 0,
+	mfa_secret_encrypted      TEXT NOT NULL DEFAULT '',
+	mfa_scratch_code_hashes   TEXT NOT NULL DEFAULT '[]',
+	mfa_last_accepted_counter INTEGER NOT NULL DEFAULT 0
+)`
+
+// resetTokenSchema creates the password_reset_tokens table, keyed by the
+// token hash itself since every lookup already has the hash in hand.
+const resetTokenSchema = `
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+	token_hash      TEXT PRIMARY KEY,
+	encrypted_email TEXT NOT NULL,
+	expires_at      TEXT NOT NULL,
+	used_at         TEXT,
+	created_at      TEXT NOT NULL
+)`
+
+// issuedCertificateSchema creates the issued_certificates table, keyed by
+// the certificate serial itself since every lookup already has the serial in hand.
+const issuedCertificateSchema = `
+CREATE TABLE IF NOT EXISTS issued_certificates (
+	serial            TEXT PRIMARY KEY,
+	encrypted_email   TEXT NOT NULL,
+	not_after         TEXT NOT NULL,
+	revoked           INTEGER NOT NULL DEFAULT 0,
+	revoked_at        TEXT,
+	revocation_reason INTEGER NOT NULL DEFAULT 0,
+	created_at        TEXT NOT NULL
+)`
+
+// Storage is a storage.UserStorage backed by a single SQLite file.
+//
+// Construct with Open.
+type Storage struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+// Open opens (creating if absent) the SQLite file at cfg.SQLitePath and
+// ensures the users table exists.
+//
+// Returns error if the file cannot be opened or schema creation fails.
+func Open(cfg storage.StorageConfig) (storage.UserStorage, error) {
+	if cfg.SQLitePath == "" {
+		return nil, fmt.Errorf("sqlite: storage.sqlite_path must not be empty")
+	}
+
+	db, err := sql.Open("sqlite", cfg.SQLitePath)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: failed to open %q: %v", cfg.SQLitePath, err)
+	}
+	db.SetMaxOpenConns(1) // modernc.org/sqlite serializes writers; avoid SQLITE_BUSY under concurrent access
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlite: failed to create users table: %v", err)
+	}
+	if _, err := db.Exec(resetTokenSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlite: failed to create password_reset_tokens table: %v", err)
+	}
+	if _, err := db.Exec(issuedCertificateSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlite: failed to create issued_certificates table: %v", err)
+	}
+
+	qt := cfg.QueryTimeout
+	if qt <= 0 {
+		qt = 5 * time.Second
+	}
+	return &Storage{db: db, queryTimeout: qt}, nil
+}
+
+func (s *Storage) ctx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), s.queryTimeout)
+}
+
+// StoreUser inserts user, classifying a UNIQUE constraint violation into the
+// matching ErrorUserExists/ErrorSSHKeyExists case.
+func (s *Storage) StoreUser(user types.StoredUser) error {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO users (encrypted_email, email_blind_index, password_hash, ssh_public_key, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		user.EncryptedEmail, user.EmailBlindIndex, user.PasswordHash, user.SSHPubKey,
+		user.CreatedAt.Format(time.RFC3339Nano), user.UpdatedAt.Format(time.RFC3339Nano))
+	if err == nil {
+		return nil
+	}
+
+	if isUniqueViolation(err) {
+		if strings.Contains(err.Error(), "ssh_public_key") {
+			return storage.NewStorageError(storage.ErrorSSHKeyExists, "StoreUser", err.Error(), "SSH public key already in use.")
+		}
+		return storage.NewStorageError(storage.ErrorUserExists, "StoreUser", err.Error(), "Email address already registered.")
+	}
+	return storage.NewStorageError(storage.ErrorDatabaseConnection, "StoreUser", err.Error(), "Database error during registration.")
+}
+
+// isUniqueViolation reports whether err is a SQLite UNIQUE constraint failure.
+func isUniqueViolation(err error) bool {
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+// GetUserByEncryptedEmail returns the matching, non-deleted row or nil.
+func (s *Storage) GetUserByEncryptedEmail(encryptedEmail string) (*types.StoredUser, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+	return s.scanUser(ctx, `SELECT encrypted_email, email_blind_index, password_hash, ssh_public_key, created_at, updated_at, failed_login_attempts, last_failed_login, mfa_enabled, mfa_secret_encrypted, mfa_scratch_code_hashes, mfa_last_accepted_counter
+		FROM users WHERE encrypted_email = ? AND deleted_at IS NULL`, encryptedEmail)
+}
+
+// EmailExists reports whether a non-deleted row with encryptedEmail exists.
+func (s *Storage) EmailExists(encryptedEmail string) (bool, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE encrypted_email = ? AND deleted_at IS NULL)`, encryptedEmail).Scan(&exists)
+	if err != nil {
+		return false, storage.NewStorageError(storage.ErrorDatabaseConnection, "EmailExists", err.Error(), "Database error during duplicate check.")
+	}
+	return exists, nil
+}
+
+// GetUserByEmailBlindIndex returns the matching, non-deleted row or nil.
+func (s *Storage) GetUserByEmailBlindIndex(blindIndex string) (*types.StoredUser, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+	return s.scanUser(ctx, `SELECT encrypted_email, email_blind_index, password_hash, ssh_public_key, created_at, updated_at, failed_login_attempts, last_failed_login, mfa_enabled, mfa_secret_encrypted, mfa_scratch_code_hashes, mfa_last_accepted_counter
+		FROM users WHERE email_blind_index = ? AND deleted_at IS NULL`, blindIndex)
+}
+
+// SSHKeyExists reports whether a non-deleted row with sshKey exists.
+func (s *Storage) SSHKeyExists(sshKey string) (bool, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE ssh_public_key = ? AND deleted_at IS NULL)`, sshKey).Scan(&exists)
+	if err != nil {
+		return false, storage.NewStorageError(storage.ErrorDatabaseConnection, "SSHKeyExists", err.Error(), "Database error during SSH key check.")
+	}
+	return exists, nil
+}
+
+// UpdateUser applies the non-nil fields in updates to the row identified by encryptedEmail.
+func (s *Storage) UpdateUser(encryptedEmail string, updates storage.UserUpdateRequest) error {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	existing, err := s.scanUser(ctx, `SELECT encrypted_email, email_blind_index, password_hash, ssh_public_key, created_at, updated_at, failed_login_attempts, last_failed_login, mfa_enabled, mfa_secret_encrypted, mfa_scratch_code_hashes, mfa_last_accepted_counter
+		FROM users WHERE encrypted_email = ? AND deleted_at IS NULL`, encryptedEmail)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return storage.NewStorageError(storage.ErrorUserNotFound, "UpdateUser", "no matching row", "User not found.")
+	}
+
+	passwordHash := existing.PasswordHash
+	if updates.NewPasswordHash != nil {
+		passwordHash = *updates.NewPasswordHash
+	}
+	sshPubKey := existing.SSHPubKey
+	if updates.NewSSHPubKey != nil {
+		if exists, err := s.SSHKeyExists(*updates.NewSSHPubKey); err != nil {
+			return err
+		} else if exists && *updates.NewSSHPubKey != existing.SSHPubKey {
+			return storage.NewStorageError(storage.ErrorSSHKeyExists, "UpdateUser", "ssh key already in use", "SSH public key already in use.")
+		}
+		sshPubKey = *updates.NewSSHPubKey
+	}
+
+	failedLoginAttempts := existing.FailedLoginAttempts
+	if updates.NewFailedLoginAttempts != nil {
+		failedLoginAttempts = *updates.NewFailedLoginAttempts
+	}
+	var lastFailedLogin interface{}
+	if updates.NewLastFailedLogin != nil {
+		lastFailedLogin = updates.NewLastFailedLogin.Format(time.RFC3339Nano)
+	} else if existing.LastFailedLogin != nil {
+		lastFailedLogin = existing.LastFailedLogin.Format(time.RFC3339Nano)
+	}
+
+	mfaEnabled := existing.MFAEnabled
+	if updates.NewMFAEnabled != nil {
+		mfaEnabled = *updates.NewMFAEnabled
+	}
+	mfaSecretEncrypted := existing.MFASecretEncrypted
+	if updates.NewMFASecretEncrypted != nil {
+		mfaSecretEncrypted = *updates.NewMFASecretEncrypted
+	}
+	mfaScratchCodeHashes := existing.MFAScratchCodeHashes
+	if updates.NewMFAScratchCodeHashes != nil {
+		mfaScratchCodeHashes = *updates.NewMFAScratchCodeHashes
+	}
+	scratchCodeHashesJSON, err := json.Marshal(mfaScratchCodeHashes)
+	if err != nil {
+		return storage.NewStorageError(storage.ErrorInvalidUserData, "UpdateUser", err.Error(), "Invalid MFA scratch code data.")
+	}
+	mfaLastAcceptedCounter := existing.MFALastAcceptedCounter
+	if updates.NewMFALastAcceptedCounter != nil {
+		mfaLastAcceptedCounter = *updates.NewMFALastAcceptedCounter
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		`UPDATE users SET password_hash = ?, ssh_public_key = ?, failed_login_attempts = ?, last_failed_login = ?,
+			mfa_enabled = ?, mfa_secret_encrypted = ?, mfa_scratch_code_hashes = ?, mfa_last_accepted_counter = ?, updated_at = ?
+		 WHERE encrypted_email = ?`,
+		passwordHash, sshPubKey, failedLoginAttempts, lastFailedLogin,
+		mfaEnabled, mfaSecretEncrypted, string(scratchCodeHashesJSON), mfaLastAcceptedCounter, time.Now().Format(time.RFC3339Nano), encryptedEmail)
+	if err != nil {
+		return storage.NewStorageError(storage.ErrorQueryExecution, "UpdateUser", err.Error(), "Database error during update.")
+	}
+	return nil
+}
+
+// DeleteUser soft-deletes by setting deleted_at, or permanently removes the row when permanent is true.
+func (s *Storage) DeleteUser(encryptedEmail string, permanent bool) error {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	var result sql.Result
+	var err error
+	if permanent {
+		result, err = s.db.ExecContext(ctx, `DELETE FROM users WHERE encrypted_email = ?`, encryptedEmail)
+	} else {
+		result, err = s.db.ExecContext(ctx, `UPDATE users SET deleted_at = ? WHERE encrypted_email = ? AND deleted_at IS NULL`,
+			time.Now().Format(time.RFC3339Nano), encryptedEmail)
+	}
+	if err != nil {
+		return storage.NewStorageError(storage.ErrorQueryExecution, "DeleteUser", err.Error(), "Database error during deletion.")
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return storage.NewStorageError(storage.ErrorUserNotFound, "DeleteUser", "no matching row", "User not found.")
+	}
+	return nil
+}
+
+// GetUserStats returns aggregate counts across non-deleted rows.
+func (s *Storage) GetUserStats() (*storage.UserStats, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	stats := &storage.UserStats{}
+	var lastRegistration string
+	cutoff := time.Now().Add(-24 * time.Hour).Format(time.RFC3339Nano)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT
+			COUNT(*),
+			(SELECT COUNT(*) FROM users WHERE deleted_at IS NULL AND created_at > ?),
+			COALESCE(MAX(created_at), '')
+		FROM users WHERE deleted_at IS NULL`, cutoff).Scan(&stats.TotalUsers, &stats.RegistrationsToday, &lastRegistration)
+	if err != nil {
+		return nil, storage.NewStorageError(storage.ErrorQueryExecution, "GetUserStats", err.Error(), "Database error retrieving statistics.")
+	}
+	if lastRegistration != "" {
+		stats.LastRegistration, _ = time.Parse(time.RFC3339Nano, lastRegistration)
+	}
+	stats.ActiveUsers = stats.TotalUsers
+
+	nowStr := time.Now().Format(time.RFC3339Nano)
+	err = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM password_reset_tokens WHERE used_at IS NULL AND expires_at > ?`, nowStr).Scan(&stats.PendingResets)
+	if err != nil {
+		return nil, storage.NewStorageError(storage.ErrorQueryExecution, "GetUserStats", err.Error(), "Database error retrieving statistics.")
+	}
+	return stats, nil
+}
+
+// CreatePasswordResetToken generates and persists a new single-use token for
+// encryptedEmail, storing only crypto.HashResetToken(token).
+func (s *Storage) CreatePasswordResetToken(encryptedEmail string, ttl time.Duration) (string, error) {
+	token, err := crypto.GenerateResetToken()
+	if err != nil {
+		return "", storage.NewStorageError(storage.ErrorUnknown, "CreatePasswordResetToken", err.Error(), "Failed to issue password reset token.")
+	}
+
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	now := time.Now()
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO password_reset_tokens (token_hash, encrypted_email, expires_at, created_at) VALUES (?, ?, ?, ?)`,
+		crypto.HashResetToken(token), encryptedEmail, now.Add(ttl).Format(time.RFC3339Nano), now.Format(time.RFC3339Nano))
+	if err != nil {
+		return "", storage.NewStorageError(storage.ErrorQueryExecution, "CreatePasswordResetToken", err.Error(), "Database error issuing password reset token.")
+	}
+	return token, nil
+}
+
+// ConsumePasswordResetToken redeems tokenID, checking used_at/expires_at
+// itself before the UPDATE since modernc.org/sqlite's driver has no
+// UPDATE...RETURNING support in the version this module pins.
+func (s *Storage) ConsumePasswordResetToken(tokenID string) (string, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	tokenHash := crypto.HashResetToken(tokenID)
+	nowStr := time.Now().Format(time.RFC3339Nano)
+
+	var encryptedEmail string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT encrypted_email FROM password_reset_tokens WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?`,
+		tokenHash, nowStr).Scan(&encryptedEmail)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", storage.NewStorageError(storage.ErrorUserNotFound, "ConsumePasswordResetToken", "no matching unexpired, unused token", "Password reset token is invalid or has expired.")
+	}
+	if err != nil {
+		return "", storage.NewStorageError(storage.ErrorQueryExecution, "ConsumePasswordResetToken", err.Error(), "Database error redeeming password reset token.")
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE password_reset_tokens SET used_at = ? WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?`,
+		nowStr, tokenHash, nowStr)
+	if err != nil {
+		return "", storage.NewStorageError(storage.ErrorQueryExecution, "ConsumePasswordResetToken", err.Error(), "Database error redeeming password reset token.")
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		// Lost the race to a concurrent redemption between the SELECT and this UPDATE.
+		return "", storage.NewStorageError(storage.ErrorUserNotFound, "ConsumePasswordResetToken", "token consumed concurrently", "Password reset token is invalid or has expired.")
+	}
+	return encryptedEmail, nil
+}
+
+// DeleteExpiredPasswordResetTokens removes every token past its expiry,
+// regardless of whether it was ever consumed.
+func (s *Storage) DeleteExpiredPasswordResetTokens() (int, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM password_reset_tokens WHERE expires_at <= ?`, time.Now().Format(time.RFC3339Nano))
+	if err != nil {
+		return 0, storage.NewStorageError(storage.ErrorQueryExecution, "DeleteExpiredPasswordResetTokens", err.Error(), "Database error sweeping expired password reset tokens.")
+	}
+	n, _ := result.RowsAffected()
+	return int(n), nil
+}
+
+// StoreIssuedCertificate persists a record of a freshly signed user certificate.
+func (s *Storage) StoreIssuedCertificate(cert types.IssuedCertificate) error {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO issued_certificates (serial, encrypted_email, not_after, created_at) VALUES (?, ?, ?, ?)`,
+		cert.Serial, cert.EncryptedEmail, cert.NotAfter.Format(time.RFC3339Nano), cert.CreatedAt.Format(time.RFC3339Nano))
+	if err != nil {
+		return storage.NewStorageError(storage.ErrorQueryExecution, "StoreIssuedCertificate", err.Error(), "Database error recording issued certificate.")
+	}
+	return nil
+}
+
+// GetCertificateBySerial retrieves an issued certificate's record by serial.
+func (s *Storage) GetCertificateBySerial(serial string) (*types.IssuedCertificate, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	var cert types.IssuedCertificate
+	var notAfterStr, createdAtStr string
+	var revokedAtStr sql.NullString
+	var revokedInt int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT serial, encrypted_email, not_after, revoked, revoked_at, revocation_reason, created_at
+		 FROM issued_certificates WHERE serial = ?`, serial).
+		Scan(&cert.Serial, &cert.EncryptedEmail, &notAfterStr, &revokedInt, &revokedAtStr, &cert.RevocationReason, &createdAtStr)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, storage.NewStorageError(storage.ErrorQueryExecution, "GetCertificateBySerial", err.Error(), "Database error retrieving certificate record.")
+	}
+
+	cert.NotAfter, err = time.Parse(time.RFC3339Nano, notAfterStr)
+	if err != nil {
+		return nil, storage.NewStorageError(storage.ErrorQueryExecution, "GetCertificateBySerial", err.Error(), "Database error retrieving certificate record.")
+	}
+	cert.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAtStr)
+	if err != nil {
+		return nil, storage.NewStorageError(storage.ErrorQueryExecution, "GetCertificateBySerial", err.Error(), "Database error retrieving certificate record.")
+	}
+	cert.Revoked = revokedInt != 0
+	if revokedAtStr.Valid {
+		revokedAt, err := time.Parse(time.RFC3339Nano, revokedAtStr.String)
+		if err != nil {
+			return nil, storage.NewStorageError(storage.ErrorQueryExecution, "GetCertificateBySerial", err.Error(), "Database error retrieving certificate record.")
+		}
+		cert.RevokedAt = &revokedAt
+	}
+	return &cert, nil
+}
+
+// RevokeCertificate marks an issued certificate as revoked. Idempotent: an
+// already-revoked row's revoked_at is left untouched by the WHERE clause.
+func (s *Storage) RevokeCertificate(serial string, reason int) error {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE issued_certificates SET revoked = 1, revoked_at = ?, revocation_reason = ?
+		 WHERE serial = ? AND revoked = 0`,
+		time.Now().Format(time.RFC3339Nano), reason, serial)
+	if err != nil {
+		return storage.NewStorageError(storage.ErrorQueryExecution, "RevokeCertificate", err.Error(), "Database error revoking certificate.")
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		var exists bool
+		if err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM issued_certificates WHERE serial = ?)`, serial).Scan(&exists); err != nil {
+			return storage.NewStorageError(storage.ErrorQueryExecution, "RevokeCertificate", err.Error(), "Database error revoking certificate.")
+		}
+		if !exists {
+			return storage.NewStorageError(storage.ErrorUserNotFound, "RevokeCertificate", "no certificate with that serial", "No certificate found with that serial.")
+		}
+	}
+	return nil
+}
+
+// ListUsersByKeyPrefix returns up to limit non-deleted rows whose
+// EncryptedEmail is prefixed with keyID, after cursor in lexicographic order.
+func (s *Storage) ListUsersByKeyPrefix(keyID string, cursor string, limit int) ([]types.StoredUser, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT encrypted_email, email_blind_index, password_hash, ssh_public_key, created_at, updated_at, failed_login_attempts, last_failed_login, mfa_enabled, mfa_secret_encrypted, mfa_scratch_code_hashes, mfa_last_accepted_counter
+		FROM users
+		WHERE encrypted_email LIKE ? AND encrypted_email > ? AND deleted_at IS NULL
+		ORDER BY encrypted_email
+		LIMIT ?`, keyID+":%", cursor, limit)
+	if err != nil {
+		return nil, storage.NewStorageError(storage.ErrorQueryExecution, "ListUsersByKeyPrefix", err.Error(), "Database error during rotation scan.")
+	}
+	defer rows.Close()
+
+	var users []types.StoredUser
+	for rows.Next() {
+		u, err := scanRow(rows)
+		if err != nil {
+			return nil, storage.NewStorageError(storage.ErrorQueryExecution, "ListUsersByKeyPrefix", err.Error(), "Database error during rotation scan.")
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// UpdateEncryptedEmail compare-and-swaps EncryptedEmail from oldEncryptedEmail to newEncryptedEmail.
+func (s *Storage) UpdateEncryptedEmail(oldEncryptedEmail, newEncryptedEmail string) error {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, `UPDATE users SET encrypted_email = ? WHERE encrypted_email = ?`, newEncryptedEmail, oldEncryptedEmail)
+	if err != nil {
+		return storage.NewStorageError(storage.ErrorQueryExecution, "UpdateEncryptedEmail", err.Error(), "Database error during re-encryption.")
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return storage.NewStorageError(storage.ErrorUserNotFound, "UpdateEncryptedEmail", "row changed between read and write", "Row no longer matches expected state.")
+	}
+	return nil
+}
+
+// HealthCheck confirms the database file is still reachable.
+func (s *Storage) HealthCheck() (*storage.StorageHealth, error) {
+	ctx, cancel := s.ctx()
+	defer cancel()
+
+	start := time.Now()
+	err := s.db.PingContext(ctx)
+	health := &storage.StorageHealth{
+		Connected:       err == nil,
+		ResponseTime:    time.Since(start),
+		ConnectionCount: 1,
+		LastHealthCheck: time.Now(),
+	}
+	if err != nil {
+		return health, storage.NewStorageError(storage.ErrorDatabaseConnection, "HealthCheck", err.Error(), "Database service unavailable.")
+	}
+	return health, nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRow(r rowScanner) (types.StoredUser, error) {
+	var u types.StoredUser
+	var createdAt, updatedAt string
+	var lastFailedLogin sql.NullString
+	var scratchCodeHashesJSON string
+	if err := r.Scan(&u.EncryptedEmail, &u.EmailBlindIndex, &u.PasswordHash, &u.SSHPubKey, &createdAt, &updatedAt,
+		&u.FailedLoginAttempts, &lastFailedLogin, &u.MFAEnabled, &u.MFASecretEncrypted, &scratchCodeHashesJSON, &u.MFALastAcceptedCounter); err != nil {
+		return u, err
+	}
+	u.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+	u.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updatedAt)
+	if lastFailedLogin.Valid {
+		t, _ := time.Parse(time.RFC3339Nano, lastFailedLogin.String)
+		u.LastFailedLogin = &t
+	}
+	if err := json.Unmarshal([]byte(scratchCodeHashesJSON), &u.MFAScratchCodeHashes); err != nil {
+		return u, fmt.Errorf("invalid mfa_scratch_code_hashes JSON: %v", err)
+	}
+	return u, nil
+}
+
+// scanUser runs query with args and scans a single optional row into a types.StoredUser.
+func (s *Storage) scanUser(ctx context.Context, query string, args ...interface{}) (*types.StoredUser, error) {
+	u, err := scanRow(s.db.QueryRowContext(ctx, query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, storage.NewStorageError(storage.ErrorQueryExecution, "scanUser", err.Error(), "Database error during lookup.")
+	}
+	return &u, nil
+}
@@ -0,0 +1,19 @@
+package sqlite
+
+import (
+	"database-vault/storage"
+	"database-vault/storage/storagetest"
+	"path/filepath"
+	"testing"
+)
+
+func TestStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.UserStorage {
+		s, err := Open(storage.StorageConfig{SQLitePath: filepath.Join(t.TempDir(), "sqlite.db")})
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		t.Cleanup(func() { s.(*Storage).db.Close() })
+		return s
+	})
+}
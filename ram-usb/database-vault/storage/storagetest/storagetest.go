@@ -0,0 +1,257 @@
+/*
+Shared storage.UserStorage conformance suite for Database-Vault's storage backends.
+
+Run exercises the core user-lifecycle contract - StoreUser,
+GetUserByEncryptedEmail, EmailExists, SSHKeyExists, UpdateUser, and
+DeleteUser, including duplicate detection and soft-delete - against whatever
+storage.UserStorage a backend package's test constructs, so postgres,
+sqlite, bolt, and mongo all prove the same behavior instead of each backend
+package hand-rolling its own variant of the same assertions.
+*/
+package storagetest
+
+import (
+	"database-vault/storage"
+	"database-vault/types"
+	"testing"
+	"time"
+)
+
+// Run exercises the conformance suite against the backend newStorage
+// constructs. newStorage is called once per subtest so each subtest starts
+// from a fresh, isolated storage instance; it is responsible for its own
+// cleanup (e.g. via t.Cleanup), typically through t.TempDir() or an
+// env-configured connection a backend's test skips without.
+func Run(t *testing.T, newStorage func(t *testing.T) storage.UserStorage) {
+	t.Run("StoreAndRetrieve", func(t *testing.T) { testStoreAndRetrieve(t, newStorage(t)) })
+	t.Run("DuplicateEmail", func(t *testing.T) { testDuplicateEmail(t, newStorage(t)) })
+	t.Run("DuplicateSSHKey", func(t *testing.T) { testDuplicateSSHKey(t, newStorage(t)) })
+	t.Run("EmailExists", func(t *testing.T) { testEmailExists(t, newStorage(t)) })
+	t.Run("SSHKeyExists", func(t *testing.T) { testSSHKeyExists(t, newStorage(t)) })
+	t.Run("UpdateUser", func(t *testing.T) { testUpdateUser(t, newStorage(t)) })
+	t.Run("SoftDelete", func(t *testing.T) { testSoftDelete(t, newStorage(t)) })
+	t.Run("PermanentDelete", func(t *testing.T) { testPermanentDelete(t, newStorage(t)) })
+}
+
+// testUser builds a StoredUser fixture whose encrypted email, blind index,
+// and SSH key are all derived from suffix, so callers can get distinct,
+// non-colliding rows within the same storage instance just by varying it.
+func testUser(suffix string) types.StoredUser {
+	now := time.Now()
+	return types.StoredUser{
+		EncryptedEmail:  "encrypted-email-" + suffix,
+		EmailBlindIndex: "blind-index-" + suffix,
+		PasswordHash:    "$argon2id$v=19$m=65536,t=1,p=4$c29tZXNhbHQ$aGFzaA",
+		SSHPubKey:       "ssh-ed25519 AAAA" + suffix + " test@storagetest",
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+}
+
+func testStoreAndRetrieve(t *testing.T, s storage.UserStorage) {
+	user := testUser("store-and-retrieve")
+	if err := s.StoreUser(user); err != nil {
+		t.Fatalf("StoreUser: %v", err)
+	}
+
+	byEmail, err := s.GetUserByEncryptedEmail(user.EncryptedEmail)
+	if err != nil {
+		t.Fatalf("GetUserByEncryptedEmail: %v", err)
+	}
+	if byEmail == nil {
+		t.Fatal("GetUserByEncryptedEmail: got nil, want the stored user")
+	}
+	if byEmail.EmailBlindIndex != user.EmailBlindIndex || byEmail.PasswordHash != user.PasswordHash || byEmail.SSHPubKey != user.SSHPubKey {
+		t.Fatalf("GetUserByEncryptedEmail: got %+v, want fields matching %+v", byEmail, user)
+	}
+
+	byBlindIndex, err := s.GetUserByEmailBlindIndex(user.EmailBlindIndex)
+	if err != nil {
+		t.Fatalf("GetUserByEmailBlindIndex: %v", err)
+	}
+	if byBlindIndex == nil || byBlindIndex.EncryptedEmail != user.EncryptedEmail {
+		t.Fatalf("GetUserByEmailBlindIndex: got %+v, want a user with EncryptedEmail %q", byBlindIndex, user.EncryptedEmail)
+	}
+
+	missing, err := s.GetUserByEncryptedEmail("no-such-encrypted-email")
+	if err != nil {
+		t.Fatalf("GetUserByEncryptedEmail(missing): %v", err)
+	}
+	if missing != nil {
+		t.Fatalf("GetUserByEncryptedEmail(missing): got %+v, want nil", missing)
+	}
+}
+
+func testDuplicateEmail(t *testing.T, s storage.UserStorage) {
+	user := testUser("duplicate-email")
+	if err := s.StoreUser(user); err != nil {
+		t.Fatalf("StoreUser: %v", err)
+	}
+
+	dup := testUser("duplicate-email")
+	dup.SSHPubKey = "ssh-ed25519 AAAAdifferent-key test@storagetest"
+	err := s.StoreUser(dup)
+	if err == nil {
+		t.Fatal("StoreUser with a re-used EncryptedEmail: got nil error, want ErrorUserExists")
+	}
+	storageErr, ok := err.(*storage.StorageError)
+	if !ok || storageErr.Type != storage.ErrorUserExists {
+		t.Fatalf("StoreUser with a re-used EncryptedEmail: got %v, want a *storage.StorageError with Type ErrorUserExists", err)
+	}
+}
+
+func testDuplicateSSHKey(t *testing.T, s storage.UserStorage) {
+	user := testUser("duplicate-ssh-key")
+	if err := s.StoreUser(user); err != nil {
+		t.Fatalf("StoreUser: %v", err)
+	}
+
+	dup := testUser("duplicate-ssh-key-2")
+	dup.SSHPubKey = user.SSHPubKey
+	err := s.StoreUser(dup)
+	if err == nil {
+		t.Fatal("StoreUser with a re-used SSHPubKey: got nil error, want ErrorSSHKeyExists")
+	}
+	storageErr, ok := err.(*storage.StorageError)
+	if !ok || storageErr.Type != storage.ErrorSSHKeyExists {
+		t.Fatalf("StoreUser with a re-used SSHPubKey: got %v, want a *storage.StorageError with Type ErrorSSHKeyExists", err)
+	}
+}
+
+func testEmailExists(t *testing.T, s storage.UserStorage) {
+	user := testUser("email-exists")
+	if exists, err := s.EmailExists(user.EncryptedEmail); err != nil {
+		t.Fatalf("EmailExists(before StoreUser): %v", err)
+	} else if exists {
+		t.Fatal("EmailExists(before StoreUser): got true, want false")
+	}
+
+	if err := s.StoreUser(user); err != nil {
+		t.Fatalf("StoreUser: %v", err)
+	}
+
+	if exists, err := s.EmailExists(user.EncryptedEmail); err != nil {
+		t.Fatalf("EmailExists(after StoreUser): %v", err)
+	} else if !exists {
+		t.Fatal("EmailExists(after StoreUser): got false, want true")
+	}
+}
+
+func testSSHKeyExists(t *testing.T, s storage.UserStorage) {
+	user := testUser("ssh-key-exists")
+	if exists, err := s.SSHKeyExists(user.SSHPubKey); err != nil {
+		t.Fatalf("SSHKeyExists(before StoreUser): %v", err)
+	} else if exists {
+		t.Fatal("SSHKeyExists(before StoreUser): got true, want false")
+	}
+
+	if err := s.StoreUser(user); err != nil {
+		t.Fatalf("StoreUser: %v", err)
+	}
+
+	if exists, err := s.SSHKeyExists(user.SSHPubKey); err != nil {
+		t.Fatalf("SSHKeyExists(after StoreUser): %v", err)
+	} else if !exists {
+		t.Fatal("SSHKeyExists(after StoreUser): got false, want true")
+	}
+}
+
+func testUpdateUser(t *testing.T, s storage.UserStorage) {
+	user := testUser("update-user")
+	if err := s.StoreUser(user); err != nil {
+		t.Fatalf("StoreUser: %v", err)
+	}
+
+	newHash := "$argon2id$v=19$m=65536,t=1,p=4$c29tZXNhbHQ$dXBkYXRlZA"
+	attempts := 3
+	if err := s.UpdateUser(user.EncryptedEmail, storage.UserUpdateRequest{
+		NewPasswordHash:        &newHash,
+		NewFailedLoginAttempts: &attempts,
+	}); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+
+	updated, err := s.GetUserByEncryptedEmail(user.EncryptedEmail)
+	if err != nil {
+		t.Fatalf("GetUserByEncryptedEmail: %v", err)
+	}
+	if updated == nil {
+		t.Fatal("GetUserByEncryptedEmail: got nil after UpdateUser, want the updated row")
+	}
+	if updated.PasswordHash != newHash {
+		t.Fatalf("PasswordHash after UpdateUser: got %q, want %q", updated.PasswordHash, newHash)
+	}
+	if updated.FailedLoginAttempts != attempts {
+		t.Fatalf("FailedLoginAttempts after UpdateUser: got %d, want %d", updated.FailedLoginAttempts, attempts)
+	}
+
+	err = s.UpdateUser("no-such-encrypted-email", storage.UserUpdateRequest{NewPasswordHash: &newHash})
+	if err == nil {
+		t.Fatal("UpdateUser(missing row): got nil error, want ErrorUserNotFound")
+	}
+	storageErr, ok := err.(*storage.StorageError)
+	if !ok || storageErr.Type != storage.ErrorUserNotFound {
+		t.Fatalf("UpdateUser(missing row): got %v, want a *storage.StorageError with Type ErrorUserNotFound", err)
+	}
+}
+
+func testSoftDelete(t *testing.T, s storage.UserStorage) {
+	user := testUser("soft-delete")
+	if err := s.StoreUser(user); err != nil {
+		t.Fatalf("StoreUser: %v", err)
+	}
+
+	if err := s.DeleteUser(user.EncryptedEmail, false); err != nil {
+		t.Fatalf("DeleteUser(permanent=false): %v", err)
+	}
+
+	found, err := s.GetUserByEncryptedEmail(user.EncryptedEmail)
+	if err != nil {
+		t.Fatalf("GetUserByEncryptedEmail(after soft delete): %v", err)
+	}
+	if found != nil {
+		t.Fatalf("GetUserByEncryptedEmail(after soft delete): got %+v, want nil", found)
+	}
+
+	if exists, err := s.EmailExists(user.EncryptedEmail); err != nil {
+		t.Fatalf("EmailExists(after soft delete): %v", err)
+	} else if exists {
+		t.Fatal("EmailExists(after soft delete): got true, want false")
+	}
+
+	// A second soft delete finds no non-deleted row left to delete.
+	err = s.DeleteUser(user.EncryptedEmail, false)
+	if err == nil {
+		t.Fatal("DeleteUser(already soft-deleted): got nil error, want ErrorUserNotFound")
+	}
+	storageErr, ok := err.(*storage.StorageError)
+	if !ok || storageErr.Type != storage.ErrorUserNotFound {
+		t.Fatalf("DeleteUser(already soft-deleted): got %v, want a *storage.StorageError with Type ErrorUserNotFound", err)
+	}
+}
+
+func testPermanentDelete(t *testing.T, s storage.UserStorage) {
+	user := testUser("permanent-delete")
+	if err := s.StoreUser(user); err != nil {
+		t.Fatalf("StoreUser: %v", err)
+	}
+
+	if err := s.DeleteUser(user.EncryptedEmail, true); err != nil {
+		t.Fatalf("DeleteUser(permanent=true): %v", err)
+	}
+
+	found, err := s.GetUserByEncryptedEmail(user.EncryptedEmail)
+	if err != nil {
+		t.Fatalf("GetUserByEncryptedEmail(after permanent delete): %v", err)
+	}
+	if found != nil {
+		t.Fatalf("GetUserByEncryptedEmail(after permanent delete): got %+v, want nil", found)
+	}
+
+	// The SSH key and email are free again since the row is gone outright,
+	// unlike a soft delete which only clears it from non-deleted lookups.
+	reregistered := testUser("permanent-delete")
+	if err := s.StoreUser(reregistered); err != nil {
+		t.Fatalf("StoreUser after permanent delete freed the row: %v", err)
+	}
+}
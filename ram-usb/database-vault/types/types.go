@@ -6,8 +6,6 @@ secure user credential storage with Argon2id hashing, and standardized
 response formatting. Ensures consistent data handling, validation, and
 JSON serialization across the final storage layer of the R.A.M.-U.S.B.
 distributed authentication system.
-
-TO-DO in LoginRequest: not implemented
 */
 package types
 
@@ -27,26 +25,36 @@ type RegisterRequest struct {
 	Email     string `json:"email"`          // User email address for account identification
 	Password  string `json:"password"`       // Plain password for secure Argon2id hashing
 	SSHPubKey string `json:"ssh_public_key"` // SSH public key for storage service authentication
+	CertMode  bool   `json:"cert_mode"`      // If true, SSHPubKey is signed into a short-lived certificate instead of stored as-is (see ramusb/sshca)
 }
 
 // StoredUser represents complete user record for secure database persistence.
 //
 // Security features:
 // - AES-256-GCM encrypted email serves as primary key preventing email enumeration
-// - Argon2id password hash with cryptographically secure salt prevents rainbow table attacks
-// - Salt separation ensures unique hash even for identical passwords across users
+// - EmailBlindIndex is an HMAC-SHA256 of the normalized email (see crypto.FieldCipher), giving an indexable lookup column now that EncryptedEmail is sealed with a random nonce and no longer comparable across rows
+// - Argon2id PHC-encoded hash carries its own salt and cost parameters, preventing rainbow table attacks and allowing in-place parameter upgrades (see crypto.VerifyPassword)
 // - SSH public key storage enables zero-knowledge file access authentication
 // - Timestamp tracking for security auditing and account lifecycle management
 // - No plaintext email or password storage maintains zero-knowledge principles
 //
 // Persisted in database with email-level encryption using AES-256-GCM authenticated encryption.
 type StoredUser struct {
-	EncryptedEmail string    `json:"encrypted_email"` // Primary key - AES-256-GCM encrypted email
-	PasswordHash   string    `json:"password_hash"`   // Argon2id hash of password with salt
-	PasswordSalt   string    `json:"password_salt"`   // Cryptographically secure random salt for Argon2id
-	SSHPubKey      string    `json:"ssh_public_key"`  // SSH public key for Storage-Service authentication
-	CreatedAt      time.Time `json:"created_at"`      // Account creation timestamp for auditing
-	UpdatedAt      time.Time `json:"updated_at"`      // Last modification timestamp for security monitoring
+	EncryptedEmail  string    `json:"encrypted_email"`         // Primary key - AES-256-GCM encrypted email, random nonce per row
+	EmailBlindIndex string    `json:"email_blind_index"`       // HMAC-SHA256 of normalized email, for indexable lookup without decryption
+	PasswordHash    string    `json:"password_hash"`           // Self-describing scheme-prefixed hash (Argon2id, bcrypt, pbkdf2-sha256, or sha512_crypt - see crypto.VerifyPassword)
+	PasswordSalt    string    `json:"password_salt,omitempty"` // Deprecated: legacy hex salt, empty for PHC hashes
+	SSHPubKey       string    `json:"ssh_public_key"`          // SSH public key for Storage-Service authentication
+	CreatedAt       time.Time `json:"created_at"`              // Account creation timestamp for auditing
+	UpdatedAt       time.Time `json:"updated_at"`              // Last modification timestamp for security monitoring
+
+	FailedLoginAttempts int        `json:"failed_login_attempts"`       // Consecutive failed logins since the last success, for lockout tracking
+	LastFailedLogin     *time.Time `json:"last_failed_login,omitempty"` // Timestamp of the most recent failed login, nil if none recorded
+
+	MFAEnabled             bool     `json:"mfa_enabled"`                         // True once ConfirmMFAHandler has verified the first TOTP code
+	MFASecretEncrypted     string   `json:"mfa_secret_encrypted,omitempty"`      // TOTP seed, sealed with the same crypto.FieldCipher master key as EncryptedEmail
+	MFAScratchCodeHashes   []string `json:"mfa_scratch_code_hashes,omitempty"`   // crypto.HashScratchCode digests; a redeemed code is removed from this list
+	MFALastAcceptedCounter int64    `json:"mfa_last_accepted_counter,omitempty"` // Highest TOTP step counter accepted so far, rejecting replay of that code or any earlier one
 }
 
 // Response provides standardized API response format for Security-Switch communication.
@@ -59,8 +67,12 @@ type StoredUser struct {
 //
 // Used for Database-Vault responses to Security-Switch via mTLS authentication.
 type Response struct {
-	Success bool   `json:"success"` // Operation success indicator for service validation
-	Message string `json:"message"` // Human-readable status or error description
+	Success        bool   `json:"success"`                   // Operation success indicator for service validation
+	Message        string `json:"message"`                   // Human-readable status or error description
+	Code           string `json:"code,omitempty"`            // Stable machine-readable error code (see ramusb/errs), empty on success
+	SSHCertificate string `json:"ssh_certificate,omitempty"` // Signed OpenSSH user certificate, present only when the request set cert_mode
+	SessionToken   string `json:"session_token,omitempty"`   // Short-lived signed session JWT (see session.Issuer), present only on successful login
+	MFARequired    bool   `json:"mfa_required,omitempty"`    // True when the password verified but LoginRequest.MFACode is still needed
 }
 
 // HealthResponse provides comprehensive Database-Vault health information for monitoring.
@@ -82,6 +94,35 @@ type HealthResponse struct {
 	Dependencies    map[string]string `json:"dependencies,omitempty"`     // External service dependency status
 }
 
+// DependencyDetail captures per-dependency diagnostics for the detailed health endpoint.
+//
+// Security features:
+// - LastError is operator-facing text only, never returned to unauthenticated callers
+// - Exposed exclusively behind the mTLS peer check on /api/health/detailed
+//
+// Used by DetailedHealthResponse for monitoring dashboards and incident response.
+type DependencyDetail struct {
+	Status        string    `json:"status"`               // "ok", "degraded", or "unavailable"
+	LatencyMS     int64     `json:"latency_ms"`           // Round-trip time of the last check
+	LastError     string    `json:"last_error,omitempty"` // Most recent error message, if any
+	LastCheckedAt time.Time `json:"last_checked_at"`      // When this dependency was last probed
+}
+
+// DetailedHealthResponse provides per-dependency latency and error diagnostics for monitoring.
+//
+// Security features:
+// - Gated behind mTLS peer verification (see middleware.VerifyMTLS), unlike the cheap, unauthenticated /healthz liveness probe
+// - Dependency detail never includes credentials or connection strings
+//
+// Returned by the /api/health/detailed endpoint for Security-Switch and operator dashboards.
+type DetailedHealthResponse struct {
+	Success      bool                        `json:"success"`      // Overall service availability indicator
+	Message      string                      `json:"message"`      // Human-readable status description
+	Service      string                      `json:"service"`      // Service name identifier
+	Status       string                      `json:"status"`       // "healthy", "degraded", or "unavailable"
+	Dependencies map[string]DependencyDetail `json:"dependencies"` // Per-dependency latency and error detail
+}
+
 // StorageError represents Database-Vault specific error conditions for detailed error handling.
 //
 // Security features:
@@ -120,15 +161,155 @@ type UserExists struct {
 	SSHKeyExists         bool `json:"ssh_key_exists"`         // SSH public key already in use indicator
 }
 
-// LoginRequest defines user authentication data structure for future implementation.
+// PasswordResetRecord represents a single-use password-reset token persisted
+// by the storage layer, analogous to dex's Password resource.
+//
+// Security features:
+//   - TokenHash stores crypto.HashResetToken(token), never the plaintext
+//     token, so a database compromise alone cannot be replayed into a reset
+//   - UsedAt, once set by ConsumePasswordResetToken, permanently retires the
+//     token; ExpiresAt retires it independently once its TTL elapses
+//
+// Created by UserStorage.CreatePasswordResetToken, redeemed exactly once by
+// UserStorage.ConsumePasswordResetToken, and swept once expired by
+// UserStorage.DeleteExpiredPasswordResetTokens.
+type PasswordResetRecord struct {
+	TokenHash      string     `json:"token_hash"`        // SHA-256 hex digest of the plaintext token
+	EncryptedEmail string     `json:"encrypted_email"`   // Owning user's EncryptedEmail, returned on successful consume
+	ExpiresAt      time.Time  `json:"expires_at"`        // Token validity deadline
+	UsedAt         *time.Time `json:"used_at,omitempty"` // Set exactly once, by the first successful consume
+	CreatedAt      time.Time  `json:"created_at"`        // Issuance timestamp for auditing
+}
+
+// PasswordResetIssueRequest requests a new password-reset token for an
+// existing user, received from Security-Switch over mTLS.
+type PasswordResetIssueRequest struct {
+	Email string `json:"email"` // Account email; resolved to its blind index for lookup, never persisted in the resulting token row
+}
+
+// PasswordResetIssueResponse carries a newly issued password-reset token
+// back to Security-Switch for out-of-band delivery to the user.
+//
+// Security features:
+//   - ResetToken is returned exactly once and never logged or persisted in
+//     plaintext - see crypto.HashResetToken
+type PasswordResetIssueResponse struct {
+	Success    bool      `json:"success"`
+	ResetToken string    `json:"reset_token,omitempty"`
+	ExpiresAt  time.Time `json:"expires_at,omitempty"`
+}
+
+// PasswordResetConsumeRequest redeems a password-reset token for a new password.
+type PasswordResetConsumeRequest struct {
+	ResetToken  string `json:"reset_token"`
+	NewPassword string `json:"new_password"`
+}
+
+// LoginRequest contains user authentication data from Security-Switch.
 //
 // Security features:
-// - Email-based account lookup for user identification
-// - Password field for Argon2id verification against stored hash
+// - Email-based account lookup via its blind index, never a direct EncryptedEmail comparison (see crypto.FieldCipher)
+// - Password field for Argon2id verification against the stored PHC hash
 // - Structured format for consistent authentication processing
 //
-// Reserved for future login functionality implementation.
-// type LoginRequest struct {
-// 	Email    string `json:"email"`    // User email for account lookup
-//	Password string `json:"password"` // Password for Argon2id verification
-// }
+// Received via mTLS from authenticated Security-Switch instances only.
+type LoginRequest struct {
+	Email    string `json:"email"`              // User email for account lookup
+	Password string `json:"password"`           // Password for Argon2id verification
+	MFACode  string `json:"mfa_code,omitempty"` // RFC 6238 TOTP code, or a scratch code; required once StoredUser.MFAEnabled is true
+}
+
+// MFAEnrollRequest begins TOTP enrollment for an existing account,
+// generating (but not yet activating) a new secret.
+//
+// Received via mTLS from authenticated Security-Switch instances only,
+// after Security-Switch has already verified the caller's session token.
+type MFAEnrollRequest struct {
+	Email string `json:"email"` // Account email; resolved to its blind index for lookup
+}
+
+// MFAEnrollResponse carries a freshly generated TOTP secret back to
+// Security-Switch for display to the user.
+//
+// Security features:
+//   - Secret is returned in this response only; from here on it exists solely
+//     encrypted (see StoredUser.MFASecretEncrypted) until ConfirmMFAHandler
+//     activates it, and never again afterward
+//
+// ProvisioningURI is an otpauth:// URI suitable for a client to render as a
+// QR code for the user's authenticator app; Secret is the same seed,
+// base32-encoded, for manual entry when scanning isn't possible.
+type MFAEnrollResponse struct {
+	Success         bool   `json:"success"`
+	Secret          string `json:"secret,omitempty"`
+	ProvisioningURI string `json:"provisioning_uri,omitempty"`
+}
+
+// MFAConfirmRequest verifies the first TOTP code generated from an
+// in-progress enrollment before ConfirmMFAHandler persists MFAEnabled = true.
+type MFAConfirmRequest struct {
+	Email string `json:"email"` // Account email; resolved to its blind index for lookup
+	Code  string `json:"code"`  // First TOTP code generated from the enrolled secret
+}
+
+// MFAConfirmResponse carries the one-time set of scratch codes issued when
+// MFA enrollment completes.
+//
+// Security features:
+//   - ScratchCodes are returned exactly once; storage.UserStorage persists
+//     only crypto.HashScratchCode digests of them (see StoredUser.MFAScratchCodeHashes)
+type MFAConfirmResponse struct {
+	Success      bool     `json:"success"`
+	ScratchCodes []string `json:"scratch_codes,omitempty"`
+}
+
+// IssuedCertificate represents a single user client certificate issued by
+// pki.CA for Storage-Service mTLS authentication, persisted so
+// RevokeCertHandler and the OCSP responder can later look it up by serial.
+//
+// Security features:
+//   - Serial, not EncryptedEmail, is the lookup key here - an OCSP request
+//     only ever carries a serial number, never an identity
+//   - Revoked/RevokedAt/RevocationReason are set exactly once, by
+//     UserStorage.RevokeCertificate, and checked on every OCSP response
+//
+// Created by UserStorage.StoreIssuedCertificate, looked up by
+// UserStorage.GetCertificateBySerial, and retired by UserStorage.RevokeCertificate.
+type IssuedCertificate struct {
+	Serial           string     `json:"serial"`                      // Hex-encoded certificate serial number, the OCSP/revocation lookup key
+	EncryptedEmail   string     `json:"encrypted_email"`             // Owning user's EncryptedEmail, bound into the certificate's Subject.CommonName
+	NotAfter         time.Time  `json:"not_after"`                   // Certificate expiry, mirrored from the signed certificate for quick pruning
+	Revoked          bool       `json:"revoked"`                     // Set once, by RevokeCertHandler
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`        // Set exactly once, alongside Revoked
+	RevocationReason int        `json:"revocation_reason,omitempty"` // ocsp revocation reason code, meaningful only when Revoked
+	CreatedAt        time.Time  `json:"created_at"`                  // Issuance timestamp for auditing
+}
+
+// CertIssueRequest requests a client certificate for an existing, already
+// session-authenticated account, carrying the CSR the client built over a
+// private key that never leaves its own machine.
+type CertIssueRequest struct {
+	Email string `json:"email"`   // Account email; resolved to its blind index for lookup, never persisted beyond that
+	CSR   string `json:"csr_pem"` // PEM-encoded PKCS#10 certificate signing request
+}
+
+// CertIssueResponse carries a freshly issued client certificate back to
+// Security-Switch.
+type CertIssueResponse struct {
+	Success     bool      `json:"success"`
+	Certificate string    `json:"certificate_pem,omitempty"`
+	Serial      string    `json:"serial,omitempty"`
+	NotAfter    time.Time `json:"not_after,omitempty"`
+}
+
+// CertRevokeRequest retires a previously issued client certificate by serial,
+// e.g. after its private key is reported compromised.
+type CertRevokeRequest struct {
+	Serial string `json:"serial"`
+	Reason int    `json:"reason,omitempty"` // ocsp revocation reason code, defaults to ocsp.Unspecified (0)
+}
+
+// CertRevokeResponse confirms a certificate revocation.
+type CertRevokeResponse struct {
+	Success bool `json:"success"`
+}
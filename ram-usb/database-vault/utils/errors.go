@@ -10,10 +10,13 @@ and storage layer operation monitoring.
 package utils
 
 import (
+	"database-vault/logging"
 	"database-vault/types"
 	"encoding/json"
-	"log"
+	"errors"
 	"net/http"
+
+	"ramusb/errs"
 )
 
 // SendErrorResponse creates standardized error response for Security-Switch communication.
@@ -60,6 +63,60 @@ func SendSuccessResponse(w http.ResponseWriter, statusCode int, message string)
 	})
 }
 
+// SendSuccessResponseWithCertificate creates a standardized success response
+// carrying a signed SSH certificate, for registrations made with cert_mode set.
+//
+// Security features:
+// - Same standardized JSON structure as SendSuccessResponse, with the certificate as an additive field
+// - SSHCertificate is never populated unless signing actually succeeded
+//
+// Sends HTTP response with specified status code, success message, and the issued certificate.
+func SendSuccessResponseWithCertificate(w http.ResponseWriter, statusCode int, message, sshCertificate string) {
+	w.WriteHeader(statusCode)
+
+	json.NewEncoder(w).Encode(types.Response{
+		Success:        true,
+		Message:        message,
+		SSHCertificate: sshCertificate,
+	})
+}
+
+// SendSuccessResponseWithSessionToken creates a standardized success response
+// carrying a signed session token, for a successful LoginUserHandler call.
+//
+// Security features:
+// - Same standardized JSON structure as SendSuccessResponse, with the token as an additive field
+// - SessionToken is never populated unless issuance actually succeeded
+//
+// Sends HTTP response with specified status code, success message, and the issued session token.
+func SendSuccessResponseWithSessionToken(w http.ResponseWriter, statusCode int, message, sessionToken string) {
+	w.WriteHeader(statusCode)
+
+	json.NewEncoder(w).Encode(types.Response{
+		Success:      true,
+		Message:      message,
+		SessionToken: sessionToken,
+	})
+}
+
+// SendMFARequiredResponse creates a standardized response for a login whose
+// password verified but whose LoginRequest.MFACode was missing or invalid.
+//
+// Security features:
+// - Same standardized JSON structure as SendErrorResponse, with MFARequired as an additive field
+// - Never carries a session token, so a partial login cannot be mistaken for a completed one
+//
+// Sends HTTP 401 with MFARequired set and the given message.
+func SendMFARequiredResponse(w http.ResponseWriter, message string) {
+	w.WriteHeader(http.StatusUnauthorized)
+
+	json.NewEncoder(w).Encode(types.Response{
+		Success:     false,
+		Message:     message,
+		MFARequired: true,
+	})
+}
+
 // LogAndSendError provides comprehensive audit logging with sanitized Security-Switch response.
 //
 // Security features:
@@ -68,13 +125,42 @@ func SendSuccessResponse(w http.ResponseWriter, statusCode int, message string)
 // - Prevents sensitive database information leakage to external services
 // - Dual-purpose logging for both debugging and security analysis
 //
-// Logs detailed error internally and sends sanitized message to Security-Switch.
-func LogAndSendError(w http.ResponseWriter, statusCode int, logMessage, clientMessage string) {
+// Logs detailed error internally, tagged with r's request-scoped logger, and
+// sends sanitized message to Security-Switch.
+func LogAndSendError(w http.ResponseWriter, r *http.Request, statusCode int, logMessage, clientMessage string) {
 	// AUDIT LOGGING
 	// Record detailed error for security monitoring and debugging
-	log.Printf("Error: %s", logMessage)
+	logging.FromContext(r.Context()).Error(logMessage, "status", statusCode)
 
 	// SECURITY-SWITCH ERROR RESPONSE
 	// Send sanitized error message to prevent information disclosure
 	SendErrorResponse(w, statusCode, clientMessage)
 }
+
+// WriteError unwraps err into a *errs.Error - wrapping it in errs.Internal if
+// it isn't already one - and sends its Status/Code/Message to Security-Switch,
+// logging Cause server-side under r's request-scoped logger for cross-referencing.
+//
+// Security features:
+//   - Only Message and Code ever reach Security-Switch; Cause (which may contain
+//     file paths, connection errors, or other internal detail) is logged only
+//   - r's request_id attribute (attached by logging.Middleware) lets an operator
+//     tie a reported failure back to the matching server log line without
+//     exposing internal error text
+//
+// Sends err's HTTP status and structured body, and logs the underlying cause.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	var e *errs.Error
+	if !errors.As(err, &e) {
+		e = errs.Internal("internal_error", "Internal server error. Please contact administrator.", err)
+	}
+
+	logging.FromContext(r.Context()).Error("request failed", "code", e.Code, "status", e.Status, "cause", e.Cause)
+
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(types.Response{
+		Success: false,
+		Message: e.Message,
+		Code:    e.Code,
+	})
+}
@@ -1,35 +1,57 @@
 /*
 HTTP request validation utilities for Database-Vault endpoint protection.
 
-Provides method enforcement and request validation to prevent CSRF attacks
-and unauthorized HTTP method usage. Ensures consistent security policies
-across all Database-Vault mTLS API endpoints with standardized error
-responses for method violations and security policy enforcement in the
-final storage layer of the distributed authentication system.
+Provides composable method enforcement for Database-Vault's mTLS API
+endpoints in the final storage layer, layered the same way as the rest of
+the handler chain: RequireMethods returns a func(http.HandlerFunc)
+http.HandlerFunc that wraps the next handler.
+
+Database-Vault traffic is exclusively service-to-service over mTLS
+(Security-Switch is the only caller, authenticated by client certificate,
+not a browser session) - see ram-usb/entry-hub/utils/http.go's CSRFProtect
+for the double-submit cookie defense that belongs on the public-facing side
+of the system instead.
 */
 package utils
 
 import (
 	"net/http"
+	"strings"
 )
 
+// RequireMethods returns a middleware that only lets requests using one of
+// methods reach next, rejecting everything else with HTTP 405 - the general
+// form of what EnforcePOST used to hardcode for POST alone.
+func RequireMethods(methods ...string) func(http.HandlerFunc) http.HandlerFunc {
+	allowed := make(map[string]struct{}, len(methods))
+	for _, m := range methods {
+		allowed[m] = struct{}{}
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := allowed[r.Method]; !ok {
+				LogAndSendError(w, r, http.StatusMethodNotAllowed,
+					"invalid method: "+r.Method+"; allowed: "+strings.Join(methods, ", "),
+					"Method not allowed.")
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
 // EnforcePOST restricts endpoint access to POST requests only for security compliance.
 //
-// Security features:
-// - REST API semantic correctness for resource creation and credential storage
-// - Consistent method enforcement across all Database-Vault endpoints
-// - Reject non-POST requests to prevent CSRF and method confusion attacks
-// - Audit logging for unauthorized method access attempts on storage endpoints
-//
-// Returns true if request method is POST, false with HTTP 405 error response otherwise.
+// Deprecated: use RequireMethods(http.MethodPost) as a composable middleware
+// instead. This inline bool-check form is kept only so handlers written
+// against the old signature keep compiling unchanged; it drives
+// RequireMethods and reports its outcome synchronously instead of via the
+// middleware-chaining protocol.
 func EnforcePOST(w http.ResponseWriter, r *http.Request) bool {
-	// METHOD VALIDATION
-	// Reject non-POST requests to prevent CSRF and method confusion attacks
-	if r.Method != http.MethodPost {
-		LogAndSendError(w, http.StatusMethodNotAllowed,
-			"invalid method: "+r.Method+"; only POST is allowed",
-			"Method not allowed. Use POST.")
-		return false
-	}
-	return true
+	allowed := false
+	RequireMethods(http.MethodPost)(func(http.ResponseWriter, *http.Request) {
+		allowed = true
+	})(w, r)
+	return allowed
 }
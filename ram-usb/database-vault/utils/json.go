@@ -11,6 +11,8 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 )
@@ -18,18 +20,27 @@ import (
 // ReadRequestBody safely reads and validates HTTP request body content from mTLS clients.
 //
 // Security features:
-// - Protects against oversized payload attacks with built-in HTTP server limits
+// - Protects against oversized payload attacks via the MaxBytes middleware wrapping the route, answering HTTP 413 rather than 400 once that limit is exceeded
 // - Validates request body accessibility and prevents partial read attacks
 // - Standardized error responses prevent information disclosure to Security-Switch clients
 //
 // Returns request body bytes and success indicator, sends HTTP 400 error response on failure.
 func ReadRequestBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
 	// REQUEST BODY READING
-	// Read entire body with built-in size limits from HTTP server configuration
+	// Read entire body; MaxBytes (see limits.go) has already wrapped r.Body
+	// in an http.MaxBytesReader for every route that applies it
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			// Oversized payload - caller exceeded the route's MaxBytes limit
+			LogAndSendError(w, r, http.StatusRequestEntityTooLarge,
+				fmt.Sprintf("request body exceeds %d byte limit", maxBytesErr.Limit),
+				"Request body too large.")
+			return nil, false
+		}
 		// Body reading failure - malformed request or connection issue
-		LogAndSendError(w, http.StatusBadRequest,
+		LogAndSendError(w, r, http.StatusBadRequest,
 			"failed to read request body",
 			"Error reading request.")
 		return nil, false
@@ -46,12 +57,12 @@ func ReadRequestBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
 // - Input sanitization layer before final storage validation and encryption
 //
 // Returns parsing success indicator, sends HTTP 400 error response on JSON format errors.
-func ParseJSONBody(body []byte, target interface{}, w http.ResponseWriter) bool {
+func ParseJSONBody(body []byte, target interface{}, w http.ResponseWriter, r *http.Request) bool {
 	// JSON DESERIALIZATION
 	// Parse JSON with Go type safety and structure validation
 	if err := json.Unmarshal(body, target); err != nil {
 		// JSON parsing failure - malformed syntax or structure mismatch
-		LogAndSendError(w, http.StatusBadRequest,
+		LogAndSendError(w, r, http.StatusBadRequest,
 			"failed to parse JSON body: "+err.Error(),
 			"Invalid JSON format.")
 		return false
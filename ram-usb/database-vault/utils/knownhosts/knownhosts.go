@@ -0,0 +1,244 @@
+/*
+SSH known_hosts-style trust store for authorizing forwarded host and
+principal keys.
+
+validation.go's IsValidSSHKey and IsValidSSHCertificate only check that a key
+is well-formed; they have no notion of whether a specific key is the one
+trusted for a specific principal, or whether it has since been revoked. This
+package parses an OpenSSH known_hosts file - reusing its existing syntax
+rather than inventing a new one - and answers that question.
+
+Supported entry forms, matching the subset of known_hosts syntax that
+applies to plain (non-wildcard) principals:
+  - "hostname keytype base64key [comment]"
+  - "host1,host2,... keytype base64key [comment]"
+  - "@cert-authority hostname keytype base64key" - hostname's key is a
+    trusted certificate authority, not a host key itself
+  - "@revoked hostname keytype base64key" - this exact key is no longer
+    trusted for hostname, even if another entry still lists it
+  - "|1|salt|hash keytype base64key" - HMAC-SHA1-hashed hostname, so the
+    trust store file doesn't itself disclose which hosts/principals it covers
+*/
+package knownhosts
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Errors returned by HostKeyDB.Authorize.
+var (
+	// ErrUnknownHost means no entry in the trust store covers the given
+	// host or principal at all.
+	ErrUnknownHost = errors.New("knownhosts: host or principal not found in trust store")
+	// ErrKeyMismatch means the host or principal is known, but none of its
+	// trusted keys match the one presented.
+	ErrKeyMismatch = errors.New("knownhosts: presented key does not match any trusted key for host")
+	// ErrRevoked means the presented key is explicitly marked revoked for
+	// the given host or principal.
+	ErrRevoked = errors.New("knownhosts: key is marked revoked for host")
+)
+
+// markerKind distinguishes the @cert-authority/@revoked prefix a
+// known_hosts line may carry.
+type markerKind int
+
+const (
+	markerNone markerKind = iota
+	markerCertAuthority
+	markerRevoked
+)
+
+// hostPattern is one comma-separated pattern from a known_hosts line's
+// hostnames field, either in plain text or HMAC-SHA1-hashed form.
+type hostPattern struct {
+	plain  string // Empty when hashed is true
+	hashed bool
+	salt   []byte
+	digest []byte
+}
+
+// matches reports whether candidate (a hostname or principal) satisfies p.
+func (p hostPattern) matches(candidate string) bool {
+	if !p.hashed {
+		return p.plain == candidate
+	}
+	mac := hmac.New(sha1.New, p.salt)
+	mac.Write([]byte(candidate))
+	return hmac.Equal(mac.Sum(nil), p.digest)
+}
+
+// entry is one parsed known_hosts line.
+type entry struct {
+	marker   markerKind
+	patterns []hostPattern
+	key      ssh.PublicKey
+}
+
+// matchesAny reports whether any of entry's patterns cover candidate.
+func (e entry) matchesAny(candidate string) bool {
+	for _, p := range e.patterns {
+		if p.matches(candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// HostKeyDB is a parsed known_hosts-format trust store.
+//
+// Construct with Load. Safe for concurrent read-only use; callers that
+// append new entries via AppendEntry should Load a fresh HostKeyDB
+// afterward rather than mutate one already in use.
+type HostKeyDB struct {
+	entries []entry
+}
+
+// Load parses the known_hosts-format file at path into a HostKeyDB.
+//
+// Returns error if path cannot be read, or if a non-blank, non-comment line
+// fails to parse.
+func Load(path string) (*HostKeyDB, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trust store %q: %v", path, err)
+	}
+
+	db := &HostKeyDB{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		e, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("trust store %q line %d: %v", path, lineNum, err)
+		}
+		db.entries = append(db.entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read trust store %q: %v", path, err)
+	}
+
+	return db, nil
+}
+
+// parseLine parses one non-blank, non-comment known_hosts line into an entry.
+func parseLine(line string) (entry, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return entry{}, fmt.Errorf("expected at least 3 fields, got %d", len(fields))
+	}
+
+	marker := markerNone
+	switch fields[0] {
+	case "@cert-authority":
+		marker = markerCertAuthority
+		fields = fields[1:]
+	case "@revoked":
+		marker = markerRevoked
+		fields = fields[1:]
+	}
+	if len(fields) < 3 {
+		return entry{}, fmt.Errorf("expected hostnames, keytype, and key after marker")
+	}
+
+	patterns, err := parsePatterns(fields[0])
+	if err != nil {
+		return entry{}, err
+	}
+
+	// ssh.ParseAuthorizedKey expects "keytype base64key [comment]", the
+	// same encoding known_hosts uses for its own keytype+key fields.
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(strings.Join(fields[1:], " ")))
+	if err != nil {
+		return entry{}, fmt.Errorf("failed to parse key: %v", err)
+	}
+
+	return entry{marker: marker, patterns: patterns, key: key}, nil
+}
+
+// parsePatterns splits a comma-separated hostnames field into hostPatterns,
+// recognizing the "|1|salt|hash" hashed-hostname form.
+func parsePatterns(field string) ([]hostPattern, error) {
+	var patterns []hostPattern
+	for _, raw := range strings.Split(field, ",") {
+		if !strings.HasPrefix(raw, "|1|") {
+			patterns = append(patterns, hostPattern{plain: raw})
+			continue
+		}
+
+		parts := strings.SplitN(raw, "|", 4)
+		if len(parts) != 4 {
+			return nil, fmt.Errorf("malformed hashed hostname %q", raw)
+		}
+		salt, err := base64.StdEncoding.DecodeString(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("malformed hashed hostname salt: %v", err)
+		}
+		digest, err := base64.StdEncoding.DecodeString(parts[3])
+		if err != nil {
+			return nil, fmt.Errorf("malformed hashed hostname digest: %v", err)
+		}
+		patterns = append(patterns, hostPattern{hashed: true, salt: salt, digest: digest})
+	}
+	return patterns, nil
+}
+
+// Authorize reports whether key is trusted for hostnameOrPrincipal.
+//
+// key may be a raw ssh.PublicKey or an *ssh.Certificate. For a certificate,
+// Authorize also accepts a matching @cert-authority entry whose key equals
+// the certificate's signing key - the trust store vouches for the CA, not
+// every certificate it has ever signed; callers should already have run the
+// certificate through IsValidSSHCertificate for structural and validity-
+// window checks before calling Authorize.
+//
+// Returns nil if key is trusted, ErrRevoked if hostnameOrPrincipal has
+// explicitly revoked this exact key, ErrKeyMismatch if hostnameOrPrincipal
+// is known but none of its trusted keys match, or ErrUnknownHost if no entry
+// covers hostnameOrPrincipal at all.
+func (db *HostKeyDB) Authorize(hostnameOrPrincipal string, key ssh.PublicKey) error {
+	cert, isCert := key.(*ssh.Certificate)
+
+	// A matching @revoked entry wins wherever it appears in the file,
+	// including after a plain entry that still lists the same key.
+	for _, e := range db.entries {
+		if e.marker == markerRevoked && e.matchesAny(hostnameOrPrincipal) && bytes.Equal(e.key.Marshal(), key.Marshal()) {
+			return ErrRevoked
+		}
+	}
+
+	found := false
+	for _, e := range db.entries {
+		if !e.matchesAny(hostnameOrPrincipal) {
+			continue
+		}
+		found = true
+
+		if e.marker == markerCertAuthority && isCert && bytes.Equal(e.key.Marshal(), cert.SignatureKey.Marshal()) {
+			return nil
+		}
+		if e.marker == markerNone && bytes.Equal(e.key.Marshal(), key.Marshal()) {
+			return nil
+		}
+	}
+
+	if !found {
+		return ErrUnknownHost
+	}
+	return ErrKeyMismatch
+}
@@ -0,0 +1,20 @@
+//go:build !unix
+
+package knownhosts
+
+import "os"
+
+// lockFile is a no-op on non-unix platforms, which lack flock.
+//
+// Returns nil always; AppendEntry callers are expected to be single-writer
+// on these platforms.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// unlockFile is a no-op on non-unix platforms, matching lockFile.
+//
+// Returns nil always.
+func unlockFile(f *os.File) error {
+	return nil
+}
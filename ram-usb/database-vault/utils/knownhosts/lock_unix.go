@@ -0,0 +1,24 @@
+//go:build unix
+
+package knownhosts
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockFile takes an exclusive advisory lock on f's underlying descriptor on
+// unix platforms, blocking until it is available.
+//
+// Returns nil on success, or the underlying flock syscall error.
+func lockFile(f *os.File) error {
+	return unix.Flock(int(f.Fd()), unix.LOCK_EX)
+}
+
+// unlockFile releases a lock previously acquired by lockFile.
+//
+// Returns nil on success, or the underlying flock syscall error.
+func unlockFile(f *os.File) error {
+	return unix.Flock(int(f.Fd()), unix.LOCK_UN)
+}
@@ -0,0 +1,61 @@
+package knownhosts
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// AppendEntry atomically appends one known_hosts-format line to the trust
+// store file at path, creating it if necessary. A file lock (see
+// lock_unix.go/lock_other.go) serializes concurrent writers so two
+// operators running an enrollment or revocation script at once can't
+// interleave partial lines.
+//
+// marker is "" for a plain entry, "@cert-authority", or "@revoked".
+// hostnames are written comma-separated and unhashed; hash them yourself
+// before calling AppendEntry if the trust store file shouldn't disclose
+// which hosts/principals it covers.
+//
+// Returns error if marker is not one of the three recognized values, or if
+// the file cannot be opened, locked, or written.
+func AppendEntry(path, marker string, hostnames []string, key ssh.PublicKey) error {
+	switch marker {
+	case "", "@cert-authority", "@revoked":
+	default:
+		return fmt.Errorf("knownhosts: unrecognized marker %q", marker)
+	}
+	if len(hostnames) == 0 {
+		return fmt.Errorf("knownhosts: at least one hostname is required")
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("knownhosts: failed to open trust store %q: %v", path, err)
+	}
+	defer f.Close()
+
+	if err := lockFile(f); err != nil {
+		return fmt.Errorf("knownhosts: failed to lock trust store %q: %v", path, err)
+	}
+	defer unlockFile(f)
+
+	line := formatEntry(marker, hostnames, key)
+	if _, err := f.WriteString(line); err != nil {
+		return fmt.Errorf("knownhosts: failed to write trust store %q: %v", path, err)
+	}
+	return nil
+}
+
+// formatEntry renders one known_hosts-format line, newline-terminated.
+func formatEntry(marker string, hostnames []string, key ssh.PublicKey) string {
+	// ssh.MarshalAuthorizedKey already renders "keytype base64key\n".
+	keyLine := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(key)), "\n")
+
+	if marker != "" {
+		return marker + " " + strings.Join(hostnames, ",") + " " + keyLine + "\n"
+	}
+	return strings.Join(hostnames, ",") + " " + keyLine + "\n"
+}
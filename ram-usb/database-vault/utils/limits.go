@@ -0,0 +1,28 @@
+/*
+Request body size limiting for Database-Vault endpoints.
+
+A compromised Security-Switch instance could otherwise post an arbitrarily
+large body before the handler ever gets a chance to reject it, tying up a
+connection and memory for every byte ReadRequestBody's io.ReadAll pulls in.
+*/
+package utils
+
+import "net/http"
+
+// MaxBytes returns middleware that caps the request body at maxBytes,
+// wrapping r.Body in an http.MaxBytesReader before calling next.
+//
+// Security features:
+// - Enforced at the io.Reader level, so the limit applies regardless of which handler or JSON helper ends up reading the body
+// - ReadRequestBody recognizes the resulting *http.MaxBytesError and responds 413, audit-logged via LogAndSendError
+//
+// Mirrors the VerifyMTLS wrapping pattern already used for mTLS enforcement:
+// callers opt a route in explicitly rather than this being global.
+func MaxBytes(maxBytes int64) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next(w, r)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+/*
+Input validation utilities for Database-Vault final-layer credential verification.
+
+Implements RFC-compliant email parsing, policy-driven SSH key and certificate
+validation built on golang.org/x/crypto/ssh, and an entropy-based password
+strength estimate as the last validation pass before permanent credential
+storage, re-checking everything Security-Switch already validated rather than
+trusting its upstream result.
+*/
+package utils
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// IsValidEmail validates email format using RFC 5322 compliant pattern matching.
+//
+// Security features:
+// - Prevents email header injection attacks through strict format validation
+// - Blocks malformed addresses that could bypass basic validation
+// - Defense-in-depth validation layer despite Security-Switch pre-validation
+//
+// Returns true if email passes RFC compliance checks.
+func IsValidEmail(email string) bool {
+	pattern := `^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`
+	regex := regexp.MustCompile(pattern)
+	return regex.MatchString(email)
+}
+
+// SSHKeyPolicy configures the constraints IsValidSSHKey and
+// IsValidSSHCertificate enforce on a parsed key: which algorithms are
+// accepted, the minimum acceptable RSA modulus size, and whether only
+// hardware-backed (sk-*) keys may pass.
+type SSHKeyPolicy struct {
+	AllowedAlgorithms     map[string]struct{} // ssh.PublicKey.Type() values this policy accepts
+	MinRSABits            int                 // Minimum (*rsa.PublicKey).N.BitLen(), ignored for non-RSA keys
+	RequireHardwareBacked bool                // If true, only sk-*@openssh.com key types pass
+}
+
+// DefaultSSHKeyPolicy is the policy IsValidSSHKey and IsValidSSHCertificate
+// apply, matching the algorithm set this package has always accepted for
+// Storage-Service access.
+var DefaultSSHKeyPolicy = SSHKeyPolicy{
+	AllowedAlgorithms: map[string]struct{}{
+		ssh.KeyAlgoRSA:        {},
+		ssh.KeyAlgoED25519:    {},
+		ssh.KeyAlgoECDSA256:   {},
+		ssh.KeyAlgoECDSA384:   {},
+		ssh.KeyAlgoECDSA521:   {},
+		ssh.KeyAlgoSKED25519:  {},
+		ssh.KeyAlgoSKECDSA256: {},
+	},
+	MinRSABits: 2048,
+}
+
+// IsValidSSHKey performs comprehensive SSH public key validation with protocol-level verification.
+//
+// Security features:
+// - Algorithm whitelist prevents unsupported/weak cryptographic methods
+// - ssh.ParseAuthorizedKey rejects malformed encoding or wire format before policy is even consulted
+// - RSA modulus length check rejects undersized keys a raw algorithm whitelist can't catch
+// - Defense-in-depth validation despite Security-Switch pre-validation
+//
+// A thin wrapper over DefaultSSHKeyPolicy.Validate; see SSHKeyPolicy to
+// configure a different algorithm set, minimum RSA size, or hardware-backed
+// requirement.
+//
+// Returns true if key passes all validation layers.
+func IsValidSSHKey(sshKey string) bool {
+	return DefaultSSHKeyPolicy.Validate(sshKey) == nil
+}
+
+// Validate parses authLine as an OpenSSH authorized_keys line and reports
+// whether the resulting key satisfies p's algorithm, RSA bit length, and
+// hardware-backed constraints.
+//
+// Returns a descriptive error on the first failed check, or nil if the key passes.
+func (p SSHKeyPolicy) Validate(authLine string) error {
+	pub, err := parseAuthorizedKey(authLine)
+	if err != nil {
+		return err
+	}
+
+	if _, allowed := p.AllowedAlgorithms[pub.Type()]; !allowed {
+		return fmt.Errorf("unsupported SSH key algorithm: %s", pub.Type())
+	}
+
+	if p.RequireHardwareBacked && !strings.HasPrefix(pub.Type(), "sk-") {
+		return fmt.Errorf("hardware-backed SSH key required, got algorithm: %s", pub.Type())
+	}
+
+	if p.MinRSABits > 0 && pub.Type() == ssh.KeyAlgoRSA {
+		rsaKey, ok := pub.(ssh.CryptoPublicKey).CryptoPublicKey().(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("failed to recover RSA public key for bit-length check")
+		}
+		if rsaKey.N.BitLen() < p.MinRSABits {
+			return fmt.Errorf("RSA key too small: %d bits, minimum %d", rsaKey.N.BitLen(), p.MinRSABits)
+		}
+	}
+
+	return nil
+}
+
+// parseAuthorizedKey parses authLine's leading "algorithm base64-key-data
+// [comment]" fields via ssh.ParseAuthorizedKey, which handles the
+// authorized_keys line format, base64 decoding, and SSH wire-format
+// unmarshaling in one pass.
+func parseAuthorizedKey(authLine string) (ssh.PublicKey, error) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(authLine)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH public key: %v", err)
+	}
+	return pub, nil
+}
+
+// IsValidSSHCertificate parses authLine as an OpenSSH certificate
+// (*-cert-v01@openssh.com key types) signed over a principal's key, and
+// reports whether it is a well-formed, currently valid user certificate.
+//
+// Security features:
+// - CertType check rejects a host certificate presented where a user certificate is required
+// - ValidBefore/ValidAfter checks reject expired or not-yet-valid certificates
+// - KeyId and ValidPrincipals checks reject a certificate with no bound identity
+// - Unrecognized critical options are rejected, matching OpenSSH client/server behavior
+//
+// Returns the parsed certificate alongside the bool so a caller that needs
+// KeyId, ValidPrincipals, or Permissions after validation doesn't have to
+// re-parse authLine.
+func IsValidSSHCertificate(authLine string) (bool, *ssh.Certificate, error) {
+	pub, err := parseAuthorizedKey(authLine)
+	if err != nil {
+		return false, nil, err
+	}
+
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return false, nil, fmt.Errorf("SSH key is not a certificate: %s", pub.Type())
+	}
+
+	if cert.CertType != ssh.UserCert {
+		return false, cert, fmt.Errorf("expected a user certificate, got cert type %d", cert.CertType)
+	}
+
+	now := uint64(time.Now().Unix())
+	if cert.ValidAfter != 0 && now < cert.ValidAfter {
+		return false, cert, fmt.Errorf("certificate is not yet valid: valid after %d", cert.ValidAfter)
+	}
+	if cert.ValidBefore != ssh.CertTimeInfinity && now >= cert.ValidBefore {
+		return false, cert, fmt.Errorf("certificate has expired: valid before %d", cert.ValidBefore)
+	}
+
+	if cert.KeyId == "" {
+		return false, cert, fmt.Errorf("certificate has no key ID")
+	}
+	if len(cert.ValidPrincipals) == 0 {
+		return false, cert, fmt.Errorf("certificate has no valid principals")
+	}
+
+	for name := range cert.CriticalOptions {
+		if _, known := knownCriticalOptions[name]; !known {
+			return false, cert, fmt.Errorf("certificate has unrecognized critical option: %s", name)
+		}
+	}
+
+	return true, cert, nil
+}
+
+// knownCriticalOptions are the OpenSSH critical option names this package
+// understands how to enforce; per the OpenSSH certificate format, any
+// critical option a verifier doesn't recognize must cause rejection rather
+// than being silently ignored.
+var knownCriticalOptions = map[string]struct{}{
+	"force-command":  {},
+	"source-address": {},
+}
+
+// IsWeakPassword checks against a small database of commonly compromised
+// passwords, as a second gate alongside HasSufficientEntropy rather than the
+// primary strength check - a password can score above MinPasswordEntropyBits
+// and still be a known-breached value.
+//
+// Returns true if password appears in the weak password database.
+func IsWeakPassword(password string) bool {
+	weakPasswords := []string{
+		"password", "12345678", "qwerty12", "admin123",
+		"password123", "letmein12", "welcome1",
+		"monkey12", "dragon12", "1234567890", "qwertyuiop",
+	}
+
+	lowerPass := strings.ToLower(password)
+	for _, weak := range weakPasswords {
+		if lowerPass == weak {
+			return true
+		}
+	}
+	return false
+}
+
+// MinPasswordEntropyBits is the minimum PasswordEntropyBits score
+// HasSufficientEntropy requires, chosen to admit a long lowercase-only
+// passphrase or a short fully-random mixed-character password alike. The
+// score depends only on length and which character categories appear, so a
+// long but predictable password (twelve identical lowercase letters score
+// about 56 bits) still meets it.
+const MinPasswordEntropyBits = 50.0
+
+// PasswordEntropyBits estimates a password's strength as length times
+// log2(poolSize), where poolSize is the size of the character set the
+// password actually draws from (lowercase/uppercase/digit/special/other),
+// the same pool-based estimate NIST SP 800-63B's guidance is built on -
+// a worst-case bound assuming every character was chosen independently and
+// uniformly from whatever categories appear, not a measure of the specific
+// string's real-world guessability.
+func PasswordEntropyBits(password string) float64 {
+	poolSize := characterPoolSize(password)
+	if poolSize == 0 {
+		return 0
+	}
+	return float64(len(password)) * math.Log2(float64(poolSize))
+}
+
+// characterPoolSize sums the size of each character category present in password.
+func characterPoolSize(password string) int {
+	var hasLower, hasUpper, hasDigit, hasSpecial, hasOther bool
+	for _, r := range password {
+		switch {
+		case 'a' <= r && r <= 'z':
+			hasLower = true
+		case 'A' <= r && r <= 'Z':
+			hasUpper = true
+		case '0' <= r && r <= '9':
+			hasDigit = true
+		case strings.ContainsRune("!@#$%^&*()_+-=[]{}|;:,.<>?", r):
+			hasSpecial = true
+		default:
+			hasOther = true
+		}
+	}
+
+	pool := 0
+	if hasLower {
+		pool += 26
+	}
+	if hasUpper {
+		pool += 26
+	}
+	if hasDigit {
+		pool += 10
+	}
+	if hasSpecial {
+		pool += 32
+	}
+	if hasOther {
+		pool += 32 // conservative catch-all for unicode/other characters
+	}
+	return pool
+}
+
+// HasSufficientEntropy reports whether password's PasswordEntropyBits meets
+// MinPasswordEntropyBits.
+func HasSufficientEntropy(password string) bool {
+	return PasswordEntropyBits(password) >= MinPasswordEntropyBits
+}
@@ -0,0 +1,256 @@
+/*
+ACME-issued, auto-renewing client certificates for inter-service mTLS.
+
+Drives an ACME order/authorize/finalize cycle the same way a public
+certificate is obtained, but for a short-lived client certificate whose
+Subject Organization matches what peer middleware.VerifyMTLS checks for
+(e.g. "EntryHub"), rather than for a publicly trusted server certificate.
+*/
+package certmgr
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// cacheKeyPrefix namespaces ACMEClientSource's cache entries within a Cache
+// that may be shared with other certificate material (e.g. https_server/tls's
+// public-certificate autocert.Cache).
+const cacheKeyPrefix = "entry-hub-client-cert-"
+
+// minRenewalWait bounds how soon ACMEClientSource will retry renewal after a
+// very short-lived certificate, so a misbehaving CA cannot spin the renewal
+// loop into a tight retry storm.
+const minRenewalWait = time.Minute
+
+// ACMEClientSource obtains and auto-renews a short-lived client certificate
+// via ACME, rather than loading a long-lived one from disk.
+//
+// Security features:
+// - The ACME account key is generated fresh in memory and never written to disk, so process compromise alone cannot forge future orders after a restart
+// - Renewal at 2/3 of the certificate's lifetime keeps the mesh working through a transient ACME CA outage
+//
+// Construct with NewACMEClientSource.
+type ACMEClientSource struct {
+	client       *acme.Client
+	identifier   string         // DNS identifier the ACME CA authorizes (e.g. "entry-hub.ramusb.local")
+	organization string         // Subject Organization on the issued certificate
+	cache        autocert.Cache // Optional; nil means the certificate lives in memory only
+
+	mu       sync.RWMutex
+	current  *tls.Certificate
+	notAfter time.Time
+}
+
+// NewACMEClientSource registers an ACME account at directoryURL, obtains an
+// initial client certificate for identifier with Subject Organization
+// organization, then starts a background goroutine renewing it at 2/3 of its
+// lifetime until ctx is done. challenge must be mounted at the Entry-Hub :80
+// listener's /.well-known/acme-challenge/ path.
+//
+// cache, if non-nil, persists the issued certificate across restarts so a
+// process restart doesn't force an immediate reissuance; wrap it in a
+// SealedCache to keep the private key encrypted at rest. Pass nil to keep
+// the certificate in memory only, as before.
+//
+// Returns error if account registration or the initial issuance fails.
+func NewACMEClientSource(ctx context.Context, directoryURL, identifier, organization string, challenge *ChallengeResponder, cache autocert.Cache) (*ACMEClientSource, error) {
+	accountKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ACME account key: %v", err)
+	}
+
+	client := &acme.Client{Key: accountKey, DirectoryURL: directoryURL}
+	if _, err := client.Register(ctx, &acme.Account{}, acme.AcceptTOS); err != nil && err != acme.ErrAccountAlreadyExists {
+		return nil, fmt.Errorf("failed to register ACME account: %v", err)
+	}
+
+	s := &ACMEClientSource{client: client, identifier: identifier, organization: organization, cache: cache}
+
+	if s.loadFromCache(ctx) {
+		go s.renewalLoop(ctx, challenge)
+		return s, nil
+	}
+
+	if err := s.renew(ctx, challenge); err != nil {
+		return nil, fmt.Errorf("initial client certificate issuance failed: %v", err)
+	}
+
+	go s.renewalLoop(ctx, challenge)
+
+	return s, nil
+}
+
+// loadFromCache installs a still-valid certificate from cache, if one exists.
+//
+// Returns false if cache is nil, empty, unreadable, malformed, or already
+// within minRenewalWait of expiry - any of which fall through to the normal
+// initial-issuance path.
+func (s *ACMEClientSource) loadFromCache(ctx context.Context) bool {
+	if s.cache == nil {
+		return false
+	}
+
+	data, err := s.cache.Get(ctx, cacheKeyPrefix+s.identifier)
+	if err != nil {
+		return false
+	}
+
+	cert, err := decodeBundle(data)
+	if err != nil {
+		return false
+	}
+	if time.Until(cert.Leaf.NotAfter) < minRenewalWait {
+		return false
+	}
+
+	s.mu.Lock()
+	s.current = cert
+	s.notAfter = cert.Leaf.NotAfter
+	s.mu.Unlock()
+	return true
+}
+
+// saveToCache writes cert to cache, if configured. renew discards a failure
+// here: it is neither counted in Metrics nor treated as a renewal failure,
+// since the freshly issued certificate is already installed and usable in memory.
+func (s *ACMEClientSource) saveToCache(ctx context.Context, cert *tls.Certificate) error {
+	if s.cache == nil {
+		return nil
+	}
+	return s.cache.Put(ctx, cacheKeyPrefix+s.identifier, encodeBundle(cert))
+}
+
+// GetClientCertificate implements Source, returning the most recently issued certificate.
+func (s *ACMEClientSource) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.current == nil {
+		return nil, fmt.Errorf("no client certificate issued yet")
+	}
+	return s.current, nil
+}
+
+// renewalLoop re-issues the certificate at 2/3 of its remaining lifetime until ctx is done.
+func (s *ACMEClientSource) renewalLoop(ctx context.Context, challenge *ChallengeResponder) {
+	for {
+		s.mu.RLock()
+		notAfter := s.notAfter
+		s.mu.RUnlock()
+
+		wait := time.Until(notAfter) * 2 / 3
+		if wait < minRenewalWait {
+			wait = minRenewalWait
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		if err := s.renew(ctx, challenge); err != nil {
+			Metrics.recordRenewal(err)
+			continue
+		}
+		Metrics.recordRenewal(nil)
+	}
+}
+
+// renew drives one full ACME order/authorize/finalize cycle and installs the resulting certificate.
+func (s *ACMEClientSource) renew(ctx context.Context, challenge *ChallengeResponder) error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return fmt.Errorf("failed to generate certificate key: %v", err)
+	}
+
+	order, err := s.client.AuthorizeOrder(ctx, acme.DomainIDs(s.identifier))
+	if err != nil {
+		return fmt.Errorf("failed to create ACME order: %v", err)
+	}
+
+	if err := s.completeAuthorizations(ctx, order, challenge); err != nil {
+		return err
+	}
+
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{Organization: []string{s.organization}, CommonName: s.identifier},
+		DNSNames: []string{s.identifier},
+	}, key)
+	if err != nil {
+		return fmt.Errorf("failed to create certificate request: %v", err)
+	}
+
+	der, _, err := s.client.CreateOrderCert(ctx, order.FinalizeURL, csrDER, true)
+	if err != nil {
+		return fmt.Errorf("failed to finalize ACME order: %v", err)
+	}
+
+	leaf, err := x509.ParseCertificate(der[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse issued certificate: %v", err)
+	}
+
+	cert := &tls.Certificate{Certificate: der, PrivateKey: key, Leaf: leaf}
+	s.mu.Lock()
+	s.current = cert
+	s.notAfter = leaf.NotAfter
+	s.mu.Unlock()
+
+	// CACHE PERSISTENCE (best-effort)
+	// A failed write leaves the newly issued certificate installed and
+	// serving in memory; only the next restart loses the head start.
+	_ = s.saveToCache(ctx, cert)
+
+	return nil
+}
+
+// completeAuthorizations walks every authorization on order, satisfying any
+// pending http-01 challenge via challenge.
+func (s *ACMEClientSource) completeAuthorizations(ctx context.Context, order *acme.Order, challenge *ChallengeResponder) error {
+	for _, authzURL := range order.AuthzURLs {
+		authz, err := s.client.GetAuthorization(ctx, authzURL)
+		if err != nil {
+			return fmt.Errorf("failed to fetch ACME authorization: %v", err)
+		}
+		if authz.Status == acme.StatusValid {
+			continue
+		}
+
+		var httpChallenge *acme.Challenge
+		for _, c := range authz.Challenges {
+			if c.Type == "http-01" {
+				httpChallenge = c
+			}
+		}
+		if httpChallenge == nil {
+			return fmt.Errorf("no http-01 challenge offered for %s", s.identifier)
+		}
+
+		keyAuth, err := s.client.HTTP01ChallengeResponse(httpChallenge.Token)
+		if err != nil {
+			return fmt.Errorf("failed to build http-01 challenge response: %v", err)
+		}
+		challenge.Set(s.client.HTTP01ChallengePath(httpChallenge.Token), keyAuth)
+
+		if _, err := s.client.Accept(ctx, httpChallenge); err != nil {
+			return fmt.Errorf("failed to accept http-01 challenge: %v", err)
+		}
+		if _, err := s.client.WaitAuthorization(ctx, authzURL); err != nil {
+			return fmt.Errorf("authorization did not complete: %v", err)
+		}
+	}
+	return nil
+}
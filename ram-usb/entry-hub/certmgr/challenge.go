@@ -0,0 +1,42 @@
+package certmgr
+
+import (
+	"net/http"
+	"sync"
+)
+
+// ChallengeResponder serves ACME http-01 challenge key authorizations for
+// ACMEClientSource's order/finalize flow. Mount it at the same :80 listener
+// the public TLS manager's own http-01 challenges already use (see
+// https_server/tls.Manager.HTTPHandler).
+type ChallengeResponder struct {
+	mu        sync.RWMutex
+	responses map[string]string // challenge URL path -> key authorization
+}
+
+// NewChallengeResponder returns an empty ChallengeResponder.
+func NewChallengeResponder() *ChallengeResponder {
+	return &ChallengeResponder{responses: make(map[string]string)}
+}
+
+// Set records the key authorization to serve at path until the challenge completes.
+func (c *ChallengeResponder) Set(path, keyAuth string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.responses[path] = keyAuth
+}
+
+// ServeHTTP implements http.Handler, responding to the ACME CA's challenge fetch.
+func (c *ChallengeResponder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.RLock()
+	keyAuth, ok := c.responses[r.URL.Path]
+	c.mu.RUnlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(keyAuth))
+}
@@ -0,0 +1,39 @@
+package certmgr
+
+import "sync/atomic"
+
+// certmgrMetrics counts ACMEClientSource renewal outcomes. Plain atomic
+// counters rather than a Prometheus client, since Entry-Hub pulls in no
+// Prometheus dependency (only database-vault does); an operator can still read
+// Metrics.Snapshot() from a debug endpoint or log line.
+type certmgrMetrics struct {
+	renewalSuccessTotal int64
+	renewalFailureTotal int64
+}
+
+// Metrics is the package-level renewal counter set, analogous to
+// keyprovider.Metrics and ratelimit.Metrics elsewhere in this repo.
+var Metrics certmgrMetrics
+
+// MetricsSnapshot is a point-in-time, race-free read of Metrics.
+type MetricsSnapshot struct {
+	RenewalSuccessTotal int64
+	RenewalFailureTotal int64
+}
+
+// recordRenewal increments the success or failure counter for one renewal attempt.
+func (m *certmgrMetrics) recordRenewal(err error) {
+	if err != nil {
+		atomic.AddInt64(&m.renewalFailureTotal, 1)
+		return
+	}
+	atomic.AddInt64(&m.renewalSuccessTotal, 1)
+}
+
+// Snapshot returns the current counter values.
+func (m *certmgrMetrics) Snapshot() MetricsSnapshot {
+	return MetricsSnapshot{
+		RenewalSuccessTotal: atomic.LoadInt64(&m.renewalSuccessTotal),
+		RenewalFailureTotal: atomic.LoadInt64(&m.renewalFailureTotal),
+	}
+}
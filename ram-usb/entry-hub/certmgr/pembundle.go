@@ -0,0 +1,67 @@
+package certmgr
+
+import (
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+// encodeBundle PEM-encodes cert alongside its RSA private key, in the order
+// Cache implementations store and ACMEClientSource.loadFromCache expects:
+// one "CERTIFICATE" block per entry in cert.Certificate, followed by one
+// "RSA PRIVATE KEY" block.
+func encodeBundle(cert *tls.Certificate) []byte {
+	var out []byte
+	for _, der := range cert.Certificate {
+		out = append(out, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+
+	key := cert.PrivateKey.(*rsa.PrivateKey)
+	out = append(out, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})...)
+	return out
+}
+
+// decodeBundle parses a PEM bundle produced by encodeBundle back into a
+// tlsCertificate.
+//
+// Returns error if data contains no certificate, no private key, or either
+// fails to parse.
+func decodeBundle(data []byte) (*tls.Certificate, error) {
+	var certDERs [][]byte
+	var keyDER []byte
+
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		switch block.Type {
+		case "CERTIFICATE":
+			certDERs = append(certDERs, block.Bytes)
+		case "RSA PRIVATE KEY":
+			keyDER = block.Bytes
+		}
+	}
+
+	if len(certDERs) == 0 {
+		return nil, fmt.Errorf("pem bundle contains no certificate")
+	}
+	if keyDER == nil {
+		return nil, fmt.Errorf("pem bundle contains no private key")
+	}
+
+	key, err := x509.ParsePKCS1PrivateKey(keyDER)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(certDERs[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %v", err)
+	}
+
+	return &tls.Certificate{Certificate: certDERs, PrivateKey: key, Leaf: leaf}, nil
+}
@@ -0,0 +1,20 @@
+/*
+Client certificate sourcing for Entry-Hub's outbound mTLS connection to
+Security-Switch.
+
+NewEntryHubClient previously loaded a single long-lived certificate from disk
+once at client construction time. Source decouples "how do I get my current
+client certificate" from the HTTP client itself, so a certificate can be
+reloaded or renewed - by ACMEClientSource, automatically, well before expiry -
+without restarting the process.
+*/
+package certmgr
+
+import "crypto/tls"
+
+// Source resolves the client certificate an outbound mTLS connection should
+// present. Its single method matches tls.Config.GetClientCertificate's
+// signature exactly, so a Source can be wired in directly.
+type Source interface {
+	GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error)
+}
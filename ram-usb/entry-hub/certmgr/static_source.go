@@ -0,0 +1,30 @@
+package certmgr
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// StaticSource serves a single certificate loaded once from disk at
+// construction time, preserving the behavior every R.A.M.-U.S.B. service
+// used before ACME-issued client certificates existed.
+type StaticSource struct {
+	cert tls.Certificate
+}
+
+// NewStaticSource loads certFile/keyFile once and returns a Source serving
+// that certificate for the lifetime of the process.
+//
+// Returns error if the certificate/key pair cannot be loaded.
+func NewStaticSource(certFile, keyFile string) (*StaticSource, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %v", err)
+	}
+	return &StaticSource{cert: cert}, nil
+}
+
+// GetClientCertificate implements Source.
+func (s *StaticSource) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return &s.cert, nil
+}
@@ -2,45 +2,97 @@
 Configuration management for Entry-Hub HTTPS service.
 
 Provides centralized configuration including Security-Switch connection
-parameters and mTLS certificate paths. Uses hardcoded values for development.
+parameters and mTLS certificate paths. Resolves configuration through the
+shared ramusb/config module (config file plus RAMUSB_* environment variable
+overrides), so Tailscale IPs and certificate paths no longer need to be
+hardcoded for each deployment.
 
-TO-DO in GetConfig()
 TO-DO: Add GetPepper() function for password pepper from environment variables
 */
 package config
 
+import (
+	"fmt"
+	"log"
+	"time"
+
+	ramusbconfig "ramusb/config"
+)
+
 // Config holds Entry-Hub application configuration parameters.
 type Config struct {
 	SecuritySwitchIP string // Tailscale IP address for secure mesh communication
 	ClientCertFile   string // mTLS client certificate path for Security-Switch authentication
 	ClientKeyFile    string // mTLS private key path for secure communication
 	CACertFile       string // Certificate Authority for validating Security-Switch certificates
+
+	PublicTLS ramusbconfig.PublicTLSConfig // Let's Encrypt-managed public listener settings; Enabled false keeps the static-cert path
+	Workload  ramusbconfig.WorkloadConfig  // Cloud workload identity verifier settings; Enabled false keeps /api/register/workload disabled
+
+	RateLimitBackend   string // "memory" (single replica) or "redis" (shared across replicas)
+	RateLimitRedisAddr string // Redis address, only used when RateLimitBackend is "redis"
+
+	MaxBodyBytes int64 // Request body ceiling enforced via utils.MaxBytes, default 64 KiB
+
+	// WEAK PASSWORD DETECTION: Have I Been Pwned range-API lookup, see utils.PasswordValidator
+	PwnedCheckEnabled    bool          // false falls back to utils.StaticPwnedChecker alone
+	PwnedCheckFailClosed bool          // true rejects a password outright when the HIBP API is unreachable
+	PwnedCacheSize       int           // Max range-response prefixes held in the LRU cache
+	PwnedCacheTTL        time.Duration // How long a cached range response is trusted
+
+	resolved *ramusbconfig.Config // Underlying layered config, kept for ValidateConfig
 }
 
 // GetConfig returns Entry-Hub configuration with security connection parameters.
 //
 // Security features:
-// - Hardcoded Tailscale IPs prevent external network exposure
+// - Layered resolution (file, then RAMUSB_* env vars, then defaults) replaces hardcoded Tailscale IPs and certificate paths
 // - mTLS certificate paths ensure mutual authentication
 // - CA validation prevents man-in-the-middle attacks
 //
 // Returns pointer to Config struct with all required connection parameters.
+// Terminates the process if the underlying ramusb/config layer cannot be
+// loaded, preserving this function's existing fail-fast contract for callers.
 //
-// TO-DO: In production, load this from environment variables or config file.
-// TO-DO: Replace with actual Security-Switch IP and port. This is the macbook Tailscale IP
 // TO-DO: Load pepper from PASSWORD_PEPPER environment variable with fatal error if missing
-// TO-DO: Load SECURITY_SWITCH_IP from environment variable instead of hardcoded value
 func GetConfig() *Config {
+	resolved, err := ramusbconfig.Load("entry-hub")
+	if err != nil {
+		log.Fatalf("Failed to load Entry-Hub configuration: %v", err)
+	}
+
 	return &Config{
-		// SECURITY-SWITCH CONNECTION
-		// Use Tailscale private network to prevent external access
-		// TO-DO: Replace hardcoded IP with os.Getenv("SECURITY_SWITCH_IP")
-		SecuritySwitchIP: "100.93.246.69:8444",
-
-		// MTLS CERTIFICATE CONFIGURATION
-		// Client credentials for mutual TLS authentication with Security-Switch
-		ClientCertFile: "../certificates/entry-hub/client.crt",
-		ClientKeyFile:  "../certificates/entry-hub/client.key",
-		CACertFile:     "../certificates/certification-authority/ca.crt",
+		SecuritySwitchIP: resolved.Client.TargetAddress,
+		ClientCertFile:   resolved.Client.CertFile,
+		ClientKeyFile:    resolved.Client.KeyFile,
+		CACertFile:       resolved.Client.CACertFile,
+		PublicTLS:        resolved.PublicTLS,
+		Workload:         resolved.Workload,
+
+		RateLimitBackend:   resolved.RateLimit.Backend,
+		RateLimitRedisAddr: resolved.RateLimit.RedisAddr,
+
+		MaxBodyBytes: resolved.Server.MaxBodyBytes,
+
+		PwnedCheckEnabled:    resolved.PasswordPolicy.PwnedCheckEnabled,
+		PwnedCheckFailClosed: resolved.PasswordPolicy.PwnedCheckFailClosed,
+		PwnedCacheSize:       resolved.PasswordPolicy.PwnedCacheSize,
+		PwnedCacheTTL:        resolved.PasswordPolicy.PwnedCacheTTL,
+
+		resolved: resolved,
+	}
+}
+
+// ValidateConfig reports every Entry-Hub configuration problem at once.
+//
+// Security features:
+// - Delegates to ramusb/config.Validate, which walks every field and reports every problem at once instead of failing on the first
+//
+// Returns error (a *multierror.Error enumerating every problem found) if any
+// configuration component is invalid or missing.
+func (c *Config) ValidateConfig() error {
+	if err := ramusbconfig.Validate(c.resolved); err != nil {
+		return fmt.Errorf("entry-hub configuration invalid:\n%v", err)
 	}
+	return nil
 }
@@ -1,36 +1,177 @@
 /*
-Health check endpoint for Entry-Hub REST API monitoring.
+Health check handlers for Entry-Hub.
 
-Provides lightweight status verification for load balancers, monitoring
-systems, and service discovery. Enables automated detection of service
-availability without exposing sensitive system information.
-*/
+HealthHandler reports status following the draft application/health+json
+convention (draft-inadarei-api-health-check): it probes the Security-Switch
+hop and this service's own mTLS client certificate expiry, rather than
+returning a static message. ReadyHandler gates readiness on startup
+certificate load and configuration validation instead of process liveness
+alone.
 
+TO-DO: Cache the Security-Switch probe result for a few seconds so a
+monitoring system polling /api/health frequently doesn't open a fresh mTLS
+connection on every call.
+*/
 package handlers
 
 import (
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"https_server/certmgr"
+	"https_server/config"
+	"https_server/interfaces"
 	"https_server/types"
 	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
 )
 
-// HealthHandler provides service status verification for monitoring systems.
+// certExpiryWarnWindow is how long before the client certificate's expiry
+// checkClientCertExpiry degrades its result from "pass" to "warn".
+const certExpiryWarnWindow = 7 * 24 * time.Hour
+
+// ready flips true once main has finished loading startup certificates and
+// validating configuration; ReadyHandler reports 503 until then.
+var ready atomic.Bool
+
+// SetReady marks Entry-Hub ready for traffic. Called once from main after
+// startup certificate load and configuration validation both succeed.
+func SetReady() {
+	ready.Store(true)
+}
+
+// HealthHandler reports Entry-Hub's health following the draft
+// application/health+json convention, checking the Security-Switch hop and
+// this service's own mTLS client certificate expiry.
+//
+// ?verbose=false collapses the response to a bare status, for load balancers
+// that only care about the HTTP status code. The per-check breakdown (probe
+// latency, error detail, certificate expiry) is withheld unless the caller
+// presented a verified peer certificate, so an anonymous caller on the public
+// internet this endpoint is reachable from can't learn Security-Switch's
+// address or how soon Entry-Hub's client certificate expires.
 //
 // Security features:
-// - No sensitive information disclosure in response
-// - Minimal resource usage
-// - JSON response format ensures consistent monitoring integration
+// - Per-check detail gated behind mTLS regardless of the verbose query param
+// - Security-Switch reachability and certificate expiry are independent checks, so a slow hop can't mask an expiring certificate or vice versa
 //
-// Returns HTTP 200 with success status indicating Entry-Hub availability.
+// Returns HTTP 200 for overall status "pass" or "warn", 503 for "fail".
 func HealthHandler(w http.ResponseWriter, r *http.Request) {
-	// JSON RESPONSE SETUP
-	// Ensure consistent content type for monitoring tools
+	checks := map[string]types.HealthCheckResult{
+		"security_switch": probeSecuritySwitch(),
+		"cert_expiry":     checkClientCertExpiry(),
+	}
+	status := worstStatus(checks)
+
+	resp := types.HealthResponse{Status: status}
+	if r.URL.Query().Get("verbose") != "false" && isMTLSAuthenticated(r) {
+		resp.Checks = checks
+	}
+
+	w.Header().Set("Content-Type", "application/health+json")
+	if status == "fail" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ReadyHandler reports whether Entry-Hub has finished startup. Unlike
+// HealthHandler, it runs no downstream probes, so a slow or unreachable
+// Security-Switch can't make an Entry-Hub that has merely finished starting
+// up look unready.
+//
+// Returns HTTP 200 once SetReady has been called, 503 before that.
+func ReadyHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	if !ready.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(types.Response{Success: false, Message: "Entry-Hub is starting up."})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(types.Response{Success: true, Message: "Entry-Hub is ready."})
+}
+
+// isMTLSAuthenticated reports whether r arrived with a verified client
+// certificate. Entry-Hub's public listener doesn't request one by default,
+// so this is false for the overwhelming majority of callers - by design,
+// since the detail it gates is meant for internal monitoring, not the public internet.
+func isMTLSAuthenticated(r *http.Request) bool {
+	return r.TLS != nil && len(r.TLS.PeerCertificates) > 0
+}
+
+// probeSecuritySwitch performs an mTLS GET against Security-Switch's
+// /api/health and reports the result as a health+json check.
+func probeSecuritySwitch() types.HealthCheckResult {
+	cfg := config.GetConfig()
+
+	clientCertSource, err := certmgr.NewStaticSource(cfg.ClientCertFile, cfg.ClientKeyFile)
+	if err != nil {
+		return types.HealthCheckResult{Status: "fail", Error: err.Error()}
+	}
+
+	client, err := interfaces.NewEntryHubClient(cfg.SecuritySwitchIP, clientCertSource, cfg.CACertFile, nil, interfaces.RevocationDisabled, nil)
+	if err != nil {
+		return types.HealthCheckResult{Status: "fail", Error: err.Error()}
+	}
+
+	start := time.Now()
+	err = client.Probe()
+	latency := time.Since(start)
+	if err != nil {
+		return types.HealthCheckResult{Status: "fail", LatencyMS: latency.Milliseconds(), Error: err.Error()}
+	}
+	return types.HealthCheckResult{Status: "pass", LatencyMS: latency.Milliseconds()}
+}
+
+// checkClientCertExpiry reports "warn" within certExpiryWarnWindow of the
+// client certificate's expiry, "fail" once it has expired, and "pass"
+// otherwise.
+func checkClientCertExpiry() types.HealthCheckResult {
+	cfg := config.GetConfig()
+
+	pemBytes, err := os.ReadFile(cfg.ClientCertFile)
+	if err != nil {
+		return types.HealthCheckResult{Status: "fail", Error: err.Error()}
+	}
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return types.HealthCheckResult{Status: "fail", Error: "client certificate is not valid PEM"}
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return types.HealthCheckResult{Status: "fail", Error: err.Error()}
+	}
+
+	result := types.HealthCheckResult{ExpiresAt: cert.NotAfter.Format(time.RFC3339)}
+	switch {
+	case time.Now().After(cert.NotAfter):
+		result.Status = "fail"
+		result.Error = "client certificate has expired"
+	case time.Until(cert.NotAfter) < certExpiryWarnWindow:
+		result.Status = "warn"
+		result.Error = fmt.Sprintf("client certificate expires in %s", time.Until(cert.NotAfter).Round(time.Minute))
+	default:
+		result.Status = "pass"
+	}
+	return result
+}
 
-	// STATUS RESPONSE
-	// Simple success indicator for automated health monitoring
-	json.NewEncoder(w).Encode(types.Response{
-		Success: true,
-		Message: "HTTPS server working!",
-	})
+// worstStatus reduces checks to the single worst status: "fail" beats "warn" beats "pass".
+func worstStatus(checks map[string]types.HealthCheckResult) string {
+	worst := "pass"
+	for _, c := range checks {
+		switch c.Status {
+		case "fail":
+			return "fail"
+		case "warn":
+			worst = "warn"
+		}
+	}
+	return worst
 }
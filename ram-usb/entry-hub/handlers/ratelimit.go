@@ -0,0 +1,47 @@
+/*
+Brute-force lockout for Entry-Hub's registration endpoint.
+
+Complements the per-IP/per-account token buckets main.go wires via
+ramusb/ratelimit.PerIP/PerAccount: those bound request volume, while
+registrationLockout bounds consecutive failures for one account regardless
+of how slowly an attacker spaces them out to stay under the rate limit.
+*/
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"ramusb/ratelimit"
+)
+
+// registrationLockout trips after 5 consecutive failed registration
+// attempts for the same email within an hour, locking the account out for a
+// minute and doubling on each further trip up to 24 hours.
+var registrationLockout = ratelimit.NewLockout(5, time.Hour, time.Minute, 24*time.Hour)
+
+// RegistrationAccountKey extracts the "email" field from a registration
+// request body for ramusb/ratelimit.PerAccount, restoring r.Body afterward
+// so RegisterHandler's own ReadRequestBody call still sees the full body.
+//
+// Returns the email and true, or "" and false if the body can't be read or
+// names no email - such requests pass through PerAccount unthrottled,
+// relying on PerIP and RegisterHandler's own validation to cover them.
+func RegistrationAccountKey(r *http.Request) (string, bool) {
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		return "", false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(raw))
+
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(raw, &req); err != nil || req.Email == "" {
+		return "", false
+	}
+	return req.Email, true
+}
@@ -16,13 +16,18 @@ package handlers
 
 import (
 	"fmt"
+	"https_server/certmgr"
 	"https_server/config"
 	"https_server/interfaces"
 	"https_server/types"
 	"https_server/utils"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+
+	"ramusb/errs"
 )
 
 // RegisterHandler processes user registration requests with multi-layer validation.
@@ -35,9 +40,11 @@ import (
 //
 // Returns HTTP 201 on successful registration, 4xx on validation errors, 5xx on service errors.
 //
-// TO-DO: Implement rate limiting to prevent brute force attacks (e.g., 5 attempts per IP per minute)
+// Rate limiting (ramusb/ratelimit.PerIP/PerAccount, wired in main.go) and
+// registrationLockout together bound brute-force attempts: PerIP/PerAccount
+// cap request volume, while registrationLockout catches an attacker spacing
+// requests out to stay under that volume cap.
 func RegisterHandler(w http.ResponseWriter, r *http.Request) {
-	// TO-DO: Add rate limiting check here before processing request
 	// REQUEST LOGGING
 	// Audit trail for security monitoring and debugging
 	fmt.Printf("Request: \n\tfrom:\t%s \n\tmethod:\t%s\n", r.RemoteAddr, r.Method)
@@ -87,6 +94,17 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// BRUTE-FORCE LOCKOUT CHECK
+	// Reject outright if this email has tripped registrationLockout, regardless
+	// of current rate limit standing
+	if locked, retryAfter := registrationLockout.IsLocked(req.Email); locked {
+		log.Printf("Registration attempt for locked-out account: %s", req.Email)
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		utils.SendErrorResponse(w, http.StatusTooManyRequests,
+			"Too many failed attempts for this account. Please try again later.")
+		return
+	}
+
 	// PASSWORD LENGTH VALIDATION
 	// Enforce minimum security threshold
 	if len(req.Password) < 8 {
@@ -94,17 +112,27 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// SERVICE CONFIGURATION
+	// Needed for both the weak-password check below and the Security-Switch
+	// client setup further down
+	cfg := config.GetConfig()
+
 	// WEAK PASSWORD DETECTION
 	// Prevent dictionary and credential stuffing attacks
-	if utils.IsWeakPassword(req.Password) {
+	passwordValidator := utils.PasswordValidator{Checker: utils.StaticPwnedChecker{}}
+	if cfg.PwnedCheckEnabled {
+		passwordValidator.Checker = utils.NewHTTPPwnedChecker(nil, cfg.PwnedCacheSize, cfg.PwnedCacheTTL, cfg.PwnedCheckFailClosed)
+	}
+	if passwordValidator.IsWeakPassword(r.Context(), req.Password) {
 		utils.SendErrorResponse(w, http.StatusBadRequest, "Password is too common, please choose a stronger password.")
 		return
 	}
 
-	// PASSWORD COMPLEXITY VALIDATION
-	// Enforce character diversity for resistance to brute force
-	if !utils.HasPasswordComplexity(req.Password) {
-		utils.SendErrorResponse(w, http.StatusBadRequest, "Password must contain at least 3 of: uppercase, lowercase, numbers, special characters.")
+	// PASSWORD STRENGTH VALIDATION
+	// zxcvbn-style guess estimate, penalizing passwords built from the
+	// account's own email local-part
+	if !utils.HasPasswordComplexity(req.Password, strings.SplitN(req.Email, "@", 2)[0]) {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Password is too easily guessed, please choose a stronger password.")
 		return
 	}
 
@@ -124,32 +152,22 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 
 	// SECURITY-SWITCH CLIENT SETUP
 	// Configure mTLS client for secure service communication
-	config := config.GetConfig()
+	clientCertSource, err := certmgr.NewStaticSource(cfg.ClientCertFile, cfg.ClientKeyFile)
+	if err != nil {
+		utils.WriteError(w, errs.CertificateError("client_certificate_unreadable",
+			"Certificate configuration error. Please contact administrator.", err))
+		return
+	}
+
 	securityClient, err := interfaces.NewEntryHubClient(
-		config.SecuritySwitchIP,
-		config.ClientCertFile,
-		config.ClientKeyFile,
-		config.CACertFile,
+		cfg.SecuritySwitchIP,
+		clientCertSource,
+		cfg.CACertFile,
+		nil, interfaces.RevocationDisabled, // Opt-in: no revocation checking until a RevocationChecker is wired in
+		nil, // Opt-in: use interfaces.DefaultMetrics until a caller-specific Metrics is wired in
 	)
 	if err != nil {
-		errorMsg := fmt.Sprintf("Failed to initialize Security-Switch client: %v", err)
-		log.Printf("Error: %s", errorMsg)
-
-		// MTLS CONFIGURATION ERRORS
-		// Distinguish between certificate and network issues
-		if strings.Contains(err.Error(), "certificate") {
-			// Certificate validation failure - configuration issue
-			utils.SendErrorResponse(w, http.StatusInternalServerError,
-				"Certificate configuration error. Please contact administrator.")
-		} else if strings.Contains(err.Error(), "file") {
-			// Certificate files missing - deployment issue
-			utils.SendErrorResponse(w, http.StatusInternalServerError,
-				"Certificate files not found. Please contact administrator.")
-		} else {
-			// Generic client initialization failure - system issue
-			utils.SendErrorResponse(w, http.StatusInternalServerError,
-				"Security-Switch client initialization failed. Please contact administrator.")
-		}
+		utils.WriteError(w, err)
 		return
 	}
 
@@ -159,28 +177,7 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 
 	switchResponse, err := securityClient.ForwardRegistration(req)
 	if err != nil {
-		errorMsg := fmt.Sprintf("Failed to contact Security-Switch for %s: %v", req.Email, err)
-		log.Printf("Error: %s", errorMsg)
-
-		// NETWORK ERROR CATEGORIZATION
-		// Provide specific guidance based on failure type
-		if strings.Contains(err.Error(), "connection refused") {
-			// Service unavailable - temporary outage
-			utils.SendErrorResponse(w, http.StatusServiceUnavailable,
-				"Security-Switch service is unavailable. Please try again later.")
-		} else if strings.Contains(err.Error(), "timeout") {
-			// Service overloaded - retry recommended
-			utils.SendErrorResponse(w, http.StatusGatewayTimeout,
-				"Security-Switch service timeout. Please try again later.")
-		} else if strings.Contains(err.Error(), "certificate") || strings.Contains(err.Error(), "tls") {
-			// TLS/certificate error - configuration issue
-			utils.SendErrorResponse(w, http.StatusInternalServerError,
-				"Security certificate validation failed. Please contact administrator.")
-		} else {
-			// Generic network error - service issue
-			utils.SendErrorResponse(w, http.StatusBadGateway,
-				"Unable to reach Security-Switch service. Please try again later.")
-		}
+		utils.WriteError(w, err)
 		return
 	}
 
@@ -188,13 +185,20 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	// Verify Security-Switch successfully processed registration
 	if !switchResponse.Success {
 		log.Printf("Security-Switch rejected registration for %s: %s", req.Email, switchResponse.Message)
+		registrationLockout.RecordFailure(req.Email)
 		utils.SendErrorResponse(w, http.StatusBadRequest,
 			fmt.Sprintf("Registration failed: %s", switchResponse.Message))
 		return
 	}
 
 	// SUCCESS RESPONSE
-	// Complete Entry-Hub registration flow with audit logging
+	// Complete Entry-Hub registration flow with audit logging, passing through
+	// the signed SSH certificate when the request used cert_mode
+	registrationLockout.Reset(req.Email)
 	log.Printf("User successfully registered via Security-Switch: %s", req.Email)
-	utils.SendSuccessResponse(w, http.StatusCreated, "User successfully registered!")
+	if switchResponse.SSHCertificate != "" {
+		utils.SendSuccessResponseWithCertificate(w, http.StatusCreated, "User successfully registered!", switchResponse.SSHCertificate)
+	} else {
+		utils.SendSuccessResponse(w, http.StatusCreated, "User successfully registered!")
+	}
 }
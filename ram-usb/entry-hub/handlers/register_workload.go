@@ -0,0 +1,221 @@
+/*
+Cloud workload identity registration handler for Entry-Hub REST API service.
+
+Lets a VM or managed identity register by presenting a cloud-provider-issued
+identity credential (Azure Managed Identity access token, AWS EC2 Instance
+Identity Document, or GCP instance identity token) instead of an email and
+password. Once the credential verifies, this handler synthesizes the same
+RegisterRequest RegisterHandler builds from a form, so the rest of the
+Entry-Hub -> Security-Switch -> Database-Vault pipeline is unchanged.
+*/
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"https_server/certmgr"
+	"https_server/config"
+	"https_server/interfaces"
+	"https_server/types"
+	"https_server/utils"
+	"log"
+	"net/http"
+	"os"
+	ramusbconfig "ramusb/config"
+	"ramusb/workloadid"
+	"regexp"
+	"strings"
+)
+
+// WorkloadRegisterHandler processes cloud workload identity registration requests.
+//
+// Security features:
+// - Credential is verified by the provider-matched workloadid.Verifier before any identity is trusted
+// - Synthetic account password is generated server-side with crypto/rand, never client-supplied
+// - SSH key validation and mTLS forwarding identical to RegisterHandler
+//
+// Returns HTTP 201 on successful registration, 4xx on validation errors, 5xx on service errors.
+func WorkloadRegisterHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("Request: \n\tfrom:\t%s \n\tmethod:\t%s\n", r.RemoteAddr, r.Method)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if !utils.EnforcePOST(w, r) {
+		return
+	}
+
+	body, ok := utils.ReadRequestBody(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.WorkloadRegisterRequest
+	if !utils.ParseJSONBody(body, &req, w) {
+		return
+	}
+
+	if req.Provider == "" || req.Credential == "" {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Provider and credential are required.")
+		return
+	}
+
+	// SSH KEY FORMAT VALIDATION
+	// Verify algorithm, encoding, and internal structure
+	if !utils.IsValidSSHKey(req.SSHPubKey) {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid SSH public key format.")
+		return
+	}
+	if !strings.HasPrefix(req.SSHPubKey, "ssh-") {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid SSH public key format.")
+		return
+	}
+
+	cfg := config.GetConfig()
+	if !cfg.Workload.Enabled {
+		utils.SendErrorResponse(w, http.StatusServiceUnavailable, "Workload identity registration is not enabled.")
+		return
+	}
+
+	verifier, err := verifierFor(req.Provider, cfg.Workload)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	identity, err := verifier.Verify(r.Context(), req.Credential)
+	if err != nil {
+		log.Printf("Workload identity verification failed for provider %s: %v", req.Provider, err)
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "Workload identity credential could not be verified.")
+		return
+	}
+
+	password, err := randomPassword()
+	if err != nil {
+		log.Printf("Error: failed to generate synthetic password: %v", err)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "Registration failed. Please contact administrator.")
+		return
+	}
+
+	registerReq := types.RegisterRequest{
+		Email:     identity.Email(),
+		Password:  password,
+		SSHPubKey: req.SSHPubKey,
+		CertMode:  req.CertMode,
+	}
+
+	clientCertSource, err := certmgr.NewStaticSource(cfg.ClientCertFile, cfg.ClientKeyFile)
+	if err != nil {
+		errorMsg := fmt.Sprintf("Failed to load Security-Switch client certificate: %v", err)
+		log.Printf("Error: %s", errorMsg)
+		utils.SendErrorResponse(w, http.StatusInternalServerError,
+			"Certificate configuration error. Please contact administrator.")
+		return
+	}
+
+	securityClient, err := interfaces.NewEntryHubClient(
+		cfg.SecuritySwitchIP,
+		clientCertSource,
+		cfg.CACertFile,
+		nil, interfaces.RevocationDisabled, // Opt-in: no revocation checking until a RevocationChecker is wired in
+		nil, // Opt-in: use interfaces.DefaultMetrics until a caller-specific Metrics is wired in
+	)
+	if err != nil {
+		errorMsg := fmt.Sprintf("Failed to initialize Security-Switch client: %v", err)
+		log.Printf("Error: %s", errorMsg)
+		utils.SendErrorResponse(w, http.StatusInternalServerError,
+			"Security-Switch client initialization failed. Please contact administrator.")
+		return
+	}
+
+	log.Printf("Attempting to forward workload registration request for identity: %s", registerReq.Email)
+
+	switchResponse, err := securityClient.ForwardRegistration(registerReq)
+	if err != nil {
+		errorMsg := fmt.Sprintf("Failed to contact Security-Switch for %s: %v", registerReq.Email, err)
+		log.Printf("Error: %s", errorMsg)
+		utils.SendErrorResponse(w, http.StatusBadGateway,
+			"Unable to reach Security-Switch service. Please try again later.")
+		return
+	}
+
+	if !switchResponse.Success {
+		log.Printf("Security-Switch rejected workload registration for %s: %s", registerReq.Email, switchResponse.Message)
+		utils.SendErrorResponse(w, http.StatusBadRequest,
+			fmt.Sprintf("Registration failed: %s", switchResponse.Message))
+		return
+	}
+
+	log.Printf("Workload successfully registered via Security-Switch: %s", registerReq.Email)
+	if switchResponse.SSHCertificate != "" {
+		utils.SendSuccessResponseWithCertificate(w, http.StatusCreated, "Workload successfully registered!", switchResponse.SSHCertificate)
+	} else {
+		utils.SendSuccessResponse(w, http.StatusCreated, "Workload successfully registered!")
+	}
+}
+
+// verifierFor builds the workloadid.Verifier matching provider, scoped by the
+// resolved workload configuration.
+//
+// Returns error if provider names an unknown or unconfigured cloud provider,
+// or if its configuration cannot be assembled (e.g. an unreadable signing
+// certificate or an invalid resource group pattern).
+func verifierFor(provider string, cfg ramusbconfig.WorkloadConfig) (workloadid.Verifier, error) {
+	switch provider {
+	case "azure":
+		if cfg.AzureTenantID == "" {
+			return nil, fmt.Errorf("azure workload registration is not configured")
+		}
+		patterns := make([]*regexp.Regexp, 0, len(cfg.AzureAllowedResourceGroups))
+		for _, p := range cfg.AzureAllowedResourceGroups {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid azure_allowed_resource_groups pattern %q: %v", p, err)
+			}
+			patterns = append(patterns, re)
+		}
+		return workloadid.NewAzureVerifier(workloadid.AzureConfig{
+			TenantID:              cfg.AzureTenantID,
+			Audience:              cfg.AzureAudience,
+			AllowedSubscriptions:  cfg.AzureAllowedSubscriptions,
+			AllowedResourceGroups: patterns,
+		}), nil
+
+	case "aws":
+		if cfg.AWSSigningCertFile == "" {
+			return nil, fmt.Errorf("aws workload registration is not configured")
+		}
+		certPEM, err := os.ReadFile(cfg.AWSSigningCertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read aws_signing_cert_file: %v", err)
+		}
+		return workloadid.NewAWSVerifier(workloadid.AWSConfig{
+			SigningCertPEM:    certPEM,
+			AllowedAccountIDs: cfg.AWSAllowedAccountIDs,
+			AllowedRegions:    cfg.AWSAllowedRegions,
+		})
+
+	case "gcp":
+		if cfg.GCPAudience == "" {
+			return nil, fmt.Errorf("gcp workload registration is not configured")
+		}
+		return workloadid.NewGCPVerifier(workloadid.GCPConfig{
+			Audience:          cfg.GCPAudience,
+			AllowedProjectIDs: cfg.GCPAllowedProjectIDs,
+		}), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported provider %q", provider)
+	}
+}
+
+// randomPassword generates a password for workload accounts, which have no
+// password-based login path but still need one to satisfy RegisterRequest's
+// existing shape.
+func randomPassword() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -2,10 +2,9 @@
 mTLS client interface for Entry-Hub to Security-Switch communication.
 
 Provides secure request forwarding with mutual TLS authentication and certificate
-validation. Implements connection pooling and timeout management for reliable
-distributed service communication within the R.A.M.-U.S.B. architecture.
-
-TO-DO in NewEntryHubClient
+validation. Implements connection pooling, retry with backoff, per-endpoint
+circuit breaking, and metrics export on top of structured error handling, for
+reliable distributed service communication within the R.A.M.-U.S.B. architecture.
 */
 package interfaces
 
@@ -14,108 +13,235 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"https_server/certmgr"
 	"https_server/types"
+	"io"
 	"net/http"
 	"os"
 	"time"
+
+	"ramusb/errs"
+	"ramusb/mtlsauth"
 )
 
+// entryHubMax* and entryHubIdleConnTimeout bound the shared http.Transport's
+// connection pool, so a Security-Switch outage or traffic spike exhausts
+// neither file descriptors nor ephemeral ports.
+const (
+	entryHubMaxIdleConns        = 100
+	entryHubMaxIdleConnsPerHost = 10
+	entryHubMaxConnsPerHost     = 50
+	entryHubIdleConnTimeout     = 90 * time.Second
+
+	entryHubResponseHeaderTimeout = 10 * time.Second
+	entryHubExpectContinueTimeout = 1 * time.Second
+)
+
+// entryHubEndpoints lists every path EntryHubClient calls through
+// sendRequest, so newCircuitBreakers can pre-populate one breaker per
+// endpoint at construction time rather than creating them lazily under
+// concurrent access.
+var entryHubEndpoints = []string{
+	"/api/register",
+	"/api/health",
+}
+
+// newCircuitBreakers returns a breaker map pre-populated for every known
+// Security-Switch endpoint, so breakerFor never writes to the map after
+// construction and needs no locking of its own.
+func newCircuitBreakers() map[string]*circuitBreaker {
+	breakers := make(map[string]*circuitBreaker, len(entryHubEndpoints))
+	for _, path := range entryHubEndpoints {
+		breakers[path] = newCircuitBreaker()
+	}
+	return breakers
+}
+
 // EntryHubClient manages secure communication with Security-Switch servers.
 type EntryHubClient struct {
-	baseURL    string       // HTTPS endpoint for Security-Switch service
-	httpClient *http.Client // mTLS-configured HTTP client with certificate validation
+	baseURL    string                     // HTTPS endpoint for Security-Switch service
+	httpClient *http.Client               // mTLS-configured HTTP client with certificate validation
+	breakers   map[string]*circuitBreaker // Keyed by endpoint path; see breakerFor
+	metrics    Metrics                    // Forward attempt/retry/circuit-state counters
+}
+
+// breakerFor returns the circuit breaker for endpoint path. Breakers are
+// all pre-populated by newCircuitBreakers, so this never writes to the map
+// and needs no locking of its own. A path absent from entryHubEndpoints
+// (which should never happen - every sendRequest caller passes one of its
+// entries) gets a fresh, unshared breaker rather than a nil-pointer panic.
+func (c *EntryHubClient) breakerFor(path string) *circuitBreaker {
+	if b, ok := c.breakers[path]; ok {
+		return b
+	}
+	return newCircuitBreaker()
 }
 
 // NewEntryHubClient creates mTLS-enabled client for secure Security-Switch communication.
 //
+// source resolves the client certificate presented on every handshake via
+// tls.Config.GetClientCertificate, rather than a certificate loaded once at
+// startup - so a certmgr.ACMEClientSource can rotate it without restarting
+// Entry-Hub. Pass a certmgr.StaticSource to preserve the previous
+// load-once-from-disk behavior.
+//
 // Security features:
 // - Mutual TLS authentication with certificate verification
 // - CA validation prevents man-in-the-middle attacks
 // - TLS 1.3 enforcement for maximum cryptographic security
 // - Certificate CN validation ensures correct service identity
 //
-// Returns configured client or error if certificate validation fails.
-func NewEntryHubClient(securitySwitchIP string, clientCertFile, clientKeyFile, caCertFile string) (*EntryHubClient, error) {
-	// CLIENT CERTIFICATE LOADING
-	// Load Entry-Hub credentials for mutual authentication
-	clientCert, err := tls.LoadX509KeyPair(clientCertFile, clientKeyFile)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load client certificate: %v", err)
-	}
-
+// revocation, if non-nil, checks Security-Switch's server certificate via
+// OCSP (falling back to CRL) on every handshake, enforced according to
+// policy. Pass a nil revocation (or RevocationDisabled) to skip revocation
+// checking entirely, as before.
+//
+// metrics records forward attempt/retry counts and circuit state for
+// operators to observe transport health without reading logs; pass nil to
+// use DefaultMetrics.
+//
+// Returns configured client or error if the CA certificate cannot be loaded.
+func NewEntryHubClient(securitySwitchIP string, source certmgr.Source, caCertFile string, revocation *mtlsauth.RevocationChecker, policy RevocationPolicy, metrics Metrics) (*EntryHubClient, error) {
 	// CA CERTIFICATE LOADING
 	// Load trusted CA for Security-Switch certificate validation
 	caCert, err := os.ReadFile(caCertFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read CA certificate: %v", err)
+		return nil, errs.CertificateError("ca_certificate_unreadable", "Certificate files not found. Please contact administrator.", err)
 	}
 
 	// CERTIFICATE POOL SETUP
 	// Configure trusted certificate authorities for server validation
 	caCertPool := x509.NewCertPool()
 	if !caCertPool.AppendCertsFromPEM(caCert) {
-		return nil, fmt.Errorf("failed to parse CA certificate")
+		return nil, errs.CertificateError("ca_certificate_invalid", "Certificate configuration error. Please contact administrator.", nil)
 	}
 
 	// MTLS CONFIGURATION
 	// Configure mutual TLS with certificate validation and modern security
 	tlsConfig := &tls.Config{
-		Certificates: []tls.Certificate{clientCert}, // Entry-Hub client certificate
-		RootCAs:      caCertPool,                    // Trusted CAs for server verification
-		ServerName:   "security-switch",             // Expected server certificate CN
-		MinVersion:   tls.VersionTLS13,              // Enforce modern TLS version
+		GetClientCertificate:  source.GetClientCertificate,            // Entry-Hub client certificate, resolved per-handshake
+		RootCAs:               caCertPool,                             // Trusted CAs for server verification
+		ServerName:            "security-switch",                      // Expected server certificate CN
+		MinVersion:            tls.VersionTLS13,                       // Enforce modern TLS version
+		VerifyPeerCertificate: revocationVerifier(revocation, policy), // nil unless revocation checking is enabled
 	}
 
 	// HTTP CLIENT SETUP
-	// Create client with mTLS transport and connection timeout
-	//
-	// TO-DO: Add connection pooling to prevent "too many open files" crashes
-	// TO-DO: MaxIdleConns: 10, MaxIdleConnsPerHost: 3, IdleConnTimeout: 30*time.Second
+	// Bounded idle-connection pool prevents the "too many open files" failure
+	// mode an unconfigured Transport invited; the header/continue timeouts
+	// bound how long a single attempt can stall before withRetryMetered gives up on it.
 	client := &http.Client{
 		Transport: &http.Transport{
-			TLSClientConfig: tlsConfig,
+			TLSClientConfig:       tlsConfig,
+			MaxIdleConns:          entryHubMaxIdleConns,
+			MaxIdleConnsPerHost:   entryHubMaxIdleConnsPerHost,
+			MaxConnsPerHost:       entryHubMaxConnsPerHost,
+			IdleConnTimeout:       entryHubIdleConnTimeout,
+			ResponseHeaderTimeout: entryHubResponseHeaderTimeout,
+			ExpectContinueTimeout: entryHubExpectContinueTimeout,
 		},
 		Timeout: 30 * time.Second, // Prevent hanging connections
 	}
 
+	if metrics == nil {
+		metrics = DefaultMetrics
+	}
+
 	// Create and return the EntryHubClient instance
 	return &EntryHubClient{
 		baseURL:    fmt.Sprintf("https://%s", securitySwitchIP),
 		httpClient: client, // Use the http client created earlier, which uses TLS
+		breakers:   newCircuitBreakers(),
+		metrics:    metrics,
 	}, nil
 }
 
+// sendRequest issues method against path, returning Security-Switch's
+// response for the caller to decode. Every EntryHubClient method funnels
+// through here so pooling, circuit breaking, and metrics apply uniformly.
+//
+// Security features:
+// - A tripped circuit breaker fails fast (no dial attempted) rather than letting a caller queue up behind a timeout during a Security-Switch outage
+// - idempotent must be true only for calls Security-Switch can safely receive more than once (see withRetryMetered) - never for calls with a persistence side effect, such as registration
+//
+// Returns the HTTP response (caller must close its body) or a classified
+// *errs.Error describing the circuit-open, revoked-certificate, or dial failure.
+func (c *EntryHubClient) sendRequest(method, path string, jsonData []byte, idempotent bool) (*http.Response, error) {
+	breaker := c.breakerFor(path)
+	if !breaker.Allow() {
+		c.metrics.SetCircuitState(path, breaker.State().String())
+		return nil, errs.ServiceUnavailable("circuit_open", "Security-Switch is currently unavailable. Please try again shortly.", nil)
+	}
+
+	var resp *http.Response
+	attempt := func() error {
+		c.metrics.IncForwardAttempt(path)
+
+		var bodyReader io.Reader
+		if jsonData != nil {
+			bodyReader = bytes.NewBuffer(jsonData)
+		}
+		httpReq, err := http.NewRequest(method, c.baseURL+path, bodyReader)
+		if err != nil {
+			return err
+		}
+		if jsonData != nil {
+			httpReq.Header.Set("Content-Type", "application/json")
+		}
+
+		r, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return err
+		}
+		resp = r
+		return nil
+	}
+
+	var err error
+	if idempotent {
+		err = withRetryMetered(attempt, func() { c.metrics.IncForwardRetry(path) })
+	} else {
+		err = attempt()
+	}
+
+	breaker.RecordResult(err)
+	c.metrics.SetCircuitState(path, breaker.State().String())
+	if err != nil {
+		var revoked *revokedError
+		if errors.As(err, &revoked) {
+			return nil, errs.CertificateError("security_switch_certificate_revoked",
+				"Security-Switch certificate has been revoked. Please contact administrator.", err)
+		}
+		return nil, errs.ClassifyDialError("Security-Switch", err)
+	}
+	return resp, nil
+}
+
 // ForwardRegistration securely transmits user registration to Security-Switch.
 //
 // Security features:
 // - JSON payload serialization with input validation
-// - mTLS transport with certificate verification
+// - mTLS transport with certificate verification, circuit breaking, and revocation detection
 // - Structured error handling for network and protocol failures
 //
+// Not retried: a registration Security-Switch never acknowledged may or may
+// not have been persisted, so retrying here risks a duplicate attempt at
+// account creation.
+//
 // Returns Security-Switch response or error for network/parsing failures.
 func (c *EntryHubClient) ForwardRegistration(req types.RegisterRequest) (*types.Response, error) {
 	// REQUEST SERIALIZATION
 	// Convert registration data to JSON for secure transmission
 	jsonData, err := json.Marshal(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %v", err)
+		return nil, errs.Internal("request_marshal_failed", "Internal server error. Please contact administrator.", err)
 	}
 
-	// HTTP REQUEST SETUP
-	// Create POST request to Security-Switch registration endpoint
-	httpReq, err := http.NewRequest("POST", c.baseURL+"/api/register", bytes.NewBuffer(jsonData))
+	resp, err := c.sendRequest("POST", "/api/register", jsonData, false)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
-
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	// SECURE TRANSMISSION
-	// Send request via mTLS-authenticated connection
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request to Security-Switch: %v", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -123,8 +249,28 @@ func (c *EntryHubClient) ForwardRegistration(req types.RegisterRequest) (*types.
 	// Parse Security-Switch JSON response
 	var switchResponse types.Response
 	if err := json.NewDecoder(resp.Body).Decode(&switchResponse); err != nil {
-		return nil, fmt.Errorf("failed to decode Security-Switch response: %v", err)
+		return nil, errs.BadGateway("invalid_response", "Security-Switch returned an invalid response. Please try again later.", err)
 	}
 
 	return &switchResponse, nil
 }
+
+// Probe performs an mTLS GET against Security-Switch's /api/health, for
+// Entry-Hub's own health check to report the hop's reachability. A health
+// check is safe to repeat, so unlike ForwardRegistration it is retried via
+// sendRequest's idempotent path.
+//
+// Returns nil if Security-Switch responded with HTTP 200, or an error
+// describing the dial failure or non-200 status otherwise.
+func (c *EntryHubClient) Probe() error {
+	resp, err := c.sendRequest("GET", "/api/health", nil, true)
+	if err != nil {
+		return fmt.Errorf("security-switch unreachable: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("security-switch health returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package interfaces
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Metrics records EntryHubClient's forwarding activity for operators to
+// observe mTLS transport health without reading logs. Named after the
+// Prometheus counters/gauges an operator would wire this into
+// (forward_attempts_total, forward_retries_total, circuit_state), though no
+// Prometheus client is pulled in here - NewEntryHubClient accepts any
+// implementation, so a caller wanting real Prometheus export can supply one
+// backed by a prometheus.CounterVec without this package depending on it.
+//
+// DefaultMetrics is an in-memory implementation sufficient for Snapshot-based
+// inspection; pass nil to NewEntryHubClient to use it.
+type Metrics interface {
+	// IncForwardAttempt records one attempt (including retries) to forward a request on path.
+	IncForwardAttempt(path string)
+	// IncForwardRetry records one retry of a forward on path, i.e. an attempt beyond the first.
+	IncForwardRetry(path string)
+	// SetCircuitState records path's current circuit breaker state for export.
+	SetCircuitState(path string, state string)
+}
+
+// inMemoryMetrics is the default Metrics implementation, backing DefaultMetrics.
+type inMemoryMetrics struct {
+	mu        sync.Mutex
+	endpoints map[string]*endpointCounters
+}
+
+// endpointCounters accumulates counters for a single Security-Switch endpoint path.
+type endpointCounters struct {
+	attemptsTotal int64
+	retriesTotal  int64
+	circuitState  atomic.Value // string, written by SetCircuitState
+}
+
+// DefaultMetrics is the package-level Metrics instance NewEntryHubClient uses
+// when called with a nil metrics argument.
+var DefaultMetrics Metrics = newInMemoryMetrics()
+
+func newInMemoryMetrics() *inMemoryMetrics {
+	return &inMemoryMetrics{endpoints: make(map[string]*endpointCounters)}
+}
+
+// endpoint returns path's counters, creating them on first observation.
+func (m *inMemoryMetrics) endpoint(path string) *endpointCounters {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.endpoints[path]
+	if !ok {
+		e = &endpointCounters{}
+		e.circuitState.Store(circuitClosed.String())
+		m.endpoints[path] = e
+	}
+	return e
+}
+
+func (m *inMemoryMetrics) IncForwardAttempt(path string) {
+	atomic.AddInt64(&m.endpoint(path).attemptsTotal, 1)
+}
+
+func (m *inMemoryMetrics) IncForwardRetry(path string) {
+	atomic.AddInt64(&m.endpoint(path).retriesTotal, 1)
+}
+
+func (m *inMemoryMetrics) SetCircuitState(path string, state string) {
+	m.endpoint(path).circuitState.Store(state)
+}
+
+// EndpointSnapshot is a point-in-time read of one endpoint's counters.
+type EndpointSnapshot struct {
+	ForwardAttemptsTotal int64
+	ForwardRetriesTotal  int64
+	CircuitState         string
+}
+
+// Snapshot returns the current counters for every endpoint observed so far,
+// safe to read without racing concurrent requests. Only meaningful for the
+// default in-memory Metrics; a caller-supplied Metrics implementation exports
+// its own counters however it sees fit.
+func (m *inMemoryMetrics) Snapshot() map[string]EndpointSnapshot {
+	m.mu.Lock()
+	endpoints := make(map[string]*endpointCounters, len(m.endpoints))
+	for path, e := range m.endpoints {
+		endpoints[path] = e
+	}
+	m.mu.Unlock()
+
+	snapshot := make(map[string]EndpointSnapshot, len(endpoints))
+	for path, e := range endpoints {
+		snapshot[path] = EndpointSnapshot{
+			ForwardAttemptsTotal: atomic.LoadInt64(&e.attemptsTotal),
+			ForwardRetriesTotal:  atomic.LoadInt64(&e.retriesTotal),
+			CircuitState:         e.circuitState.Load().(string),
+		}
+	}
+	return snapshot
+}
@@ -0,0 +1,80 @@
+package interfaces
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+)
+
+// retryMaxAttempts bounds how many times withRetryMetered calls fn, including the initial attempt.
+const retryMaxAttempts = 3
+
+// retryBaseDelay and retryMaxDelay bound the exponential backoff applied
+// between attempts, before jitter.
+const (
+	retryBaseDelay = 100 * time.Millisecond
+	retryMaxDelay  = 2 * time.Second
+)
+
+// withRetryMetered calls fn up to retryMaxAttempts times, applying
+// exponential backoff with full jitter between attempts, and returns as soon
+// as fn succeeds or fn's error is classified non-retryable by isRetryable.
+// onRetry is called immediately before each attempt beyond the first, so a
+// caller can track retry counts (see EntryHubClient.sendRequest) without this
+// function itself depending on Metrics. Intended only for idempotent calls
+// (see sendRequest's idempotent parameter) - retrying a non-idempotent call
+// risks a duplicate side effect at Security-Switch.
+//
+// Returns nil on the first successful call, or the last error seen if every attempt fails.
+func withRetryMetered(fn func() error, onRetry func()) error {
+	var err error
+	for attempt := 0; attempt < retryMaxAttempts; attempt++ {
+		if attempt > 0 {
+			if !isRetryable(err) {
+				return err
+			}
+			onRetry()
+			time.Sleep(backoffDelay(attempt))
+		}
+		if err = fn(); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// isRetryable reports whether err looks like a transient transport failure
+// worth a retry, as opposed to a terminal failure (certificate revocation, a
+// 4xx Security-Switch rejected the request with, or anything else another
+// attempt can't fix).
+func isRetryable(err error) bool {
+	var revoked *revokedError
+	if errors.As(err, &revoked) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return netErr.Timeout() || isConnectionReset(err)
+	}
+	return isConnectionReset(err)
+}
+
+// isConnectionReset reports whether err's chain includes ECONNRESET or
+// ECONNREFUSED, the two raw network failures net.Error doesn't always wrap as timeouts.
+func isConnectionReset(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// backoffDelay returns the delay before retry attempt number attempt
+// (1-indexed), doubling retryBaseDelay per attempt up to retryMaxDelay, then
+// applying full jitter so many clients retrying at once don't re-dial in lockstep.
+func backoffDelay(attempt int) time.Duration {
+	delay := retryBaseDelay * time.Duration(1<<uint(attempt-1))
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(delay)))
+}
@@ -0,0 +1,72 @@
+package interfaces
+
+import (
+	"crypto/x509"
+	"fmt"
+	"log"
+
+	"ramusb/mtlsauth"
+)
+
+// RevocationPolicy controls how EntryHubClient reacts when
+// mtlsauth.RevocationChecker cannot confirm Security-Switch's server
+// certificate is unrevoked.
+type RevocationPolicy int
+
+const (
+	// RevocationDisabled performs no revocation check at all - the behavior
+	// before this policy existed.
+	RevocationDisabled RevocationPolicy = iota
+	// RevocationSoft logs a revoked-or-undeterminable status but still
+	// completes the handshake, for staged rollout before an issuer's
+	// OCSP/CRL infrastructure is fully trusted.
+	RevocationSoft
+	// RevocationHard refuses the handshake outright.
+	RevocationHard
+)
+
+// revokedError marks a TLS handshake refused under RevocationHard because
+// checker.Check did not confirm Security-Switch's certificate as good -
+// whether it reported the certificate revoked or could not determine its
+// status - so sendRequest can map it to a distinct error code instead of the
+// generic classification errs.ClassifyDialError falls back to.
+type revokedError struct {
+	cause error
+}
+
+func (e *revokedError) Error() string {
+	return fmt.Sprintf("security-switch certificate revoked: %v", e.cause)
+}
+
+func (e *revokedError) Unwrap() error {
+	return e.cause
+}
+
+// revocationVerifier builds a tls.Config.VerifyPeerCertificate hook
+// enforcing policy via checker.
+//
+// Returns nil if checker is nil or policy is RevocationDisabled, leaving
+// tls.Config.VerifyPeerCertificate unset and preserving prior behavior.
+func revocationVerifier(checker *mtlsauth.RevocationChecker, policy RevocationPolicy) func([][]byte, [][]*x509.Certificate) error {
+	if checker == nil || policy == RevocationDisabled {
+		return nil
+	}
+
+	return func(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+		if len(verifiedChains) == 0 || len(verifiedChains[0]) == 0 {
+			return fmt.Errorf("no verified security-switch certificate chain")
+		}
+
+		err := checker.Check(verifiedChains[0][0])
+		if err == nil {
+			return nil
+		}
+
+		if policy == RevocationSoft {
+			log.Printf("WARNING: security-switch certificate revocation check failed, proceeding under soft policy: %v", err)
+			return nil
+		}
+
+		return &revokedError{cause: err}
+	}
+}
@@ -14,8 +14,12 @@ import (
 	"fmt"
 	"https_server/config"
 	"https_server/handlers"
+	"https_server/tls"
+	"https_server/utils"
 	"log"
 	"net/http"
+
+	"ramusb/ratelimit"
 )
 
 // main initializes and starts the Entry-Hub HTTPS server with TLS encryption.
@@ -33,21 +37,50 @@ func main() {
 	// Load mTLS parameters for secure inter-service communication
 	cfg := config.GetConfig()
 
+	// CONFIGURATION VALIDATION
+	// Ensure all critical configuration components are valid
+	if err := cfg.ValidateConfig(); err != nil {
+		log.Fatalf("Configuration validation failed: %v", err)
+	}
+
 	// ZERO-TRUST ARCHITECTURE LOGGING
 	// Confirm mTLS client setup for distributed service mesh
 	fmt.Printf("Security-Switch IP: %s\n", cfg.SecuritySwitchIP)
 	fmt.Println("mTLS certificates configured for distributed service communication")
 
+	// READINESS
+	// Startup configuration validation has passed by this point, so
+	// /api/ready can start reporting 200 - see handlers.ReadyHandler
+	handlers.SetReady()
+
+	// RATE LIMIT BACKEND
+	// Shared token-bucket backend for the per-IP/per-account limiters below;
+	// "memory" for a single replica or "redis" for several behind a load balancer
+	rateLimitBackend, err := ratelimit.NewBackendFromEnv(cfg.RateLimitBackend, cfg.RateLimitRedisAddr)
+	if err != nil {
+		log.Fatalf("Failed to initialize rate limit backend: %v", err)
+	}
+
 	// ROUTE CONFIGURATION
 	// Setup REST API endpoints with secure handlers
-	http.HandleFunc("/api/register", handlers.RegisterHandler)
+	maxBytes := utils.MaxBytes(cfg.MaxBodyBytes)
+	registerHandler := maxBytes(ratelimit.PerAccount(
+		ratelimit.PerIP(handlers.RegisterHandler, rateLimitBackend, ratelimit.Config{Rate: 5.0 / 60.0, Burst: 5}),
+		rateLimitBackend, ratelimit.Config{Rate: 20.0 / 3600.0, Burst: 20},
+		handlers.RegistrationAccountKey,
+	))
+	http.HandleFunc("/api/register", registerHandler)
+	http.HandleFunc("/api/register/workload", maxBytes(handlers.WorkloadRegisterHandler))
 	http.HandleFunc("/api/health", handlers.HealthHandler)
+	http.HandleFunc("/api/ready", handlers.ReadyHandler)
 
 	// SERVICE INFORMATION DISPLAY
 	// Provide endpoint documentation and usage examples
 	fmt.Println("Available endpoints:")
 	fmt.Println("\tPOST /api/register (User registration)")
+	fmt.Println("\tPOST /api/register/workload (Cloud workload identity registration)")
 	fmt.Println("\tGET  /api/health (Check server status)")
+	fmt.Println("\tGET  /api/ready (Readiness probe: 200 once startup has completed)")
 	fmt.Println("Use the command below to register a new user:")
 	fmt.Println("\tcurl https://IP TAILSCALE DEL CONTAINER:8443/api/register --insecure --header \"Content-Type: application/json\" --request \"POST\" --data '{\"email\":\"your.email@example.com\",\"password\":\"password123\",\"ssh_public_key\":\"ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ... your-ssh-key\"}'")
 	fmt.Println("To stop the server press Ctrl+C")
@@ -61,5 +94,25 @@ func main() {
 	// TO-DO STEP 1: Change bind from "0.0.0.0:8443" to "127.0.0.1:8443" (localhost only)
 	// TO-DO STEP 2: Setup Tailscale serve: `tailscale serve https / http://localhost:8443`
 	// TO-DO STEP 3: Add firewall rules to block non-Tailscale traffic as backup
+	if cfg.PublicTLS.Enabled {
+		// ACME-MANAGED PUBLIC TLS
+		// Let's Encrypt issues and renews the public certificate automatically;
+		// the HTTP-01 challenge needs its own listener on :80.
+		manager, err := tls.NewManager(cfg.PublicTLS)
+		if err != nil {
+			log.Fatalf("Failed to initialize ACME TLS manager: %v", err)
+		}
+
+		go func() {
+			log.Fatal(http.ListenAndServe(":80", manager.HTTPHandler(nil)))
+		}()
+
+		server := &http.Server{
+			Addr:      "0.0.0.0:8443",
+			TLSConfig: manager.TLSConfig(),
+		}
+		log.Fatal(server.ListenAndServeTLS("", "")) // Empty strings - certificates loaded in TLSConfig
+	}
+
 	log.Fatal(http.ListenAndServeTLS("0.0.0.0:8443", "../certificates/entry-hub/server.crt", "../certificates/entry-hub/server.key", nil))
 }
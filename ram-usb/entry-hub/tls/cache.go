@@ -0,0 +1,25 @@
+package tls
+
+import (
+	"fmt"
+
+	ramusbconfig "ramusb/config"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// newCache selects the autocert.Cache implementation backing cfg.CacheBackend.
+//
+// Returns error if cfg.CacheBackend is not one this package implements.
+func newCache(cfg ramusbconfig.PublicTLSConfig) (autocert.Cache, error) {
+	switch cfg.CacheBackend {
+	case "disk":
+		return autocert.DirCache(cfg.CacheDir), nil
+	case "s3":
+		return newS3Cache(cfg)
+	case "vault":
+		return newVaultCache(cfg)
+	default:
+		return nil, fmt.Errorf("unknown public_tls.cache_backend %q", cfg.CacheBackend)
+	}
+}
@@ -0,0 +1,84 @@
+/*
+S3-backed autocert.Cache, for running Entry-Hub across multiple replicas
+that must share issued certificates rather than each requesting their own.
+*/
+package tls
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	ramusbconfig "ramusb/config"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// s3Cache implements autocert.Cache by storing each named cache entry as an
+// S3 object, keyed by name directly.
+type s3Cache struct {
+	client *s3.Client
+	bucket string
+}
+
+// newS3Cache builds an s3Cache from cfg, resolving AWS credentials via the
+// SDK's default chain.
+//
+// Returns error if the AWS SDK default configuration cannot be loaded.
+func newS3Cache(cfg ramusbconfig.PublicTLSConfig) (*s3Cache, error) {
+	awsCfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(cfg.S3Region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS SDK configuration: %v", err)
+	}
+
+	return &s3Cache{client: s3.NewFromConfig(awsCfg), bucket: cfg.S3Bucket}, nil
+}
+
+// Get implements autocert.Cache.
+//
+// Returns autocert.ErrCacheMiss if name does not exist in the bucket.
+func (c *s3Cache) Get(ctx context.Context, name string) ([]byte, error) {
+	out, err := c.client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(c.bucket), Key: aws.String(name)})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, autocert.ErrCacheMiss
+		}
+		return nil, fmt.Errorf("s3 cache get failed: %v", err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("s3 cache get failed reading body: %v", err)
+	}
+	return data, nil
+}
+
+// Put implements autocert.Cache.
+func (c *s3Cache) Put(ctx context.Context, name string, data []byte) error {
+	_, err := c.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(name),
+		Body:   bytes.NewReader(data),
+	})
+	if err != nil {
+		return fmt.Errorf("s3 cache put failed: %v", err)
+	}
+	return nil
+}
+
+// Delete implements autocert.Cache.
+func (c *s3Cache) Delete(ctx context.Context, name string) error {
+	_, err := c.client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(c.bucket), Key: aws.String(name)})
+	if err != nil {
+		return fmt.Errorf("s3 cache delete failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+/*
+Vault-backed autocert.Cache, storing each issued certificate and account key
+as its own KV v2 secret beneath the configured base path.
+*/
+package tls
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	ramusbconfig "ramusb/config"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// vaultCache implements autocert.Cache using Vault's KV v2 secrets engine,
+// one secret per cache entry name.
+type vaultCache struct {
+	client    *vaultapi.Client
+	mountPath string
+	basePath  string
+}
+
+// newVaultCache builds a vaultCache from cfg.
+//
+// Returns error if the Vault client cannot be constructed.
+func newVaultCache(cfg ramusbconfig.PublicTLSConfig) (*vaultCache, error) {
+	vaultConfig := vaultapi.DefaultConfig()
+	vaultConfig.Address = cfg.VaultAddr
+	client, err := vaultapi.NewClient(vaultConfig)
+	if err != nil {
+		return nil, fmt.Errorf("vault client initialization failed: %v", err)
+	}
+	client.SetToken(cfg.VaultToken)
+
+	mountPath := cfg.VaultKVMount
+	if mountPath == "" {
+		mountPath = "secret"
+	}
+
+	return &vaultCache{client: client, mountPath: mountPath, basePath: cfg.VaultKVPath}, nil
+}
+
+// Get implements autocert.Cache.
+//
+// Returns autocert.ErrCacheMiss if name has never been cached.
+func (c *vaultCache) Get(ctx context.Context, name string) ([]byte, error) {
+	secret, err := c.client.Logical().ReadWithContext(ctx, fmt.Sprintf("%s/data/%s/%s", c.mountPath, c.basePath, name))
+	if err != nil {
+		return nil, fmt.Errorf("vault cache get failed: %v", err)
+	}
+	if secret == nil || secret.Data["data"] == nil {
+		return nil, autocert.ErrCacheMiss
+	}
+
+	data, ok := secret.Data["data"].(map[string]interface{})
+	if !ok {
+		return nil, autocert.ErrCacheMiss
+	}
+
+	encoded, ok := data["value"].(string)
+	if !ok {
+		return nil, autocert.ErrCacheMiss
+	}
+
+	return base64.StdEncoding.DecodeString(encoded)
+}
+
+// Put implements autocert.Cache.
+func (c *vaultCache) Put(ctx context.Context, name string, data []byte) error {
+	_, err := c.client.Logical().WriteWithContext(ctx,
+		fmt.Sprintf("%s/data/%s/%s", c.mountPath, c.basePath, name),
+		map[string]interface{}{"data": map[string]interface{}{"value": base64.StdEncoding.EncodeToString(data)}})
+	if err != nil {
+		return fmt.Errorf("vault cache put failed: %v", err)
+	}
+	return nil
+}
+
+// Delete implements autocert.Cache.
+func (c *vaultCache) Delete(ctx context.Context, name string) error {
+	_, err := c.client.Logical().DeleteWithContext(ctx, fmt.Sprintf("%s/data/%s/%s", c.mountPath, c.basePath, name))
+	if err != nil {
+		return fmt.Errorf("vault cache delete failed: %v", err)
+	}
+	return nil
+}
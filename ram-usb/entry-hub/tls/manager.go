@@ -0,0 +1,70 @@
+/*
+ACME-managed public TLS for Entry-Hub, via Let's Encrypt.
+
+Selectable via RAMUSB_PUBLIC_TLS_ENABLED. Only the public-facing listener on
+:8443 uses this; the mTLS client leg to Security-Switch keeps using its own
+private-CA certificate pair untouched.
+*/
+package tls
+
+import (
+	stdtls "crypto/tls"
+	"fmt"
+	"net/http"
+
+	ramusbconfig "ramusb/config"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// letsEncryptStagingDirectory is Let's Encrypt's staging ACME directory,
+// used instead of the production one to avoid tripping production rate
+// limits while developing or testing certificate issuance.
+const letsEncryptStagingDirectory = "https://acme-staging-v02.api.letsencrypt.org/directory"
+
+// Manager wraps autocert.Manager with Entry-Hub's configured host whitelist
+// and cache backend, automating certificate issuance and renewal for the
+// public listener.
+type Manager struct {
+	autocert *autocert.Manager
+}
+
+// NewManager builds a Manager from a populated, already-validated PublicTLSConfig.
+//
+// Security features:
+// - HostPolicy restricted to cfg.HostWhitelist prevents autocert from requesting a certificate for an arbitrary Host header
+// - Staging directory override lets an operator rehearse issuance without consuming the production account's rate limit
+//
+// Returns error if cfg selects an unknown cache backend.
+func NewManager(cfg ramusbconfig.PublicTLSConfig) (*Manager, error) {
+	cache, err := newCache(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize autocert cache: %v", err)
+	}
+
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.HostWhitelist...),
+		Cache:      cache,
+		Email:      cfg.Email,
+	}
+
+	if cfg.Staging {
+		m.Client = &acme.Client{DirectoryURL: letsEncryptStagingDirectory}
+	}
+
+	return &Manager{autocert: m}, nil
+}
+
+// TLSConfig returns the *tls.Config the public HTTPS listener should serve with.
+func (m *Manager) TLSConfig() *stdtls.Config {
+	return m.autocert.TLSConfig()
+}
+
+// HTTPHandler returns the handler the separate :80 listener must serve, so
+// Let's Encrypt can complete the HTTP-01 challenge. fallback handles any
+// request that isn't part of the challenge; nil redirects to HTTPS.
+func (m *Manager) HTTPHandler(fallback http.Handler) http.Handler {
+	return m.autocert.HTTPHandler(fallback)
+}
@@ -22,6 +22,20 @@ type RegisterRequest struct {
 	Email     string `json:"email"`          // User email address for account identification
 	Password  string `json:"password"`       // Plain password for secure hashing at Database-Vault
 	SSHPubKey string `json:"ssh_public_key"` // SSH public key for storage service authentication
+	CertMode  bool   `json:"cert_mode"`      // If true, SSHPubKey is signed into a short-lived certificate instead of stored as-is
+}
+
+// WorkloadRegisterRequest contains a cloud workload identity credential
+// submitted to POST /api/register/workload in place of an email and password.
+//
+// Security features:
+// - Credential is opaque to Entry-Hub until handed to the provider-matched workloadid.Verifier, which checks its signature before anything else is trusted
+// - Provider selects which Verifier validates Credential, so a token minted for one cloud cannot be replayed against another provider's endpoint
+type WorkloadRegisterRequest struct {
+	Provider   string `json:"provider"`       // Cloud provider the credential was issued by: "azure", "aws", or "gcp"
+	Credential string `json:"credential"`     // Provider-specific identity credential (see ramusb/workloadid)
+	SSHPubKey  string `json:"ssh_public_key"` // SSH public key for storage service authentication
+	CertMode   bool   `json:"cert_mode"`      // If true, SSHPubKey is signed into a short-lived certificate instead of stored as-is
 }
 
 // LoginRequest defines user authentication data structure.
@@ -43,6 +57,27 @@ type LoginRequest struct {
 //
 // Ensures consistent error handling and success indication across services.
 type Response struct {
-	Success bool   `json:"success"` // Operation success indicator
-	Message string `json:"message"` // Human-readable status or error description
+	Success        bool   `json:"success"`                   // Operation success indicator
+	Message        string `json:"message"`                   // Human-readable status or error description
+	Code           string `json:"code,omitempty"`            // Stable machine-readable error code (see ramusb/errs), empty on success
+	SSHCertificate string `json:"ssh_certificate,omitempty"` // Signed OpenSSH user certificate, present only when the request set cert_mode
+}
+
+// HealthCheckResult is one named check within a HealthResponse, following
+// the draft application/health+json convention (draft-inadarei-api-health-check).
+type HealthCheckResult struct {
+	Status    string `json:"status"`              // "pass", "warn", or "fail"
+	LatencyMS int64  `json:"latencyMs,omitempty"` // How long the check took to run, in milliseconds
+	Error     string `json:"error,omitempty"`     // Failure or degradation detail, present only when Status isn't "pass"
+	ExpiresAt string `json:"expiresAt,omitempty"` // RFC3339 expiry timestamp, present only on the cert_expiry check
+}
+
+// HealthResponse is the GET /api/health response body, following the draft
+// application/health+json convention.
+//
+// Checks is omitted entirely for callers that either asked for ?verbose=false
+// or didn't present a verified peer certificate - see handlers.HealthHandler.
+type HealthResponse struct {
+	Status string                       `json:"status"` // Worst status across Checks: "pass", "warn", or "fail"
+	Checks map[string]HealthCheckResult `json:"checks,omitempty"`
 }
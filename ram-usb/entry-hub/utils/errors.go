@@ -8,10 +8,15 @@ Prevents information disclosure through standardized error messages.
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"https_server/types"
 	"log"
 	"net/http"
+
+	"ramusb/errs"
 )
 
 // SendErrorResponse creates standardized error response for client communication.
@@ -56,6 +61,24 @@ func SendSuccessResponse(w http.ResponseWriter, statusCode int, message string)
 	})
 }
 
+// SendSuccessResponseWithCertificate creates a standardized success response
+// carrying a signed SSH certificate, for registrations made with cert_mode set.
+//
+// Security features:
+// - Same standardized JSON structure as SendSuccessResponse, with the certificate as an additive field
+// - SSHCertificate is only ever passed through from Security-Switch, never generated here
+//
+// Sends HTTP response with specified status code, success message, and the issued certificate.
+func SendSuccessResponseWithCertificate(w http.ResponseWriter, statusCode int, message, sshCertificate string) {
+	w.WriteHeader(statusCode)
+
+	json.NewEncoder(w).Encode(types.Response{
+		Success:        true,
+		Message:        message,
+		SSHCertificate: sshCertificate,
+	})
+}
+
 // LogAndSendError provides audit logging with standardized error response.
 //
 // Security features:
@@ -73,3 +96,41 @@ func LogAndSendError(w http.ResponseWriter, statusCode int, logMessage, clientMe
 	// Send sanitized error message to prevent information disclosure
 	SendErrorResponse(w, statusCode, clientMessage)
 }
+
+// WriteError unwraps err into a *errs.Error - wrapping it in errs.Internal if
+// it isn't already one - and sends its Status/Code/Message to the client,
+// logging Cause server-side under a correlation ID for cross-referencing.
+//
+// Security features:
+//   - Only Message and Code ever reach the client; Cause (which may contain
+//     file paths, connection errors, or other internal detail) is logged only
+//   - Correlation ID lets an operator tie a client-reported failure back to the
+//     matching server log line without exposing internal error text
+//
+// Sends err's HTTP status and structured body, and logs the underlying cause.
+func WriteError(w http.ResponseWriter, err error) {
+	var e *errs.Error
+	if !errors.As(err, &e) {
+		e = errs.Internal("internal_error", "Internal server error. Please contact administrator.", err)
+	}
+
+	correlationID := newCorrelationID()
+	log.Printf("Error [%s]: %s", correlationID, e.Error())
+
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(types.Response{
+		Success: false,
+		Message: e.Message,
+		Code:    e.Code,
+	})
+}
+
+// newCorrelationID generates a short random hex token for tying a client-facing
+// error response back to its server-side log line.
+func newCorrelationID() string {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(buf)
+}
@@ -10,6 +10,8 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 )
@@ -17,16 +19,25 @@ import (
 // ReadRequestBody safely reads and validates HTTP request body content.
 //
 // Security features:
-// - Protects against oversized payload attacks
+// - Protects against oversized payload attacks via the MaxBytes middleware wrapping the route
 // - Validates request body accessibility and format
 // - Standardized error responses prevent information disclosure
 //
 // Returns request body bytes and success indicator, sends error response on failure.
 func ReadRequestBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
 	// REQUEST BODY READING
-	// Read entire body with built-in size limits from HTTP server configuration
+	// Read entire body; MaxBytes (see limits.go) has already wrapped r.Body
+	// in an http.MaxBytesReader for every route that applies it
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			// Oversized payload - caller exceeded the route's MaxBytes limit
+			LogAndSendError(w, http.StatusRequestEntityTooLarge,
+				fmt.Sprintf("request body exceeds %d byte limit", maxBytesErr.Limit),
+				"Request body too large.")
+			return nil, false
+		}
 		// Body reading failure - malformed request or connection issue
 		LogAndSendError(w, http.StatusBadRequest, "failed to read request body", "Error reading request.")
 		return nil, false
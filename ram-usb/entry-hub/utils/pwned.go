@@ -0,0 +1,263 @@
+/*
+Have I Been Pwned k-anonymity integration for weak password detection.
+
+HTTPPwnedChecker queries the Pwned Passwords range API (see
+https://haveibeenpwned.com/API/v3#PwnedPasswords) without ever sending the
+full password, or even its full hash, over the network: only the first 5
+hex characters of the password's SHA-1 digest leave this process, and the
+API returns every breached suffix sharing that prefix for a local match.
+*/
+package utils
+
+import (
+	"bufio"
+	"container/list"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pwnedRangeURL is the Pwned Passwords k-anonymity range endpoint; the
+// caller appends the 5-character hash prefix.
+const pwnedRangeURL = "https://api.pwnedpasswords.com/range/"
+
+// pwnedRequestTimeout bounds a single range-API call, so an unreachable HIBP
+// API fails fast into HTTPPwnedChecker's configured fail-open/fail-closed
+// behavior instead of stalling the registration request.
+const pwnedRequestTimeout = 3 * time.Second
+
+// PwnedChecker reports how many times a password appears in a breach corpus.
+//
+// Count returns 0 for a password that hasn't been seen breached, or an error
+// if the check itself could not be completed (as opposed to completing and
+// finding zero occurrences).
+type PwnedChecker interface {
+	Count(ctx context.Context, password string) (int, error)
+}
+
+// HTTPPwnedChecker is a PwnedChecker backed by the Pwned Passwords range API,
+// with an LRU cache of recent range responses to throttle outbound calls for
+// repeated or colliding password prefixes.
+//
+// Construct with NewHTTPPwnedChecker. Safe for concurrent use.
+type HTTPPwnedChecker struct {
+	httpClient *http.Client
+	cache      *pwnedCache
+	failClosed bool // If true, an unreachable API counts the password as breached rather than admitting it unchecked
+}
+
+// NewHTTPPwnedChecker returns an HTTPPwnedChecker backed by httpClient (or a
+// pwnedRequestTimeout-bounded default client if nil), caching up to
+// cacheSize range responses for cacheTTL each.
+func NewHTTPPwnedChecker(httpClient *http.Client, cacheSize int, cacheTTL time.Duration, failClosed bool) *HTTPPwnedChecker {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: pwnedRequestTimeout}
+	}
+	return &HTTPPwnedChecker{
+		httpClient: httpClient,
+		cache:      newPwnedCache(cacheSize, cacheTTL),
+		failClosed: failClosed,
+	}
+}
+
+// Count reports how many times password appears in the HIBP corpus, via a
+// k-anonymity range lookup on its SHA-1 digest's first 5 hex characters.
+//
+// Returns the breach count (0 if not found), or nil error always - a range
+// API failure is absorbed into the failClosed/fail-open decision rather than
+// surfaced as an error, since a deployment's tolerance for an unreachable
+// HIBP API is exactly what failClosed configures.
+func (c *HTTPPwnedChecker) Count(ctx context.Context, password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := digest[:5], digest[5:]
+
+	suffixes, ok := c.cache.get(prefix)
+	if !ok {
+		var err error
+		suffixes, err = c.fetchRange(ctx, prefix)
+		if err != nil {
+			if c.failClosed {
+				return 1, nil
+			}
+			return 0, nil
+		}
+		c.cache.put(prefix, suffixes)
+	}
+
+	return suffixes[suffix], nil
+}
+
+// fetchRange performs the range-API call for prefix, returning every
+// returned suffix's breach count keyed by the 35 remaining hex characters.
+func (c *HTTPPwnedChecker) fetchRange(ctx context.Context, prefix string) (map[string]int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pwnedRangeURL+prefix, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build pwned passwords request: %v", err)
+	}
+	req.Header.Set("Add-Padding", "true") // Decoy suffixes of random length, so traffic analysis can't infer the real response size
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("pwned passwords range request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("pwned passwords range API returned status %d", resp.StatusCode)
+	}
+
+	suffixes := make(map[string]int)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		suffix, count, ok := parseRangeLine(scanner.Text())
+		if !ok || count == 0 { // Add-Padding injects zero-count decoy suffixes; skip so a real future breach isn't shadowed by a cached zero
+			continue
+		}
+		suffixes[suffix] = count
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read pwned passwords response: %v", err)
+	}
+
+	return suffixes, nil
+}
+
+// parseRangeLine parses one "SUFFIX:count" response line.
+func parseRangeLine(line string) (suffix string, count int, ok bool) {
+	parts := strings.SplitN(strings.TrimSpace(line), ":", 2)
+	if len(parts) != 2 {
+		return "", 0, false
+	}
+	count, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, false
+	}
+	return parts[0], count, true
+}
+
+// pwnedCacheEntry holds one cached range response and when it stops being
+// trusted.
+type pwnedCacheEntry struct {
+	prefix    string
+	suffixes  map[string]int
+	expiresAt time.Time
+}
+
+// pwnedCache is a small fixed-capacity, TTL-bounded LRU cache of HIBP range
+// responses, keyed by the 5-character hash prefix, so repeated or colliding
+// password prefixes don't round-trip to the range API every time.
+//
+// Construct with newPwnedCache. Safe for concurrent use.
+type pwnedCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // front = most recently used
+}
+
+// newPwnedCache returns an empty pwnedCache holding at most capacity
+// entries, each trusted for ttl after it was cached.
+func newPwnedCache(capacity int, ttl time.Duration) *pwnedCache {
+	return &pwnedCache{
+		ttl:      ttl,
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// get returns the cached suffix counts for prefix, or false if there is none
+// or it has expired - an expired entry is evicted on the way out.
+func (c *pwnedCache) get(prefix string) (map[string]int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[prefix]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*pwnedCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, prefix)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.suffixes, true
+}
+
+// put caches suffixes for prefix, evicting the least recently used entry if
+// this insertion would exceed capacity.
+func (c *pwnedCache) put(prefix string, suffixes map[string]int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[prefix]; ok {
+		entry := elem.Value.(*pwnedCacheEntry)
+		entry.suffixes = suffixes
+		entry.expiresAt = time.Now().Add(c.ttl)
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	elem := c.order.PushFront(&pwnedCacheEntry{prefix: prefix, suffixes: suffixes, expiresAt: time.Now().Add(c.ttl)})
+	c.entries[prefix] = elem
+
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*pwnedCacheEntry).prefix)
+	}
+}
+
+// StaticPwnedChecker is the hard-coded breached-password list IsWeakPassword
+// used before HTTPPwnedChecker existed, kept as the fallback PwnedChecker for
+// when password_policy.pwned_check_enabled is false.
+type StaticPwnedChecker struct{}
+
+// staticWeakPasswords are common passwords from breach analysis and
+// dictionary attacks; HTTPPwnedChecker's corpus is far larger, so this list
+// only matters when that check is disabled.
+var staticWeakPasswords = map[string]struct{}{
+	"password": {}, "12345678": {}, "qwerty12": {}, "admin123": {},
+	"password123": {}, "letmein12": {}, "welcome1": {},
+	"monkey12": {}, "dragon12": {}, "1234567890": {}, "qwertyuiop": {},
+}
+
+// Count returns 1 if password (case-insensitively) matches a known weak
+// entry, 0 otherwise; ctx is unused since the check is a local map lookup.
+func (StaticPwnedChecker) Count(_ context.Context, password string) (int, error) {
+	if _, weak := staticWeakPasswords[strings.ToLower(password)]; weak {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// PasswordValidator decides whether a candidate password is too commonly
+// breached to accept, delegating the actual lookup to a PwnedChecker so
+// callers can swap StaticPwnedChecker and HTTPPwnedChecker per deployment.
+type PasswordValidator struct {
+	Checker PwnedChecker
+}
+
+// IsWeakPassword reports whether password has appeared in Checker's breach
+// corpus. A Checker error (only possible from a caller's own PwnedChecker
+// implementation, since HTTPPwnedChecker and StaticPwnedChecker never return
+// one) is treated as "not weak", admitting the password rather than blocking
+// registration on an internal failure unrelated to the password itself.
+func (v PasswordValidator) IsWeakPassword(ctx context.Context, password string) bool {
+	count, err := v.Checker.Count(ctx, password)
+	if err != nil {
+		return false
+	}
+	return count > 0
+}
@@ -0,0 +1,199 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// rangeHandler serves a fixed HIBP range response body for every request,
+// counting how many requests it actually receives so tests can assert on
+// HTTPPwnedChecker's cache behavior.
+func rangeHandler(t *testing.T, body string) (*httptest.Server, *int32) {
+	t.Helper()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("Add-Padding") != "true" {
+			t.Errorf("request missing Add-Padding header")
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// newTestChecker builds an HTTPPwnedChecker whose *http.Client redirects
+// every request to server instead of the real Pwned Passwords API, via a
+// RoundTripper that rewrites the scheme/host HTTPPwnedChecker's hard-coded
+// pwnedRangeURL produces, leaving production code untouched.
+func newTestChecker(t *testing.T, server *httptest.Server, cacheSize int, cacheTTL time.Duration, failClosed bool) *HTTPPwnedChecker {
+	t.Helper()
+	target, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("url.Parse(%q): %v", server.URL, err)
+	}
+	httpClient := &http.Client{Transport: &redirectTransport{target: target}}
+	return NewHTTPPwnedChecker(httpClient, cacheSize, cacheTTL, failClosed)
+}
+
+// redirectTransport rewrites every outbound request's scheme and host to
+// target before delegating to http.DefaultTransport.
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestHTTPPwnedCheckerParsesRangeResponse(t *testing.T) {
+	// The digest for "password" is 5BAA6...1E4C9B93F3F0682250B6CF8331B7EE68FD8,
+	// prefix "5BAA6", suffix "1E4C9B93F3F0682250B6CF8331B7EE68FD8".
+	server, requests := rangeHandler(t, "1E4C9B93F3F0682250B6CF8331B7EE68FD8:3730471\r\nDEADBEEF00000000000000000000000000:1\r\n")
+	checker := newTestChecker(t, server, 10, time.Minute, false)
+
+	count, err := checker.Count(context.Background(), "password")
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	if count != 3730471 {
+		t.Fatalf("Count: got %d, want 3730471", count)
+	}
+	if got := atomic.LoadInt32(requests); got != 1 {
+		t.Fatalf("requests: got %d, want 1", got)
+	}
+
+	count, err = checker.Count(context.Background(), "some other unbreached password")
+	if err != nil {
+		t.Fatalf("Count(unbreached): %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("Count(unbreached): got %d, want 0", count)
+	}
+}
+
+func TestHTTPPwnedCheckerSkipsZeroCountPaddingLines(t *testing.T) {
+	server, _ := rangeHandler(t, "DEADBEEF00000000000000000000000000:0\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:3730471\r\n")
+	checker := newTestChecker(t, server, 10, time.Minute, false)
+
+	count, err := checker.Count(context.Background(), "password")
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	if count != 3730471 {
+		t.Fatalf("Count: got %d, want 3730471", count)
+	}
+}
+
+func TestHTTPPwnedCheckerCachesRangeResponse(t *testing.T) {
+	server, requests := rangeHandler(t, "1E4C9B93F3F0682250B6CF8331B7EE68FD8:3730471\r\n")
+	checker := newTestChecker(t, server, 10, time.Minute, false)
+
+	for i := 0; i < 3; i++ {
+		if _, err := checker.Count(context.Background(), "password"); err != nil {
+			t.Fatalf("Count: %v", err)
+		}
+	}
+
+	if got := atomic.LoadInt32(requests); got != 1 {
+		t.Fatalf("requests after 3 lookups of the same prefix: got %d, want 1 (cached)", got)
+	}
+}
+
+func TestHTTPPwnedCheckerExpiresCacheEntryAfterTTL(t *testing.T) {
+	server, requests := rangeHandler(t, "1E4C9B93F3F0682250B6CF8331B7EE68FD8:3730471\r\n")
+	checker := newTestChecker(t, server, 10, time.Millisecond, false)
+
+	if _, err := checker.Count(context.Background(), "password"); err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if _, err := checker.Count(context.Background(), "password"); err != nil {
+		t.Fatalf("Count (after TTL expiry): %v", err)
+	}
+
+	if got := atomic.LoadInt32(requests); got != 2 {
+		t.Fatalf("requests after TTL expiry: got %d, want 2 (cache entry expired and re-fetched)", got)
+	}
+}
+
+func TestHTTPPwnedCheckerEvictsLeastRecentlyUsed(t *testing.T) {
+	server, requests := rangeHandler(t, "1E4C9B93F3F0682250B6CF8331B7EE68FD8:3730471\r\n")
+	checker := newTestChecker(t, server, 1, time.Minute, false)
+
+	if _, err := checker.Count(context.Background(), "password"); err != nil {
+		t.Fatalf("Count(password): %v", err)
+	}
+	if _, err := checker.Count(context.Background(), "a completely different password"); err != nil {
+		t.Fatalf("Count(other): %v", err)
+	}
+	// Capacity 1 evicted "password"'s cache entry, so this re-fetches.
+	if _, err := checker.Count(context.Background(), "password"); err != nil {
+		t.Fatalf("Count(password again): %v", err)
+	}
+
+	if got := atomic.LoadInt32(requests); got != 3 {
+		t.Fatalf("requests: got %d, want 3 (capacity-1 cache evicted the first entry)", got)
+	}
+}
+
+func TestHTTPPwnedCheckerFailOpenOnUnreachableAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+	checker := newTestChecker(t, server, 10, time.Minute, false)
+
+	count, err := checker.Count(context.Background(), "password")
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("Count (fail-open on API error): got %d, want 0", count)
+	}
+}
+
+func TestHTTPPwnedCheckerFailClosedOnUnreachableAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+	checker := newTestChecker(t, server, 10, time.Minute, true)
+
+	count, err := checker.Count(context.Background(), "password")
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	if count == 0 {
+		t.Fatal("Count (fail-closed on API error): got 0, want a nonzero count treating the password as breached")
+	}
+}
+
+func TestParseRangeLine(t *testing.T) {
+	cases := []struct {
+		line       string
+		wantSuffix string
+		wantCount  int
+		wantOK     bool
+	}{
+		{"1E4C9B93F3F0682250B6CF8331B7EE68FD8:3730471", "1E4C9B93F3F0682250B6CF8331B7EE68FD8", 3730471, true},
+		{"DEADBEEF00000000000000000000000000:0", "DEADBEEF00000000000000000000000000", 0, true},
+		{"malformed-line-no-colon", "", 0, false},
+		{"SUFFIX:not-a-number", "", 0, false},
+	}
+	for _, c := range cases {
+		suffix, count, ok := parseRangeLine(c.line)
+		if ok != c.wantOK || suffix != c.wantSuffix || count != c.wantCount {
+			t.Errorf("parseRangeLine(%q): got (%q, %d, %v), want (%q, %d, %v)",
+				c.line, suffix, count, ok, c.wantSuffix, c.wantCount, c.wantOK)
+		}
+	}
+}
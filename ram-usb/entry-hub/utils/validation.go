@@ -5,7 +5,7 @@ Implements multi-layer validation including RFC-compliant email parsing,
 SSH wire format validation, and cryptographic parameter analysis.
 Provides defense against injection attacks, weak credentials, and malformed authentication data.
 
-TO-DO in IsWeakPassword()
+Weak-password detection lives in pwned.go, backed by PasswordValidator.
 */
 package utils
 
@@ -175,84 +175,6 @@ func validateKeyStructure(algorithm string, decoded []byte) bool {
 	return embeddedAlgorithm == algorithm
 }
 
-// IsWeakPassword checks against database of commonly compromised passwords.
-//
-// Security features:
-// - Dictionary attack prevention using known weak password database
-// - Case-insensitive matching catches common variations
-// - Blocks passwords from major data breaches and credential dumps
-//
-// Returns true if password appears in weak password database.
-//
-// TO-DO: Expand weak password database or integrate with Have I Been Pwned API
-// TO-DO: Consider loading weak passwords from external file or service
-func IsWeakPassword(password string) bool {
-	// WEAK PASSWORD DATABASE
-	// Common passwords from breach analysis and dictionary attacks
-	// TO-DO: Expand this list significantly or use external password breach database
-	weakPasswords := []string{
-		"password", "12345678", "qwerty12", "admin123", "12345678",
-		"password123", "admin123", "letmein12", "welcome1",
-		"monkey12", "dragon12", "1234567890", "qwertyuiop",
-	}
-
-	// CASE-INSENSITIVE COMPARISON
-	// Convert to lowercase to catch variations like "Password123", "PASSWORD"
-	lowerPass := strings.ToLower(password)
-	for _, weak := range weakPasswords {
-		if lowerPass == weak {
-			return true
-		}
-	}
-	return false
-}
-
-// HasPasswordComplexity evaluates character diversity for brute force resistance.
-//
-// Security features:
-// - Multi-category character requirement increases entropy
-// - Balanced approach: 3 of 4 categories prevents overly restrictive policies
-// - Entropy calculation considers real-world password cracking methods
-//
-// Character categories: uppercase (A-Z), lowercase (a-z), digits (0-9), special (!@#...)
-// Returns true if password contains at least 3 character categories.
-func HasPasswordComplexity(password string) bool {
-	// CHARACTER CATEGORY TRACKING
-	// Track presence of each character type for entropy calculation
-	var hasUpper, hasLower, hasDigit, hasSpecial bool
-
-	// CHARACTER CLASSIFICATION
-	// Analyze each character to determine category membership
-	for _, char := range password {
-		switch {
-		case 'A' <= char && char <= 'Z':
-			hasUpper = true
-		case 'a' <= char && char <= 'z':
-			hasLower = true
-		case '0' <= char && char <= '9':
-			hasDigit = true
-		case strings.ContainsRune("!@#$%^&*()_+-=[]{}|;:,.<>?", char):
-			hasSpecial = true
-		}
-	}
-
-	// COMPLEXITY SCORING
-	// Count character categories for entropy assessment
-	complexity := 0
-	if hasUpper {
-		complexity++
-	}
-	if hasLower {
-		complexity++
-	}
-	if hasDigit {
-		complexity++
-	}
-	if hasSpecial {
-		complexity++
-	}
-
-	// MINIMUM COMPLEXITY THRESHOLD
-	// Require 3 of 4 categories for adequate entropy without user frustration
-	return complexity >= 3
-}
+// HasPasswordComplexity (and the fuller EstimatePasswordStrength it
+// delegates to) now live in strength.go, replacing the old 3-of-4
+// character-class count with a zxcvbn-style guess estimate.
@@ -0,0 +1,44 @@
+package errs
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// ClassifyDialError maps a raw error returned from an (*http.Client).Do call
+// against a downstream R.A.M.-U.S.B. service into a typed *Error, replacing
+// the strings.Contains(err.Error(), "connection refused")-style ladder
+// Entry-Hub's and Security-Switch's clients used to repeat. serviceName
+// (e.g. "Security-Switch") is interpolated into the client-facing message only.
+//
+// Returns nil if err is nil.
+func ClassifyDialError(serviceName string, err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return GatewayTimeout("service_timeout",
+			fmt.Sprintf("%s service timeout. Please try again later.", serviceName), err)
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return ServiceUnavailable("service_unreachable",
+			fmt.Sprintf("%s service is unavailable. Please try again later.", serviceName), err)
+	}
+
+	var certInvalid x509.CertificateInvalidError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameMismatch x509.HostnameError
+	if errors.As(err, &certInvalid) || errors.As(err, &unknownAuthority) || errors.As(err, &hostnameMismatch) {
+		return CertificateError("tls_certificate_invalid",
+			fmt.Sprintf("%s certificate validation failed. Please contact administrator.", serviceName), err)
+	}
+
+	return BadGateway("service_call_failed",
+		fmt.Sprintf("Unable to reach %s service. Please try again later.", serviceName), err)
+}
@@ -0,0 +1,100 @@
+/*
+Shared typed errors for Entry-Hub, Security-Switch, and Database-Vault handlers.
+
+Replaces the strings.Contains(err.Error(), "connection refused") style ladder
+each service's handler used to repeat after calling its downstream client, by
+giving that client a small set of typed constructors to return instead. A
+handler recovers one with a single errors.As and reads Status/Code/Message
+off it - no string matching against an error's formatted text.
+*/
+package errs
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Error is a client-facing error carrying the HTTP status and
+// machine-readable code a handler should respond with, plus the underlying
+// Cause for server-side logging only.
+//
+// Security features:
+//   - Message is always safe to return to a caller; Cause, which may contain
+//     internal details (file paths, connection errors), is never serialized
+//   - Code gives callers a stable value to branch on that survives Message wording changes
+//
+// Construct with BadRequest, Unauthorized, NotFound, Conflict,
+// ServiceUnavailable, GatewayTimeout, BadGateway, CertificateError, or Internal.
+type Error struct {
+	Status  int    // HTTP status code to respond with
+	Code    string // Stable machine-readable code, e.g. "weak_password", "switch_unreachable"
+	Message string // Client-safe description
+	Cause   error  // Underlying error, logged but never sent to the client
+}
+
+// Error implements the error interface, folding Cause into the message for
+// callers that only log err.Error() rather than unwrapping it.
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Cause)
+	}
+	return e.Message
+}
+
+// Unwrap exposes Cause to errors.Is/errors.As chains.
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// newError builds an Error for the given status; cause may be nil when there
+// is no underlying error to attach (e.g. a validation failure detected inline).
+func newError(status int, code, message string, cause error) *Error {
+	return &Error{Status: status, Code: code, Message: message, Cause: cause}
+}
+
+// BadRequest reports a client-supplied request that failed validation.
+func BadRequest(code, message string, cause error) *Error {
+	return newError(http.StatusBadRequest, code, message, cause)
+}
+
+// Unauthorized reports a missing or invalid credential.
+func Unauthorized(code, message string, cause error) *Error {
+	return newError(http.StatusUnauthorized, code, message, cause)
+}
+
+// NotFound reports a referenced resource that does not exist.
+func NotFound(code, message string, cause error) *Error {
+	return newError(http.StatusNotFound, code, message, cause)
+}
+
+// Conflict reports a request that collides with existing state, e.g. a duplicate email.
+func Conflict(code, message string, cause error) *Error {
+	return newError(http.StatusConflict, code, message, cause)
+}
+
+// CertificateError reports a failure loading, parsing, or validating mTLS
+// certificate material - a deployment/configuration problem, not a transient one.
+func CertificateError(code, message string, cause error) *Error {
+	return newError(http.StatusInternalServerError, code, message, cause)
+}
+
+// Internal reports an unexpected failure with no more specific classification.
+func Internal(code, message string, cause error) *Error {
+	return newError(http.StatusInternalServerError, code, message, cause)
+}
+
+// ServiceUnavailable reports a downstream service that refused the connection outright.
+func ServiceUnavailable(code, message string, cause error) *Error {
+	return newError(http.StatusServiceUnavailable, code, message, cause)
+}
+
+// GatewayTimeout reports a downstream service call that exceeded its deadline.
+func GatewayTimeout(code, message string, cause error) *Error {
+	return newError(http.StatusGatewayTimeout, code, message, cause)
+}
+
+// BadGateway reports a downstream service that is reachable but returned an
+// unusable response, or any network failure that doesn't fit a more specific category.
+func BadGateway(code, message string, cause error) *Error {
+	return newError(http.StatusBadGateway, code, message, cause)
+}
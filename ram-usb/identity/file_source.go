@@ -0,0 +1,31 @@
+package identity
+
+import (
+	"crypto/tls"
+
+	"ramusb/tlsprofile"
+)
+
+// FileSource resolves TLS configuration from statically configured
+// certificate/key/CA files, via each role's tlsprofile.Profile. This is
+// today's behavior for every R.A.M.-U.S.B. service, now behind the Source
+// interface so it can be swapped for SpiffeSource without touching callers.
+type FileSource struct {
+	Server tlsprofile.Profile
+	Client tlsprofile.Profile
+}
+
+// ServerTLSConfig implements Source.
+func (f FileSource) ServerTLSConfig() (*tls.Config, error) {
+	return f.Server.ServerConfig()
+}
+
+// ClientTLSConfig implements Source.
+func (f FileSource) ClientTLSConfig() (*tls.Config, error) {
+	return f.Client.ClientConfig()
+}
+
+// Close implements Source. FileSource holds no background resources.
+func (f FileSource) Close() error {
+	return nil
+}
@@ -0,0 +1,24 @@
+/*
+Workload identity sourcing for R.A.M.-U.S.B. distributed services.
+
+The three services currently share a single hand-rolled CA with certificate
+paths hardcoded per role, requiring long-lived certs to be shipped to each
+node by hand. Source abstracts where a service's TLS identity comes from, so
+that hand-rolled PKI can be swapped for short-lived, automatically-rotated
+SPIFFE/SVID identities issued by a local SPIRE agent without touching the
+servers and clients that consume a Source.
+*/
+package identity
+
+import "crypto/tls"
+
+// Source resolves the *tls.Config a service should serve or dial with, for
+// whichever identity backend it wraps.
+type Source interface {
+	// ServerTLSConfig returns the *tls.Config an inbound mTLS listener should serve with.
+	ServerTLSConfig() (*tls.Config, error)
+	// ClientTLSConfig returns the *tls.Config an outbound mTLS connection should dial with.
+	ClientTLSConfig() (*tls.Config, error)
+	// Close releases any background resources (e.g. a SPIRE Workload API stream).
+	Close() error
+}
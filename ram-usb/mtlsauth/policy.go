@@ -0,0 +1,147 @@
+/*
+Shared mTLS client authorization middleware building blocks.
+
+Lets Database-Vault, Security-Switch, and Storage-Service authorize inbound
+mTLS client certificates with the same revocation checking and identity
+extraction logic, behind whichever AuthzPolicy fits that service's trust
+model - the legacy Subject.Organization check, or a SPIFFE-ID allowlist.
+*/
+package mtlsauth
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strings"
+)
+
+// AuthzPolicy decides whether a verified client certificate is authorized to
+// call a particular service, independent of how that certificate's chain and
+// revocation status were checked.
+type AuthzPolicy interface {
+	// Authorize returns nil if cert is authorized, or an error describing why not.
+	Authorize(cert *x509.Certificate) error
+}
+
+// OrgPolicy authorizes a client certificate whose first Subject.Organization
+// entry matches Org exactly - the scheme every service used before SPIFFE
+// identities existed.
+type OrgPolicy struct {
+	Org string
+}
+
+// Authorize implements AuthzPolicy.
+func (p OrgPolicy) Authorize(cert *x509.Certificate) error {
+	if len(cert.Subject.Organization) == 0 || cert.Subject.Organization[0] != p.Org {
+		return fmt.Errorf("unauthorized organization: %v", cert.Subject.Organization)
+	}
+	return nil
+}
+
+// SPIFFEPolicy authorizes a client certificate carrying a spiffe:// URI SAN
+// that matches one of Allowed. An allowed entry ending in "/*" matches any
+// ID sharing that prefix, e.g. "spiffe://ramusb.local/security-switch/*".
+type SPIFFEPolicy struct {
+	Allowed []string
+}
+
+// Authorize implements AuthzPolicy.
+func (p SPIFFEPolicy) Authorize(cert *x509.Certificate) error {
+	id, ok := SPIFFEID(cert)
+	if !ok {
+		return fmt.Errorf("certificate has no spiffe:// URI SAN")
+	}
+	for _, pattern := range p.Allowed {
+		if spiffeMatches(pattern, id) {
+			return nil
+		}
+	}
+	return fmt.Errorf("spiffe ID %q is not in the allowed list", id)
+}
+
+// SPIFFEID returns the certificate's spiffe:// URI SAN, if it has one.
+func SPIFFEID(cert *x509.Certificate) (string, bool) {
+	for _, u := range cert.URIs {
+		if u.Scheme == "spiffe" {
+			return u.String(), true
+		}
+	}
+	return "", false
+}
+
+// IdentityOf returns the identity a service should log and authorize
+// against for cert: its spiffe:// URI SAN if it has one, else its
+// Subject.CommonName. Used by callers layering a per-route identity check
+// on top of a service-wide AuthzPolicy, where SPIFFEPolicy/SANPolicy's own
+// matching logic isn't otherwise exposed.
+func IdentityOf(cert *x509.Certificate) string {
+	if id, ok := SPIFFEID(cert); ok {
+		return id
+	}
+	return cert.Subject.CommonName
+}
+
+// MatchesAny reports whether id satisfies any pattern in allowed, using the
+// same trailing "/*" wildcard convention as SPIFFEPolicy.
+func MatchesAny(id string, allowed []string) bool {
+	for _, pattern := range allowed {
+		if spiffeMatches(pattern, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// spiffeMatches reports whether id satisfies pattern, treating a trailing
+// "/*" as a wildcard over everything after that prefix.
+func spiffeMatches(pattern, id string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(id, prefix+"/")
+	}
+	return pattern == id
+}
+
+// SANPolicy authorizes a client certificate carrying at least one DNS or URI
+// SAN present in its allowlist, re-validating SANs explicitly rather than
+// trusting that chaining to the CA alone proves the peer is who this
+// service expects - the same reasoning behind PermittedDNSDomains name
+// constraints, applied at the consuming service instead of the CA. A
+// certificate with no SANs at all is never authorized; CommonName is never
+// consulted as a fallback.
+type SANPolicy struct {
+	AllowedDNS  []string // e.g. "*.security-switch.ramusb.internal"
+	AllowedURIs []string // e.g. "spiffe://ramusb.local/security-switch/*"
+}
+
+// Authorize implements AuthzPolicy.
+func (p SANPolicy) Authorize(cert *x509.Certificate) error {
+	if len(cert.DNSNames) == 0 && len(cert.URIs) == 0 {
+		return fmt.Errorf("certificate has no DNS or URI SANs")
+	}
+
+	for _, name := range cert.DNSNames {
+		for _, pattern := range p.AllowedDNS {
+			if dnsNameMatches(pattern, name) {
+				return nil
+			}
+		}
+	}
+	for _, u := range cert.URIs {
+		id := u.String()
+		for _, pattern := range p.AllowedURIs {
+			if spiffeMatches(pattern, id) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("no DNS or URI SAN (DNS=%v, URIs=%v) matched the allowed list", cert.DNSNames, cert.URIs)
+}
+
+// dnsNameMatches reports whether name satisfies pattern, treating a leading
+// "*." as a PermittedDNSDomains-style name constraint: it matches any name
+// under that domain, at any depth, but not the domain itself.
+func dnsNameMatches(pattern, name string) bool {
+	if domain, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(name, "."+domain)
+	}
+	return pattern == name
+}
@@ -0,0 +1,174 @@
+package mtlsauth
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// crlRefreshInterval is how long a fetched CRL is reused before the CRL
+// fallback refetches it (the CRL's own NextUpdate is not consulted), and the
+// cache lifetime given to an OCSP response that carries no NextUpdate.
+const crlRefreshInterval = 1 * time.Hour
+
+// ocspCacheEntry is a cached OCSP response for one certificate serial number.
+type ocspCacheEntry struct {
+	status     int
+	nextUpdate time.Time
+}
+
+// RevocationChecker checks a client certificate's revocation status via
+// OCSP, falling back to a periodically refreshed CRL when the certificate
+// has no usable OCSP responder or the responder cannot be reached.
+//
+// Security features:
+// - OCSP responses are cached by serial number honoring the responder's own NextUpdate, so a revoked certificate cannot outlive the cache past the CA's stated freshness window
+// - CRL fallback means a temporarily unreachable OCSP responder does not silently grant access
+//
+// Construct with NewRevocationChecker.
+type RevocationChecker struct {
+	issuer     *x509.Certificate
+	crlURLs    []string
+	httpClient *http.Client
+
+	mu         sync.Mutex
+	ocspCache  map[string]ocspCacheEntry
+	crl        *x509.RevocationList
+	crlFetched time.Time
+}
+
+// NewRevocationChecker returns a RevocationChecker that validates
+// certificates issued by issuer, consulting OCSP first and crlURLs (in
+// order) as a fallback.
+func NewRevocationChecker(issuer *x509.Certificate, crlURLs []string) *RevocationChecker {
+	return &RevocationChecker{
+		issuer:     issuer,
+		crlURLs:    crlURLs,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		ocspCache:  make(map[string]ocspCacheEntry),
+	}
+}
+
+// Check returns error if cert is revoked, or if revocation status cannot be
+// determined by either OCSP or the configured CRL distribution points.
+func (c *RevocationChecker) Check(cert *x509.Certificate) error {
+	serial := cert.SerialNumber.String()
+
+	c.mu.Lock()
+	entry, cached := c.ocspCache[serial]
+	c.mu.Unlock()
+	if cached && time.Now().Before(entry.nextUpdate) {
+		if entry.status == ocsp.Revoked {
+			return fmt.Errorf("certificate serial %s is revoked (OCSP)", serial)
+		}
+		return nil
+	}
+
+	status, nextUpdate, err := c.checkOCSP(cert)
+	if err == nil {
+		c.mu.Lock()
+		c.ocspCache[serial] = ocspCacheEntry{status: status, nextUpdate: nextUpdate}
+		c.mu.Unlock()
+		if status == ocsp.Revoked {
+			return fmt.Errorf("certificate serial %s is revoked (OCSP)", serial)
+		}
+		return nil
+	}
+
+	return c.checkCRL(cert)
+}
+
+// checkOCSP builds an OCSP request via ocsp.CreateRequest, POSTs it to the
+// responder URL parsed from cert's AuthorityInformationAccess, and parses
+// the response.
+func (c *RevocationChecker) checkOCSP(cert *x509.Certificate) (status int, nextUpdate time.Time, err error) {
+	if len(cert.OCSPServer) == 0 {
+		return 0, time.Time{}, fmt.Errorf("certificate has no OCSP responder")
+	}
+
+	reqBytes, err := ocsp.CreateRequest(cert, c.issuer, nil)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to build OCSP request: %v", err)
+	}
+
+	resp, err := c.httpClient.Post(cert.OCSPServer[0], "application/ocsp-request", bytes.NewReader(reqBytes))
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("OCSP responder unreachable: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to read OCSP response: %v", err)
+	}
+
+	parsed, err := ocsp.ParseResponse(body, c.issuer)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to parse OCSP response: %v", err)
+	}
+
+	responseNextUpdate := parsed.NextUpdate
+	if responseNextUpdate.IsZero() {
+		responseNextUpdate = time.Now().Add(crlRefreshInterval)
+	}
+	return parsed.Status, responseNextUpdate, nil
+}
+
+// checkCRL refreshes the cached CRL if stale, then looks cert's serial
+// number up in it.
+func (c *RevocationChecker) checkCRL(cert *x509.Certificate) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.crl == nil || time.Now().After(c.crlFetched.Add(crlRefreshInterval)) {
+		crl, err := c.fetchCRL()
+		if err != nil {
+			return fmt.Errorf("revocation status unknown: OCSP failed and CRL fetch failed: %v", err)
+		}
+		c.crl = crl
+		c.crlFetched = time.Now()
+	}
+
+	for _, revoked := range c.crl.RevokedCertificateEntries {
+		if revoked.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+			return fmt.Errorf("certificate serial %s is revoked (CRL)", cert.SerialNumber.String())
+		}
+	}
+	return nil
+}
+
+// fetchCRL tries each configured distribution point in order and parses the
+// first one that succeeds.
+func (c *RevocationChecker) fetchCRL() (*x509.RevocationList, error) {
+	if len(c.crlURLs) == 0 {
+		return nil, fmt.Errorf("no CRL distribution points configured")
+	}
+
+	var lastErr error
+	for _, url := range c.crlURLs {
+		resp, err := c.httpClient.Get(url)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		crl, err := x509.ParseRevocationList(body)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return crl, nil
+	}
+	return nil, lastErr
+}
@@ -0,0 +1,53 @@
+package mtlsauth
+
+import (
+	"crypto/x509"
+	"fmt"
+)
+
+// Verifier authorizes mTLS client certificates by checking revocation status
+// and then delegating identity authorization to an AuthzPolicy, so
+// Database-Vault, Security-Switch, and Storage-Service can share one
+// implementation behind different policies.
+//
+// Construct with NewVerifier.
+type Verifier struct {
+	policy     AuthzPolicy
+	revocation *RevocationChecker // nil disables revocation checking
+}
+
+// NewVerifier returns a Verifier that authorizes certificates against
+// policy, optionally checking revocation via revocation. Pass a nil
+// revocation to skip revocation checking entirely.
+func NewVerifier(policy AuthzPolicy, revocation *RevocationChecker) *Verifier {
+	return &Verifier{policy: policy, revocation: revocation}
+}
+
+// Authorize returns nil if cert is both unrevoked (when revocation checking
+// is enabled) and authorized by the configured policy, or the first error
+// encountered otherwise.
+func (v *Verifier) Authorize(cert *x509.Certificate) error {
+	if v.revocation != nil {
+		if err := v.revocation.Check(cert); err != nil {
+			return fmt.Errorf("revocation check failed: %v", err)
+		}
+	}
+	if err := v.policy.Authorize(cert); err != nil {
+		return fmt.Errorf("authorization denied: %v", err)
+	}
+	return nil
+}
+
+// VerifyPeerCertificate adapts Authorize to the tls.Config.VerifyPeerCertificate
+// signature, so a server can reject an unauthorized or revoked peer at the
+// TLS handshake itself, as defense in depth alongside the HTTP-level check
+// VerifyMTLS middleware already performs after routing. verifiedChains is
+// only populated once crypto/tls has confirmed the presented chain verifies
+// against ClientCAs, so Authorize only ever sees a certificate already known
+// to chain to the configured trust root.
+func (v *Verifier) VerifyPeerCertificate(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	if len(verifiedChains) == 0 || len(verifiedChains[0]) == 0 {
+		return fmt.Errorf("no verified client certificate chain")
+	}
+	return v.Authorize(verifiedChains[0][0])
+}
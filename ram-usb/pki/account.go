@@ -0,0 +1,113 @@
+/*
+ACME account registration, bound to a service identity via external account
+binding (EAB) rather than left open to anyone who can reach new-account.
+
+Each R.A.M.-U.S.B. node is provisioned out-of-band with a bootstrap JWK (a
+shared HS256 key) naming the service it is entitled to request certificates
+for. new-account requires the new account key to be bound to that bootstrap
+key, so a compromised network position alone can't mint an account claiming
+to be, say, "database-vault".
+*/
+package pki
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"sync"
+)
+
+// Account is a registered ACME account, scoped to exactly one
+// R.A.M.-U.S.B. service identity.
+type Account struct {
+	Thumbprint  string // RFC 7638 JWK thumbprint; also the account's URL path segment
+	PublicKey   *ecdsa.PublicKey
+	ServiceName string // e.g. "entry-hub", matched against the CSR's spiffe:// URI SAN at finalize time
+}
+
+// AccountStore holds registered accounts in memory, enforcing that each
+// service identity is bound to at most one account key at a time.
+//
+// Construct with NewAccountStore.
+type AccountStore struct {
+	mu        sync.Mutex
+	byThumb   map[string]*Account
+	byService map[string]*Account
+}
+
+// NewAccountStore returns an empty AccountStore.
+func NewAccountStore() *AccountStore {
+	return &AccountStore{
+		byThumb:   make(map[string]*Account),
+		byService: make(map[string]*Account),
+	}
+}
+
+// Register binds a new account key to serviceName, or returns the existing
+// account if this exact key is already registered (new-account is
+// idempotent per RFC 8555).
+//
+// Returns error if serviceName already has a different account key
+// registered - "account keys must be unique per service" means a service
+// can't silently rotate its key without first revoking the old account out
+// of band.
+func (s *AccountStore) Register(thumbprint, serviceName string, pub *ecdsa.PublicKey) (*Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.byThumb[thumbprint]; ok {
+		return existing, nil
+	}
+	if existing, ok := s.byService[serviceName]; ok {
+		return nil, fmt.Errorf("service %q already has a registered account (thumbprint %s); revoke it before re-enrolling", serviceName, existing.Thumbprint)
+	}
+
+	account := &Account{Thumbprint: thumbprint, PublicKey: pub, ServiceName: serviceName}
+	s.byThumb[thumbprint] = account
+	s.byService[serviceName] = account
+	return account, nil
+}
+
+// Lookup returns the account registered under thumbprint, if any.
+func (s *AccountStore) Lookup(thumbprint string) (*Account, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account, ok := s.byThumb[thumbprint]
+	return account, ok
+}
+
+// Provisioners holds the bootstrap HS256 keys new-account verifies external
+// account bindings against, one per service identity.
+//
+// Construct with NewProvisioners.
+type Provisioners struct {
+	keys map[string][]byte // service name -> bootstrap key material
+}
+
+// NewProvisioners returns a Provisioners trusting keys, typically loaded
+// from a bootstrap JWK file provisioned onto each node ahead of time.
+func NewProvisioners(keys map[string][]byte) *Provisioners {
+	return &Provisioners{keys: keys}
+}
+
+// verifyBinding checks that eab - the externalAccountBinding JWS from a
+// new-account request - is signed by serviceName's bootstrap key and
+// carries accountJWK as its payload, proving whoever holds the new account
+// key also holds that node's bootstrap secret.
+func (p *Provisioners) verifyBinding(serviceName string, eab *jws, accountJWK *jsonWebKey) error {
+	key, ok := p.keys[serviceName]
+	if !ok {
+		return fmt.Errorf("no provisioner bootstrap key configured for service %q", serviceName)
+	}
+	if err := eab.verifyHMAC(key); err != nil {
+		return fmt.Errorf("external account binding verification failed: %v", err)
+	}
+
+	var boundJWK jsonWebKey
+	if err := eab.decodePayload(&boundJWK); err != nil {
+		return fmt.Errorf("failed to parse external account binding payload: %v", err)
+	}
+	if boundJWK != *accountJWK {
+		return fmt.Errorf("external account binding does not match the requesting account key")
+	}
+	return nil
+}
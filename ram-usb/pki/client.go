@@ -0,0 +1,376 @@
+/*
+ACME client for enrolling a R.A.M.-U.S.B. service identity against this
+internal CA.
+
+Generalizes the order/authorize/finalize cycle
+https_server/certmgr.ACMEClientSource drives against a public ACME CA,
+binding instead to this CA's external-account-binding provisioning model and
+issuing certificates carrying a spiffe://ramusb/<service> URI SAN rather
+than a plain DNS-validated leaf.
+*/
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// minRenewalWait bounds how soon Client retries renewal after a very
+// short-lived certificate, so a misconfigured CA TTL can't spin the renewal
+// loop into a tight retry storm - mirrors certmgr.minRenewalWait.
+const minRenewalWait = time.Minute
+
+// Client enrolls serviceName against a pki.Server and keeps its leaf
+// certificate renewed in the background.
+//
+// Security features:
+// - The account key and leaf key are generated fresh in memory and never written to disk
+// - Renewal at 2/3 of the certificate's lifetime (1/3 of its validity remaining) keeps the mesh working through a transient CA outage
+//
+// Construct with NewClient.
+type Client struct {
+	baseURL      string
+	serviceName  string
+	identifier   string // Tailscale hostname this node answers to
+	bootstrapKey []byte // this node's EAB secret, provisioned out of band
+	httpClient   *http.Client
+
+	accountKey *ecdsa.PrivateKey
+	accountJWK *jsonWebKey
+	kid        string // account URL, populated after registration
+
+	dir directory
+
+	mu       sync.RWMutex
+	current  *tls.Certificate
+	notAfter time.Time
+}
+
+// NewClient registers a new ACME account bound to serviceName via
+// bootstrapKey, obtains an initial leaf certificate for identifier, then
+// starts a background goroutine renewing it until ctx is done.
+//
+// Returns error if account registration or the initial issuance fails.
+func NewClient(ctx context.Context, baseURL, serviceName, identifier string, bootstrapKey []byte, httpClient *http.Client) (*Client, error) {
+	accountKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ACME account key: %v", err)
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	c := &Client{
+		baseURL:      baseURL,
+		serviceName:  serviceName,
+		identifier:   identifier,
+		bootstrapKey: bootstrapKey,
+		httpClient:   httpClient,
+		accountKey:   accountKey,
+		accountJWK:   jwkFromECDSAPublicKey(&accountKey.PublicKey),
+	}
+
+	dirResp, err := c.httpClient.Get(baseURL + "/acme/directory")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch ACME directory: %v", err)
+	}
+	defer dirResp.Body.Close()
+	if err := json.NewDecoder(dirResp.Body).Decode(&c.dir); err != nil {
+		return nil, fmt.Errorf("failed to parse ACME directory: %v", err)
+	}
+
+	if err := c.registerAccount(); err != nil {
+		return nil, fmt.Errorf("account registration failed: %v", err)
+	}
+	if err := c.renew(); err != nil {
+		return nil, fmt.Errorf("initial certificate issuance failed: %v", err)
+	}
+
+	go c.renewalLoop(ctx)
+
+	return c, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate, for services
+// terminating inbound mTLS with this identity.
+func (c *Client) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.certificate()
+}
+
+// GetClientCertificate implements certmgr.Source and tls.Config.GetClientCertificate,
+// for services dialing the next hop with this identity.
+func (c *Client) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return c.certificate()
+}
+
+func (c *Client) certificate() (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.current == nil {
+		return nil, fmt.Errorf("no certificate issued yet")
+	}
+	return c.current, nil
+}
+
+// renewalLoop re-enrolls at 2/3 of the certificate's lifetime (i.e. with
+// 1/3 of its validity remaining) until ctx is done.
+func (c *Client) renewalLoop(ctx context.Context) {
+	for {
+		c.mu.RLock()
+		notAfter := c.notAfter
+		c.mu.RUnlock()
+
+		wait := time.Until(notAfter) * 2 / 3
+		if wait < minRenewalWait {
+			wait = minRenewalWait
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		if err := c.renew(); err != nil {
+			continue
+		}
+	}
+}
+
+// registerAccount fetches a nonce and submits new-account, bound to
+// c.serviceName via external account binding against c.bootstrapKey.
+func (c *Client) registerAccount() error {
+	nonce, err := c.fetchNonce()
+	if err != nil {
+		return err
+	}
+
+	eab, err := signHS256(c.bootstrapKey, c.dir.NewAccount, c.serviceName, c.accountJWK)
+	if err != nil {
+		return fmt.Errorf("failed to build external account binding: %v", err)
+	}
+	eabJSON, err := json.Marshal(eab)
+	if err != nil {
+		return fmt.Errorf("failed to marshal external account binding: %v", err)
+	}
+
+	payload := newAccountRequest{TermsOfServiceAgreed: true, ExternalAccountBinding: eabJSON}
+	envelope, err := signES256(c.accountKey, c.dir.NewAccount, nonce, "", c.accountJWK, payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.post(c.dir.NewAccount, envelope)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("new-account failed: %s", readProblem(resp))
+	}
+
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return fmt.Errorf("new-account response carried no account URL")
+	}
+	c.kid = location
+	return nil
+}
+
+// renew drives one full order/finalize cycle and installs the resulting certificate.
+func (c *Client) renew() error {
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate leaf key: %v", err)
+	}
+
+	order, err := c.newOrder()
+	if err != nil {
+		return fmt.Errorf("new-order failed: %v", err)
+	}
+
+	spiffeURI, err := url.Parse("spiffe://ramusb/" + c.serviceName)
+	if err != nil {
+		return fmt.Errorf("failed to build SPIFFE URI: %v", err)
+	}
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: c.serviceName},
+		DNSNames: []string{c.identifier},
+		URIs:     []*url.URL{spiffeURI},
+	}, leafKey)
+	if err != nil {
+		return fmt.Errorf("failed to create CSR: %v", err)
+	}
+
+	finalizeURL, ok := order["finalize"].(string)
+	if !ok {
+		return fmt.Errorf("order response carried no finalize URL")
+	}
+	finalized, err := c.finalize(finalizeURL, csrDER)
+	if err != nil {
+		return fmt.Errorf("finalize failed: %v", err)
+	}
+
+	certURL, ok := finalized["certificate"].(string)
+	if !ok {
+		return fmt.Errorf("finalized order carried no certificate URL")
+	}
+	der, issuerDER, err := c.fetchCert(certURL)
+	if err != nil {
+		return err
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return fmt.Errorf("failed to parse issued certificate: %v", err)
+	}
+
+	c.mu.Lock()
+	c.current = &tls.Certificate{Certificate: [][]byte{der, issuerDER}, PrivateKey: leafKey, Leaf: leaf}
+	c.notAfter = leaf.NotAfter
+	c.mu.Unlock()
+
+	return nil
+}
+
+func (c *Client) newOrder() (map[string]interface{}, error) {
+	nonce, err := c.fetchNonce()
+	if err != nil {
+		return nil, err
+	}
+	payload := newOrderRequest{Identifiers: []Identifier{{Type: "dns", Value: c.identifier}}}
+	envelope, err := signES256(c.accountKey, c.dir.NewOrder, nonce, c.kid, nil, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.post(c.dir.NewOrder, envelope)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("%s", readProblem(resp))
+	}
+
+	var order map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&order); err != nil {
+		return nil, fmt.Errorf("failed to parse order response: %v", err)
+	}
+	return order, nil
+}
+
+func (c *Client) finalize(finalizeURL string, csrDER []byte) (map[string]interface{}, error) {
+	nonce, err := c.fetchNonce()
+	if err != nil {
+		return nil, err
+	}
+	payload := finalizeRequest{CSR: base64.RawURLEncoding.EncodeToString(csrDER)}
+	envelope, err := signES256(c.accountKey, finalizeURL, nonce, c.kid, nil, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.post(finalizeURL, envelope)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s", readProblem(resp))
+	}
+
+	var order map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&order); err != nil {
+		return nil, fmt.Errorf("failed to parse finalize response: %v", err)
+	}
+	return order, nil
+}
+
+func (c *Client) fetchCert(certURL string) (leafDER, issuerDER []byte, err error) {
+	nonce, err := c.fetchNonce()
+	if err != nil {
+		return nil, nil, err
+	}
+	envelope, err := signES256(c.accountKey, certURL, nonce, c.kid, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := c.post(certURL, envelope)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("%s", readProblem(resp))
+	}
+
+	var body struct {
+		Certificate string `json:"certificate"`
+		Issuer      string `json:"issuer"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse cert response: %v", err)
+	}
+
+	leafDER, err = base64.RawURLEncoding.DecodeString(body.Certificate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid certificate encoding: %v", err)
+	}
+	issuerDER, err = base64.RawURLEncoding.DecodeString(body.Issuer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid issuer encoding: %v", err)
+	}
+	return leafDER, issuerDER, nil
+}
+
+// fetchNonce requests a fresh nonce via new-nonce.
+func (c *Client) fetchNonce() (string, error) {
+	resp, err := c.httpClient.Head(c.dir.NewNonce)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch nonce: %v", err)
+	}
+	defer resp.Body.Close()
+	nonce := resp.Header.Get("Replay-Nonce")
+	if nonce == "" {
+		return "", fmt.Errorf("new-nonce response carried no Replay-Nonce header")
+	}
+	return nonce, nil
+}
+
+// post JSON-encodes envelope and POSTs it to urlStr.
+func (c *Client) post(urlStr string, envelope *jws) (*http.Response, error) {
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JWS: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, urlStr, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/jose+json")
+	return c.httpClient.Do(req)
+}
+
+// readProblem renders an ACME problem document for an error message.
+func readProblem(resp *http.Response) string {
+	var p problem
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil || p.Detail == "" {
+		return resp.Status
+	}
+	return p.Detail
+}
@@ -0,0 +1,161 @@
+/*
+acme-ca runs the internal ACME certificate authority for R.A.M.-U.S.B.
+inter-service mTLS, issuing and renewing the short-lived leaf certificates
+ramusb/pki.Client obtains on each service's behalf.
+
+The ACME directory is served over mTLS (ramusb/tlsprofile) rather than plain
+HTTPS: a node must already present a certificate issued by an existing
+trust root (typically the same long-lived bootstrap certificate its
+bootstrap JWK was provisioned alongside) before its external-account-bound
+requests are even considered. This is defense in depth on top of the JWS
+authentication ramusb/pki.Server already performs at the application layer.
+
+Usage:
+
+	acme-ca -ca-cert ca.crt -ca-key ca.key -bootstrap-keys provisioners.json \
+		-base-url https://acme-ca.ramusb.ts.net:8444 -server-cert server.crt -server-key server.key \
+		-client-ca-cert client-ca.crt -listen 0.0.0.0:8444
+*/
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"ramusb/pki"
+	"ramusb/tlsprofile"
+)
+
+// crlRefreshInterval bounds how often acme-ca regenerates its CRL.
+const crlRefreshInterval = 10 * time.Minute
+
+// leafTTL is how long each certificate acme-ca issues remains valid.
+const leafTTL = 24 * time.Hour
+
+func main() {
+	caCertFile := flag.String("ca-cert", "", "PEM-encoded CA certificate")
+	caKeyFile := flag.String("ca-key", "", "PEM-encoded CA private key")
+	bootstrapKeysFile := flag.String("bootstrap-keys", "", "JSON file mapping service name to base64url bootstrap key")
+	listenAddr := flag.String("listen", "0.0.0.0:8444", "address to serve the ACME directory on")
+	clientCACertFile := flag.String("client-ca-cert", "", "PEM-encoded CA trusted for inbound mTLS client certificates")
+	serverCertFile := flag.String("server-cert", "", "PEM-encoded server certificate for this listener")
+	serverKeyFile := flag.String("server-key", "", "PEM-encoded server key for this listener")
+	baseURL := flag.String("base-url", "", "externally reachable base URL this CA is addressed at, e.g. https://acme-ca.ramusb.ts.net:8444")
+	flag.Parse()
+
+	if *caCertFile == "" || *caKeyFile == "" || *bootstrapKeysFile == "" || *baseURL == "" {
+		fmt.Fprintln(os.Stderr, "acme-ca: -ca-cert, -ca-key, -bootstrap-keys, and -base-url are required")
+		os.Exit(2)
+	}
+
+	caCert, caSigner, err := loadCA(*caCertFile, *caKeyFile)
+	if err != nil {
+		log.Fatalf("Failed to load CA: %v", err)
+	}
+
+	provisioners, err := loadProvisioners(*bootstrapKeysFile)
+	if err != nil {
+		log.Fatalf("Failed to load bootstrap keys: %v", err)
+	}
+
+	ca := pki.NewCA(caCert, caSigner, leafTTL)
+	crl := pki.NewCRLPublisher(ca)
+	server := pki.NewServer(*baseURL, ca, provisioners, crl)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	if err := crl.Start(ctx, crlRefreshInterval); err != nil {
+		log.Fatalf("Failed to start CRL publisher: %v", err)
+	}
+
+	profile := tlsprofile.Profile{
+		Mode:       tlsprofile.MutualTLS,
+		CertFile:   *serverCertFile,
+		KeyFile:    *serverKeyFile,
+		CACertFile: *clientCACertFile,
+	}
+	tlsConfig, err := profile.ServerConfig()
+	if err != nil {
+		log.Fatalf("Failed to build TLS configuration: %v", err)
+	}
+
+	httpServer := &http.Server{Addr: *listenAddr, Handler: server, TLSConfig: tlsConfig}
+
+	go func() {
+		<-ctx.Done()
+		httpServer.Close()
+	}()
+
+	log.Printf("acme-ca listening on %s", *listenAddr)
+	if err := httpServer.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("acme-ca server failed: %v", err)
+	}
+}
+
+// loadCA reads and parses the CA certificate and private key acme-ca signs with.
+func loadCA(certFile, keyFile string) (*x509.Certificate, crypto.Signer, error) {
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CA certificate: %v", err)
+	}
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return nil, nil, fmt.Errorf("failed to decode CA certificate PEM")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse CA certificate: %v", err)
+	}
+
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CA key: %v", err)
+	}
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, nil, fmt.Errorf("failed to decode CA key PEM")
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse CA key: %v", err)
+	}
+
+	return cert, key, nil
+}
+
+// loadProvisioners reads a JSON file mapping service name to its base64url
+// bootstrap key and returns it as a pki.Provisioners.
+func loadProvisioners(path string) (*pki.Provisioners, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bootstrap keys file: %v", err)
+	}
+
+	var encoded map[string]string
+	if err := json.Unmarshal(raw, &encoded); err != nil {
+		return nil, fmt.Errorf("failed to parse bootstrap keys file: %v", err)
+	}
+
+	keys := make(map[string][]byte, len(encoded))
+	for service, b64 := range encoded {
+		key, err := base64.RawURLEncoding.DecodeString(b64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bootstrap key for service %q: %v", service, err)
+		}
+		keys[service] = key
+	}
+
+	return pki.NewProvisioners(keys), nil
+}
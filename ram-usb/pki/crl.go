@@ -0,0 +1,116 @@
+/*
+Periodically refreshed CRL for the internal ACME CA.
+
+ramusb/mtlsauth.RevocationChecker already knows how to fetch and cache a CRL
+from a distribution point URL as its OCSP fallback; CRLPublisher is the
+other half, serving that CRL from the CA that issues the certificates being
+checked.
+*/
+package pki
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// crlValidity is how long each issued CRL claims to be current for; refresh
+// runs well inside this window so a consumer's cached copy never goes stale.
+const crlValidity = 1 * time.Hour
+
+// CRLPublisher periodically regenerates CA's revocation list and serves the
+// latest one over HTTP.
+//
+// Construct with NewCRLPublisher, then call Start once a background context
+// is available.
+type CRLPublisher struct {
+	ca     *CA
+	number int64 // monotonically increasing CRL number, required by RFC 5280
+
+	mu      sync.RWMutex
+	current []byte
+}
+
+// NewCRLPublisher returns a CRLPublisher for ca with no CRL generated yet;
+// call Refresh or Start before serving traffic.
+func NewCRLPublisher(ca *CA) *CRLPublisher {
+	return &CRLPublisher{ca: ca}
+}
+
+// Start refreshes the CRL immediately, then every refreshEvery until ctx is done.
+func (p *CRLPublisher) Start(ctx context.Context, refreshEvery time.Duration) error {
+	if err := p.Refresh(); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(refreshEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := p.Refresh(); err != nil {
+					// A stale-but-still-valid CRL is safer to keep serving than none at all.
+					continue
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Refresh regenerates the CRL from the CA's current revocation list.
+func (p *CRLPublisher) Refresh() error {
+	number := atomic.AddInt64(&p.number, 1)
+
+	revoked := p.ca.revokedEntries()
+	entries := make([]x509.RevocationListEntry, 0, len(revoked))
+	for serial, revokedAt := range revoked {
+		serialNum, ok := new(big.Int).SetString(serial, 10)
+		if !ok {
+			continue
+		}
+		entries = append(entries, x509.RevocationListEntry{SerialNumber: serialNum, RevocationTime: revokedAt})
+	}
+
+	now := time.Now()
+	template := &x509.RevocationList{
+		Number:                    big.NewInt(number),
+		RevokedCertificateEntries: entries,
+		ThisUpdate:                now,
+		NextUpdate:                now.Add(crlValidity),
+	}
+
+	der, err := x509.CreateRevocationList(rand.Reader, template, p.ca.Certificate(), p.ca.signer)
+	if err != nil {
+		return fmt.Errorf("failed to generate CRL: %v", err)
+	}
+
+	p.mu.Lock()
+	p.current = der
+	p.mu.Unlock()
+	return nil
+}
+
+// ServeHTTP serves the latest CRL as application/pkix-crl, the content type
+// RevocationChecker's CRL fallback expects.
+func (p *CRLPublisher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.RLock()
+	der := p.current
+	p.mu.RUnlock()
+
+	if der == nil {
+		http.Error(w, "CRL not yet generated", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/pkix-crl")
+	w.Write(der)
+}
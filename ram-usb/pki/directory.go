@@ -0,0 +1,20 @@
+package pki
+
+// directory is the ACME directory document (RFC 8555 §7.1.1) advertising
+// this CA's endpoint URLs. Fetching it is the only unauthenticated,
+// nonce-free request the protocol defines.
+type directory struct {
+	NewNonce   string `json:"newNonce"`
+	NewAccount string `json:"newAccount"`
+	NewOrder   string `json:"newOrder"`
+	RevokeCert string `json:"revokeCert"`
+}
+
+func (s *Server) directory() directory {
+	return directory{
+		NewNonce:   s.baseURL + "/acme/new-nonce",
+		NewAccount: s.baseURL + "/acme/new-account",
+		NewOrder:   s.baseURL + "/acme/new-order",
+		RevokeCert: s.baseURL + "/acme/revoke-cert",
+	}
+}
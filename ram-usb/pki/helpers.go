@@ -0,0 +1,29 @@
+package pki
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxRequestBody bounds read request bodies well above any legitimate ACME
+// request this CA's endpoints accept, so a malicious client can't exhaust
+// memory with an oversized body.
+const maxRequestBody = 64 * 1024
+
+// readAll reads and closes an HTTP request body.
+func readAll(r *http.Request) ([]byte, error) {
+	defer r.Body.Close()
+	return io.ReadAll(io.LimitReader(r.Body, maxRequestBody))
+}
+
+// parseCertificate wraps x509.ParseCertificate, matching the error-wrapping
+// convention this package's other constructors use.
+func parseCertificate(der []byte) (*x509.Certificate, error) {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %v", err)
+	}
+	return cert, nil
+}
@@ -0,0 +1,242 @@
+/*
+Minimal JWS (JSON Web Signature) support for the ACME protocol.
+
+Only the ES256 (account and order requests) and HS256 (external account
+binding, see account.go) algorithms are implemented - the two this CA's
+services and provisioners actually use - rather than pulling in a general
+JOSE dependency for a protocol surface this small.
+*/
+package pki
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// jsonWebKey is the subset of RFC 7517 this CA understands: P-256 EC public
+// keys (account and order requests) and raw symmetric key material (the
+// bootstrap key a provisioner uses for HS256 external account binding).
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	K   string `json:"k,omitempty"`
+}
+
+// jwsHeader is the protected header of a JWS, decoded from its base64url segment.
+type jwsHeader struct {
+	Alg   string      `json:"alg"`
+	Nonce string      `json:"nonce"`
+	URL   string      `json:"url"`
+	JWK   *jsonWebKey `json:"jwk,omitempty"`
+	Kid   string      `json:"kid,omitempty"`
+}
+
+// jws is the JSON Web Signature flattened serialization ACME clients send:
+// base64url-encoded protected header, payload, and signature.
+type jws struct {
+	Protected string `json:"protected"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// parseJWS decodes a request body into its three JWS segments.
+func parseJWS(body []byte) (*jws, error) {
+	var j jws
+	if err := json.Unmarshal(body, &j); err != nil {
+		return nil, fmt.Errorf("failed to parse JWS: %v", err)
+	}
+	return &j, nil
+}
+
+// header decodes and parses j's protected header.
+func (j *jws) header() (*jwsHeader, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(j.Protected)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode JWS protected header: %v", err)
+	}
+	var h jwsHeader
+	if err := json.Unmarshal(raw, &h); err != nil {
+		return nil, fmt.Errorf("failed to parse JWS protected header: %v", err)
+	}
+	return &h, nil
+}
+
+// signingInput returns the bytes ES256/HS256 sign: the protected header and
+// payload segments joined with ".", exactly as received.
+func (j *jws) signingInput() []byte {
+	return []byte(j.Protected + "." + j.Payload)
+}
+
+// decodePayload base64url-decodes j's payload and unmarshals it into v.
+func (j *jws) decodePayload(v interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(j.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to decode JWS payload: %v", err)
+	}
+	if len(raw) == 0 {
+		return nil // POST-as-GET carries an empty payload
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("failed to parse JWS payload: %v", err)
+	}
+	return nil
+}
+
+// verifyES256 checks j's signature against pub, the ES256 way: SHA-256 over
+// signingInput, signature as the raw 64-byte r||s encoding (not ASN.1 DER).
+func (j *jws) verifyES256(pub *ecdsa.PublicKey) error {
+	sig, err := base64.RawURLEncoding.DecodeString(j.Signature)
+	if err != nil {
+		return fmt.Errorf("failed to decode JWS signature: %v", err)
+	}
+	if len(sig) != 64 {
+		return fmt.Errorf("invalid ES256 signature length: %d", len(sig))
+	}
+
+	hash := sha256.Sum256(j.signingInput())
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	if !ecdsa.Verify(pub, hash[:], r, s) {
+		return fmt.Errorf("ES256 signature verification failed")
+	}
+	return nil
+}
+
+// verifyHMAC checks j's signature the HS256 way, for external account
+// binding JWS objects signed with a provisioner's bootstrap key.
+func (j *jws) verifyHMAC(key []byte) error {
+	sig, err := base64.RawURLEncoding.DecodeString(j.Signature)
+	if err != nil {
+		return fmt.Errorf("failed to decode JWS signature: %v", err)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(j.signingInput())
+	expected := mac.Sum(nil)
+
+	if !hmac.Equal(sig, expected) {
+		return fmt.Errorf("HS256 signature verification failed")
+	}
+	return nil
+}
+
+// signES256 builds a flattened JWS over payload, signed by key, with
+// protected header fields url, nonce, and either jwk (new-account, which has
+// no account yet to name by kid) or kid (every other authenticated request).
+func signES256(key *ecdsa.PrivateKey, url, nonce, kid string, jwk *jsonWebKey, payload interface{}) (*jws, error) {
+	header := jwsHeader{Alg: "ES256", Nonce: nonce, URL: url, JWK: jwk, Kid: kid}
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JWS header: %v", err)
+	}
+
+	var payloadJSON []byte
+	if payload != nil {
+		payloadJSON, err = json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal JWS payload: %v", err)
+		}
+	}
+
+	protected := base64.RawURLEncoding.EncodeToString(headerJSON)
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payloadJSON)
+
+	hash := sha256.Sum256([]byte(protected + "." + encodedPayload))
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign JWS: %v", err)
+	}
+
+	size := (key.Curve.Params().BitSize + 7) / 8
+	sig := append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...)
+
+	return &jws{
+		Protected: protected,
+		Payload:   encodedPayload,
+		Signature: base64.RawURLEncoding.EncodeToString(sig),
+	}, nil
+}
+
+// signHS256 builds the external-account-binding JWS new-account requests
+// carry: protected header {alg: HS256, kid: serviceName, url}, payload the
+// account's own JWK, signed with the node's bootstrap key.
+func signHS256(key []byte, url, serviceName string, accountJWK *jsonWebKey) (*jws, error) {
+	header := jwsHeader{Alg: "HS256", URL: url, Kid: serviceName}
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal EAB header: %v", err)
+	}
+	payloadJSON, err := json.Marshal(accountJWK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal EAB payload: %v", err)
+	}
+
+	protected := base64.RawURLEncoding.EncodeToString(headerJSON)
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payloadJSON)
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(protected + "." + encodedPayload))
+
+	return &jws{
+		Protected: protected,
+		Payload:   encodedPayload,
+		Signature: base64.RawURLEncoding.EncodeToString(mac.Sum(nil)),
+	}, nil
+}
+
+// ecdsaPublicKey decodes a P-256 jsonWebKey into a *ecdsa.PublicKey.
+func ecdsaPublicKey(jwk *jsonWebKey) (*ecdsa.PublicKey, error) {
+	if jwk == nil {
+		return nil, fmt.Errorf("missing JWK")
+	}
+	if jwk.Kty != "EC" || jwk.Crv != "P-256" {
+		return nil, fmt.Errorf("unsupported JWK type %q/%q: only EC P-256 is supported", jwk.Kty, jwk.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWK x coordinate: %v", err)
+	}
+	y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWK y coordinate: %v", err)
+	}
+	return &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}, nil
+}
+
+// jwkFromECDSAPublicKey encodes pub as the jsonWebKey account.go's
+// thumbprint and registration logic operate on.
+func jwkFromECDSAPublicKey(pub *ecdsa.PublicKey) *jsonWebKey {
+	size := (pub.Curve.Params().BitSize + 7) / 8
+	return &jsonWebKey{
+		Kty: "EC",
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size))),
+		Y:   base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size))),
+	}
+}
+
+// jwkThumbprint computes the RFC 7638 JWK thumbprint: base64url(SHA-256) of
+// the JWK's required members, serialized with sorted keys and no whitespace.
+// Used as the account identifier, so two requests presenting the same public
+// key always resolve to the same Account.
+func jwkThumbprint(jwk *jsonWebKey) (string, error) {
+	if jwk == nil {
+		return "", fmt.Errorf("missing JWK")
+	}
+	canonical := fmt.Sprintf(`{"crv":%q,"kty":%q,"x":%q,"y":%q}`, jwk.Crv, jwk.Kty, jwk.X, jwk.Y)
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
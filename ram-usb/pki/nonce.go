@@ -0,0 +1,70 @@
+package pki
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// nonceTTL bounds how long an issued nonce remains redeemable, so the
+// pool's memory is bounded even if a client requests a nonce and never
+// spends it.
+const nonceTTL = 5 * time.Minute
+
+// NoncePool issues one-time Replay-Nonce values for the ACME protocol's
+// anti-replay requirement: every POST must carry a nonce from new-nonce (no
+// other response of this CA carries a Replay-Nonce), and a nonce is valid
+// for exactly one request.
+//
+// Construct with NewNoncePool.
+type NoncePool struct {
+	mu     sync.Mutex
+	active map[string]time.Time // nonce -> expiry
+}
+
+// NewNoncePool returns an empty NoncePool.
+func NewNoncePool() *NoncePool {
+	return &NoncePool{active: make(map[string]time.Time)}
+}
+
+// Issue mints a fresh nonce, valid until consumed or nonceTTL elapses.
+func (p *NoncePool) Issue() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		// crypto/rand failing is unrecoverable; a predictable nonce would
+		// defeat the anti-replay guarantee this pool exists to provide.
+		panic("pki: failed to generate nonce: " + err.Error())
+	}
+	nonce := hex.EncodeToString(buf)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.gc()
+	p.active[nonce] = time.Now().Add(nonceTTL)
+	return nonce
+}
+
+// Consume redeems nonce, returning true exactly once for a nonce Issue
+// returned and that has not yet expired.
+func (p *NoncePool) Consume(nonce string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	expiry, ok := p.active[nonce]
+	if !ok {
+		return false
+	}
+	delete(p.active, nonce)
+	return time.Now().Before(expiry)
+}
+
+// gc drops expired nonces. Called with p.mu held.
+func (p *NoncePool) gc() {
+	now := time.Now()
+	for nonce, expiry := range p.active {
+		if now.After(expiry) {
+			delete(p.active, nonce)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+/*
+ACME order and authorization bookkeeping.
+
+A real public ACME CA authorizes an order by challenging the requester to
+prove control of each identifier (http-01, dns-01, ...). This CA's
+requesters have already proven who they are at new-account time, via
+external account binding against a per-node bootstrap key (see account.go),
+so every authorization here is created pre-validated - there is no second,
+weaker identity check left to perform before finalize.
+*/
+package pki
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// orderTTL bounds how long an order may sit unfinalized before it expires.
+const orderTTL = 1 * time.Hour
+
+// Identifier is a single name an order requests a certificate for.
+type Identifier struct {
+	Type  string `json:"type"`  // always "dns"
+	Value string `json:"value"` // Tailscale hostname, e.g. "database-vault.ramusb.ts.net"
+}
+
+// Authorization records that account is authorized to request a
+// certificate for Identifier - always Status "valid" for the reason
+// explained above.
+type Authorization struct {
+	ID         string
+	Identifier Identifier
+	Status     string // always "valid" (see above)
+}
+
+// Order tracks one certificate request from creation through finalization.
+type Order struct {
+	ID               string
+	AccountThumb     string
+	Status           string // "ready" -> "valid" once Finalize succeeds
+	Identifiers      []Identifier
+	AuthorizationIDs []string
+	Expires          time.Time
+	CertDER          []byte
+}
+
+// OrderStore holds in-flight orders and their authorizations in memory.
+//
+// Construct with NewOrderStore.
+type OrderStore struct {
+	mu     sync.Mutex
+	orders map[string]*Order
+	authz  map[string]*Authorization
+}
+
+// NewOrderStore returns an empty OrderStore.
+func NewOrderStore() *OrderStore {
+	return &OrderStore{
+		orders: make(map[string]*Order),
+		authz:  make(map[string]*Authorization),
+	}
+}
+
+// NewOrder creates an order for account over identifiers, pre-authorizing
+// every identifier (see the package doc comment for why).
+func (s *OrderStore) NewOrder(account *Account, identifiers []Identifier) (*Order, error) {
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+
+	order := &Order{
+		ID:           id,
+		AccountThumb: account.Thumbprint,
+		Status:       "ready",
+		Identifiers:  identifiers,
+		Expires:      time.Now().Add(orderTTL),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ident := range identifiers {
+		authzID, err := randomID()
+		if err != nil {
+			return nil, err
+		}
+		s.authz[authzID] = &Authorization{ID: authzID, Identifier: ident, Status: "valid"}
+		order.AuthorizationIDs = append(order.AuthorizationIDs, authzID)
+	}
+	s.orders[id] = order
+
+	return order, nil
+}
+
+// Order returns the order registered under id.
+func (s *OrderStore) Order(id string) (*Order, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order, ok := s.orders[id]
+	return order, ok
+}
+
+// Authorization returns the authorization registered under id.
+func (s *OrderStore) Authorization(id string) (*Authorization, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	authz, ok := s.authz[id]
+	return authz, ok
+}
+
+// Finalize records der as order's issued certificate and marks it valid.
+func (s *OrderStore) Finalize(order *Order, der []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order.CertDER = der
+	order.Status = "valid"
+}
+
+// randomID returns a random hex identifier for orders and authorizations.
+func randomID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
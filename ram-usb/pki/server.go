@@ -0,0 +1,415 @@
+/*
+HTTP handlers implementing the ACME subset this CA speaks: new-nonce,
+new-account, new-order, finalize, cert, and revoke-cert. Meant to be served
+behind an mTLS listener (see cmd/acme-ca) - JWS signatures authenticate the
+ACME account, while the transport-level client certificate is just this
+CA's own bootstrap bootstrapping problem (a node must already hold *some*
+cert, e.g. a long-lived enrollment cert, to reach the CA at all).
+*/
+package pki
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Server implements http.Handler for the ACME endpoints this CA exposes.
+//
+// Construct with NewServer.
+type Server struct {
+	baseURL      string
+	ca           *CA
+	accounts     *AccountStore
+	orders       *OrderStore
+	nonces       *NoncePool
+	provisioners *Provisioners
+	crl          *CRLPublisher
+
+	mux *http.ServeMux
+}
+
+// NewServer wires every ACME endpoint under baseURL (e.g.
+// "https://ca.ramusb.internal:8444").
+func NewServer(baseURL string, ca *CA, provisioners *Provisioners, crl *CRLPublisher) *Server {
+	s := &Server{
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+		ca:           ca,
+		accounts:     NewAccountStore(),
+		orders:       NewOrderStore(),
+		nonces:       NewNoncePool(),
+		provisioners: provisioners,
+		crl:          crl,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/acme/directory", s.handleDirectory)
+	mux.HandleFunc("/acme/new-nonce", s.handleNewNonce)
+	mux.HandleFunc("/acme/new-account", s.handleNewAccount)
+	mux.HandleFunc("/acme/new-order", s.handleNewOrder)
+	mux.HandleFunc("/acme/finalize/", s.handleFinalize)
+	mux.HandleFunc("/acme/cert/", s.handleCert)
+	mux.HandleFunc("/acme/revoke-cert", s.handleRevokeCert)
+	mux.HandleFunc("/acme/crl", s.crl.ServeHTTP)
+	s.mux = mux
+
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleDirectory serves the directory document. GET only, and - per the
+// ACME spec - must not mint a nonce: a client fetching the directory hasn't
+// necessarily decided to make an authenticated request yet.
+func (s *Server) handleDirectory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeProblem(w, http.StatusMethodNotAllowed, "directory requires GET")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.directory())
+}
+
+// handleNewNonce mints a fresh nonce into the Replay-Nonce header. GET or
+// HEAD, consistent with RFC 8555 §7.2.
+func (s *Server) handleNewNonce(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeProblem(w, http.StatusMethodNotAllowed, "new-nonce requires GET or HEAD")
+		return
+	}
+	w.Header().Set("Replay-Nonce", s.nonces.Issue())
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newAccountRequest is the new-account payload.
+type newAccountRequest struct {
+	TermsOfServiceAgreed   bool            `json:"termsOfServiceAgreed"`
+	ExternalAccountBinding json.RawMessage `json:"externalAccountBinding"`
+}
+
+// handleNewAccount registers an account key, requiring external account
+// binding to a provisioner's bootstrap key (see account.go).
+func (s *Server) handleNewAccount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeProblem(w, http.StatusMethodNotAllowed, "new-account requires POST")
+		return
+	}
+
+	envelope, header, err := s.readAndConsumeNonce(r)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if header.JWK == nil {
+		writeProblem(w, http.StatusBadRequest, "new-account JWS must carry jwk, not kid")
+		return
+	}
+
+	pub, err := ecdsaPublicKey(header.JWK)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := envelope.verifyES256(pub); err != nil {
+		writeProblem(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req newAccountRequest
+	if err := envelope.decodePayload(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !req.TermsOfServiceAgreed {
+		writeProblem(w, http.StatusBadRequest, "termsOfServiceAgreed must be true")
+		return
+	}
+	if len(req.ExternalAccountBinding) == 0 {
+		writeProblem(w, http.StatusBadRequest, "externalAccountBinding is required")
+		return
+	}
+
+	eab, err := parseJWS(req.ExternalAccountBinding)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	eabHeader, err := eab.header()
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	serviceName := eabHeader.Kid
+	if serviceName == "" {
+		writeProblem(w, http.StatusBadRequest, "externalAccountBinding must carry a kid naming the service identity")
+		return
+	}
+
+	if err := s.provisioners.verifyBinding(serviceName, eab, header.JWK); err != nil {
+		writeProblem(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	thumbprint, err := jwkThumbprint(header.JWK)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	account, err := s.accounts.Register(thumbprint, serviceName, pub)
+	if err != nil {
+		writeProblem(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	w.Header().Set("Location", s.baseURL+"/acme/account/"+account.Thumbprint)
+	writeJSON(w, http.StatusCreated, map[string]string{
+		"status":  "valid",
+		"service": account.ServiceName,
+	})
+}
+
+// newOrderRequest is the new-order payload.
+type newOrderRequest struct {
+	Identifiers []Identifier `json:"identifiers"`
+}
+
+// handleNewOrder creates a pre-authorized order for the requesting account (see order.go).
+func (s *Server) handleNewOrder(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeProblem(w, http.StatusMethodNotAllowed, "new-order requires POST")
+		return
+	}
+
+	account, envelope, err := s.authenticateAccount(r)
+	if err != nil {
+		writeProblem(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req newOrderRequest
+	if err := envelope.decodePayload(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(req.Identifiers) == 0 {
+		writeProblem(w, http.StatusBadRequest, "at least one identifier is required")
+		return
+	}
+
+	order, err := s.orders.NewOrder(account, req.Identifiers)
+	if err != nil {
+		writeProblem(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Location", s.baseURL+"/acme/order/"+order.ID)
+	writeJSON(w, http.StatusCreated, s.orderResponse(order))
+}
+
+// finalizeRequest is the finalize payload: a base64url-encoded, DER-encoded CSR.
+type finalizeRequest struct {
+	CSR string `json:"csr"`
+}
+
+// handleFinalize signs the order's CSR, re-verifying the requested identity
+// against the authenticated account (see CA.FinalizeOrder).
+func (s *Server) handleFinalize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeProblem(w, http.StatusMethodNotAllowed, "finalize requires POST")
+		return
+	}
+
+	orderID := strings.TrimPrefix(r.URL.Path, "/acme/finalize/")
+	order, ok := s.orders.Order(orderID)
+	if !ok {
+		writeProblem(w, http.StatusNotFound, "no such order")
+		return
+	}
+	if time.Now().After(order.Expires) {
+		writeProblem(w, http.StatusForbidden, "order has expired")
+		return
+	}
+
+	account, envelope, err := s.authenticateAccount(r)
+	if err != nil {
+		writeProblem(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if account.Thumbprint != order.AccountThumb {
+		writeProblem(w, http.StatusForbidden, "order belongs to a different account")
+		return
+	}
+
+	var req finalizeRequest
+	if err := envelope.decodePayload(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	csrDER, err := base64.RawURLEncoding.DecodeString(req.CSR)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid csr encoding")
+		return
+	}
+
+	der, err := s.ca.FinalizeOrder(order, csrDER, account)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.orders.Finalize(order, der)
+
+	writeJSON(w, http.StatusOK, s.orderResponse(order))
+}
+
+// handleCert returns the issued certificate chain for a finalized order, DER-encoded and base64url.
+func (s *Server) handleCert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeProblem(w, http.StatusMethodNotAllowed, "cert requires POST-as-GET")
+		return
+	}
+
+	orderID := strings.TrimPrefix(r.URL.Path, "/acme/cert/")
+	order, ok := s.orders.Order(orderID)
+	if !ok || order.Status != "valid" {
+		writeProblem(w, http.StatusNotFound, "no issued certificate for this order")
+		return
+	}
+
+	account, _, err := s.authenticateAccount(r)
+	if err != nil {
+		writeProblem(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if account.Thumbprint != order.AccountThumb {
+		writeProblem(w, http.StatusForbidden, "order belongs to a different account")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"certificate": base64.RawURLEncoding.EncodeToString(order.CertDER),
+		"issuer":      base64.RawURLEncoding.EncodeToString(s.ca.Certificate().Raw),
+	})
+}
+
+// revokeCertRequest is the revoke-cert payload.
+type revokeCertRequest struct {
+	Certificate string `json:"certificate"` // base64url DER
+}
+
+// handleRevokeCert revokes a certificate this CA issued.
+func (s *Server) handleRevokeCert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeProblem(w, http.StatusMethodNotAllowed, "revoke-cert requires POST")
+		return
+	}
+
+	_, envelope, err := s.authenticateAccount(r)
+	if err != nil {
+		writeProblem(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req revokeCertRequest
+	if err := envelope.decodePayload(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	der, err := base64.RawURLEncoding.DecodeString(req.Certificate)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid certificate encoding")
+		return
+	}
+	cert, err := parseCertificate(der)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.ca.Revoke(cert.SerialNumber)
+	w.WriteHeader(http.StatusOK)
+}
+
+// authenticateAccount verifies the request's JWS against its kid's
+// registered account key, consuming the request's nonce.
+func (s *Server) authenticateAccount(r *http.Request) (*Account, *jws, error) {
+	envelope, header, err := s.readAndConsumeNonce(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if header.Kid == "" {
+		return nil, nil, fmt.Errorf("request must carry kid, not jwk")
+	}
+
+	thumbprint := strings.TrimPrefix(header.Kid, s.baseURL+"/acme/account/")
+	account, ok := s.accounts.Lookup(thumbprint)
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown account %q", thumbprint)
+	}
+	if err := envelope.verifyES256(account.PublicKey); err != nil {
+		return nil, nil, err
+	}
+	return account, envelope, nil
+}
+
+// readAndConsumeNonce parses the request body as a JWS, decodes its
+// protected header, and consumes its nonce exactly once.
+func (s *Server) readAndConsumeNonce(r *http.Request) (*jws, *jwsHeader, error) {
+	body, err := readAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read request body: %v", err)
+	}
+	envelope, err := parseJWS(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	header, err := envelope.header()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !s.nonces.Consume(header.Nonce) {
+		return nil, nil, fmt.Errorf("missing or already-used nonce")
+	}
+	return envelope, header, nil
+}
+
+// orderResponse builds an order's ACME-shaped JSON representation.
+func (s *Server) orderResponse(order *Order) map[string]interface{} {
+	authzURLs := make([]string, len(order.AuthorizationIDs))
+	for i, id := range order.AuthorizationIDs {
+		authzURLs[i] = s.baseURL + "/acme/authz/" + id
+	}
+	resp := map[string]interface{}{
+		"status":         order.Status,
+		"expires":        order.Expires,
+		"identifiers":    order.Identifiers,
+		"authorizations": authzURLs,
+		"finalize":       s.baseURL + "/acme/finalize/" + order.ID,
+	}
+	if order.Status == "valid" {
+		resp["certificate"] = s.baseURL + "/acme/cert/" + order.ID
+	}
+	return resp
+}
+
+// problem is a minimal ACME-style error document (RFC 8555 §6.7).
+type problem struct {
+	Detail string `json:"detail"`
+}
+
+func writeProblem(w http.ResponseWriter, status int, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem{Detail: detail})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
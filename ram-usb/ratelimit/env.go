@@ -0,0 +1,19 @@
+package ratelimit
+
+import "fmt"
+
+// NewBackendFromEnv builds a Backend using backendKind ("memory" or "redis")
+// and, for "redis", redisAddr as the Redis instance address.
+//
+// Returns a ready-to-use Backend, or error if backendKind is unrecognized or
+// the selected backend cannot be reached.
+func NewBackendFromEnv(backendKind, redisAddr string) (Backend, error) {
+	switch backendKind {
+	case "redis":
+		return NewRedisBackend(redisAddr)
+	case "memory", "":
+		return NewMemoryBackend(), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q: expected \"memory\" or \"redis\"", backendKind)
+	}
+}
@@ -0,0 +1,21 @@
+package ratelimit
+
+import (
+	"net"
+	"net/http"
+)
+
+// ClientIP returns the requesting client's IP address, stripped of port.
+//
+// Entry-Hub has no trusted reverse proxy in front of it today, so this reads
+// r.RemoteAddr directly rather than an X-Forwarded-For header a client could
+// forge to evade per-IP limiting.
+//
+// Returns r.RemoteAddr unchanged if it cannot be split into host and port.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// lockoutEntry tracks one key's consecutive-failure history.
+type lockoutEntry struct {
+	failures    int
+	windowStart time.Time
+	lockedUntil time.Time
+}
+
+// Lockout applies exponential-backoff lockout to a key (typically an email
+// address) after threshold consecutive failures within window, independent
+// of the token-bucket rate limiting PerIP/PerAccount enforce. A compromised
+// credential stuffing attempt that stays under the per-IP/per-account rate
+// limit by spacing requests out is still caught here, since Lockout counts
+// failures rather than request volume.
+//
+// Construct with NewLockout.
+type Lockout struct {
+	threshold   int           // consecutive failures within window before lockout trips
+	window      time.Duration // failures older than this reset the counter
+	baseBackoff time.Duration // lockout duration on first trip
+	maxBackoff  time.Duration // ceiling each doubling is capped at
+
+	mu      sync.Mutex
+	entries map[string]*lockoutEntry
+}
+
+// NewLockout builds a Lockout that trips after threshold consecutive
+// failures for the same key within window, locking out for baseBackoff and
+// doubling on each subsequent trip up to maxBackoff.
+func NewLockout(threshold int, window, baseBackoff, maxBackoff time.Duration) *Lockout {
+	return &Lockout{
+		threshold:   threshold,
+		window:      window,
+		baseBackoff: baseBackoff,
+		maxBackoff:  maxBackoff,
+		entries:     make(map[string]*lockoutEntry),
+	}
+}
+
+// IsLocked reports whether key is currently locked out.
+//
+// Returns true with the remaining lockout duration, or false with zero.
+func (l *Lockout) IsLocked(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[key]
+	if !ok {
+		return false, 0
+	}
+
+	now := time.Now()
+	if now.Before(entry.lockedUntil) {
+		return true, entry.lockedUntil.Sub(now)
+	}
+	return false, 0
+}
+
+// RecordFailure registers a failed attempt for key, starting a new failure
+// window if the previous one has expired, and locks key out once failures
+// within the window reach threshold.
+//
+// Returns the lockout duration applied, or 0 if this failure didn't trip one.
+func (l *Lockout) RecordFailure(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	entry, ok := l.entries[key]
+	if !ok || now.Sub(entry.windowStart) > l.window {
+		entry = &lockoutEntry{windowStart: now}
+		l.entries[key] = entry
+	}
+	entry.failures++
+
+	if entry.failures < l.threshold {
+		return 0
+	}
+
+	backoff := l.baseBackoff * time.Duration(uint(1)<<uint(entry.failures-l.threshold))
+	if backoff <= 0 || backoff > l.maxBackoff {
+		backoff = l.maxBackoff
+	}
+	entry.lockedUntil = now.Add(backoff)
+	return backoff
+}
+
+// Reset clears key's failure history, for use after a successful attempt.
+func (l *Lockout) Reset(key string) {
+	l.mu.Lock()
+	delete(l.entries, key)
+	l.mu.Unlock()
+}
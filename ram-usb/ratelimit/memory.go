@@ -0,0 +1,79 @@
+/*
+In-memory token bucket backend for Entry-Hub rate limiting.
+
+Suitable for a single Entry-Hub replica, or as the default backend when no
+shared state store is configured. State does not survive a process restart
+and is not shared across replicas; use RedisBackend when running more than
+one Entry-Hub instance behind a load balancer.
+*/
+package ratelimit
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// shardCount bounds lock contention under concurrent requests from many
+// distinct IPs/accounts without requiring a single shared mutex per bucket.
+const shardCount = 32
+
+// MemoryBackend implements Backend with per-key *rate.Limiter buckets, held
+// in a fixed number of sharded sync.Maps to spread concurrent access.
+//
+// Construct with NewMemoryBackend.
+type MemoryBackend struct {
+	shards [shardCount]sync.Map // key -> *rate.Limiter
+}
+
+// NewMemoryBackend returns an empty MemoryBackend.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{}
+}
+
+// Allow consumes one token from the rate.Limiter for key, creating it
+// pre-filled to cfg.Burst if this is the first request seen for key.
+//
+// Returns true if a token was available, or false with the wait duration
+// until the next token would be available.
+func (b *MemoryBackend) Allow(_ context.Context, key string, cfg Config) (bool, time.Duration, error) {
+	limiter := b.getOrCreate(key, cfg)
+
+	reservation := limiter.Reserve()
+	if !reservation.OK() {
+		// cfg.Burst is 0 or negative - misconfiguration, not a rate limit hit
+		return false, 0, nil
+	}
+
+	delay := reservation.Delay()
+	if delay > 0 {
+		reservation.Cancel() // Give the token back; the caller never sends the request
+		return false, delay, nil
+	}
+
+	return true, 0, nil
+}
+
+// getOrCreate returns the *rate.Limiter for key, creating one for cfg if
+// this is the first request seen for key.
+func (b *MemoryBackend) getOrCreate(key string, cfg Config) *rate.Limiter {
+	shard := &b.shards[shardIndex(key)]
+
+	if limiter, ok := shard.Load(key); ok {
+		return limiter.(*rate.Limiter)
+	}
+
+	limiter := rate.NewLimiter(rate.Limit(cfg.Rate), cfg.Burst)
+	actual, _ := shard.LoadOrStore(key, limiter)
+	return actual.(*rate.Limiter)
+}
+
+// shardIndex hashes key to a shard in [0, shardCount).
+func shardIndex(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32() % shardCount
+}
@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// response mirrors the {success, message, code} shape every R.A.M.-U.S.B.
+// service's types.Response uses, without importing any one service's types
+// package - this module is shared across services with otherwise-unrelated
+// Response definitions.
+type response struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
+}
+
+// PerIP wraps next with a token bucket keyed by the caller's IP address (see
+// ClientIP), rejecting with HTTP 429 once cfg's rate is exceeded.
+//
+// Security features:
+// - Backend errors fail closed (HTTP 503) rather than silently admitting unlimited traffic
+// - Rejections are logged with the offending IP for audit and incident response
+//
+// Mirrors the security_switch/ratelimit Limiter.Middleware wrapping pattern,
+// keyed by IP rather than mTLS identity since Entry-Hub has none to check.
+func PerIP(next http.HandlerFunc, backend Backend, cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ip := ClientIP(r)
+
+		allowed, retryAfter, err := backend.Allow(r.Context(), "ratelimit:ip:"+ip, cfg)
+		if err != nil {
+			log.Printf("Rate limiter backend error for IP %s: %v", ip, err)
+			writeResponse(w, http.StatusServiceUnavailable, "Rate limiting temporarily unavailable. Please try again later.", "")
+			return
+		}
+		if !allowed {
+			log.Printf("Rate limit exceeded for IP %s", ip)
+			sendRateLimited(w, retryAfter)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// PerAccount wraps next with a token bucket keyed by the account keyFunc
+// extracts from the request (e.g. the registration email), rejecting with
+// HTTP 429 once cfg's rate is exceeded. Requests keyFunc can't resolve an
+// account for (ok false) pass through unthrottled here, relying on PerIP and
+// the handler's own validation to cover them.
+//
+// Security features:
+// - Backend errors fail closed (HTTP 503) rather than silently admitting unlimited traffic
+// - Rejections are logged with the offending account for audit and incident response
+//
+// Account extraction is left to keyFunc rather than fixed to one request
+// shape, since Entry-Hub's registration endpoints (email+password, cloud
+// workload identity) each name their account differently.
+func PerAccount(next http.HandlerFunc, backend Backend, cfg Config, keyFunc func(r *http.Request) (string, bool)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, ok := keyFunc(r)
+		if !ok {
+			next(w, r)
+			return
+		}
+
+		allowed, retryAfter, err := backend.Allow(r.Context(), "ratelimit:account:"+account, cfg)
+		if err != nil {
+			log.Printf("Rate limiter backend error for account %s: %v", account, err)
+			writeResponse(w, http.StatusServiceUnavailable, "Rate limiting temporarily unavailable. Please try again later.", "")
+			return
+		}
+		if !allowed {
+			log.Printf("Rate limit exceeded for account %s", account)
+			sendRateLimited(w, retryAfter)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// sendRateLimited sends HTTP 429 with a Retry-After header rounded up to whole seconds.
+func sendRateLimited(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	writeResponse(w, http.StatusTooManyRequests, "Rate limit exceeded. Please try again later.", "rate_limited")
+}
+
+// writeResponse sends a standardized JSON error body at statusCode.
+func writeResponse(w http.ResponseWriter, statusCode int, message, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(response{Success: false, Message: message, Code: code})
+}
@@ -0,0 +1,32 @@
+/*
+Per-IP and per-account rate limiting for Entry-Hub's public-facing endpoints.
+
+Entry-Hub has no verified peer certificate to key off (see
+security_switch/ratelimit, which limits by mTLS identity instead), so
+limiting here is keyed by client IP and by the account (email) a request
+names. Implemented as http.HandlerFunc wrappers, matching the
+middleware.VerifyMTLS wrapping pattern already used for mTLS enforcement.
+*/
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Config describes a single token bucket's sustained rate and burst size.
+type Config struct {
+	Rate  float64 // tokens (requests) replenished per second
+	Burst int     // maximum tokens held, i.e. the allowed burst size
+}
+
+// Backend stores rate limit state and evaluates requests against it.
+//
+// Implementations must be safe for concurrent use, since PerIP and PerAccount
+// invoke Allow once per inbound request.
+type Backend interface {
+	// Allow attempts to consume one token from the bucket identified by key,
+	// configured with cfg. Returns whether the request is allowed, and if
+	// not, how long the caller should wait before retrying.
+	Allow(ctx context.Context, key string, cfg Config) (allowed bool, retryAfter time.Duration, err error)
+}
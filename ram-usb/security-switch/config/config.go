@@ -3,13 +3,21 @@ Configuration management for Security-Switch mTLS gateway service.
 
 Provides centralized configuration for dual-role mTLS operations: server configuration
 for accepting authenticated Entry-Hub connections and client configuration for secure
-Database-Vault communication. Uses hardcoded Tailscale IPs and certificate paths for
-development with zero-trust inter-service communication.
-
-// TO-DO in GetConfig()
+Database-Vault communication. Resolves configuration through the shared ramusb/config
+module (config file plus RAMUSB_* environment variable overrides), so Tailscale IPs,
+ports, and certificate paths no longer need to be hardcoded for each deployment.
 */
 package config
 
+import (
+	"fmt"
+	"log"
+	"time"
+
+	ramusbconfig "ramusb/config"
+	"ramusb/tlsprofile"
+)
+
 // Config holds Security-Switch configuration for bidirectional mTLS communication.
 //
 // Security features:
@@ -20,43 +28,142 @@ package config
 // Supports dual mTLS roles: server (accepting Entry-Hub) and client (connecting to Database-Vault).
 type Config struct {
 	// SERVER CONFIGURATION - for accepting mTLS connections from Entry-Hub
-	ServerPort     string // Port for mTLS server listening (8444)
-	ServerCertFile string // Server certificate for Entry-Hub authentication
-	ServerKeyFile  string // Server private key for TLS handshake
-	CACertFile     string // CA certificate for client certificate validation
+	ServerPort       string // Port for mTLS server listening (8444)
+	ServerCertFile   string // Server certificate for Entry-Hub authentication
+	ServerKeyFile    string // Server private key for TLS handshake
+	CACertFile       string // CA certificate for client certificate validation
+	ServerRoutesFile string // JSON admin-SAN allowlist, hot-reloaded alongside certs - see certwatch.Watcher
+	MaxBodyBytes     int64  // Request body ceiling enforced via utils.MaxBytes, default 64 KiB
 
 	// CLIENT CONFIGURATION - for outgoing mTLS connections to Database-Vault
 	DatabaseVaultIP string // Tailscale IP:port for secure mesh communication
 	ClientCertFile  string // Client certificate for Database-Vault authentication
 	ClientKeyFile   string // Client private key for mutual TLS handshake
+
+	// RATE LIMIT CONFIGURATION - token bucket backend for per-identity request throttling
+	RateLimitBackend   string // "memory" (single replica) or "redis" (shared across replicas)
+	RateLimitRedisAddr string // Redis address, only used when RateLimitBackend is "redis"
+
+	// TLS PROFILES - per-hop mode (mutual_tls/tls/disabled), for staging and dev bring-up
+	ServerTLSProfile tlsprofile.Profile // Profile for accepting Entry-Hub connections
+	ClientTLSProfile tlsprofile.Profile // Profile for connecting to Database-Vault
+
+	// MTLS CLIENT AUTHORIZATION CONFIGURATION - for VerifyMTLS's AuthzPolicy and revocation checking
+	AuthzMode                string   // "org" (default, Subject.Organization == "EntryHub"), "spiffe", or "san"
+	AuthzSPIFFEAllowed       []string // SPIFFE ID allowlist patterns, used when AuthzMode is "spiffe"
+	AuthzSANAllowedDNS       []string // DNS SAN allowlist patterns, used when AuthzMode is "san"
+	AuthzSANAllowedURIs      []string // URI SAN allowlist patterns, used when AuthzMode is "san"
+	RevocationEnabled        bool     // Opt-in: no OCSP/CRL checking until this is set
+	RevocationIssuerCertFile string   // Issuing CA certificate, needed to verify OCSP responses
+	RevocationCRLURLs        []string // Fallback CRL distribution points, consulted when OCSP is unreachable
+
+	// FORWARD-AUTH CONFIGURATION - for middleware.ForwardAuth's OPA sidecar delegation
+	OPAEnabled    bool               // Opt-in: VerifyMTLS alone gates requests until this is set
+	OPATargetAddr string             // Tailscale IP:port of the OPA sidecar
+	OPATLSProfile tlsprofile.Profile // Profile for connecting to OPA
+	OPACacheSize  int                // Max decisions held in ForwardAuth's LRU cache
+	OPACacheTTL   time.Duration      // How long a cached allow/deny decision is trusted
+
+	// WEAK PASSWORD DETECTION - Have I Been Pwned range-API lookup, see utils.PasswordValidator
+	PwnedCheckEnabled    bool          // false falls back to utils.StaticPwnedChecker alone
+	PwnedCheckFailClosed bool          // true rejects a password outright when the HIBP API is unreachable
+	PwnedCacheSize       int           // Max range-response prefixes held in the LRU cache
+	PwnedCacheTTL        time.Duration // How long a cached range response is trusted
+
+	resolved *ramusbconfig.Config // Underlying layered config, kept for ValidateConfig
 }
 
 // GetConfig returns Security-Switch configuration with mTLS parameters for zero-trust architecture.
 //
 // Security features:
-// - Hardcoded Tailscale IPs prevent accidental external exposure
+// - Layered resolution (RAMUSB_* env vars over the config file over defaults) replaces hardcoded Tailscale IPs and certificate paths
 // - Separate certificate pairs for server/client roles enhance security isolation
 // - CA validation ensures certificate chain integrity across distributed services
 //
 // Returns pointer to Config struct with all mTLS connection parameters.
-//
-// TO-DO: In production, load configuration from environment variables
-// TO-DO: Load DATABASE_VAULT_IP from environment variable instead of hardcoded value
-
+// Terminates the process if the underlying ramusb/config layer cannot be
+// loaded, preserving this function's existing fail-fast contract for callers.
 func GetConfig() *Config {
+	resolved, err := ramusbconfig.Load("security-switch")
+	if err != nil {
+		log.Fatalf("Failed to load Security-Switch configuration: %v", err)
+	}
+
+	// Parse errors surface as a clear ValidateConfig failure rather than here;
+	// an unrecognized mode falls back to tlsprofile's zero value, MutualTLS,
+	// today's existing behavior.
+	serverMode, _ := tlsprofile.ParseMode(resolved.Server.TLSMode)
+	clientMode, _ := tlsprofile.ParseMode(resolved.Client.TLSMode)
+	opaMode, _ := tlsprofile.ParseMode(resolved.OPA.TLSMode)
+
 	return &Config{
-		// MTLS SERVER SETTINGS
-		// Configuration for accepting authenticated Entry-Hub connections
-		ServerPort:     "8444", // Listen on Tailscale network only
-		ServerCertFile: "../certificates/security-switch/server.crt",
-		ServerKeyFile:  "../certificates/security-switch/server.key",
-		CACertFile:     "../certificates/certification-authority/ca.crt",
-
-		// MTLS CLIENT SETTINGS
-		// Configuration for secure Database-Vault communication
-		// TO-DO: Replace hardcoded IP with os.Getenv("DATABASE_VAULT_IP")
-		DatabaseVaultIP: "100.93.246.70:8445", // TO-DO: Replace with actual Database-Vault Tailscale IP
-		ClientCertFile:  "../certificates/security-switch/client.crt",
-		ClientKeyFile:   "../certificates/security-switch/client.key",
+		ServerPort:       resolved.Server.Port,
+		ServerCertFile:   resolved.Server.CertFile,
+		ServerKeyFile:    resolved.Server.KeyFile,
+		CACertFile:       resolved.Server.CACertFile,
+		ServerRoutesFile: resolved.Server.RoutesFile,
+		MaxBodyBytes:     resolved.Server.MaxBodyBytes,
+
+		DatabaseVaultIP: resolved.Client.TargetAddress,
+		ClientCertFile:  resolved.Client.CertFile,
+		ClientKeyFile:   resolved.Client.KeyFile,
+
+		RateLimitBackend:   resolved.RateLimit.Backend,
+		RateLimitRedisAddr: resolved.RateLimit.RedisAddr,
+
+		ServerTLSProfile: tlsprofile.Profile{
+			Mode:       serverMode,
+			CertFile:   resolved.Server.CertFile,
+			KeyFile:    resolved.Server.KeyFile,
+			CACertFile: resolved.Server.CACertFile,
+		},
+		ClientTLSProfile: tlsprofile.Profile{
+			Mode:       clientMode,
+			CertFile:   resolved.Client.CertFile,
+			KeyFile:    resolved.Client.KeyFile,
+			CACertFile: resolved.Client.CACertFile,
+			ServerName: "database-vault",
+		},
+
+		AuthzMode:                resolved.MTLSAuthz.Mode,
+		AuthzSPIFFEAllowed:       resolved.MTLSAuthz.SPIFFEAllowed,
+		AuthzSANAllowedDNS:       resolved.MTLSAuthz.SANAllowedDNS,
+		AuthzSANAllowedURIs:      resolved.MTLSAuthz.SANAllowedURIs,
+		RevocationEnabled:        resolved.MTLSAuthz.RevocationEnabled,
+		RevocationIssuerCertFile: resolved.MTLSAuthz.IssuerCertFile,
+		RevocationCRLURLs:        resolved.MTLSAuthz.CRLDistributionURLs,
+
+		OPAEnabled:    resolved.OPA.Enabled,
+		OPATargetAddr: resolved.OPA.TargetAddress,
+		OPATLSProfile: tlsprofile.Profile{
+			Mode:       opaMode,
+			CertFile:   resolved.OPA.CertFile,
+			KeyFile:    resolved.OPA.KeyFile,
+			CACertFile: resolved.OPA.CACertFile,
+			ServerName: "opa",
+		},
+		OPACacheSize: resolved.OPA.CacheSize,
+		OPACacheTTL:  resolved.OPA.CacheTTL,
+
+		PwnedCheckEnabled:    resolved.PasswordPolicy.PwnedCheckEnabled,
+		PwnedCheckFailClosed: resolved.PasswordPolicy.PwnedCheckFailClosed,
+		PwnedCacheSize:       resolved.PasswordPolicy.PwnedCacheSize,
+		PwnedCacheTTL:        resolved.PasswordPolicy.PwnedCacheTTL,
+
+		resolved: resolved,
+	}
+}
+
+// ValidateConfig reports every Security-Switch configuration problem at once.
+//
+// Security features:
+// - Delegates to ramusb/config.Validate, which walks every field and reports every problem at once instead of failing on the first
+//
+// Returns error (a *multierror.Error enumerating every problem found) if any
+// configuration component is invalid or missing.
+func (c *Config) ValidateConfig() error {
+	if err := ramusbconfig.Validate(c.resolved); err != nil {
+		return fmt.Errorf("security-switch configuration invalid:\n%v", err)
 	}
+	return nil
 }
@@ -1,37 +1,174 @@
 /*
-Health check handler for Security-Switch mTLS gateway monitoring.
+Health check handlers for Security-Switch.
 
-Provides lightweight status verification for load balancers, monitoring systems,
-and service discovery within the zero-trust architecture. Enables automated
-detection of Security-Switch availability without exposing sensitive mTLS
-configuration or certificate information to monitoring systems.
+HealthHandler reports status following the draft application/health+json
+convention (draft-inadarei-api-health-check): it probes the Database-Vault
+hop and this service's own mTLS client certificate expiry, rather than
+returning a static message. ReadyHandler gates readiness on startup
+certificate load and configuration validation instead of process liveness
+alone.
+
+TO-DO: Cache the Database-Vault probe result for a few seconds so a
+monitoring system polling /api/health frequently doesn't open a fresh mTLS
+connection on every call.
 */
 package handlers
 
 import (
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
+	"fmt"
 	"net/http"
+	"os"
+	"security_switch/config"
+	"security_switch/interfaces"
 	"security_switch/types"
+	"sync/atomic"
+	"time"
 )
 
-// HealthHandler provides Security-Switch status verification for monitoring systems.
+// certExpiryWarnWindow is how long before the client certificate's expiry
+// checkClientCertExpiry degrades its result from "pass" to "warn".
+const certExpiryWarnWindow = 7 * 24 * time.Hour
+
+// ready flips true once main has finished loading startup certificates and
+// validating configuration; ReadyHandler reports 503 until then.
+var ready atomic.Bool
+
+// SetReady marks Security-Switch ready for traffic. Called once from main
+// after startup certificate load and configuration validation both succeed.
+func SetReady() {
+	ready.Store(true)
+}
+
+// HealthHandler reports Security-Switch's health following the draft
+// application/health+json convention, checking the Database-Vault hop and
+// this service's own mTLS client certificate expiry.
+//
+// ?verbose=false collapses the response to a bare status, for load balancers
+// that only care about the HTTP status code. /api/health already sits behind
+// verifier.VerifyMTLS in MutualTLS mode, but the per-check breakdown is still
+// withheld from any caller that reached this handler without a verified peer
+// certificate (Disabled/TLS dev modes), so certificate expiry detail is never
+// exposed to an unauthenticated caller.
 //
 // Security features:
-// - mTLS middleware ensures only authenticated Entry-Hub clients can access
-// - No sensitive mTLS configuration disclosure in response
-// - Minimal resource usage
-// - JSON response format ensures consistent monitoring integration
+// - Per-check detail gated behind mTLS regardless of the verbose query param
+// - Database-Vault reachability and certificate expiry are independent checks, so a slow hop can't mask an expiring certificate or vice versa
 //
-// Returns HTTP 200 with success status indicating Security-Switch operational state.
+// Returns HTTP 200 for overall status "pass" or "warn", 503 for "fail".
 func HealthHandler(w http.ResponseWriter, r *http.Request) {
-	// JSON RESPONSE SETUP
-	// Ensure consistent content type for monitoring tools
+	checks := map[string]types.HealthCheckResult{
+		"database_vault": probeDatabaseVault(),
+		"cert_expiry":    checkClientCertExpiry(),
+	}
+	status := worstStatus(checks)
+
+	resp := types.HealthResponse{Status: status}
+	if r.URL.Query().Get("verbose") != "false" && isMTLSAuthenticated(r) {
+		resp.Checks = checks
+	}
+
+	w.Header().Set("Content-Type", "application/health+json")
+	if status == "fail" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ReadyHandler reports whether Security-Switch has finished startup. Unlike
+// HealthHandler, it runs no downstream probes, so a slow or unreachable
+// Database-Vault can't make a Security-Switch that has merely finished
+// starting up look unready.
+//
+// Returns HTTP 200 once SetReady has been called, 503 before that.
+func ReadyHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	if !ready.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(types.Response{Success: false, Message: "Security-Switch is starting up."})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(types.Response{Success: true, Message: "Security-Switch is ready."})
+}
+
+// isMTLSAuthenticated reports whether r arrived with a verified client
+// certificate.
+func isMTLSAuthenticated(r *http.Request) bool {
+	return r.TLS != nil && len(r.TLS.PeerCertificates) > 0
+}
+
+// probeDatabaseVault performs an mTLS GET against Database-Vault's
+// /api/health and reports the result as a health+json check.
+func probeDatabaseVault() types.HealthCheckResult {
+	cfg := config.GetConfig()
+
+	client, err := interfaces.NewDatabaseVaultClient(cfg.DatabaseVaultIP, cfg.ClientTLSProfile)
+	if err != nil {
+		return types.HealthCheckResult{Status: "fail", Error: err.Error()}
+	}
+
+	start := time.Now()
+	err = client.Probe()
+	latency := time.Since(start)
+	if err != nil {
+		return types.HealthCheckResult{Status: "fail", LatencyMS: latency.Milliseconds(), Error: err.Error()}
+	}
+	return types.HealthCheckResult{Status: "pass", LatencyMS: latency.Milliseconds()}
+}
+
+// checkClientCertExpiry reports "warn" within certExpiryWarnWindow of the
+// client certificate's expiry, "fail" once it has expired, and "pass"
+// otherwise. Reported "pass" when ClientTLSProfile has no CertFile configured;
+// the TLS mode itself is not consulted.
+func checkClientCertExpiry() types.HealthCheckResult {
+	cfg := config.GetConfig()
+
+	if cfg.ClientTLSProfile.CertFile == "" {
+		return types.HealthCheckResult{Status: "pass"}
+	}
+
+	pemBytes, err := os.ReadFile(cfg.ClientTLSProfile.CertFile)
+	if err != nil {
+		return types.HealthCheckResult{Status: "fail", Error: err.Error()}
+	}
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return types.HealthCheckResult{Status: "fail", Error: "client certificate is not valid PEM"}
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return types.HealthCheckResult{Status: "fail", Error: err.Error()}
+	}
+
+	result := types.HealthCheckResult{ExpiresAt: cert.NotAfter.Format(time.RFC3339)}
+	switch {
+	case time.Now().After(cert.NotAfter):
+		result.Status = "fail"
+		result.Error = "client certificate has expired"
+	case time.Until(cert.NotAfter) < certExpiryWarnWindow:
+		result.Status = "warn"
+		result.Error = fmt.Sprintf("client certificate expires in %s", time.Until(cert.NotAfter).Round(time.Minute))
+	default:
+		result.Status = "pass"
+	}
+	return result
+}
 
-	// STATUS RESPONSE
-	// Simple success indicator for automated health monitoring
-	json.NewEncoder(w).Encode(types.Response{
-		Success: true,
-		Message: "Security-Switch operational!",
-	})
+// worstStatus reduces checks to the single worst status: "fail" beats "warn" beats "pass".
+func worstStatus(checks map[string]types.HealthCheckResult) string {
+	worst := "pass"
+	for _, c := range checks {
+		switch c.Status {
+		case "fail":
+			return "fail"
+		case "warn":
+			worst = "warn"
+		}
+	}
+	return worst
 }
@@ -0,0 +1,113 @@
+/*
+Login request handler for Security-Switch mTLS gateway service.
+
+Implements the second layer of the R.A.M.-U.S.B. distributed authentication system
+with defense-in-depth validation. Receives mTLS-authenticated login requests
+from Entry-Hub instances, performs comprehensive security validation, and securely
+forwards validated requests to Database-Vault using mutual TLS authentication.
+*/
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"security_switch/config"
+	"security_switch/interfaces"
+	"security_switch/types"
+	"security_switch/utils"
+	"strings"
+)
+
+// LoginHandler processes user login requests with defense-in-depth validation.
+//
+// Security features:
+// - mTLS authentication ensures only authorized Entry-Hub instances can access
+// - Defense-in-depth input validation (re-validates all user data)
+// - Secure mTLS forwarding to Database-Vault with certificate verification
+// - Comprehensive error categorization prevents information disclosure
+// - Rate limiting (applied upstream via ratelimit.Limiter.Middleware) bounds abuse from a compromised Entry-Hub instance despite mTLS authentication
+//
+// Returns HTTP 200 with a session token on success, 4xx on validation errors, 5xx on service errors.
+func LoginHandler(w http.ResponseWriter, r *http.Request) {
+	// HTTP METHOD ENFORCEMENT
+	// Prevent CSRF attacks and enforce REST API semantics
+	if !utils.EnforcePOST(w, r) {
+		return // Sends HTTP 405 Method Not Allowed and logs violation
+	}
+
+	// REQUEST BODY PARSING
+	// Read and validate HTTP request body for JSON processing
+	body, ok := utils.ReadRequestBody(w, r)
+	if !ok {
+		return // Sends HTTP 400 Bad Request if body reading fails
+	}
+
+	// JSON DESERIALIZATION
+	// Convert raw JSON bytes into structured LoginRequest object
+	var req types.LoginRequest
+	if !utils.ParseJSONBody(body, &req, w) {
+		return // Sends HTTP 400 Bad Request if JSON parsing fails
+	}
+
+	// REQUIRED FIELDS VALIDATION (DEFENSE-IN-DEPTH)
+	// Ensure essential fields are present despite Entry-Hub validation
+	if req.Email == "" || req.Password == "" {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Email and password are required.")
+		return
+	}
+
+	// EMAIL FORMAT VALIDATION (DEFENSE-IN-DEPTH)
+	// Validate email format using RFC 5322 compliant regular expression
+	if !utils.IsValidEmail(req.Email) {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid email format.")
+		return
+	}
+
+	// EMAIL SECURITY VALIDATION (DEFENSE-IN-DEPTH)
+	// Prevent email header injection attacks via multiple @ symbols
+	if strings.Count(req.Email, "@") != 1 {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid email format.")
+		return
+	}
+
+	// DATABASE-VAULT CLIENT INITIALIZATION
+	// Create and configure mTLS client for secure Database-Vault communication
+	cfg := config.GetConfig()
+	dbClient, err := interfaces.NewDatabaseVaultClient(
+		cfg.DatabaseVaultIP,
+		cfg.ClientTLSProfile,
+	)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+
+	// SECURE REQUEST FORWARDING TO DATABASE-VAULT
+	// Log forwarding attempt for audit purposes, without the password
+	log.Printf("Forwarding login request for user: %s", req.Email)
+
+	// Forward validated login request using mTLS authentication
+	dbResponse, err := dbClient.AuthenticateUser(req)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+
+	// DATABASE-VAULT RESPONSE VALIDATION
+	// Check if Database-Vault successfully authenticated the login request
+	if !dbResponse.Success {
+		log.Printf("Database-Vault rejected login for %s: %s", req.Email, dbResponse.Message)
+		// Pass through the generic message while preventing information disclosure
+		if dbResponse.MFARequired {
+			utils.SendMFARequiredResponse(w, dbResponse.Message)
+			return
+		}
+		utils.SendErrorResponse(w, http.StatusUnauthorized, dbResponse.Message)
+		return
+	}
+
+	// SUCCESS RESPONSE
+	// Log successful login and pass the issued session token through to Entry-Hub
+	log.Printf("User successfully authenticated: %s", req.Email)
+	utils.SendSuccessResponseWithSessionToken(w, http.StatusOK, "Login successful.", dbResponse.SessionToken)
+}
@@ -6,13 +6,10 @@ with defense-in-depth validation. Receives mTLS-authenticated registration reque
 from Entry-Hub instances, performs comprehensive security validation, and securely
 forwards validated requests to Database-Vault using mutual TLS authentication.
 Acts as security checkpoint preventing invalid data from reaching storage layer.
-
-TO-DO in RegisterHandler
 */
 package handlers
 
 import (
-	"fmt"
 	"log"
 	"net/http"
 	"security_switch/config"
@@ -29,13 +26,11 @@ import (
 // - Defense-in-depth input validation (re-validates all user data)
 // - Secure mTLS forwarding to Database-Vault with certificate verification
 // - Comprehensive error categorization prevents information disclosure
+// - Rate limiting (applied upstream via ratelimit.Limiter.Middleware) bounds abuse from a compromised Entry-Hub instance despite mTLS authentication
 //
 // Returns HTTP 201 on successful registration, 4xx on validation errors, 5xx on service errors.
-//
-// TO-DO: Implement rate limiting to prevent abuse from compromised Entry-Hub instances
 
 func RegisterHandler(w http.ResponseWriter, r *http.Request) {
-	// TO-DO: Add rate limiting check here despite mTLS authentication
 	// HTTP METHOD ENFORCEMENT
 	// Prevent CSRF attacks and enforce REST API semantics
 	if !utils.EnforcePOST(w, r) {
@@ -84,17 +79,27 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// SERVICE CONFIGURATION
+	// Needed for both the weak-password check below and the Database-Vault
+	// client setup further down
+	cfg := config.GetConfig()
+
 	// WEAK PASSWORD DETECTION (DEFENSE-IN-DEPTH)
 	// Check against database of commonly used weak passwords
-	if utils.IsWeakPassword(req.Password) {
+	passwordValidator := utils.PasswordValidator{Checker: utils.StaticPwnedChecker{}}
+	if cfg.PwnedCheckEnabled {
+		passwordValidator.Checker = utils.NewHTTPPwnedChecker(nil, cfg.PwnedCacheSize, cfg.PwnedCacheTTL, cfg.PwnedCheckFailClosed)
+	}
+	if passwordValidator.IsWeakPassword(r.Context(), req.Password) {
 		utils.SendErrorResponse(w, http.StatusBadRequest, "Password is too common, please choose a stronger password.")
 		return
 	}
 
-	// PASSWORD COMPLEXITY VALIDATION (DEFENSE-IN-DEPTH)
-	// Ensure password contains at least 3 out of 4 character categories
-	if !utils.HasPasswordComplexity(req.Password) {
-		utils.SendErrorResponse(w, http.StatusBadRequest, "Password must contain at least 3 of: uppercase, lowercase, numbers, special characters.")
+	// PASSWORD STRENGTH VALIDATION (DEFENSE-IN-DEPTH)
+	// zxcvbn-style guess estimate, penalizing passwords built from the
+	// account's own email local-part
+	if !utils.HasPasswordComplexity(req.Password, strings.SplitN(req.Email, "@", 2)[0]) {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Password is too easily guessed, please choose a stronger password.")
 		return
 	}
 
@@ -114,32 +119,12 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 
 	// DATABASE-VAULT CLIENT INITIALIZATION
 	// Create and configure mTLS client for secure Database-Vault communication
-	cfg := config.GetConfig()
 	dbClient, err := interfaces.NewDatabaseVaultClient(
 		cfg.DatabaseVaultIP,
-		cfg.ClientCertFile,
-		cfg.ClientKeyFile,
-		cfg.CACertFile,
+		cfg.ClientTLSProfile,
 	)
 	if err != nil {
-		errorMsg := fmt.Sprintf("Failed to initialize Database-Vault client: %v", err)
-		log.Printf("Error: %s", errorMsg)
-
-		// MTLS CONFIGURATION ERRORS
-		// Categorize error type for appropriate client response
-		if strings.Contains(err.Error(), "certificate") {
-			// Certificate configuration error - deployment issue
-			utils.SendErrorResponse(w, http.StatusInternalServerError,
-				"Certificate configuration error. Please contact administrator.")
-		} else if strings.Contains(err.Error(), "file") {
-			// Certificate files missing - file system issue
-			utils.SendErrorResponse(w, http.StatusInternalServerError,
-				"Certificate files not found. Please contact administrator.")
-		} else {
-			// Generic client initialization failure - system issue
-			utils.SendErrorResponse(w, http.StatusInternalServerError,
-				"Database-Vault client initialization failed. Please contact administrator.")
-		}
+		utils.WriteError(w, err)
 		return
 	}
 
@@ -150,24 +135,7 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	// Forward validated registration request using mTLS authentication
 	dbResponse, err := dbClient.StoreUserCredentials(req)
 	if err != nil {
-		errorMsg := fmt.Sprintf("Failed to store user credentials for %s: %v", req.Email, err)
-		log.Printf("Error: %s", errorMsg)
-
-		// NETWORK ERROR CATEGORIZATION
-		// Provide specific guidance based on failure type
-		if strings.Contains(err.Error(), "connection refused") {
-			// Service unavailable - temporary outage
-			utils.SendErrorResponse(w, http.StatusServiceUnavailable,
-				"Database-Vault service is unavailable. Please try again later.")
-		} else if strings.Contains(err.Error(), "timeout") {
-			// Service overloaded - retry recommended
-			utils.SendErrorResponse(w, http.StatusGatewayTimeout,
-				"Database-Vault service timeout. Please try again later.")
-		} else {
-			// Generic network error - service issue
-			utils.SendErrorResponse(w, http.StatusBadGateway,
-				"Unable to store user credentials. Please try again later.")
-		}
+		utils.WriteError(w, err)
 		return
 	}
 
@@ -181,7 +149,12 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// SUCCESS RESPONSE
-	// Log successful registration and send confirmation to Entry-Hub
+	// Log successful registration and send confirmation to Entry-Hub, passing
+	// through the signed SSH certificate when the request used cert_mode
 	log.Printf("User successfully registered: %s", req.Email)
-	utils.SendSuccessResponse(w, http.StatusCreated, "User successfully registered!")
+	if dbResponse.SSHCertificate != "" {
+		utils.SendSuccessResponseWithCertificate(w, http.StatusCreated, "User successfully registered!", dbResponse.SSHCertificate)
+	} else {
+		utils.SendSuccessResponse(w, http.StatusCreated, "User successfully registered!")
+	}
 }
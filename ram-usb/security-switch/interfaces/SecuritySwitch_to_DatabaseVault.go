@@ -1,105 +1,251 @@
 /*
-mTLS client interface for Security-Switch to Database-Vault communication.
+Client interface for Security-Switch to Database-Vault communication.
 
-Provides secure request forwarding with mutual TLS authentication and certificate
-validation for the second hop in the distributed authentication pipeline.
-Implements connection pooling, timeout management, and structured error handling
-for reliable zero-trust communication within the R.A.M.-U.S.B. architecture.
-
-TO-DO in NewDatabaseVaultClient
+Provides secure request forwarding for the second hop in the distributed
+authentication pipeline. The hop's TLS posture (mutual TLS, server-only TLS,
+or disabled for dev bring-up) is resolved by the caller's tlsprofile.Profile,
+rather than fixed here.
+Implements connection pooling, retry with backoff, per-endpoint circuit
+breaking, and multi-target health-check-driven failover on top of structured
+error handling, for reliable zero-trust communication within the R.A.M.-U.S.B.
+architecture.
 */
 package interfaces
 
 import (
 	"bytes"
-	"crypto/tls"
-	"crypto/x509"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
-	"os"
 	"security_switch/types"
+	"strings"
+	"sync/atomic"
 	"time"
+
+	"ramusb/errs"
+	"ramusb/tlsprofile"
 )
 
-// DatabaseVaultClient manages secure mTLS communication with Database-Vault servers.
+// dbVaultMax* and dbVaultIdleConnTimeout bound the shared http.Transport's
+// connection pool, so a Database-Vault outage or traffic spike exhausts
+// neither file descriptors nor ephemeral ports.
+const (
+	dbVaultMaxIdleConns        = 100
+	dbVaultMaxIdleConnsPerHost = 10
+	dbVaultMaxConnsPerHost     = 50
+	dbVaultIdleConnTimeout     = 90 * time.Second
+)
+
+// dbVaultTarget tracks one Database-Vault instance's reachability, as last
+// observed by CheckHealth.
+type dbVaultTarget struct {
+	baseURL string
+	healthy atomic.Bool
+}
+
+// DatabaseVaultClient manages secure communication with Database-Vault servers.
 //
 // Security features:
-// - Mutual TLS authentication with certificate verification
-// - CA validation prevents man-in-the-middle attacks
+// - MutualTLS profile mode (the production default) keeps certificate-based mutual authentication
 // - TLS 1.3 enforcement for maximum cryptographic security
 // - Certificate-Name (CN) validation ensures correct service identity
 //
-// Handles JSON serialization, HTTPS requests, and response parsing for Database-Vault operations.
+// Handles JSON serialization, HTTPS requests, and response parsing for
+// Database-Vault operations, routing around targets CheckHealth has marked
+// unreachable and failing fast per-endpoint once a target's circuit breaker trips.
 type DatabaseVaultClient struct {
-	baseURL    string       // HTTPS endpoint for Database-Vault service
-	httpClient *http.Client // mTLS-configured HTTP client with certificate validation
+	targets    []*dbVaultTarget
+	current    atomic.Int32               // Index into targets currently used for new requests
+	httpClient *http.Client               // Transport configured per the resolved TLS profile
+	breakers   map[string]*circuitBreaker // Keyed by endpoint path; see breakerFor
 }
 
-// NewDatabaseVaultClient creates mTLS-enabled client for secure Database-Vault communication.
-// clientCertFile, clientKeyFile: Security-Switch credentials for mutual authentication
-// caCertFile: trusted CA for Database-Vault certificate validation
-// databaseVaultIP: Tailscale IP:port for zero-trust mesh communication
+// NewDatabaseVaultClient creates a client for secure Database-Vault communication.
+// databaseVaultIPs: one or more Tailscale IP:port addresses for zero-trust
+// mesh communication, comma-separated for multi-instance failover (a single
+// address is still accepted and remains the common case)
+// profile: this hop's TLS profile - MutualTLS (production default), TLS
+// (server-only verification, for local dev dependencies), or Disabled
+// (plain HTTP, refused by profile.ClientConfig outside RAMUSB_ENV=dev)
 //
 // Security features:
-// - Mutual TLS authentication with certificate verification
-// - CA validation prevents man-in-the-middle attacks
-// - TLS 1.3 enforcement for maximum cryptographic security
-// - Common-Name (CN) validation ensures correct service identity
+// - MutualTLS mode preserves today's behavior: client cert presented, CA-validated server, TLS 1.3 enforced
+// - Disabled mode is refused by tlsprofile.Profile.ClientConfig unless RAMUSB_ENV=dev, so production cannot silently downgrade
 //
-// Returns configured mTLS client or error if certificate validation fails.
-func NewDatabaseVaultClient(databaseVaultIP string, clientCertFile, clientKeyFile, caCertFile string) (*DatabaseVaultClient, error) {
-	// CLIENT CERTIFICATE LOADING
-	// Load Security-Switch credentials for mutual authentication with Database-Vault
-	clientCert, err := tls.LoadX509KeyPair(clientCertFile, clientKeyFile)
+// Returns configured client or error if certificate validation fails.
+func NewDatabaseVaultClient(databaseVaultIPs string, profile tlsprofile.Profile) (*DatabaseVaultClient, error) {
+	// TLS CONFIGURATION
+	// Resolve this hop's tls.Config per the configured profile; nil means plain HTTP (dev-only)
+	tlsConfig, err := profile.ClientConfig()
 	if err != nil {
-		return nil, fmt.Errorf("failed to load client certificate: %v", err)
+		return nil, errs.CertificateError("client_tls_config_failed", "Certificate configuration error. Please contact administrator.", err)
 	}
 
-	// CertificateAuthority(CA) CERTIFICATE LOADING
-	// Load trusted CA for Database-Vault certificate verification
-	caCert, err := os.ReadFile(caCertFile)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read CA certificate: %v", err)
-	}
-
-	// CERTIFICATE POOL SETUP
-	// Configure trusted certificate authorities for server validation
-	caCertPool := x509.NewCertPool()
-	if !caCertPool.AppendCertsFromPEM(caCert) {
-		return nil, fmt.Errorf("failed to parse CA certificate")
-	}
-
-	// MTLS CONFIGURATION
-	// Configure mutual TLS with certificate validation and modern security
-	tlsConfig := &tls.Config{
-		Certificates:       []tls.Certificate{clientCert}, // Security-Switch client certificate
-		RootCAs:            caCertPool,                    // Trusted CAs for server verification
-		ServerName:         "database-vault",              // Expected server certificate Common-Name (CN)
-		InsecureSkipVerify: false,                         // Always verify certificates in production
-		MinVersion:         tls.VersionTLS13,              // Enforce modern TLS version
+	// SCHEME SELECTION
+	// Disabled mode dials plain HTTP; TLS and MutualTLS dial HTTPS
+	scheme := "https"
+	if tlsConfig == nil {
+		scheme = "http"
 	}
 
 	// HTTP CLIENT SETUP
-	// Create client with mTLS transport and connection timeout
-	//
-	// TO-DO: Add connection pooling to prevent "too many open files" crashes
-	// TO-DO: MaxIdleConns: 10, MaxIdleConnsPerHost: 3, IdleConnTimeout: 30*time.Second
+	// Shared transport across every target: a bounded idle-connection pool
+	// plus HTTP/2 (negotiated over TLS via ALPN, stdlib-only) prevents the
+	// "too many open files" failure mode a per-request Transport invited.
 	client := &http.Client{
 		Transport: &http.Transport{
-			TLSClientConfig: tlsConfig,
+			TLSClientConfig:     tlsConfig,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        dbVaultMaxIdleConns,
+			MaxIdleConnsPerHost: dbVaultMaxIdleConnsPerHost,
+			MaxConnsPerHost:     dbVaultMaxConnsPerHost,
+			IdleConnTimeout:     dbVaultIdleConnTimeout,
 		},
 		Timeout: 30 * time.Second, // Prevent hanging connections
 	}
 
-	// CLIENT INSTANCE CREATION
-	// Return configured mTLS client for Database-Vault communication
+	targets := parseTargets(databaseVaultIPs, scheme)
+	if len(targets) == 0 {
+		return nil, errs.Internal("client_no_targets", "Internal server error. Please contact administrator.", fmt.Errorf("no Database-Vault targets configured"))
+	}
+
 	return &DatabaseVaultClient{
-		baseURL:    fmt.Sprintf("https://%s", databaseVaultIP),
+		targets:    targets,
 		httpClient: client,
+		breakers:   newCircuitBreakers(),
 	}, nil
 }
 
+// dbVaultEndpoints lists every path DatabaseVaultClient calls through
+// sendRequest, so newCircuitBreakers can pre-populate one breaker per
+// endpoint at construction time rather than creating them lazily under
+// concurrent access.
+var dbVaultEndpoints = []string{
+	"/api/store-user",
+	"/api/login",
+	"/api/mfa/enroll",
+	"/api/mfa/confirm",
+	"/api/issue-cert",
+	"/api/health",
+}
+
+// newCircuitBreakers returns a breaker map pre-populated for every known
+// Database-Vault endpoint, so breakerFor never writes to the map after
+// construction and needs no locking of its own.
+func newCircuitBreakers() map[string]*circuitBreaker {
+	breakers := make(map[string]*circuitBreaker, len(dbVaultEndpoints))
+	for _, path := range dbVaultEndpoints {
+		breakers[path] = newCircuitBreaker()
+	}
+	return breakers
+}
+
+// parseTargets splits a comma-separated list of IP:port addresses into
+// dbVaultTarget entries, each initially assumed healthy until CheckHealth
+// says otherwise.
+func parseTargets(databaseVaultIPs, scheme string) []*dbVaultTarget {
+	var targets []*dbVaultTarget
+	for _, addr := range strings.Split(databaseVaultIPs, ",") {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		t := &dbVaultTarget{baseURL: fmt.Sprintf("%s://%s", scheme, addr)}
+		t.healthy.Store(true)
+		targets = append(targets, t)
+	}
+	return targets
+}
+
+// activeTarget returns the target new requests should use.
+func (c *DatabaseVaultClient) activeTarget() *dbVaultTarget {
+	return c.targets[c.current.Load()%int32(len(c.targets))]
+}
+
+// failover marks the currently active target unhealthy and advances routing
+// to the next configured target, so a request that just failed doesn't
+// immediately retry against the same unreachable instance. CheckHealth later
+// reconciles this against each target's actual reachability.
+func (c *DatabaseVaultClient) failover() {
+	c.activeTarget().healthy.Store(false)
+	if len(c.targets) > 1 {
+		c.current.Add(1)
+	}
+}
+
+// breakerFor returns the circuit breaker for endpoint path. Breakers are
+// per-endpoint rather than per-target, since an endpoint can misbehave
+// independently of Database-Vault's overall reachability; they are all
+// pre-populated by newCircuitBreakers, so this never writes to the map and
+// needs no locking of its own. A path absent from dbVaultEndpoints (which
+// should never happen - every sendRequest caller passes one of its entries)
+// gets a fresh, unshared breaker rather than a nil-pointer panic.
+func (c *DatabaseVaultClient) breakerFor(path string) *circuitBreaker {
+	if b, ok := c.breakers[path]; ok {
+		return b
+	}
+	return newCircuitBreaker()
+}
+
+// sendRequest issues method against path on the currently active target,
+// returning Database-Vault's response for the caller to decode. Every
+// DatabaseVaultClient method funnels through here so pooling, retry, circuit
+// breaking, and metrics apply uniformly.
+//
+// Security features:
+// - A tripped circuit breaker fails fast (no dial attempted) rather than letting a caller queue up behind a timeout during a Database-Vault outage
+// - idempotent must be true only for calls Database-Vault can safely receive more than once (see withRetry) - never for calls with a persistence side effect
+//
+// Returns the HTTP response (caller must close its body) or a classified
+// *errs.Error describing the circuit-open, dial, or timeout failure.
+func (c *DatabaseVaultClient) sendRequest(method, path string, jsonData []byte, idempotent bool) (*http.Response, error) {
+	breaker := c.breakerFor(path)
+	if !breaker.Allow() {
+		ClientMetrics.recordCircuitState(path, breaker.State())
+		return nil, errs.ServiceUnavailable("circuit_open", "Database-Vault is currently unavailable. Please try again shortly.", nil)
+	}
+
+	var resp *http.Response
+	attempt := func() error {
+		var bodyReader io.Reader
+		if jsonData != nil {
+			bodyReader = bytes.NewBuffer(jsonData)
+		}
+		httpReq, err := http.NewRequest(method, c.activeTarget().baseURL+path, bodyReader)
+		if err != nil {
+			return err
+		}
+		if jsonData != nil {
+			httpReq.Header.Set("Content-Type", "application/json")
+		}
+
+		start := time.Now()
+		r, err := c.httpClient.Do(httpReq)
+		ClientMetrics.recordRequest(path, time.Since(start))
+		if err != nil {
+			c.failover()
+			return err
+		}
+		resp = r
+		return nil
+	}
+
+	var err error
+	if idempotent {
+		err = withRetry(attempt)
+	} else {
+		err = attempt()
+	}
+
+	breaker.RecordResult(err)
+	ClientMetrics.recordCircuitState(path, breaker.State())
+	if err != nil {
+		return nil, errs.ClassifyDialError("Database-Vault", err)
+	}
+	return resp, nil
+}
+
 // StoreUserCredentials securely transmits user registration data to Database-Vault.
 //
 // Security features:
@@ -108,71 +254,203 @@ func NewDatabaseVaultClient(databaseVaultIP string, clientCertFile, clientKeyFil
 // - Structured error handling for network and protocol failures
 // - Response validation prevents malformed data acceptance
 //
+// Never retried: a registration has a persistence side effect at
+// Database-Vault, so retrying a request whose response was merely lost in
+// transit risks a duplicate-account error masking a successful write.
+//
 // Returns Database-Vault response or error for network/parsing failures.
 func (c *DatabaseVaultClient) StoreUserCredentials(req types.RegisterRequest) (*types.Response, error) {
-	// REQUEST SERIALIZATION
-	// Convert registration data to JSON for secure transmission
 	jsonData, err := json.Marshal(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %v", err)
+		return nil, errs.Internal("request_marshal_failed", "Internal server error. Please contact administrator.", err)
 	}
 
-	// HTTP REQUEST SETUP
-	// Create POST request to Database-Vault storage endpoint
-	httpReq, err := http.NewRequest("POST", c.baseURL+"/api/store-user", bytes.NewBuffer(jsonData))
+	resp, err := c.sendRequest("POST", "/api/store-user", jsonData, false)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var dbResponse types.Response
+	if err := json.NewDecoder(resp.Body).Decode(&dbResponse); err != nil {
+		return nil, errs.BadGateway("invalid_response", "Database-Vault returned an invalid response. Please try again later.", err)
 	}
 
-	// REQUEST HEADERS
-	// Inform Database-Vault that request content format is JSON
-	httpReq.Header.Set("Content-Type", "application/json")
+	return &dbResponse, nil
+}
+
+// AuthenticateUser submits login credentials to Database-Vault and returns
+// its verdict, including a session token on success.
+//
+// Security features:
+// - JSON payload serialization with input validation
+// - mTLS transport with certificate verification
+// - Structured error handling for network and protocol failures
+// - Response validation prevents malformed data acceptance
+//
+// Returns Database-Vault response or error for network/parsing failures.
+func (c *DatabaseVaultClient) AuthenticateUser(req types.LoginRequest) (*types.Response, error) {
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return nil, errs.Internal("request_marshal_failed", "Internal server error. Please contact administrator.", err)
+	}
 
-	// SECURE TRANSMISSION
-	// Send request via mTLS-authenticated connection
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.sendRequest("POST", "/api/login", jsonData, false)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request to Database-Vault: %v", err)
+		return nil, err
 	}
-	defer resp.Body.Close() // Ensure response body cleanup
+	defer resp.Body.Close()
 
-	// RESPONSE PROCESSING
-	// Parse Database-Vault JSON response into structured format
 	var dbResponse types.Response
 	if err := json.NewDecoder(resp.Body).Decode(&dbResponse); err != nil {
-		return nil, fmt.Errorf("failed to decode Database-Vault response: %v", err)
+		return nil, errs.BadGateway("invalid_response", "Database-Vault returned an invalid response. Please try again later.", err)
 	}
 
 	return &dbResponse, nil
 }
 
-// CheckHealth verifies Database-Vault connectivity and service availability.
+// EnrollMFA requests a new TOTP secret for an existing account, so
+// Security-Switch can orchestrate MFA enrollment ahead of a two-step login.
 //
 // Security features:
-// - mTLS authentication for health check requests
-// - Network connectivity validation through certificate verification
-// - Service discovery for load balancing and failover
+// - JSON payload serialization with input validation
+// - mTLS transport with certificate verification
+// - Structured error handling for network and protocol failures
 //
-// Returns true if Database-Vault is reachable and responding correctly.
+// Returns Database-Vault's enrollment response or error for network/parsing failures.
+func (c *DatabaseVaultClient) EnrollMFA(req types.MFAEnrollRequest) (*types.MFAEnrollResponse, error) {
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return nil, errs.Internal("request_marshal_failed", "Internal server error. Please contact administrator.", err)
+	}
+
+	resp, err := c.sendRequest("POST", "/api/mfa/enroll", jsonData, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var dbResponse types.MFAEnrollResponse
+	if err := json.NewDecoder(resp.Body).Decode(&dbResponse); err != nil {
+		return nil, errs.BadGateway("invalid_response", "Database-Vault returned an invalid response. Please try again later.", err)
+	}
+
+	return &dbResponse, nil
+}
+
+// ConfirmMFA submits the first TOTP code generated from an in-progress
+// enrollment, activating MFA on success, so Security-Switch can orchestrate
+// the second step of enrollment ahead of a two-step login.
+//
+// Security features:
+// - JSON payload serialization with input validation
+// - mTLS transport with certificate verification
+// - Structured error handling for network and protocol failures
+//
+// Returns Database-Vault's confirmation response (including scratch codes on
+// success) or error for network/parsing failures.
+func (c *DatabaseVaultClient) ConfirmMFA(req types.MFAConfirmRequest) (*types.MFAConfirmResponse, error) {
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return nil, errs.Internal("request_marshal_failed", "Internal server error. Please contact administrator.", err)
+	}
+
+	resp, err := c.sendRequest("POST", "/api/mfa/confirm", jsonData, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var dbResponse types.MFAConfirmResponse
+	if err := json.NewDecoder(resp.Body).Decode(&dbResponse); err != nil {
+		return nil, errs.BadGateway("invalid_response", "Database-Vault returned an invalid response. Please try again later.", err)
+	}
+
+	return &dbResponse, nil
+}
+
+// RequestUserCertificate submits a CSR for an existing account, so
+// Security-Switch can orchestrate issuance of a Storage-Service mTLS client
+// certificate without ever seeing the corresponding private key.
+//
+// Security features:
+// - JSON payload serialization with input validation
+// - mTLS transport with certificate verification
+// - Structured error handling for network and protocol failures
+//
+// Returns Database-Vault's issuance response (including the signed
+// certificate on success) or error for network/parsing failures.
+func (c *DatabaseVaultClient) RequestUserCertificate(req types.CertIssueRequest) (*types.CertIssueResponse, error) {
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return nil, errs.Internal("request_marshal_failed", "Internal server error. Please contact administrator.", err)
+	}
+
+	resp, err := c.sendRequest("POST", "/api/issue-cert", jsonData, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var dbResponse types.CertIssueResponse
+	if err := json.NewDecoder(resp.Body).Decode(&dbResponse); err != nil {
+		return nil, errs.BadGateway("invalid_response", "Database-Vault returned an invalid response. Please try again later.", err)
+	}
+
+	return &dbResponse, nil
+}
+
+// CheckHealth probes every configured Database-Vault target, updates each
+// target's reachability, and routes subsequent requests to the first healthy
+// one found - rather than being a standalone probe disconnected from routing.
+//
+// Returns true if at least one target responded with HTTP 200.
 func (c *DatabaseVaultClient) CheckHealth() bool {
-	// HEALTH CHECK REQUEST
-	// Create simple GET request to Database-Vault health endpoint
-	httpReq, err := http.NewRequest("GET", c.baseURL+"/api/health", nil)
+	foundHealthy := false
+	for i, t := range c.targets {
+		healthy := probeHealth(c.httpClient, t.baseURL)
+		t.healthy.Store(healthy)
+		if healthy && !foundHealthy {
+			c.current.Store(int32(i))
+			foundHealthy = true
+		}
+	}
+	return foundHealthy
+}
+
+// probeHealth issues a single GET /api/health against baseURL.
+//
+// Returns true if Database-Vault responded with HTTP 200.
+func probeHealth(client *http.Client, baseURL string) bool {
+	httpReq, err := http.NewRequest("GET", baseURL+"/api/health", nil)
 	if err != nil {
-		// Request creation failure - client misconfiguration
 		return false
 	}
 
-	// CONNECTIVITY VERIFICATION
-	// Send health check using mTLS-configured client
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := client.Do(httpReq)
 	if err != nil {
-		// Network error, certificate error, or Database-Vault unreachable
 		return false
 	}
 	defer resp.Body.Close()
 
-	// SERVICE STATUS VALIDATION
-	// Check if Database-Vault responded with success status
 	return resp.StatusCode == http.StatusOK
 }
+
+// Probe performs an mTLS GET against the currently active Database-Vault
+// target's /api/health, for Security-Switch's own health check to report the
+// hop's reachability.
+//
+// Returns nil if Database-Vault responded with HTTP 200, or an error
+// describing the dial failure or non-200 status otherwise.
+func (c *DatabaseVaultClient) Probe() error {
+	resp, err := c.sendRequest("GET", "/api/health", nil, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("database-vault health returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
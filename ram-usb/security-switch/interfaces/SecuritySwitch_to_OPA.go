@@ -0,0 +1,119 @@
+/*
+Client interface for Security-Switch to OPA (Open Policy Agent) communication.
+
+Carries the forward-auth query middleware.ForwardAuth issues before a
+request is allowed to reach its handler, turning OPA into the real policy
+decision point while VerifyMTLS stays focused on transport authentication.
+The hop's TLS posture is resolved by the caller's tlsprofile.Profile, the
+same convention every other inter-service hop in this repo follows.
+*/
+package interfaces
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"ramusb/errs"
+	"ramusb/tlsprofile"
+)
+
+// OPAInput is the redacted request context forwarded to OPA for a policy
+// decision. It never carries a password or any other credential material -
+// only what OPA needs to decide whether this request is allowed.
+type OPAInput struct {
+	Method            string `json:"method"`
+	Path              string `json:"path"`
+	Subject           string `json:"subject"` // Peer certificate Subject.CommonName
+	RequestID         string `json:"request_id"`
+	Email             string `json:"email,omitempty"`
+	SSHKeyFingerprint string `json:"ssh_key_fingerprint,omitempty"`
+}
+
+// opaQuery is the standard OPA REST API request envelope.
+type opaQuery struct {
+	Input OPAInput `json:"input"`
+}
+
+// OPADecision is OPA's standard {"result": {...}} envelope for a policy query.
+type OPADecision struct {
+	Result struct {
+		Allow  bool   `json:"allow"`
+		Reason string `json:"reason"`
+	} `json:"result"`
+}
+
+// OPAClient manages secure communication with an OPA sidecar.
+//
+// Security features:
+// - Profile-resolved TLS (MutualTLS by default) keeps certificate-based mutual authentication
+// - Structured error handling distinguishes network failures from policy-level denial
+//
+// Construct with NewOPAClient.
+type OPAClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewOPAClient creates a client for secure OPA sidecar communication.
+// opaAddr: Tailscale IP:port of the OPA sidecar
+// profile: this hop's TLS profile - MutualTLS (production default), TLS, or
+// Disabled (plain HTTP, refused by profile.ClientConfig outside RAMUSB_ENV=dev)
+//
+// Returns configured client or error if certificate validation fails.
+func NewOPAClient(opaAddr string, profile tlsprofile.Profile) (*OPAClient, error) {
+	tlsConfig, err := profile.ClientConfig()
+	if err != nil {
+		return nil, errs.CertificateError("opa_tls_config_failed", "Certificate configuration error. Please contact administrator.", err)
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+		},
+		Timeout: 5 * time.Second, // OPA decisions gate every request; fail fast rather than hang the caller
+	}
+
+	scheme := "https"
+	if tlsConfig == nil {
+		scheme = "http"
+	}
+
+	return &OPAClient{
+		baseURL:    fmt.Sprintf("%s://%s", scheme, opaAddr),
+		httpClient: client,
+	}, nil
+}
+
+// Evaluate queries OPA's data API for an allow/deny decision over input.
+//
+// Returns OPA's decision, or error if OPA cannot be reached or its response
+// cannot be parsed - callers must fail closed on either.
+func (c *OPAClient) Evaluate(input OPAInput) (*OPADecision, error) {
+	jsonData, err := json.Marshal(opaQuery{Input: input})
+	if err != nil {
+		return nil, errs.Internal("request_marshal_failed", "Internal server error. Please contact administrator.", err)
+	}
+
+	httpReq, err := http.NewRequest("POST", c.baseURL+"/v1/data/ramusb/authz", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, errs.Internal("request_build_failed", "Internal server error. Please contact administrator.", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-Request-Id", input.RequestID)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, errs.ClassifyDialError("OPA", err)
+	}
+	defer resp.Body.Close()
+
+	var decision OPADecision
+	if err := json.NewDecoder(resp.Body).Decode(&decision); err != nil {
+		return nil, errs.BadGateway("invalid_response", "OPA returned an invalid response. Please try again later.", err)
+	}
+
+	return &decision, nil
+}
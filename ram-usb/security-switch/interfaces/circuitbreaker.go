@@ -0,0 +1,125 @@
+package interfaces
+
+import (
+	"sync"
+	"time"
+)
+
+// circuitState enumerates a circuitBreaker's lifecycle: closed allows all
+// traffic, open fails fast without dialing Database-Vault at all, and
+// half-open lets calls through until the first recorded result closes or
+// reopens the breaker; calls made concurrently while half-open are not
+// limited to a single trial.
+type circuitState int32
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// String renders state the way ClientMetrics.Snapshot reports it.
+func (s circuitState) String() string {
+	switch s {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+const (
+	circuitWindow           = 30 * time.Second // Sliding window the failure rate is measured over
+	circuitMinSamples       = 5                // Minimum samples in a window before the failure rate is trusted
+	circuitFailureThreshold = 0.5              // Failure rate that trips the breaker open
+	circuitOpenDuration     = 15 * time.Second // How long the breaker stays open before allowing a half-open trial
+)
+
+// circuitBreaker fails fast once a Database-Vault endpoint's failure rate
+// crosses circuitFailureThreshold, rather than letting every caller queue up
+// behind a dial timeout and exhaust Security-Switch's file descriptors during
+// an outage.
+//
+// One circuitBreaker exists per endpoint path (see DatabaseVaultClient.breakerFor),
+// since distinct endpoints can fail independently of one another.
+type circuitBreaker struct {
+	mu          sync.Mutex
+	state       circuitState
+	windowStart time.Time
+	successes   int
+	failures    int
+	openedAt    time.Time
+}
+
+// newCircuitBreaker returns a closed breaker with a fresh sliding window.
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{windowStart: time.Now()}
+}
+
+// Allow reports whether a call should be attempted now, transitioning an
+// open breaker to half-open once circuitOpenDuration has elapsed.
+func (b *circuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == circuitOpen && time.Since(b.openedAt) >= circuitOpenDuration {
+		b.state = circuitHalfOpen
+	}
+	return b.state != circuitOpen
+}
+
+// RecordResult updates the breaker with the outcome of a call Allow
+// permitted. A half-open trial closes the breaker on success or reopens it
+// on failure; otherwise a failure rate crossing circuitFailureThreshold over
+// the current window trips the breaker open.
+func (b *circuitBreaker) RecordResult(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == circuitHalfOpen {
+		if err != nil {
+			b.trip()
+		} else {
+			b.reset()
+		}
+		return
+	}
+
+	if time.Since(b.windowStart) >= circuitWindow {
+		b.successes, b.failures = 0, 0
+		b.windowStart = time.Now()
+	}
+
+	if err != nil {
+		b.failures++
+	} else {
+		b.successes++
+	}
+
+	total := b.successes + b.failures
+	if total >= circuitMinSamples && float64(b.failures)/float64(total) >= circuitFailureThreshold {
+		b.trip()
+	}
+}
+
+// trip opens the breaker. Callers must hold b.mu.
+func (b *circuitBreaker) trip() {
+	b.state = circuitOpen
+	b.openedAt = time.Now()
+}
+
+// reset closes the breaker and starts a fresh sliding window. Callers must hold b.mu.
+func (b *circuitBreaker) reset() {
+	b.state = circuitClosed
+	b.successes, b.failures = 0, 0
+	b.windowStart = time.Now()
+}
+
+// State returns the breaker's current state, for metrics export.
+func (b *circuitBreaker) State() circuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
@@ -0,0 +1,89 @@
+package interfaces
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// clientMetrics tracks per-endpoint request counts, cumulative latency, and
+// circuit-breaker state for monitoring export.
+//
+// Named to mirror the dbvault_client_requests_total / _latency_seconds /
+// _circuit_state counters an operator would wire into Prometheus; no
+// Prometheus client exists in security-switch (see ratelimit.Metrics), so
+// these are exposed as plain in-memory counters via Snapshot rather than
+// pulling in that dependency for this one package.
+// Use the package-level ClientMetrics variable rather than constructing this directly.
+type clientMetrics struct {
+	mu        sync.Mutex
+	endpoints map[string]*endpointMetrics
+}
+
+// endpointMetrics accumulates counters for a single Database-Vault endpoint path.
+type endpointMetrics struct {
+	requestsTotal int64
+	latencyNanos  int64 // Cumulative request latency; divide by requestsTotal for the mean
+	circuitState  int32 // Current circuitState, updated by DatabaseVaultClient.sendRequest
+}
+
+// ClientMetrics is the package-level counter instance updated by DatabaseVaultClient.sendRequest.
+var ClientMetrics = &clientMetrics{endpoints: make(map[string]*endpointMetrics)}
+
+// EndpointSnapshot is a point-in-time read of one endpoint's counters.
+type EndpointSnapshot struct {
+	RequestsTotal      int64
+	LatencySecondsMean float64
+	CircuitState       string
+}
+
+// endpoint returns path's counters, creating them on first observation.
+func (m *clientMetrics) endpoint(path string) *endpointMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.endpoints[path]
+	if !ok {
+		e = &endpointMetrics{}
+		m.endpoints[path] = e
+	}
+	return e
+}
+
+// recordRequest increments path's request count and adds latency to its cumulative total.
+func (m *clientMetrics) recordRequest(path string, latency time.Duration) {
+	e := m.endpoint(path)
+	atomic.AddInt64(&e.requestsTotal, 1)
+	atomic.AddInt64(&e.latencyNanos, int64(latency))
+}
+
+// recordCircuitState records path's current circuit breaker state for export.
+func (m *clientMetrics) recordCircuitState(path string, state circuitState) {
+	e := m.endpoint(path)
+	atomic.StoreInt32(&e.circuitState, int32(state))
+}
+
+// Snapshot returns the current counters for every endpoint observed so far,
+// safe to read without racing concurrent requests.
+func (m *clientMetrics) Snapshot() map[string]EndpointSnapshot {
+	m.mu.Lock()
+	endpoints := make(map[string]*endpointMetrics, len(m.endpoints))
+	for path, e := range m.endpoints {
+		endpoints[path] = e
+	}
+	m.mu.Unlock()
+
+	snapshot := make(map[string]EndpointSnapshot, len(endpoints))
+	for path, e := range endpoints {
+		requests := atomic.LoadInt64(&e.requestsTotal)
+		var latencyMean float64
+		if requests > 0 {
+			latencyMean = time.Duration(atomic.LoadInt64(&e.latencyNanos) / requests).Seconds()
+		}
+		snapshot[path] = EndpointSnapshot{
+			RequestsTotal:      requests,
+			LatencySecondsMean: latencyMean,
+			CircuitState:       circuitState(atomic.LoadInt32(&e.circuitState)).String(),
+		}
+	}
+	return snapshot
+}
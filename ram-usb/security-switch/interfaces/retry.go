@@ -0,0 +1,47 @@
+package interfaces
+
+import (
+	"math/rand"
+	"time"
+)
+
+// retryMaxAttempts bounds how many times withRetry calls fn, including the initial attempt.
+const retryMaxAttempts = 3
+
+// retryBaseDelay and retryMaxDelay bound the exponential backoff applied
+// between attempts, before jitter.
+const (
+	retryBaseDelay = 100 * time.Millisecond
+	retryMaxDelay  = 2 * time.Second
+)
+
+// withRetry calls fn up to retryMaxAttempts times, applying exponential
+// backoff with full jitter between attempts, and returns as soon as fn
+// succeeds. Intended only for idempotent calls (see
+// DatabaseVaultClient.sendRequest) - retrying a non-idempotent call risks a
+// duplicate side effect at Database-Vault.
+//
+// Returns nil on the first successful call, or the last error seen if every attempt fails.
+func withRetry(fn func() error) error {
+	var err error
+	for attempt := 0; attempt < retryMaxAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoffDelay(attempt))
+		}
+		if err = fn(); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// backoffDelay returns the delay before retry attempt number attempt
+// (1-indexed), doubling retryBaseDelay per attempt up to retryMaxDelay, then
+// applying full jitter so many clients retrying at once don't re-dial in lockstep.
+func backoffDelay(attempt int) time.Duration {
+	delay := retryBaseDelay * time.Duration(1<<uint(attempt-1))
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(delay)))
+}
@@ -0,0 +1,169 @@
+/*
+AWS KMS-backed KeyProvider for Security-Switch.
+
+Selectable as the "awskms" backend via RAMUSB_KEY_PROVIDER. Key material
+never persists as plaintext outside process memory; only ciphertext blobs
+and the AWS KMS key ID are retained, and each version remains independently
+decryptable after Rotate.
+*/
+package keyprovider
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/config"
+	awskms "github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+// AWSKMSKeyProvider retrieves key material by decrypting KMS-wrapped ciphertext blobs.
+//
+// Security features:
+// - IAM-authenticated Decrypt/GenerateDataKey calls, credentials resolved by the AWS SDK default chain
+// - Each version's ciphertext blob is retained in memory so Get can still decrypt a retired version after Rotate; only the CMK (never a plaintext key) needs to persist across restarts
+// - Rotate requests a fresh data key from KMS rather than reusing local entropy, so key generation stays auditable in CloudTrail
+//
+// Construct with NewAWSKMSKeyProviderFromEnv.
+type AWSKMSKeyProvider struct {
+	client *awskms.Client
+	keyID  string
+
+	mu             sync.Mutex
+	versions       map[int][]byte // version -> ciphertext blob
+	currentVersion int
+}
+
+// NewAWSKMSKeyProviderFromEnv builds an AWSKMSKeyProvider from RAMUSB_AWS_KMS_* environment variables.
+//
+// Returns a ready-to-use AWSKMSKeyProvider seeded with version 1 from
+// RAMUSB_AWS_KMS_CIPHERTEXT, or error if configuration is missing or the
+// AWS SDK default credential chain cannot be resolved.
+func NewAWSKMSKeyProviderFromEnv() (*AWSKMSKeyProvider, error) {
+	ciphertextB64 := os.Getenv("RAMUSB_AWS_KMS_CIPHERTEXT")
+	if ciphertextB64 == "" {
+		return nil, fmt.Errorf("RAMUSB_AWS_KMS_CIPHERTEXT environment variable not set")
+	}
+
+	blob, err := base64.StdEncoding.DecodeString(ciphertextB64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 RAMUSB_AWS_KMS_CIPHERTEXT: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	awsCfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS SDK configuration: %v", err)
+	}
+
+	p := &AWSKMSKeyProvider{
+		client:         awskms.NewFromConfig(awsCfg),
+		keyID:          os.Getenv("RAMUSB_AWS_KMS_KEY_ID"),
+		versions:       map[int][]byte{1: blob},
+		currentVersion: 1,
+	}
+
+	if _, _, err := p.Current(); err != nil {
+		return nil, fmt.Errorf("initial AWS KMS key load failed: %v", err)
+	}
+
+	return p, nil
+}
+
+// Current decrypts and returns the active version's ciphertext blob.
+//
+// Returns the version id (as a decimal string) and the decrypted key, or
+// error if the KMS decrypt call fails.
+func (p *AWSKMSKeyProvider) Current() (string, []byte, error) {
+	p.mu.Lock()
+	version := p.currentVersion
+	p.mu.Unlock()
+
+	id := strconv.Itoa(version)
+	key, err := p.Get(id)
+	if err != nil {
+		return "", nil, err
+	}
+	return id, key, nil
+}
+
+// Get decrypts a specific version's ciphertext blob by id.
+//
+// Returns the decrypted key, or error if id is unknown or decryption fails.
+func (p *AWSKMSKeyProvider) Get(id string) ([]byte, error) {
+	version, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid AWS KMS key id %q: %v", id, err)
+	}
+
+	p.mu.Lock()
+	blob, ok := p.versions[version]
+	p.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown AWS KMS key version %d", version)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	input := &awskms.DecryptInput{CiphertextBlob: blob}
+	if p.keyID != "" {
+		input.KeyId = aws.String(p.keyID)
+	}
+
+	out, err := p.client.Decrypt(ctx, input)
+	if err != nil {
+		Metrics.recordLoad(err)
+		return nil, fmt.Errorf("AWS KMS decrypt failed: %v", err)
+	}
+
+	if len(out.Plaintext) != 32 {
+		err := fmt.Errorf("invalid key length: AES-256 requires 32 bytes, got %d", len(out.Plaintext))
+		Metrics.recordLoad(err)
+		return nil, err
+	}
+
+	Metrics.recordLoad(nil)
+	return out.Plaintext, nil
+}
+
+// Rotate requests a fresh data key from KMS and registers it as the new active version.
+//
+// Returns error if the GenerateDataKey call fails.
+func (p *AWSKMSKeyProvider) Rotate() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	input := &awskms.GenerateDataKeyInput{KeySpec: "AES_256"}
+	if p.keyID != "" {
+		input.KeyId = aws.String(p.keyID)
+	}
+
+	out, err := p.client.GenerateDataKey(ctx, input)
+	if err != nil {
+		Metrics.recordLoad(err)
+		return fmt.Errorf("AWS KMS GenerateDataKey failed: %v", err)
+	}
+	zeroBytes(out.Plaintext)
+
+	p.mu.Lock()
+	p.currentVersion++
+	p.versions[p.currentVersion] = out.CiphertextBlob
+	p.mu.Unlock()
+
+	Metrics.recordRotation()
+	return nil
+}
+
+// zeroBytes overwrites key material no longer needed by this package.
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Environment-variable-backed KeyProvider for Security-Switch.
+
+Reproduces the current RAMUSB_ENCRYPTION_KEY behavior as the "env" backend
+selectable via RAMUSB_KEY_PROVIDER, for deployments that don't yet need a
+managed secret store.
+*/
+package keyprovider
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// EnvKeyProvider loads a hex-encoded key directly from an environment variable.
+//
+// Security features:
+// - Hex decoding and length validation reject malformed or non-AES-256 keys before use
+// - Rotate re-reads the environment variable, so an orchestrator updating the injected value (e.g. via a reloaded secret-backed env) takes effect without a restart
+//
+// Current's id is always "env"; Get only recognizes that id, since a plain
+// environment variable has no concept of retired versions.
+type EnvKeyProvider struct {
+	mu      sync.Mutex
+	envName string
+	key     []byte
+}
+
+// NewEnvKeyProvider constructs an EnvKeyProvider reading RAMUSB_ENCRYPTION_KEY.
+//
+// Returns a ready-to-use EnvKeyProvider, or error if the variable is unset or invalid.
+func NewEnvKeyProvider() (*EnvKeyProvider, error) {
+	p := &EnvKeyProvider{envName: "RAMUSB_ENCRYPTION_KEY"}
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// load reads and validates the key from the environment variable.
+func (p *EnvKeyProvider) load() error {
+	keyHex := os.Getenv(p.envName)
+	if keyHex == "" {
+		err := fmt.Errorf("%s environment variable not set", p.envName)
+		Metrics.recordLoad(err)
+		return err
+	}
+
+	key, err := hex.DecodeString(keyHex)
+	if err != nil {
+		err = fmt.Errorf("invalid hex format in %s: %v", p.envName, err)
+		Metrics.recordLoad(err)
+		return err
+	}
+
+	if len(key) != 32 {
+		err = fmt.Errorf("invalid key length: AES-256 requires 32 bytes, got %d", len(key))
+		Metrics.recordLoad(err)
+		return err
+	}
+
+	p.mu.Lock()
+	p.key = key
+	p.mu.Unlock()
+
+	Metrics.recordLoad(nil)
+	return nil
+}
+
+// Current returns id "env" and the currently loaded key.
+func (p *EnvKeyProvider) Current() (string, []byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return "env", p.key, nil
+}
+
+// Get returns the loaded key if id is "env", error otherwise.
+//
+// Returns the key bytes, or error if id does not match the single known version.
+func (p *EnvKeyProvider) Get(id string) ([]byte, error) {
+	if id != "env" {
+		return nil, fmt.Errorf("unknown key id %q: EnvKeyProvider has no retired versions", id)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.key, nil
+}
+
+// Rotate re-reads the environment variable, picking up a new value if one was injected.
+//
+// Returns error if the environment variable is now unset or invalid.
+func (p *EnvKeyProvider) Rotate() error {
+	if err := p.load(); err != nil {
+		return err
+	}
+	Metrics.recordRotation()
+	return nil
+}
@@ -0,0 +1,121 @@
+/*
+File-backed KeyProvider for Security-Switch.
+
+Selectable as the "file" backend via RAMUSB_KEY_PROVIDER, for deployments
+that mount key material as a protected file rather than an environment
+variable.
+*/
+package keyprovider
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileKeyProvider loads a key from a protected file system location.
+//
+// Security features:
+// - File permission validation (should be 600 or 400) before trusting file contents
+// - Accepts either 32-byte binary or 64-byte hex-encoded key files
+// - Rotate re-reads the file, picking up a secret rotated by the orchestrator in place
+//
+// Current's id is always "file"; Get only recognizes that id, matching EnvKeyProvider.
+type FileKeyProvider struct {
+	mu      sync.Mutex
+	keyPath string
+	key     []byte
+}
+
+// NewFileKeyProvider constructs a FileKeyProvider reading the key from keyPath.
+//
+// Returns a ready-to-use FileKeyProvider, or error if keyPath is empty, missing,
+// insecurely permissioned, or contains an invalid key.
+func NewFileKeyProvider(keyPath string) (*FileKeyProvider, error) {
+	if keyPath == "" {
+		return nil, fmt.Errorf("RAMUSB_KEY_FILE environment variable not set")
+	}
+	p := &FileKeyProvider{keyPath: keyPath}
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// load reads, validates, and caches the key from the configured file path.
+func (p *FileKeyProvider) load() error {
+	fileInfo, err := os.Stat(p.keyPath)
+	if err != nil {
+		err = fmt.Errorf("key file access error: %v", err)
+		Metrics.recordLoad(err)
+		return err
+	}
+
+	if mode := fileInfo.Mode(); mode&0077 != 0 {
+		err := fmt.Errorf("key file has insecure permissions: %v (should be 600 or 400)", mode)
+		Metrics.recordLoad(err)
+		return err
+	}
+
+	keyData, err := os.ReadFile(p.keyPath)
+	if err != nil {
+		err = fmt.Errorf("failed to read key file: %v", err)
+		Metrics.recordLoad(err)
+		return err
+	}
+
+	var key []byte
+	switch len(keyData) {
+	case 64:
+		key, err = hex.DecodeString(string(keyData))
+		if err != nil {
+			err = fmt.Errorf("key file contains invalid hex data: %v", err)
+			Metrics.recordLoad(err)
+			return err
+		}
+	case 32:
+		key = keyData
+	default:
+		err = fmt.Errorf("key file has invalid length: expected 32 or 64 bytes, got %d", len(keyData))
+		Metrics.recordLoad(err)
+		return err
+	}
+
+	p.mu.Lock()
+	p.key = key
+	p.mu.Unlock()
+
+	Metrics.recordLoad(nil)
+	return nil
+}
+
+// Current returns id "file" and the currently loaded key.
+func (p *FileKeyProvider) Current() (string, []byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return "file", p.key, nil
+}
+
+// Get returns the loaded key if id is "file", error otherwise.
+//
+// Returns the key bytes, or error if id does not match the single known version.
+func (p *FileKeyProvider) Get(id string) ([]byte, error) {
+	if id != "file" {
+		return nil, fmt.Errorf("unknown key id %q: FileKeyProvider has no retired versions", id)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.key, nil
+}
+
+// Rotate re-reads the key file, picking up a new key if one was written in place.
+//
+// Returns error if the file is now missing, insecurely permissioned, or invalid.
+func (p *FileKeyProvider) Rotate() error {
+	if err := p.load(); err != nil {
+		return err
+	}
+	Metrics.recordRotation()
+	return nil
+}
@@ -0,0 +1,69 @@
+/*
+Pluggable secret backend selection for Security-Switch encryption key material.
+
+Defines the KeyProvider interface and the environment-driven selection logic
+that picks one of its implementations (EnvKeyProvider, FileKeyProvider,
+VaultKeyProvider, AWSKMSKeyProvider) via RAMUSB_KEY_PROVIDER. Every
+implementation is versioned: Current returns the active key id alongside its
+bytes, and Get resolves any previously active id, so callers can stamp a key
+id onto ciphertext and keep decrypting old rows after Rotate promotes a new
+key. Mirrored in Database-Vault, where LoadKeyringFromSources and
+crypto.Keyring already provide equivalent versioned key management; see
+database-vault/keyprovider for an adapter exposing that implementation behind
+this same interface.
+*/
+package keyprovider
+
+import (
+	"fmt"
+	"os"
+)
+
+// KeyProvider supplies versioned encryption key material from a pluggable secret backend.
+//
+// Security features:
+// - Versioned lookup (Current/Get) allows ciphertext written under an old key to keep decrypting after rotation
+// - Rotate is explicit and serialized by each implementation, never implicit on read
+//
+// Implemented by EnvKeyProvider, FileKeyProvider, VaultKeyProvider, and AWSKMSKeyProvider.
+type KeyProvider interface {
+	// Current returns the active key id and its key bytes.
+	Current() (id string, key []byte, err error)
+	// Get returns the key bytes for a specific, possibly retired, key id.
+	Get(id string) ([]byte, error)
+	// Rotate advances the active key, keeping prior keys available via Get.
+	Rotate() error
+}
+
+// Metrics counts key-load outcomes for operational monitoring.
+//
+// Security features:
+// - Counts only outcomes, never key material or identifying error detail
+//
+// Safe for concurrent use; read with Snapshot.
+var Metrics = &keyProviderMetrics{}
+
+// NewFromEnv selects and constructs a KeyProvider based on RAMUSB_KEY_PROVIDER.
+//
+// Security features:
+// - Fails closed: an unset or unrecognized provider name is an error, never a silent fallback to a weaker backend
+// - Every constructed provider is validated before being returned, so startup fails fast on misconfiguration
+//
+// Returns the selected KeyProvider, or error if RAMUSB_KEY_PROVIDER is unset,
+// unrecognized, or the selected backend fails to initialize.
+func NewFromEnv() (KeyProvider, error) {
+	switch backend := os.Getenv("RAMUSB_KEY_PROVIDER"); backend {
+	case "env":
+		return NewEnvKeyProvider()
+	case "file":
+		return NewFileKeyProvider(os.Getenv("RAMUSB_KEY_FILE"))
+	case "vault":
+		return NewVaultKeyProviderFromEnv()
+	case "awskms":
+		return NewAWSKMSKeyProviderFromEnv()
+	case "":
+		return nil, fmt.Errorf("RAMUSB_KEY_PROVIDER environment variable not set")
+	default:
+		return nil, fmt.Errorf("unrecognized RAMUSB_KEY_PROVIDER: %q (expected env, file, vault, or awskms)", backend)
+	}
+}
@@ -0,0 +1,47 @@
+package keyprovider
+
+import "sync/atomic"
+
+// keyProviderMetrics tracks key-load successes and failures across all KeyProvider implementations.
+//
+// Security features:
+// - Only aggregate counts are kept; never key material, identities, or raw errors
+//
+// Use the package-level Metrics variable rather than constructing this directly.
+type keyProviderMetrics struct {
+	loadSuccesses int64
+	loadFailures  int64
+	rotations     int64
+}
+
+// MetricsSnapshot is a point-in-time read of keyProviderMetrics counters.
+type MetricsSnapshot struct {
+	LoadSuccesses int64
+	LoadFailures  int64
+	Rotations     int64
+}
+
+// recordLoad increments the success or failure counter for a key-load attempt.
+func (m *keyProviderMetrics) recordLoad(err error) {
+	if err != nil {
+		atomic.AddInt64(&m.loadFailures, 1)
+		return
+	}
+	atomic.AddInt64(&m.loadSuccesses, 1)
+}
+
+// recordRotation increments the rotation counter.
+func (m *keyProviderMetrics) recordRotation() {
+	atomic.AddInt64(&m.rotations, 1)
+}
+
+// Snapshot returns the current counter values for monitoring export.
+//
+// Returns a MetricsSnapshot safe to read without racing concurrent key loads.
+func (m *keyProviderMetrics) Snapshot() MetricsSnapshot {
+	return MetricsSnapshot{
+		LoadSuccesses: atomic.LoadInt64(&m.loadSuccesses),
+		LoadFailures:  atomic.LoadInt64(&m.loadFailures),
+		Rotations:     atomic.LoadInt64(&m.rotations),
+	}
+}
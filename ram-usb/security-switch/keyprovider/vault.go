@@ -0,0 +1,257 @@
+/*
+HashiCorp Vault-backed KeyProvider for Security-Switch.
+
+Selectable as the "vault" backend via RAMUSB_KEY_PROVIDER. Uses Vault's KV v2
+secrets engine, whose native secret versioning backs Current/Get/Rotate
+directly - no separate version-tracking scheme is needed.
+*/
+package keyprovider
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// tokenRenewalInterval bounds how often the background goroutine checks
+// whether the Vault auth token needs renewing.
+const tokenRenewalInterval = 5 * time.Minute
+
+// VaultKeyProvider loads versioned key material from Vault's KV v2 secrets engine.
+//
+// Security features:
+// - AppRole or token authentication via the standard Vault API client, never embedded credentials
+// - KV v2's native secret versioning backs Current/Get/Rotate directly, so every historical key id remains independently fetchable
+// - Background goroutine renews the auth token's lease before it expires, so long-running processes don't lose Vault access mid-run
+//
+// Construct with NewVaultKeyProviderFromEnv; call Close to stop the renewal goroutine.
+type VaultKeyProvider struct {
+	client    *vaultapi.Client
+	mountPath string
+	path      string
+
+	mu     sync.Mutex
+	stopCh chan struct{}
+}
+
+// NewVaultKeyProviderFromEnv builds a VaultKeyProvider from RAMUSB_VAULT_* environment variables.
+//
+// Security features:
+// - AppRole login (RAMUSB_VAULT_ROLE_ID/RAMUSB_VAULT_SECRET_ID) is preferred over a static token when both are configured
+// - Starts the token renewal goroutine only for tokens obtained via AppRole login, since a caller-supplied static token's lifecycle is not ours to manage
+//
+// Returns a ready-to-use VaultKeyProvider, or error if the Vault client or
+// authentication cannot be established.
+func NewVaultKeyProviderFromEnv() (*VaultKeyProvider, error) {
+	addr := os.Getenv("RAMUSB_VAULT_ADDR")
+	if addr == "" {
+		return nil, fmt.Errorf("RAMUSB_VAULT_ADDR environment variable not set")
+	}
+
+	vaultConfig := vaultapi.DefaultConfig()
+	vaultConfig.Address = addr
+	client, err := vaultapi.NewClient(vaultConfig)
+	if err != nil {
+		return nil, fmt.Errorf("vault client initialization failed: %v", err)
+	}
+
+	renewable := false
+	if roleID, secretID := os.Getenv("RAMUSB_VAULT_ROLE_ID"), os.Getenv("RAMUSB_VAULT_SECRET_ID"); roleID != "" && secretID != "" {
+		if err := approleLogin(client, roleID, secretID); err != nil {
+			return nil, fmt.Errorf("vault AppRole login failed: %v", err)
+		}
+		renewable = true
+	} else if token := os.Getenv("RAMUSB_VAULT_TOKEN"); token != "" {
+		client.SetToken(token)
+	} else {
+		return nil, fmt.Errorf("neither RAMUSB_VAULT_ROLE_ID/RAMUSB_VAULT_SECRET_ID nor RAMUSB_VAULT_TOKEN configured")
+	}
+
+	mountPath := os.Getenv("RAMUSB_VAULT_KV_MOUNT")
+	if mountPath == "" {
+		mountPath = "secret"
+	}
+	path := os.Getenv("RAMUSB_VAULT_KV_PATH")
+	if path == "" {
+		return nil, fmt.Errorf("RAMUSB_VAULT_KV_PATH environment variable not set")
+	}
+
+	p := &VaultKeyProvider{client: client, mountPath: mountPath, path: path}
+
+	if _, _, err := p.Current(); err != nil {
+		return nil, fmt.Errorf("initial vault key load failed: %v", err)
+	}
+
+	if renewable {
+		p.stopCh = make(chan struct{})
+		go p.renewTokenPeriodically()
+	}
+
+	return p, nil
+}
+
+// approleLogin authenticates client via Vault's AppRole auth method.
+//
+// Returns error if the login request fails or returns no auth token.
+func approleLogin(client *vaultapi.Client, roleID, secretID string) error {
+	secret, err := client.Logical().Write("auth/approle/login", map[string]interface{}{
+		"role_id":   roleID,
+		"secret_id": secretID,
+	})
+	if err != nil {
+		return fmt.Errorf("AppRole login request failed: %v", err)
+	}
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return fmt.Errorf("AppRole login returned no client token")
+	}
+	client.SetToken(secret.Auth.ClientToken)
+	return nil
+}
+
+// renewTokenPeriodically renews the current Vault token's lease until Close is called.
+//
+// Security features:
+// - Renewal failures are logged, never fatal, so a transient Vault outage does not crash the process; the next successful Current/Get call will surface any real loss of access
+func (p *VaultKeyProvider) renewTokenPeriodically() {
+	ticker := time.NewTicker(tokenRenewalInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := p.client.Auth().Token().RenewSelf(int(tokenRenewalInterval.Seconds()) * 2); err != nil {
+				log.Printf("Vault token renewal failed: %v", err)
+			}
+		case <-p.stopCh:
+			return
+		}
+	}
+}
+
+// Close stops the background token renewal goroutine, if one was started.
+func (p *VaultKeyProvider) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopCh != nil {
+		close(p.stopCh)
+		p.stopCh = nil
+	}
+}
+
+// Current reads the latest KV v2 version of the configured secret.
+//
+// Returns the version number (as a decimal string) and the hex-decoded key, or
+// error if Vault is unreachable or the secret is malformed.
+func (p *VaultKeyProvider) Current() (string, []byte, error) {
+	return p.readVersion(0)
+}
+
+// Get reads a specific KV v2 version of the configured secret by id.
+//
+// Returns the key bytes for that version, or error if id is not a valid
+// version number or the read fails.
+func (p *VaultKeyProvider) Get(id string) ([]byte, error) {
+	version, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault key id %q: %v", id, err)
+	}
+	_, key, err := p.readVersion(version)
+	return key, err
+}
+
+// readVersion reads the secret at the given KV v2 version (0 meaning latest).
+//
+// Returns the version read (as a string) and the decoded key.
+func (p *VaultKeyProvider) readVersion(version int) (string, []byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	params := map[string][]string{}
+	if version > 0 {
+		params["version"] = []string{strconv.Itoa(version)}
+	}
+
+	secret, err := p.client.Logical().ReadWithDataWithContext(ctx,
+		fmt.Sprintf("%s/data/%s", p.mountPath, p.path), params)
+	if err != nil {
+		Metrics.recordLoad(err)
+		return "", nil, fmt.Errorf("vault KV v2 read failed: %v", err)
+	}
+	if secret == nil || secret.Data["data"] == nil {
+		err := fmt.Errorf("vault KV v2 secret not found: %s/%s", p.mountPath, p.path)
+		Metrics.recordLoad(err)
+		return "", nil, err
+	}
+
+	data, ok := secret.Data["data"].(map[string]interface{})
+	if !ok {
+		err := fmt.Errorf("vault KV v2 secret has unexpected shape")
+		Metrics.recordLoad(err)
+		return "", nil, err
+	}
+
+	keyHex, ok := data["key"].(string)
+	if !ok {
+		err := fmt.Errorf("vault KV v2 secret missing 'key' field")
+		Metrics.recordLoad(err)
+		return "", nil, err
+	}
+
+	key, err := hex.DecodeString(keyHex)
+	if err != nil {
+		err = fmt.Errorf("vault KV v2 secret has invalid hex key: %v", err)
+		Metrics.recordLoad(err)
+		return "", nil, err
+	}
+
+	if len(key) != 32 {
+		err := fmt.Errorf("invalid key length: AES-256 requires 32 bytes, got %d", len(key))
+		Metrics.recordLoad(err)
+		return "", nil, err
+	}
+
+	resolvedVersion := version
+	if meta, ok := secret.Data["metadata"].(map[string]interface{}); ok {
+		if v, ok := meta["version"].(float64); ok {
+			resolvedVersion = int(v)
+		}
+	}
+
+	Metrics.recordLoad(nil)
+	return strconv.Itoa(resolvedVersion), key, nil
+}
+
+// Rotate generates a fresh random key and writes it as a new KV v2 secret version.
+//
+// Security features:
+// - crypto/rand generation ensures the new key has full cryptographic entropy
+// - The prior version remains independently readable via Get, so in-flight ciphertext keeps decrypting
+//
+// Returns error if key generation or the Vault write fails.
+func (p *VaultKeyProvider) Rotate() error {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return fmt.Errorf("failed to generate new key: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := p.client.Logical().WriteWithContext(ctx,
+		fmt.Sprintf("%s/data/%s", p.mountPath, p.path),
+		map[string]interface{}{"data": map[string]interface{}{"key": hex.EncodeToString(key)}})
+	if err != nil {
+		return fmt.Errorf("vault KV v2 write failed: %v", err)
+	}
+
+	Metrics.recordRotation()
+	return nil
+}
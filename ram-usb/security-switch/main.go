@@ -7,19 +7,34 @@ Implements zero-trust inter-service communication with certificate-based authent
 and comprehensive validation middleware within the R.A.M.-U.S.B. distributed architecture.
 
 TO-DO: Restrict listening to specific Tailscale IPs (Entry-Hub, Database-Vault, Storage-Service, OPA)
+
+OPA is now wired in as a forward-auth sidecar (see middleware.ForwardAuth),
+queried for /api/register once cfg.OPAEnabled is set.
+
+In MutualTLS mode, server TLS material comes from a certwatch.Watcher
+instead of a one-time tlsprofile.Profile.ServerConfig load, so rotating the
+CA or server leaf no longer requires a restart (see POST /admin/reload).
 */
 package main
 
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"security_switch/config"
 	"security_switch/handlers"
+	"security_switch/interfaces"
 	"security_switch/middleware"
+	"security_switch/ratelimit"
+	"security_switch/utils"
+
+	"ramusb/certwatch"
+	"ramusb/mtlsauth"
+	"ramusb/tlsprofile"
 )
 
 // main initializes and starts the Security-Switch mTLS server.
@@ -29,6 +44,7 @@ import (
 // - Certificate Authority validation prevents unauthorized certificates
 // - TLS 1.3 enforcement for maximum cryptographic security
 // - mTLS middleware ensures only authenticated clients reach endpoints
+// - Token-bucket rate limiting bounds per-identity and aggregate request volume
 //
 // Starts secure gateway server on port 8444 with comprehensive error handling.
 func main() {
@@ -36,71 +52,190 @@ func main() {
 	// Load mTLS parameters and service endpoints for distributed communication
 	cfg := config.GetConfig()
 
+	// CONFIGURATION VALIDATION
+	// Ensure all critical configuration components are valid
+	if err := cfg.ValidateConfig(); err != nil {
+		log.Fatalf("Configuration validation failed: %v", err)
+	}
+
 	// SERVICE STARTUP LOGGING
 	// Log configuration without sensitive certificate data
 	fmt.Printf("Security-Switch starting on port %s\n", cfg.ServerPort)
 	fmt.Printf("Database-Vault endpoint: %s\n", cfg.DatabaseVaultIP)
 	fmt.Println("mTLS authentication enabled")
 
-	// CA CERTIFICATE LOADING
-	// Load Certificate Authority for client certificate validation
-	caCert, err := os.ReadFile(cfg.CACertFile)
+	// RATE LIMITER INITIALIZATION
+	// Build the token-bucket limiter guarding against a compromised Entry-Hub instance
+	limiter, err := ratelimit.NewFromEnv(cfg.RateLimitBackend, cfg.RateLimitRedisAddr)
 	if err != nil {
-		// CA certificate loading failure - critical security error
-		log.Fatalf("Failed to read CA certificate: %v", err)
+		// Rate limiter configuration failure - fail fast rather than start unprotected
+		log.Fatalf("Failed to initialize rate limiter: %v", err)
 	}
 
-	// CERTIFICATE POOL CREATION
-	// Configure trusted certificate authorities for client validation
-	caCertPool := x509.NewCertPool()
-	if !caCertPool.AppendCertsFromPEM(caCert) {
-		// CA certificate parsing failure - invalid certificate format
-		log.Fatal("Failed to parse CA certificate")
+	// MTLS CLIENT AUTHORIZATION
+	// Build the policy (Subject.Organization, SPIFFE ID, or SAN) and optional OCSP/CRL
+	// revocation checker VerifyMTLS delegates to, per cfg.AuthzMode/RevocationEnabled
+	authzVerifier, err := buildAuthzVerifier(cfg)
+	if err != nil {
+		log.Fatalf("Failed to build mTLS authorization policy: %v", err)
 	}
+	verifier := middleware.NewVerifier(authzVerifier)
 
-	// SERVER CERTIFICATE LOADING
-	// Load Security-Switch server credentials for client authentication
-	serverCert, err := tls.LoadX509KeyPair(cfg.ServerCertFile, cfg.ServerKeyFile)
-	if err != nil {
-		// Server certificate loading failure - deployment configuration error
-		log.Fatalf("Failed to load server certificate: %v", err)
+	// TLS PROFILE RESOLUTION
+	// MutualTLS mode (production default) gets a certwatch.Watcher, so
+	// rotating the CA or server leaf is a file write instead of a restart
+	// that would drop in-flight registrations; TLS/Disabled (dev bring-up
+	// only, see ramusb/tlsprofile) keep the static, one-time ServerConfig
+	// path since there's no client certificate material to rotate there.
+	var tlsConfig *tls.Config
+	var certWatcher *certwatch.Watcher
+	if cfg.ServerTLSProfile.Mode == tlsprofile.MutualTLS {
+		certWatcher, err = certwatch.New(certwatch.Sources{
+			CACertFile:     cfg.CACertFile,
+			ServerCertFile: cfg.ServerCertFile,
+			ServerKeyFile:  cfg.ServerKeyFile,
+			RoutesFile:     cfg.ServerRoutesFile,
+			ServiceName:    "security-switch",
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize certificate watcher: %v", err)
+		}
+		go certWatcher.Watch()
+
+		// TLS HANDSHAKE-LEVEL AUTHORIZATION
+		// Defense in depth alongside VerifyMTLS: reject an unauthorized peer
+		// at the handshake itself. Attached per-handshake, since GetConfigForClient
+		// returning a Config discards everything set on the outer one.
+		tlsConfig = &tls.Config{
+			GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+				liveConfig, err := certWatcher.GetConfigForClient(hello)
+				if err != nil {
+					return nil, err
+				}
+				liveConfig.VerifyPeerCertificate = authzVerifier.VerifyPeerCertificate
+				return liveConfig, nil
+			},
+		}
+	} else {
+		tlsConfig, err = cfg.ServerTLSProfile.ServerConfig()
+		if err != nil {
+			log.Fatalf("Failed to build server TLS configuration: %v", err)
+		}
 	}
 
-	// MTLS CONFIGURATION
-	// Configure mutual TLS with comprehensive security parameters
-	tlsConfig := &tls.Config{
-		Certificates: []tls.Certificate{serverCert},  // Server certificate for client authentication
-		ClientAuth:   tls.RequireAndVerifyClientCert, // Enforce mutual authentication requirement
-		ClientCAs:    caCertPool,                     // Trusted CAs for client certificate validation
-		MinVersion:   tls.VersionTLS13,               // Enforce modern TLS version for security
+	// READINESS
+	// Startup certificate load and configuration validation have both passed
+	// by this point, so /api/ready can start reporting 200 - see handlers.ReadyHandler
+	handlers.SetReady()
+
+	// FORWARD-AUTH INITIALIZATION
+	// Build the OPA client and decision cache /api/register delegates the
+	// actual authorization decision to, opt-in via cfg.OPAEnabled
+	registerHandler := utils.MaxBytes(cfg.MaxBodyBytes)(limiter.Middleware("register")(handlers.RegisterHandler))
+	if cfg.OPAEnabled {
+		opaClient, err := interfaces.NewOPAClient(cfg.OPATargetAddr, cfg.OPATLSProfile)
+		if err != nil {
+			log.Fatalf("Failed to build OPA client: %v", err)
+		}
+		forwardAuth := middleware.NewForwardAuth(opaClient, cfg.OPACacheSize, cfg.OPACacheTTL)
+		registerHandler = forwardAuth.Middleware(registerHandler)
 	}
+	loginHandler := utils.MaxBytes(cfg.MaxBodyBytes)(limiter.Middleware("login")(handlers.LoginHandler))
 
 	// HTTP ROUTER SETUP
 	// Configure request routing with mTLS middleware protection
 	mux := http.NewServeMux()
 
-	// ROUTE REGISTRATION WITH MTLS MIDDLEWARE
-	// Apply certificate verification middleware to all Security-Switch endpoints
-	mux.HandleFunc("/api/register", middleware.VerifyMTLS(handlers.RegisterHandler))
-	mux.HandleFunc("/api/health", middleware.VerifyMTLS(handlers.HealthHandler))
+	// ROUTE REGISTRATION WITH MTLS AND RATE LIMIT MIDDLEWARE
+	// Apply certificate verification and per-identity rate limiting to all Security-Switch endpoints.
+	// Disabled mode (RAMUSB_ENV=dev only) has no peer certificate to verify, so it
+	// skips VerifyMTLS and instead logs a loud warning on every request.
+	healthHandler := limiter.Middleware("health")(handlers.HealthHandler)
+	if cfg.ServerTLSProfile.Mode == tlsprofile.Disabled {
+		mux.HandleFunc("/api/register", cfg.ServerTLSProfile.WarnInsecure(registerHandler))
+		mux.HandleFunc("/api/login", cfg.ServerTLSProfile.WarnInsecure(loginHandler))
+		mux.HandleFunc("/api/health", cfg.ServerTLSProfile.WarnInsecure(healthHandler))
+		mux.HandleFunc("/api/ready", cfg.ServerTLSProfile.WarnInsecure(handlers.ReadyHandler))
+	} else {
+		mux.HandleFunc("/api/register", verifier.VerifyMTLS(registerHandler))
+		mux.HandleFunc("/api/login", verifier.VerifyMTLS(loginHandler))
+		mux.HandleFunc("/api/health", verifier.VerifyMTLS(healthHandler))
+		mux.HandleFunc("/api/ready", verifier.VerifyMTLS(handlers.ReadyHandler))
+	}
+
+	// ADMIN ENDPOINT REGISTRATION
+	// Forces a synchronous certificate reload; restricted beyond VerifyMTLS
+	// to whatever admin SANs cfg.ServerRoutesFile currently lists, so it's
+	// safe to register even when that allowlist is empty. Only meaningful
+	// alongside a certWatcher, i.e. MutualTLS mode.
+	if certWatcher != nil {
+		mux.HandleFunc("/admin/reload", verifier.VerifyMTLS(certWatcher.AdminReloadHandler()))
+	}
 
 	// HTTPS SERVER CONFIGURATION
-	// Create server with mTLS configuration and network binding
+	// Create server with the resolved TLS configuration and network binding
 	server := &http.Server{
 		Addr:      "0.0.0.0:" + cfg.ServerPort, // TO-DO: Restrict to Tailscale IPs only
 		Handler:   mux,                         // Router with mTLS-protected endpoints
-		TLSConfig: tlsConfig,                   // Mutual TLS authentication configuration
+		TLSConfig: tlsConfig,                   // nil in Disabled mode; resolved TLS configuration otherwise
 	}
 
 	// SERVICE INFORMATION DISPLAY
 	// Provide endpoint documentation and usage guidance
 	fmt.Println("Available endpoints:")
 	fmt.Println("\tPOST /api/register (Forward user registration to Database-Vault)")
+	fmt.Println("\tPOST /api/login (Forward user login to Database-Vault)")
 	fmt.Println("\tGET  /api/health (Check Security-Switch status)")
-	fmt.Println("Security-Switch ready to accept mTLS connections")
+	fmt.Println("\tGET  /api/ready (Readiness probe: 200 once startup has completed)")
+	if certWatcher != nil {
+		fmt.Println("\tPOST /admin/reload (Force a certificate reload, admin SAN only)")
+	}
+	fmt.Println("Security-Switch ready to accept connections")
 	fmt.Println("To stop the server press Ctrl+C")
 
-	// MTLS SERVER STARTUP
-	// Start mutual TLS server with certificate-based authentication
+	// SERVER STARTUP
+	// Disabled mode (RAMUSB_ENV=dev only) serves plain HTTP; every other mode serves TLS
+	if tlsConfig == nil {
+		log.Fatal(server.ListenAndServe())
+	}
 	log.Fatal(server.ListenAndServeTLS("", "")) // Empty strings - certificates loaded in TLSConfig
 }
+
+// buildAuthzVerifier assembles the mtlsauth.Verifier VerifyMTLS delegates to,
+// selecting an AuthzPolicy per cfg.AuthzMode and attaching revocation
+// checking only when cfg.RevocationEnabled.
+//
+// Returns error if cfg.AuthzMode is unrecognized or the revocation issuer
+// certificate cannot be read and parsed.
+func buildAuthzVerifier(cfg *config.Config) (*mtlsauth.Verifier, error) {
+	var policy mtlsauth.AuthzPolicy
+	switch cfg.AuthzMode {
+	case "", "org":
+		policy = mtlsauth.OrgPolicy{Org: "EntryHub"}
+	case "spiffe":
+		policy = mtlsauth.SPIFFEPolicy{Allowed: cfg.AuthzSPIFFEAllowed}
+	case "san":
+		policy = mtlsauth.SANPolicy{AllowedDNS: cfg.AuthzSANAllowedDNS, AllowedURIs: cfg.AuthzSANAllowedURIs}
+	default:
+		return nil, fmt.Errorf("unknown mtls_authz.mode %q", cfg.AuthzMode)
+	}
+
+	var revocation *mtlsauth.RevocationChecker
+	if cfg.RevocationEnabled {
+		issuerPEM, err := os.ReadFile(cfg.RevocationIssuerCertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read revocation issuer certificate: %v", err)
+		}
+		issuerBlock, _ := pem.Decode(issuerPEM)
+		if issuerBlock == nil {
+			return nil, fmt.Errorf("revocation issuer certificate is not valid PEM")
+		}
+		issuer, err := x509.ParseCertificate(issuerBlock.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse revocation issuer certificate: %v", err)
+		}
+		revocation = mtlsauth.NewRevocationChecker(issuer, cfg.RevocationCRLURLs)
+	}
+
+	return mtlsauth.NewVerifier(policy, revocation), nil
+}
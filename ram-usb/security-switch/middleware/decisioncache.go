@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"container/list"
+	"security_switch/interfaces"
+	"sync"
+	"time"
+)
+
+// cacheEntry holds one cached OPA decision and when it stops being trusted.
+type cacheEntry struct {
+	key       string
+	decision  *interfaces.OPADecision
+	expiresAt time.Time
+}
+
+// decisionCache is a small fixed-capacity, TTL-bounded LRU cache for OPA
+// allow/deny decisions, keyed by (subject, path, body-hash) so a burst of
+// identical requests from the same Entry-Hub instance doesn't round-trip to
+// OPA for every one.
+//
+// Construct with newDecisionCache. Safe for concurrent use.
+type decisionCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // front = most recently used
+}
+
+// newDecisionCache returns an empty decisionCache holding at most capacity
+// entries, each trusted for ttl after it was cached.
+func newDecisionCache(capacity int, ttl time.Duration) *decisionCache {
+	return &decisionCache{
+		ttl:      ttl,
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// get returns the cached decision for key, or false if there is none or it
+// has expired - an expired entry is evicted on the way out.
+func (c *decisionCache) get(key string) (*interfaces.OPADecision, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.decision, true
+}
+
+// put caches decision for key, evicting the least recently used entry if
+// this insertion would exceed capacity.
+func (c *decisionCache) put(key string, decision *interfaces.OPADecision) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.decision = decision
+		entry.expiresAt = time.Now().Add(c.ttl)
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	elem := c.order.PushFront(&cacheEntry{key: key, decision: decision, expiresAt: time.Now().Add(c.ttl)})
+	c.entries[key] = elem
+
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
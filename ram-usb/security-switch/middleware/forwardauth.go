@@ -0,0 +1,177 @@
+/*
+Forward-auth middleware that delegates the actual authorization decision to
+an OPA (Open Policy Agent) sidecar.
+
+VerifyMTLS only ever answers "is this a certificate I trust the transport
+to"; ForwardAuth is the real policy point, asking OPA whether this specific
+request - method, path, calling identity, and a redacted copy of the body -
+is allowed. A small LRU cache keyed by (subject, path, body-hash) absorbs
+burst traffic without re-querying OPA for identical requests within its TTL.
+*/
+package middleware
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"security_switch/interfaces"
+	"security_switch/utils"
+	"strings"
+	"time"
+)
+
+// forwardAuthBody is the subset of a registration request ForwardAuth
+// redacts out of the raw JSON body before it ever leaves Security-Switch -
+// the password never reaches OPA.
+type forwardAuthBody struct {
+	Email     string `json:"email"`
+	SSHPubKey string `json:"ssh_public_key"`
+}
+
+// ForwardAuth wraps a handler so it's only reached once OPA allows the
+// request, keeping VerifyMTLS focused on transport auth and OPA as the
+// actual policy decision point.
+//
+// Construct with NewForwardAuth.
+type ForwardAuth struct {
+	opa   *interfaces.OPAClient
+	cache *decisionCache
+}
+
+// NewForwardAuth returns a ForwardAuth that queries opa for each request not
+// already answered by its decision cache, a small LRU bounded by
+// cacheCapacity entries and cacheTTL to absorb burst traffic without
+// re-querying OPA for identical requests.
+func NewForwardAuth(opa *interfaces.OPAClient, cacheCapacity int, cacheTTL time.Duration) *ForwardAuth {
+	return &ForwardAuth{opa: opa, cache: newDecisionCache(cacheCapacity, cacheTTL)}
+}
+
+// Middleware returns a wrapper that queries OPA before calling next.
+//
+// Security features:
+// - Redacts the request body to email and SSH key fingerprint before forwarding it to OPA; the password never leaves Security-Switch
+// - Fails closed (HTTP 503) on any OPA communication or decoding error, rather than admitting the request
+// - Caches allow/deny decisions by (subject, path, body-hash) so a burst of identical requests doesn't round-trip to OPA for every one
+// - Denials are routed through utils.LogAndSendError, carrying OPA's stated reason into the audit log
+// - Propagates X-Request-Id so Entry-Hub, Security-Switch, and OPA logs can be correlated for a single request
+//
+// Returns a handler that responds 403 with OPA's reason on denial, or 503 if
+// OPA cannot be reached.
+func (f *ForwardAuth) Middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID := ensureRequestID(r, w)
+
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			utils.LogAndSendError(w, http.StatusUnauthorized,
+				"ForwardAuth: no verified client certificate on request",
+				"Client certificate required")
+			return
+		}
+		subject := r.TLS.PeerCertificates[0].Subject.CommonName
+
+		body, ok := utils.ReadRequestBody(w, r)
+		if !ok {
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body)) // restore for the wrapped handler
+
+		cacheKey := decisionCacheKey(subject, r.URL.Path, body)
+		decision, cached := f.cache.get(cacheKey)
+		if !cached {
+			var err error
+			decision, err = f.opa.Evaluate(redactedInput(r.Method, r.URL.Path, subject, requestID, body))
+			if err != nil {
+				utils.LogAndSendError(w, http.StatusServiceUnavailable,
+					fmt.Sprintf("ForwardAuth: OPA request failed, failing closed: %v", err),
+					"Authorization service unavailable. Please try again later.")
+				return
+			}
+			f.cache.put(cacheKey, decision)
+		}
+
+		if !decision.Result.Allow {
+			utils.LogAndSendError(w, http.StatusForbidden,
+				fmt.Sprintf("ForwardAuth: OPA denied request (subject=%s, path=%s, request_id=%s): %s",
+					subject, r.URL.Path, requestID, decision.Result.Reason),
+				decision.Result.Reason)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// redactedInput builds the OPA query input for a request, extracting only
+// email and an SSH key fingerprint from body - never the password - and
+// tolerating a body that isn't the registration shape at all (OPA then
+// decides on method, path, and subject alone).
+func redactedInput(method, path, subject, requestID string, body []byte) interfaces.OPAInput {
+	input := interfaces.OPAInput{
+		Method:    method,
+		Path:      path,
+		Subject:   subject,
+		RequestID: requestID,
+	}
+
+	var parsed forwardAuthBody
+	if err := json.Unmarshal(body, &parsed); err == nil {
+		input.Email = parsed.Email
+		if parsed.SSHPubKey != "" {
+			input.SSHKeyFingerprint = sshKeyFingerprint(parsed.SSHPubKey)
+		}
+	}
+	return input
+}
+
+// sshKeyFingerprint returns the OpenSSH-style "SHA256:<base64>" fingerprint
+// of an authorized-keys-format public key, so OPA can reason about the key
+// being registered without ever seeing key material that could itself be misused.
+func sshKeyFingerprint(sshPubKey string) string {
+	fields := strings.Fields(sshPubKey)
+	if len(fields) < 2 {
+		return ""
+	}
+	keyData, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(keyData)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// decisionCacheKey derives the LRU cache key for a request: the calling
+// identity, the route, and a hash of its (redacted-at-query-time) raw body,
+// so distinct bodies from the same identity on the same route never collide.
+func decisionCacheKey(subject, path string, body []byte) string {
+	sum := sha256.Sum256(body)
+	return subject + "|" + path + "|" + hex.EncodeToString(sum[:])
+}
+
+// ensureRequestID returns r's inbound X-Request-Id, generating and setting
+// one on both r and w if the caller didn't supply one, so every hop downstream
+// of this middleware - including the response back to Entry-Hub - can be
+// correlated against the same request ID.
+func ensureRequestID(r *http.Request, w http.ResponseWriter) string {
+	id := r.Header.Get("X-Request-Id")
+	if id == "" {
+		id = generateRequestID()
+		r.Header.Set("X-Request-Id", id)
+	}
+	w.Header().Set("X-Request-Id", id)
+	return id
+}
+
+// generateRequestID returns a random 16-byte hex-encoded request identifier.
+func generateRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "unavailable"
+	}
+	return hex.EncodeToString(buf)
+}
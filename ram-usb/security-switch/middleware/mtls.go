@@ -0,0 +1,105 @@
+/*
+mTLS middleware for Security-Switch request validation and authentication.
+
+Implements certificate-based client authentication to ensure only authorized
+Entry-Hub instances can reach Security-Switch endpoints. Provides comprehensive
+certificate validation including TLS connection verification, client certificate
+presence, and delegated authorization (organization, SPIFFE identity, or SAN, plus
+optional OCSP/CRL revocation checking) within the zero-trust inter-service
+architecture for the registration forwarding pipeline.
+*/
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"security_switch/utils"
+
+	"ramusb/mtlsauth"
+)
+
+// Verifier wraps a shared ramusb/mtlsauth.Verifier as HTTP middleware,
+// keeping Security-Switch's request handling and logging conventions while
+// delegating revocation checking and identity authorization to the policy
+// configured in NewVerifier.
+//
+// Construct with NewVerifier.
+type Verifier struct {
+	authz *mtlsauth.Verifier
+}
+
+// NewVerifier returns a Verifier that authorizes inbound client certificates
+// via authz.
+func NewVerifier(authz *mtlsauth.Verifier) *Verifier {
+	return &Verifier{authz: authz}
+}
+
+// VerifyMTLS creates middleware function for mTLS client certificate validation.
+//
+// Security features:
+// - TLS connection state verification prevents non-encrypted requests
+// - Client certificate presence validation ensures mutual authentication
+// - Delegated authorization (via mtlsauth.AuthzPolicy) restricts access to EntryHub, by organization, SPIFFE ID, or SAN
+// - Optional OCSP/CRL revocation checking rejects certificates issued to since-compromised instances
+// - Comprehensive logging provides audit trail for security monitoring
+//
+// Returns wrapped handler function with mTLS authentication or error response for unauthorized requests.
+func (v *Verifier) VerifyMTLS(next http.HandlerFunc) http.HandlerFunc {
+	// MIDDLEWARE WRAPPER FUNCTION
+	// Returns anonymous function that performs mTLS verification before calling next handler
+	return func(w http.ResponseWriter, r *http.Request) {
+		// JSON RESPONSE SETUP
+		// Ensure consistent content type for error responses
+		w.Header().Set("Content-Type", "application/json")
+
+		// TLS CONNECTION VERIFICATION
+		// Ensure request uses encrypted TLS transport
+		if r.TLS == nil {
+			// Non-TLS connection attempt - security violation
+			log.Printf("Request without TLS from %s", r.RemoteAddr)
+			utils.SendErrorResponse(w, http.StatusUnauthorized, "TLS required")
+			return
+		}
+
+		// CLIENT CERTIFICATE VERIFICATION
+		// Verify that client presented certificate for mutual authentication
+		if len(r.TLS.PeerCertificates) == 0 {
+			// Missing client certificate - authentication failure
+			log.Printf("Request without client certificate from %s", r.RemoteAddr)
+			utils.SendErrorResponse(w, http.StatusUnauthorized, "Client certificate required")
+			return
+		}
+
+		// CERTIFICATE EXTRACTION AND LOGGING
+		// Extract client certificate for detailed validation
+		clientCert := r.TLS.PeerCertificates[0]
+
+		// AUTHENTICATION SUCCESS LOGGING
+		// Log successful mTLS authentication with certificate details
+		log.Printf("mTLS authenticated request from %s (CN=%s, O=%s)",
+			r.RemoteAddr,
+			clientCert.Subject.CommonName,
+			clientCert.Subject.Organization)
+
+		// DELEGATED AUTHORIZATION
+		// Revocation status and identity authorization, per the configured AuthzPolicy
+		if err := v.authz.Authorize(clientCert); err != nil {
+			// Revoked certificate or unauthorized identity - access denied
+			utils.LogAndSendError(w, http.StatusForbidden,
+				fmt.Sprintf("Unauthorized client certificate (CN=%s, DNS=%v, URIs=%v): %v",
+					clientCert.Subject.CommonName, clientCert.DNSNames, clientCert.URIs, err),
+				"Unauthorized client")
+			return
+		}
+
+		// REQUEST AUDIT LOGGING
+		// Log authenticated request details for security monitoring
+		fmt.Printf("Authenticated request: \n\tfrom:\t%s \n\tmethod:\t%s\n\tpath:\t%s\n",
+			r.RemoteAddr, r.Method, r.URL.Path)
+
+		// AUTHORIZED REQUEST FORWARDING
+		// Call original handler after successful mTLS verification
+		next(w, r)
+	}
+}
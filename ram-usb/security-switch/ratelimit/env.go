@@ -0,0 +1,47 @@
+package ratelimit
+
+import "fmt"
+
+// defaultRouteLimits configures the routes Security-Switch currently exposes.
+// Health checks get materially higher limits than registration, since the
+// latter drives expensive Database-Vault writes and Argon2id hashing.
+func defaultRouteLimits() map[string]RouteLimits {
+	return map[string]RouteLimits{
+		"register": {
+			PerIdentity: BucketConfig{Capacity: 5, RefillRate: 5.0 / 60.0},   // burst of 5, steady-state 5/minute per Entry-Hub identity
+			Global:      BucketConfig{Capacity: 50, RefillRate: 50.0 / 60.0}, // burst of 50, steady-state 50/minute across all identities
+		},
+		"login": {
+			PerIdentity: BucketConfig{Capacity: 5, RefillRate: 5.0 / 60.0},
+			Global:      BucketConfig{Capacity: 50, RefillRate: 50.0 / 60.0},
+		},
+		"health": {
+			PerIdentity: BucketConfig{Capacity: 30, RefillRate: 30.0 / 60.0},
+			Global:      BucketConfig{Capacity: 300, RefillRate: 300.0 / 60.0},
+		},
+	}
+}
+
+// NewFromEnv builds a Limiter using backendKind ("memory" or "redis") and,
+// for "redis", redisAddr as the Redis instance address.
+//
+// Returns a ready-to-use Limiter configured with defaultRouteLimits, or error
+// if backendKind is unrecognized or the selected backend cannot be reached.
+func NewFromEnv(backendKind, redisAddr string) (*Limiter, error) {
+	var backend Backend
+
+	switch backendKind {
+	case "redis":
+		redisBackend, err := NewRedisBackend(redisAddr)
+		if err != nil {
+			return nil, err
+		}
+		backend = redisBackend
+	case "memory", "":
+		backend = NewMemoryBackend()
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q: expected \"memory\" or \"redis\"", backendKind)
+	}
+
+	return NewLimiter(backend, defaultRouteLimits()), nil
+}
@@ -0,0 +1,35 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Identity identifies the Entry-Hub instance making a request, derived from
+// its verified mTLS client certificate rather than any caller-supplied value.
+type Identity struct {
+	CommonName   string
+	SerialNumber string
+}
+
+// String returns a stable key for use as a rate limit bucket identifier.
+func (id Identity) String() string {
+	return id.CommonName + "#" + id.SerialNumber
+}
+
+// ExtractIdentity derives Identity from the request's verified peer certificate.
+//
+// Returns error if the request has no TLS connection state or no peer
+// certificate, which should not happen downstream of VerifyMTLS but is
+// checked here as defense-in-depth.
+func ExtractIdentity(r *http.Request) (Identity, error) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return Identity{}, fmt.Errorf("no verified client certificate on request")
+	}
+
+	cert := r.TLS.PeerCertificates[0]
+	return Identity{
+		CommonName:   cert.Subject.CommonName,
+		SerialNumber: cert.SerialNumber.String(),
+	}, nil
+}
@@ -0,0 +1,76 @@
+/*
+In-memory token bucket backend for Security-Switch rate limiting.
+
+Suitable for a single Security-Switch replica, or as the default backend when
+no shared state store is configured. State does not survive a process
+restart and is not shared across replicas; use RedisBackend when running
+more than one Security-Switch instance behind a load balancer.
+*/
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// memoryBucket holds a single token bucket's live state.
+type memoryBucket struct {
+	mu         sync.Mutex
+	tokens     float64
+	lastRefill time.Time
+}
+
+// MemoryBackend implements Backend with per-key buckets held in process memory.
+//
+// Construct with NewMemoryBackend.
+type MemoryBackend struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+}
+
+// NewMemoryBackend returns an empty MemoryBackend.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{buckets: make(map[string]*memoryBucket)}
+}
+
+// Allow consumes one token from the bucket for key, refilling it based on
+// elapsed time since its last access.
+//
+// Returns true if a token was available, or false with the wait duration
+// until the next token would be available.
+func (b *MemoryBackend) Allow(_ context.Context, key string, cfg BucketConfig) (bool, time.Duration, error) {
+	bucket := b.getOrCreate(key, cfg.Capacity)
+
+	bucket.mu.Lock()
+	defer bucket.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(bucket.lastRefill).Seconds()
+	bucket.tokens = math.Min(float64(cfg.Capacity), bucket.tokens+elapsed*cfg.RefillRate)
+	bucket.lastRefill = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0, nil
+	}
+
+	deficit := 1 - bucket.tokens
+	retryAfter := time.Duration(deficit / cfg.RefillRate * float64(time.Second))
+	return false, retryAfter, nil
+}
+
+// getOrCreate returns the bucket for key, creating it pre-filled to capacity
+// if this is the first request seen for key.
+func (b *MemoryBackend) getOrCreate(key string, capacity int) *memoryBucket {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	bucket, ok := b.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{tokens: float64(capacity), lastRefill: time.Now()}
+		b.buckets[key] = bucket
+	}
+	return bucket
+}
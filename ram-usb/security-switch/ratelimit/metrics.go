@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// rateLimitMetrics tracks allow/reject counts for monitoring export.
+//
+// Security features:
+// - RejectedByIdentity retains only the certificate CN/serial identity string, never request bodies or credentials
+//
+// Named to mirror the ratelimit_allowed_total / ratelimit_rejected_total{identity=...}
+// counters an operator would wire into Prometheus; no Prometheus client exists
+// in Security-Switch, so these are exposed as plain in-memory counters via
+// Snapshot rather than pulling in that dependency for this one package.
+// Use the package-level Metrics variable rather than constructing this directly.
+type rateLimitMetrics struct {
+	allowedTotal int64
+
+	mu                 sync.Mutex
+	rejectedByIdentity map[string]int64
+}
+
+// Metrics is the package-level counter instance updated by Limiter.Middleware.
+var Metrics = &rateLimitMetrics{rejectedByIdentity: make(map[string]int64)}
+
+// MetricsSnapshot is a point-in-time read of Metrics' counters.
+type MetricsSnapshot struct {
+	AllowedTotal       int64
+	RejectedByIdentity map[string]int64
+}
+
+// recordAllowed increments the total allowed-request counter.
+func (m *rateLimitMetrics) recordAllowed() {
+	atomic.AddInt64(&m.allowedTotal, 1)
+}
+
+// recordRejected increments the rejection counter for identity.
+func (m *rateLimitMetrics) recordRejected(identity string) {
+	m.mu.Lock()
+	m.rejectedByIdentity[identity]++
+	m.mu.Unlock()
+}
+
+// Snapshot returns the current counter values for monitoring export.
+//
+// Returns a MetricsSnapshot safe to read without racing concurrent requests.
+func (m *rateLimitMetrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rejected := make(map[string]int64, len(m.rejectedByIdentity))
+	for identity, count := range m.rejectedByIdentity {
+		rejected[identity] = count
+	}
+
+	return MetricsSnapshot{
+		AllowedTotal:       atomic.LoadInt64(&m.allowedTotal),
+		RejectedByIdentity: rejected,
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Token-bucket rate limiting for Security-Switch endpoints.
+
+Provides per-client-identity and per-route rate limiting on top of mTLS
+authentication, so a single compromised Entry-Hub certificate cannot exhaust
+Database-Vault or Security-Switch capacity. Identity is derived from the
+verified peer certificate rather than a caller-supplied header, and limiting
+is applied as a middleware wrapper around handlers rather than inline checks,
+matching the VerifyMTLS wrapping pattern already used for mTLS enforcement.
+*/
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"security_switch/utils"
+	"strconv"
+	"time"
+)
+
+// BucketConfig describes a single token bucket's capacity and refill rate.
+type BucketConfig struct {
+	Capacity   int     // maximum tokens held, i.e. the allowed burst size
+	RefillRate float64 // tokens added per second
+}
+
+// Backend stores token bucket state and evaluates requests against it.
+//
+// Implementations must be safe for concurrent use, since the middleware
+// invokes Allow once per inbound request.
+type Backend interface {
+	// Allow attempts to consume one token from the bucket identified by key,
+	// configured with cfg. Returns whether the request is allowed, and if
+	// not, how long the caller should wait before retrying.
+	Allow(ctx context.Context, key string, cfg BucketConfig) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// RouteLimits pairs a global bucket shared by all identities on a route with
+// a per-identity bucket scoped to a single Entry-Hub certificate.
+type RouteLimits struct {
+	PerIdentity BucketConfig
+	Global      BucketConfig
+}
+
+// Limiter enforces RouteLimits for a set of named routes against a Backend.
+//
+// Security features:
+// - Per-identity buckets isolate one compromised or misbehaving certificate from affecting others
+// - Global buckets bound aggregate load on a route regardless of identity diversity
+// - Backend errors fail closed (HTTP 503) rather than silently admitting unlimited traffic
+//
+// Construct with NewLimiter, or NewFromEnv for the repo's default route configuration.
+type Limiter struct {
+	backend Backend
+	routes  map[string]RouteLimits
+}
+
+// NewLimiter builds a Limiter over backend, enforcing routes by name.
+//
+// Returns a ready-to-use Limiter.
+func NewLimiter(backend Backend, routes map[string]RouteLimits) *Limiter {
+	return &Limiter{backend: backend, routes: routes}
+}
+
+// Middleware returns a wrapper that enforces route's configured limits before
+// calling the wrapped handler, extracting identity from the request's
+// verified peer certificate.
+//
+// Security features:
+// - Runs after VerifyMTLS in the handler chain, so identity extraction always sees a verified certificate
+// - Rejections are logged with the offending identity for audit and incident response
+//
+// Routes with no configured RouteLimits pass through unthrottled, so callers
+// must opt every protected endpoint in explicitly.
+func (l *Limiter) Middleware(route string) func(http.HandlerFunc) http.HandlerFunc {
+	limits, limited := l.routes[route]
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if !limited {
+				next(w, r)
+				return
+			}
+
+			// IDENTITY EXTRACTION
+			// Derive the caller's identity from the mTLS peer certificate already verified upstream
+			identity, err := ExtractIdentity(r)
+			if err != nil {
+				log.Printf("Rate limiter: %v from %s", err, r.RemoteAddr)
+				utils.SendErrorResponse(w, http.StatusUnauthorized, "Client certificate required")
+				return
+			}
+
+			ctx := r.Context()
+
+			// GLOBAL BUCKET CHECK
+			// Bound aggregate load on this route across every identity
+			allowed, retryAfter, err := l.backend.Allow(ctx, globalKey(route), limits.Global)
+			if err != nil {
+				log.Printf("Rate limiter backend error on global bucket for route %s: %v", route, err)
+				utils.SendErrorResponse(w, http.StatusServiceUnavailable,
+					"Rate limiting temporarily unavailable. Please try again later.")
+				return
+			}
+			if !allowed {
+				log.Printf("Rate limit exceeded (global) for route %s; rejected identity CN=%s serial=%s",
+					route, identity.CommonName, identity.SerialNumber)
+				Metrics.recordRejected(identity.String())
+				sendRateLimited(w, retryAfter)
+				return
+			}
+
+			// PER-IDENTITY BUCKET CHECK
+			// Isolate this specific Entry-Hub certificate's usage from the rest
+			allowed, retryAfter, err = l.backend.Allow(ctx, identityKey(route, identity), limits.PerIdentity)
+			if err != nil {
+				log.Printf("Rate limiter backend error on identity bucket for route %s: %v", route, err)
+				utils.SendErrorResponse(w, http.StatusServiceUnavailable,
+					"Rate limiting temporarily unavailable. Please try again later.")
+				return
+			}
+			if !allowed {
+				log.Printf("Rate limit exceeded for identity CN=%s serial=%s on route %s",
+					identity.CommonName, identity.SerialNumber, route)
+				Metrics.recordRejected(identity.String())
+				sendRateLimited(w, retryAfter)
+				return
+			}
+
+			Metrics.recordAllowed()
+			next(w, r)
+		}
+	}
+}
+
+// globalKey derives the backend key for a route's shared bucket.
+func globalKey(route string) string {
+	return "ratelimit:global:" + route
+}
+
+// identityKey derives the backend key for a route's per-identity bucket.
+func identityKey(route string, identity Identity) string {
+	return "ratelimit:identity:" + route + ":" + identity.String()
+}
+
+// sendRateLimited sends HTTP 429 with a Retry-After header rounded up to whole seconds.
+func sendRateLimited(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	utils.SendErrorResponse(w, http.StatusTooManyRequests, "Rate limit exceeded. Please try again later.")
+}
@@ -0,0 +1,111 @@
+/*
+Redis-backed token bucket backend for Security-Switch rate limiting.
+
+Lets multiple Security-Switch replicas behind a load balancer share rate
+limit state for the same Entry-Hub identity, instead of each replica
+enforcing its own independent MemoryBackend quota.
+*/
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript atomically refills and consumes a token bucket stored as
+// a Redis hash, so concurrent requests from multiple replicas cannot race
+// past each other into an over-admitted state.
+//
+// KEYS[1]: bucket key
+// ARGV[1]: capacity
+// ARGV[2]: refill rate (tokens per second)
+// ARGV[3]: current time (unix seconds, float)
+// Returns {allowed (0 or 1), retry_after (seconds, string)}.
+const tokenBucketScript = `
+local key = KEYS[1]
+local capacity = tonumber(ARGV[1])
+local refill_rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local data = redis.call("HMGET", key, "tokens", "last_refill")
+local tokens = tonumber(data[1])
+local last_refill = tonumber(data[2])
+if tokens == nil then
+	tokens = capacity
+	last_refill = now
+end
+
+local elapsed = math.max(0, now - last_refill)
+tokens = math.min(capacity, tokens + elapsed * refill_rate)
+
+local allowed = 0
+local retry_after = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry_after = (1 - tokens) / refill_rate
+end
+
+redis.call("HMSET", key, "tokens", tostring(tokens), "last_refill", tostring(now))
+redis.call("EXPIRE", key, math.ceil(capacity / refill_rate) + 1)
+
+return {allowed, tostring(retry_after)}
+`
+
+// RedisBackend implements Backend with bucket state shared via Redis.
+//
+// Security features:
+// - tokenBucketScript runs atomically server-side, so concurrent requests from different replicas cannot both consume the last token
+// - Each bucket key carries a Redis TTL derived from its own refill rate, so idle buckets expire instead of accumulating forever
+//
+// Construct with NewRedisBackend.
+type RedisBackend struct {
+	client *redis.Client
+	script *redis.Script
+}
+
+// NewRedisBackend connects to the Redis instance at addr.
+//
+// Returns a ready-to-use RedisBackend, or error if Redis is unreachable.
+func NewRedisBackend(addr string) (*RedisBackend, error) {
+	client := redis.NewClient(&redis.Options{Addr: addr})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("redis rate limit backend unreachable at %s: %v", addr, err)
+	}
+
+	return &RedisBackend{client: client, script: redis.NewScript(tokenBucketScript)}, nil
+}
+
+// Allow evaluates tokenBucketScript against the bucket for key.
+//
+// Returns true if a token was available, or false with the wait duration
+// until the next token would be available.
+func (b *RedisBackend) Allow(ctx context.Context, key string, cfg BucketConfig) (bool, time.Duration, error) {
+	now := float64(time.Now().UnixNano()) / float64(time.Second)
+
+	result, err := b.script.Run(ctx, b.client, []string{key}, cfg.Capacity, cfg.RefillRate, now).Result()
+	if err != nil {
+		return false, 0, fmt.Errorf("redis token bucket script failed: %v", err)
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return false, 0, fmt.Errorf("redis token bucket script returned unexpected shape")
+	}
+
+	allowed, _ := values[0].(int64)
+	retryAfterSeconds, err := strconv.ParseFloat(fmt.Sprintf("%v", values[1]), 64)
+	if err != nil {
+		return false, 0, fmt.Errorf("redis token bucket script returned invalid retry_after: %v", err)
+	}
+
+	return allowed == 1, time.Duration(retryAfterSeconds * float64(time.Second)), nil
+}
@@ -6,11 +6,11 @@ for secure communication between Entry-Hub and Database-Vault through the
 Security-Switch gateway. Ensures consistent data handling, JSON serialization,
 and validation across the distributed mTLS architecture with standardized
 error handling and success indication.
-
-TO-DO in LoginRequest: not implemented
 */
 package types
 
+import "time"
+
 // RegisterRequest contains validated user registration data for Database-Vault transmission.
 //
 // Security features:
@@ -24,6 +24,7 @@ type RegisterRequest struct {
 	Email     string `json:"email"`          // User email address for account identification
 	Password  string `json:"password"`       // Plain password for secure hashing at Database-Vault
 	SSHPubKey string `json:"ssh_public_key"` // SSH public key for storage service authentication
+	CertMode  bool   `json:"cert_mode"`      // If true, SSHPubKey is signed into a short-lived certificate instead of stored as-is
 }
 
 // Response provides standardized API response format for distributed service communication.
@@ -35,35 +36,95 @@ type RegisterRequest struct {
 //
 // Used for Security-Switch responses to Entry-Hub and Database-Vault responses to Security-Switch.
 type Response struct {
-	Success bool   `json:"success"` // Operation success indicator for client validation
-	Message string `json:"message"` // Human-readable status or error description
+	Success        bool   `json:"success"`                   // Operation success indicator for client validation
+	Message        string `json:"message"`                   // Human-readable status or error description
+	Code           string `json:"code,omitempty"`            // Stable machine-readable error code (see ramusb/errs), empty on success
+	SSHCertificate string `json:"ssh_certificate,omitempty"` // Signed OpenSSH user certificate, present only when the request set cert_mode
+	SessionToken   string `json:"session_token,omitempty"`   // Short-lived signed session JWT, present only on successful login
+	MFARequired    bool   `json:"mfa_required,omitempty"`    // True when the password verified but LoginRequest.MFACode is still needed
 }
 
-// HealthResponse provides comprehensive health check information for monitoring systems.
-//
-// Security features:
-// - Service status indication without sensitive configuration disclosure
-// - Dependencies status for distributed system monitoring
-// - Consistent format for automated monitoring integration
+// HealthCheckResult is one named check within a HealthResponse, following
+// the draft application/health+json convention (draft-inadarei-api-health-check).
+type HealthCheckResult struct {
+	Status    string `json:"status"`              // "pass", "warn", or "fail"
+	LatencyMS int64  `json:"latencyMs,omitempty"` // How long the check took to run, in milliseconds
+	Error     string `json:"error,omitempty"`     // Failure or degradation detail, present only when Status isn't "pass"
+	ExpiresAt string `json:"expiresAt,omitempty"` // RFC3339 expiry timestamp, present only on the cert_expiry check
+}
+
+// HealthResponse is the GET /api/health response body, following the draft
+// application/health+json convention.
 //
-// Extended response format for detailed service health verification and dependency tracking.
+// Checks is omitted entirely for callers that either asked for ?verbose=false
+// or didn't present a verified peer certificate - see handlers.HealthHandler.
 type HealthResponse struct {
-	Success      bool              `json:"success"`                // Service availability indicator
-	Message      string            `json:"message"`                // Human-readable status description
-	Service      string            `json:"service"`                // Service name identifier
-	Status       string            `json:"status"`                 // Detailed service status
-	Dependencies map[string]string `json:"dependencies,omitempty"` // Dependent service status map
+	Status string                       `json:"status"` // Worst status across Checks: "pass", "warn", or "fail"
+	Checks map[string]HealthCheckResult `json:"checks,omitempty"`
 }
 
-// LoginRequest defines user authentication data structure for future implementation.
+// LoginRequest contains user authentication data for Database-Vault transmission.
 //
 // Security features:
 // - Email-based account lookup for user identification
 // - Password field for Argon2id verification at Database-Vault
 // - Structured format for consistent authentication processing
 //
-// Reserved for future login functionality implementation.
-// type LoginRequest struct {
-// 	Email    string `json:"email"`    // User email for account lookup
-//	Password string `json:"password"` // Password for Argon2id verification
-// }
+// Serialized as JSON for mTLS communication with Database-Vault service.
+type LoginRequest struct {
+	Email    string `json:"email"`              // User email for account lookup
+	Password string `json:"password"`           // Password for Argon2id verification
+	MFACode  string `json:"mfa_code,omitempty"` // RFC 6238 TOTP code, or a scratch code; required once the account has MFA enabled
+}
+
+// MFAEnrollRequest begins TOTP enrollment for an existing account, for
+// Database-Vault transmission.
+//
+// Serialized as JSON for mTLS communication with Database-Vault service.
+type MFAEnrollRequest struct {
+	Email string `json:"email"` // Account email; resolved to its blind index for lookup at Database-Vault
+}
+
+// MFAEnrollResponse carries a freshly generated TOTP secret back from
+// Database-Vault for display to the user.
+//
+// ProvisioningURI is an otpauth:// URI suitable for a client to render as a
+// QR code for the user's authenticator app; Secret is the same seed,
+// base32-encoded, for manual entry when scanning isn't possible.
+type MFAEnrollResponse struct {
+	Success         bool   `json:"success"`
+	Secret          string `json:"secret,omitempty"`
+	ProvisioningURI string `json:"provisioning_uri,omitempty"`
+}
+
+// MFAConfirmRequest verifies the first TOTP code generated from an
+// in-progress enrollment, for Database-Vault transmission.
+type MFAConfirmRequest struct {
+	Email string `json:"email"` // Account email; resolved to its blind index for lookup at Database-Vault
+	Code  string `json:"code"`  // First TOTP code generated from the enrolled secret
+}
+
+// MFAConfirmResponse carries the one-time set of scratch codes Database-Vault
+// issues when MFA enrollment completes.
+type MFAConfirmResponse struct {
+	Success      bool     `json:"success"`
+	ScratchCodes []string `json:"scratch_codes,omitempty"`
+}
+
+// CertIssueRequest requests a Storage-Service mTLS client certificate for an
+// existing account, for Database-Vault transmission. CSR is PEM-encoded
+// PKCS#10, built by the client over a private key that never leaves its own
+// machine.
+type CertIssueRequest struct {
+	Email string `json:"email"`
+	CSR   string `json:"csr_pem"`
+}
+
+// CertIssueResponse carries a freshly issued client certificate back from
+// Database-Vault.
+type CertIssueResponse struct {
+	Success     bool      `json:"success"`
+	Certificate string    `json:"certificate_pem,omitempty"`
+	Serial      string    `json:"serial,omitempty"`
+	NotAfter    time.Time `json:"not_after,omitempty"`
+}
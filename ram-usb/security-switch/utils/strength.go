@@ -0,0 +1,402 @@
+/*
+zxcvbn-style password strength estimation.
+
+Unlike a character-class count, this estimates how many guesses a cracking
+tool would need by finding the cheapest way to explain the whole password as
+a sequence of known patterns - dictionary words (with common leet
+substitutions), keyboard/alphabetic sequences, character repeats, and dates -
+falling back to brute force for any part that matches nothing. A password
+like "P@ssw0rd2024" scores low despite satisfying a 3-of-4 character-class
+rule, because it decomposes into one leet-spelled dictionary word plus a year;
+a long random passphrase scores high because no cheap decomposition exists.
+
+wordlist.txt is a curated list of breach-common passwords and names, not the
+full ~10k-entry corpus a production zxcvbn dictionary would carry; entries
+absent from it simply fall back to brute-force scoring instead of being
+recognized as common, so this estimator under-penalizes common passwords the
+list doesn't yet cover.
+*/
+package utils
+
+import (
+	"bufio"
+	"embed"
+	"math"
+	"regexp"
+	"strings"
+)
+
+//go:embed wordlist.txt
+var wordlistFS embed.FS
+
+// dictionaryRanks maps each known word (lowercase) to its popularity rank
+// (1 = most common, hence cheapest to guess).
+var dictionaryRanks = loadDictionaryRanks()
+
+func loadDictionaryRanks() map[string]int {
+	ranks := make(map[string]int)
+
+	f, err := wordlistFS.Open("wordlist.txt")
+	if err != nil {
+		return ranks // Empty dictionary degrades gracefully to brute-force-only estimates
+	}
+	defer f.Close()
+
+	rank := 1
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if word == "" {
+			continue
+		}
+		ranks[word] = rank
+		rank++
+	}
+	return ranks
+}
+
+// leetSubstitutions maps common leet-speak characters to the letter they
+// stand in for, so "p4ssw0rd" is still recognized as "password".
+var leetSubstitutions = map[rune]rune{
+	'4': 'a', '@': 'a',
+	'3': 'e',
+	'1': 'i', '!': 'i',
+	'0': 'o',
+	'5': 's', '$': 's',
+	'7': 't',
+}
+
+// deleet returns password with leet substitutions reversed to their plain
+// letter, for dictionary lookups against leet-spelled words.
+func deleet(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if plain, ok := leetSubstitutions[r]; ok {
+			b.WriteRune(plain)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// keyboardSequences are adjacent-key runs on a standard QWERTY layout; a
+// substring matching one of these (or its reverse) is as guessable as any
+// other short sequence, not a true random string.
+var keyboardSequences = []string{
+	"qwertyuiop", "asdfghjkl", "zxcvbnm",
+	"1234567890",
+}
+
+// matchedToken describes one segment of the password explained by a single
+// pattern, with its estimated guess count.
+type matchedToken struct {
+	start, end int // [start, end) byte range within the password
+	pattern    string
+	guesses    float64
+}
+
+// PasswordStrength is the result of EstimatePasswordStrength.
+type PasswordStrength struct {
+	Score    int     // 0 (trivial) to 4 (very strong)
+	Guesses  float64 // Estimated total guesses needed
+	Sequence []string
+}
+
+// PasswordStrengthPolicy gates password acceptance on a minimum
+// EstimatePasswordStrength score.
+type PasswordStrengthPolicy struct {
+	MinScore int
+}
+
+// DefaultPasswordStrengthPolicy requires at least a "good" (3/4) estimated
+// strength score, the threshold HasPasswordComplexity enforces.
+var DefaultPasswordStrengthPolicy = PasswordStrengthPolicy{MinScore: 3}
+
+// Meets reports whether password (with optional context like the account's
+// email local-part in userInputs, penalized as a dictionary entry) clears
+// the policy's MinScore.
+func (p PasswordStrengthPolicy) Meets(password string, userInputs []string) bool {
+	return EstimatePasswordStrength(password, userInputs).Score >= p.MinScore
+}
+
+// HasPasswordComplexity estimates password's cracking resistance via
+// EstimatePasswordStrength and requires DefaultPasswordStrengthPolicy's
+// minimum score, in place of the old 3-of-4 character-class rule that let
+// through predictable passwords like "Password1!" while rejecting strong
+// passphrases. userInputs, if given, are penalized as dictionary entries -
+// pass the account's email local-part so "firstname.lastname2024" scores low.
+func HasPasswordComplexity(password string, userInputs ...string) bool {
+	return DefaultPasswordStrengthPolicy.Meets(password, userInputs)
+}
+
+// EstimatePasswordStrength finds the cheapest way to explain password as a
+// sequence of dictionary, sequence, repeat, date, and brute-force segments,
+// and maps the resulting total guess count to a 0-4 score.
+func EstimatePasswordStrength(password string, userInputs []string) PasswordStrength {
+	if password == "" {
+		return PasswordStrength{Score: 0, Guesses: 0}
+	}
+
+	matches := collectMatches(password, userInputs)
+
+	n := len(password)
+	bestGuesses := make([]float64, n+1)
+	bestToken := make([]*matchedToken, n+1)
+	bestGuesses[0] = 1
+
+	// candidatesEndingAt[i] holds every match ending at byte offset i.
+	candidatesEndingAt := make([][]*matchedToken, n+1)
+	for idx := range matches {
+		m := matches[idx]
+		candidatesEndingAt[m.end] = append(candidatesEndingAt[m.end], m)
+	}
+
+	for i := 1; i <= n; i++ {
+		// Brute-force fallback: extend the best segmentation ending at i-1 by
+		// one un-matched character.
+		bestGuesses[i] = bestGuesses[i-1] * bruteForceCharsetSize(password)
+		bestToken[i] = &matchedToken{start: i - 1, end: i, pattern: "bruteforce", guesses: bruteForceCharsetSize(password)}
+
+		for _, m := range candidatesEndingAt[i] {
+			candidate := bestGuesses[m.start] * m.guesses
+			if candidate < bestGuesses[i] {
+				bestGuesses[i] = candidate
+				bestToken[i] = m
+			}
+		}
+	}
+
+	// Walk the chosen segmentation back to front for the feedback sequence.
+	var sequence []string
+	for i := n; i > 0; {
+		tok := bestToken[i]
+		sequence = append([]string{password[tok.start:tok.end] + ":" + tok.pattern}, sequence...)
+		i = tok.start
+	}
+
+	guesses := bestGuesses[n]
+	return PasswordStrength{
+		Score:    guessesToScore(guesses),
+		Guesses:  guesses,
+		Sequence: sequence,
+	}
+}
+
+// guessesToScore maps a total guess count to zxcvbn's standard 0-4 score via
+// order-of-magnitude thresholds (10^3, 10^5, 10^8, 10^10 guesses).
+func guessesToScore(guesses float64) int {
+	if guesses < 1 {
+		guesses = 1
+	}
+	logGuesses := math.Log10(guesses)
+	switch {
+	case logGuesses < 3:
+		return 0
+	case logGuesses < 5:
+		return 1
+	case logGuesses < 8:
+		return 2
+	case logGuesses < 10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// bruteForceCharsetSize estimates the per-character guess multiplier for
+// segments that match no known pattern, sized to the character classes
+// actually present in password (lower/upper/digit/symbol).
+func bruteForceCharsetSize(password string) float64 {
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case 'a' <= r && r <= 'z':
+			hasLower = true
+		case 'A' <= r && r <= 'Z':
+			hasUpper = true
+		case '0' <= r && r <= '9':
+			hasDigit = true
+		default:
+			hasSymbol = true
+		}
+	}
+	size := 0.0
+	if hasLower {
+		size += 26
+	}
+	if hasUpper {
+		size += 26
+	}
+	if hasDigit {
+		size += 10
+	}
+	if hasSymbol {
+		size += 33
+	}
+	if size == 0 {
+		size = 10
+	}
+	return size
+}
+
+// collectMatches runs every matcher over password and returns every match
+// found, for the DP in EstimatePasswordStrength to choose between.
+func collectMatches(password string, userInputs []string) []*matchedToken {
+	var matches []*matchedToken
+	matches = append(matches, dictionaryMatches(password, userInputs)...)
+	matches = append(matches, sequenceMatches(password)...)
+	matches = append(matches, repeatMatches(password)...)
+	matches = append(matches, dateMatches(password)...)
+	return matches
+}
+
+// dictionaryMatches finds every substring of password that is a known word
+// (plain or leet-spelled) or one of userInputs, case-insensitively.
+func dictionaryMatches(password string, userInputs []string) []*matchedToken {
+	lower := strings.ToLower(password)
+	plain := deleet(lower)
+
+	userRanks := make(map[string]int, len(userInputs))
+	for i, input := range userInputs {
+		input = strings.ToLower(strings.TrimSpace(input))
+		if input != "" {
+			userRanks[input] = i + 1
+		}
+	}
+
+	var matches []*matchedToken
+	n := len(password)
+	for start := 0; start < n; start++ {
+		for end := start + 3; end <= n; end++ { // 3+ chars, to avoid matching every single letter
+			candidate := plain[start:end]
+			leetUsed := candidate != lower[start:end]
+
+			rank, known := dictionaryRanks[candidate]
+			if !known {
+				rank, known = userRanks[lower[start:end]]
+			}
+			if !known {
+				continue
+			}
+
+			guesses := float64(rank)
+			if leetUsed {
+				guesses *= 2 // Modest penalty: leet substitution is a well-known trick, not real extra entropy
+			}
+			if hasUpper(password[start:end]) {
+				guesses *= 2
+			}
+
+			matches = append(matches, &matchedToken{start: start, end: end, pattern: "dictionary", guesses: guesses})
+		}
+	}
+	return matches
+}
+
+func hasUpper(s string) bool {
+	for _, r := range s {
+		if 'A' <= r && r <= 'Z' {
+			return true
+		}
+	}
+	return false
+}
+
+// sequenceMatches finds runs of 3+ consecutive ascending/descending
+// characters (e.g. "abcd", "4321") and keyboard-row runs (e.g. "qwerty").
+func sequenceMatches(password string) []*matchedToken {
+	var matches []*matchedToken
+	n := len(password)
+
+	// Ascending/descending character runs.
+	start := 0
+	for i := 1; i <= n; i++ {
+		broken := i == n
+		if !broken {
+			delta := int(password[i]) - int(password[i-1])
+			broken = delta != 1 && delta != -1
+		}
+		if broken {
+			if i-start >= 3 {
+				matches = append(matches, &matchedToken{
+					start: start, end: i, pattern: "sequence",
+					guesses: float64(len(keyboardSequences) + 26), // Small fixed space of well-known sequences
+				})
+			}
+			start = i
+		}
+	}
+
+	// Keyboard-row runs (forward and reversed).
+	lower := strings.ToLower(password)
+	for _, row := range keyboardSequences {
+		for _, candidate := range []string{row, reverseString(row)} {
+			for start := 0; start+3 <= len(candidate); start++ {
+				for length := len(candidate) - start; length >= 3; length-- {
+					sub := candidate[start : start+length]
+					if idx := strings.Index(lower, sub); idx != -1 {
+						matches = append(matches, &matchedToken{
+							start: idx, end: idx + length, pattern: "sequence",
+							guesses: float64(len(keyboardSequences) * 2),
+						})
+					}
+				}
+			}
+		}
+	}
+
+	return matches
+}
+
+func reverseString(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+// repeatMatches finds substrings that are a short base unit repeated two or
+// more times in a row (e.g. "aaaa", "abcabc").
+func repeatMatches(password string) []*matchedToken {
+	var matches []*matchedToken
+	n := len(password)
+
+	for baseLen := 1; baseLen <= n/2; baseLen++ {
+		for start := 0; start+baseLen*2 <= n; start++ {
+			base := password[start : start+baseLen]
+			repeats := 1
+			for pos := start + baseLen; pos+baseLen <= n && password[pos:pos+baseLen] == base; pos += baseLen {
+				repeats++
+			}
+			if repeats < 2 {
+				continue
+			}
+			end := start + baseLen*repeats
+			totalLen := end - start
+			matches = append(matches, &matchedToken{
+				start: start, end: end, pattern: "repeat",
+				guesses: float64(baseLen * (totalLen - baseLen + 1)),
+			})
+		}
+	}
+	return matches
+}
+
+// datePattern matches common date shells: 4-digit years, and MM/DD/YY(YY)
+// style dates with '/', '-', or '.' separators.
+var datePattern = regexp.MustCompile(`(19|20)\d{2}|\d{1,2}[/\-.]\d{1,2}[/\-.]\d{2,4}`)
+
+// dateMatches finds substrings that look like a year or a full date, which
+// crackers try early given how often people use a birthday or anniversary.
+func dateMatches(password string) []*matchedToken {
+	var matches []*matchedToken
+	for _, loc := range datePattern.FindAllStringIndex(password, -1) {
+		matches = append(matches, &matchedToken{
+			start: loc[0], end: loc[1], pattern: "date",
+			guesses: 365 * 100, // ~100 years of days, a generous but small fixed space
+		})
+	}
+	return matches
+}
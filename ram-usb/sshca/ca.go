@@ -0,0 +1,103 @@
+/*
+OpenSSH user certificate authority for Storage-Service access.
+
+Signs short-lived ssh.Certificate credentials over a client-supplied public
+key, rather than trusting a long-lived raw public key uploaded once at
+registration. A compromised certificate expires on its own; a compromised raw
+key does not.
+*/
+package sshca
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// defaultExtensions grants an interactive PTY and nothing else, matching the
+// minimum OpenSSH server feature set Storage-Service sessions require.
+var defaultExtensions = map[string]string{"permit-pty": ""}
+
+// clockSkewAllowance backdates ValidAfter so a certificate issued seconds ago
+// is not rejected by a Storage-Service host whose clock runs slightly behind.
+const clockSkewAllowance = 1 * time.Minute
+
+// CA signs OpenSSH user certificates for Storage-Service authentication.
+//
+// Security features:
+// - Certificates are valid for TTL only, bounding the blast radius of a leaked credential
+// - ValidPrincipals pins the certificate to a single user identity
+// - Extensions default to permit-pty, denying port forwarding and agent forwarding unless explicitly granted
+//
+// Construct with NewCA (signing key on disk) or NewCAFromSigner (signing key
+// already resolved by a keyprovider-style backend).
+type CA struct {
+	signer     ssh.Signer
+	ttl        time.Duration
+	extensions map[string]string
+}
+
+// NewCA loads a PEM-encoded SSH CA private key from signingKeyFile and
+// returns a CA issuing certificates valid for ttl with the default
+// extensions (permit-pty only).
+//
+// Returns error if the key file cannot be read or parsed.
+func NewCA(signingKeyFile string, ttl time.Duration) (*CA, error) {
+	keyBytes, err := os.ReadFile(signingKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSH CA signing key: %v", err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH CA signing key: %v", err)
+	}
+
+	return NewCAFromSigner(signer, ttl), nil
+}
+
+// NewCAFromSigner wraps an already-resolved ssh.Signer, e.g. one Database-Vault's
+// keyprovider obtained from Vault or a KMS rather than reading raw key
+// material from local disk.
+func NewCAFromSigner(signer ssh.Signer, ttl time.Duration) *CA {
+	return &CA{signer: signer, ttl: ttl, extensions: defaultExtensions}
+}
+
+// WithExtensions returns a copy of c that grants extensions instead of the
+// default permit-pty-only set on every certificate it signs.
+func (c *CA) WithExtensions(extensions map[string]string) *CA {
+	return &CA{signer: c.signer, ttl: c.ttl, extensions: extensions}
+}
+
+// SignUserKey parses authorizedKey - the "ssh-ed25519 AAAA... comment" line a
+// client uploads in place of a long-lived credential - and returns a
+// short-lived ssh.Certificate over that key, valid for principal and the
+// CA's configured TTL, marshaled back into authorized-key format.
+//
+// Returns error if authorizedKey cannot be parsed or signing fails.
+func (c *CA) SignUserKey(authorizedKey []byte, principal string) ([]byte, error) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(authorizedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH public key: %v", err)
+	}
+
+	now := time.Now()
+	cert := &ssh.Certificate{
+		Key:             pub,
+		CertType:        ssh.UserCert,
+		KeyId:           principal,
+		ValidPrincipals: []string{principal},
+		ValidAfter:      uint64(now.Add(-clockSkewAllowance).Unix()),
+		ValidBefore:     uint64(now.Add(c.ttl).Unix()),
+		Permissions:     ssh.Permissions{Extensions: c.extensions},
+	}
+
+	if err := cert.SignCert(rand.Reader, c.signer); err != nil {
+		return nil, fmt.Errorf("failed to sign SSH certificate: %v", err)
+	}
+
+	return ssh.MarshalAuthorizedKey(cert), nil
+}
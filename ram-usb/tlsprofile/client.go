@@ -0,0 +1,74 @@
+package tlsprofile
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// devEnvVar, when set to "dev", is the only condition under which a Disabled
+// profile is allowed to build. Checked at connection-build time rather than
+// baked into Mode, since the same binary/config can run in multiple environments.
+const devEnvVar = "RAMUSB_ENV"
+
+// ClientConfig builds the *tls.Config an outbound connection should dial
+// with. Returns (nil, nil) for Disabled mode, signaling the caller to dial
+// plain HTTP instead of HTTPS.
+//
+// Security features:
+// - Disabled mode is refused outside RAMUSB_ENV=dev, so a misconfigured production deploy cannot silently fall back to plaintext
+// - TLS mode still validates the peer's certificate against CACertFile, even though no client certificate is presented
+func (p Profile) ClientConfig() (*tls.Config, error) {
+	if p.Mode == Disabled {
+		if os.Getenv(devEnvVar) != "dev" {
+			return nil, fmt.Errorf("tls profile: mode \"disabled\" requires %s=dev", devEnvVar)
+		}
+		return nil, nil
+	}
+
+	cfg := &tls.Config{
+		ServerName:   p.ServerName,
+		MinVersion:   resolveMinVersion(p.MinVersion),
+		CipherSuites: p.CipherSuites,
+	}
+
+	if p.CACertFile != "" {
+		pool, err := loadCertPool(p.CACertFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+
+	if p.Mode == MutualTLS {
+		cert, err := tls.LoadX509KeyPair(p.CertFile, p.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %v", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// loadCertPool reads and parses a PEM-encoded CA certificate file.
+func loadCertPool(caCertFile string) (*x509.CertPool, error) {
+	caCert, err := os.ReadFile(caCertFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("failed to parse CA certificate")
+	}
+	return pool, nil
+}
+
+// resolveMinVersion returns v, or tls.VersionTLS13 if v is unset.
+func resolveMinVersion(v uint16) uint16 {
+	if v == 0 {
+		return tls.VersionTLS13
+	}
+	return v
+}
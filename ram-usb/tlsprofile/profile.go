@@ -0,0 +1,74 @@
+/*
+Shared TLS profile for R.A.M.-U.S.B. distributed services.
+
+Each service-to-service hop (Entry-Hub to Security-Switch, Security-Switch to
+Database-Vault) previously always required a client cert, key, and CA file.
+Profile lets that requirement be relaxed per hop, for contributor onboarding
+against local dev dependencies (e.g. a plain Postgres container) without
+touching the MutualTLS defaults production deploys rely on.
+*/
+package tlsprofile
+
+import "fmt"
+
+// Mode selects how strictly a hop authenticates its peer.
+type Mode int
+
+const (
+	// MutualTLS requires both sides to present a certificate, as every R.A.M.-U.S.B.
+	// service-to-service hop has always done. Production default.
+	MutualTLS Mode = iota
+	// TLS verifies only the server's certificate against CACertFile; no client
+	// certificate is presented. Useful against dev dependencies (local Postgres, etc.)
+	// that serve plain TLS without requiring mTLS.
+	TLS
+	// Disabled allows a plain, unencrypted connection. Only ever permitted when
+	// RAMUSB_ENV=dev; ServerConfig/ClientConfig refuse to build a Disabled
+	// connection otherwise.
+	Disabled
+)
+
+// String returns the tls_mode config value Mode was parsed from.
+func (m Mode) String() string {
+	switch m {
+	case MutualTLS:
+		return "mutual_tls"
+	case TLS:
+		return "tls"
+	case Disabled:
+		return "disabled"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseMode parses a tls_mode config value. Empty defaults to MutualTLS, so
+// existing deployments that don't set tls_mode keep today's behavior.
+//
+// Returns error if s names no known mode.
+func ParseMode(s string) (Mode, error) {
+	switch s {
+	case "", "mutual_tls":
+		return MutualTLS, nil
+	case "tls":
+		return TLS, nil
+	case "disabled":
+		return Disabled, nil
+	default:
+		return 0, fmt.Errorf("unknown tls_mode %q, expected \"mutual_tls\", \"tls\", or \"disabled\"", s)
+	}
+}
+
+// Profile holds everything one side of a hop needs to build its *tls.Config,
+// for whichever Mode it resolves to.
+type Profile struct {
+	Mode Mode
+
+	CertFile   string // This side's own certificate; required for MutualTLS, ignored otherwise
+	KeyFile    string
+	CACertFile string // Peer verification CA; required for TLS and MutualTLS
+
+	ServerName   string   // Expected peer certificate CN; client side only
+	MinVersion   uint16   // Defaults to tls.VersionTLS13 when zero
+	CipherSuites []uint16 // Optional; nil lets the Go stdlib choose
+}
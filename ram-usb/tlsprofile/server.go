@@ -0,0 +1,62 @@
+package tlsprofile
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+)
+
+// ServerConfig builds the *tls.Config a listener should serve with. Returns
+// (nil, nil) for Disabled mode, signaling the caller to listen with plain
+// HTTP instead of HTTPS.
+//
+// Security features:
+// - Disabled mode is refused outside RAMUSB_ENV=dev, so a misconfigured production deploy cannot silently fall back to plaintext
+// - MutualTLS mode requires and verifies a client certificate, preserving every existing server's current behavior
+func (p Profile) ServerConfig() (*tls.Config, error) {
+	if p.Mode == Disabled {
+		if os.Getenv(devEnvVar) != "dev" {
+			return nil, fmt.Errorf("tls profile: mode \"disabled\" requires %s=dev", devEnvVar)
+		}
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(p.CertFile, p.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %v", err)
+	}
+
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   resolveMinVersion(p.MinVersion),
+		CipherSuites: p.CipherSuites,
+	}
+
+	if p.Mode == MutualTLS {
+		pool, err := loadCertPool(p.CACertFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	} else {
+		cfg.ClientAuth = tls.NoClientCert
+	}
+
+	return cfg, nil
+}
+
+// WarnInsecure wraps next with a loud per-request log line when p.Mode is
+// Disabled, so a dev-only plaintext listener stays visible in the logs
+// instead of blending in with every other request.
+func (p Profile) WarnInsecure(next http.HandlerFunc) http.HandlerFunc {
+	if p.Mode != Disabled {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("WARNING: TLS disabled (%s=dev) - serving %s %s over plain HTTP", devEnvVar, r.Method, r.URL.Path)
+		next(w, r)
+	}
+}
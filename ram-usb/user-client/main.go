@@ -21,6 +21,15 @@ type Data struct {
 	Email     string `json:"email"`
 	Password  string `json:"password"`
 	SSHPubKey string `json:"ssh_public_key"`
+	CertMode  bool   `json:"cert_mode"` // If true, the server signs SSHPubKey into a short-lived certificate instead of storing it as-is
+}
+
+// RegisterResponse mirrors the server's JSON response, including the signed
+// SSH certificate returned when registration was made with cert_mode set.
+type RegisterResponse struct {
+	Success        bool   `json:"success"`
+	Message        string `json:"message"`
+	SSHCertificate string `json:"ssh_certificate,omitempty"`
 }
 
 // readSSHPublicKey reads and validates the SSH public key file
@@ -42,6 +51,20 @@ func readSSHPublicKey(sshPubKeyPath string) (string, error) {
 	return sshPubKey, nil
 }
 
+// writeSSHCertificate saves a signed OpenSSH user certificate alongside the
+// private key it was issued for, following OpenSSH's own "-cert.pub" naming
+// convention so `ssh -i` picks it up automatically.
+func writeSSHCertificate(sshPubKeyPath, certificate string) error {
+	certPath := strings.TrimSuffix(sshPubKeyPath, ".pub") + "-cert.pub"
+
+	if err := os.WriteFile(certPath, []byte(certificate), 0600); err != nil {
+		return fmt.Errorf("failed to write SSH certificate to %s: %v", certPath, err)
+	}
+
+	fmt.Printf("Signed SSH certificate saved to %s\n", certPath)
+	return nil
+}
+
 // createMTLSClient creates an HTTP client configured for mutual TLS
 func createMTLSClient(certPath, keyPath, caPath string) (*http.Client, error) {
 	// Load client certificate and key for mTLS
@@ -93,6 +116,7 @@ func main() {
 	tlsKeyPath := os.Getenv("TLS_KEY_PATH")
 	caCertPath := os.Getenv("CA_CERT_PATH")
 	sshPubKeyPath := os.Getenv("SSH_PUBLIC_KEY_PATH")
+	certMode := os.Getenv("SSH_CERT_MODE") == "true" // Opt-in: request a signed certificate instead of raw key storage
 
 	// Validate environment variables
 	if serverInterfaceIp == "" {
@@ -131,10 +155,17 @@ func main() {
 	password := "password123"
 
 	// Register user with SSH public key using mTLS client
-	registerUser(email, password, sshPublicKey, serverInterfaceIp, client)
+	response := registerUser(email, password, sshPublicKey, certMode, serverInterfaceIp, client)
+
+	// Save the signed certificate alongside the private key, if the server issued one
+	if response.SSHCertificate != "" {
+		if err := writeSSHCertificate(sshPubKeyPath, response.SSHCertificate); err != nil {
+			log.Fatalf("Error saving SSH certificate: %v", err)
+		}
+	}
 }
 
-func registerUser(email string, password string, sshPublicKey string, interfaceIp string, client *http.Client) {
+func registerUser(email string, password string, sshPublicKey string, certMode bool, interfaceIp string, client *http.Client) RegisterResponse {
 	url := fmt.Sprintf("https://%s:8443/api/register", interfaceIp)
 
 	// Prepare the Data struct
@@ -142,6 +173,7 @@ func registerUser(email string, password string, sshPublicKey string, interfaceI
 		Email:     email,
 		Password:  password,
 		SSHPubKey: sshPublicKey,
+		CertMode:  certMode,
 	}
 
 	requestBody, err := json.Marshal(data)
@@ -186,4 +218,11 @@ func registerUser(email string, password string, sshPublicKey string, interfaceI
 	fmt.Println(string(responseBody))
 
 	fmt.Printf("Successfully attempted registration for user '%s'. Check server response for details.\n", email)
+
+	var response RegisterResponse
+	if err := json.Unmarshal(responseBody, &response); err != nil {
+		log.Fatalf("Error parsing registration response: %v", err)
+	}
+
+	return response
 }
@@ -0,0 +1,119 @@
+package workloadid
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/fullsailor/pkcs7"
+)
+
+// AWSConfig scopes which AWS accounts and regions AWSVerifier accepts.
+type AWSConfig struct {
+	SigningCertPEM    []byte   // AWS's RSA public certificate for the Instance Identity Document signature (region-specific)
+	AllowedAccountIDs []string // AWS account IDs permitted to register
+	AllowedRegions    []string // EC2 regions permitted to register
+}
+
+// awsCredential is the JSON envelope a client submits: EC2's metadata
+// service returns the identity document and its PKCS#7 signature as two
+// separate HTTP responses, so the client bundles them together here.
+type awsCredential struct {
+	Document  string `json:"document"`  // Raw instance identity document (GET 169.254.169.254/.../document)
+	Signature string `json:"signature"` // Base64-encoded PKCS#7 signature (GET 169.254.169.254/.../pkcs7)
+}
+
+// awsInstanceIdentityDocument is the subset of EC2's identity document this verifier relies on.
+type awsInstanceIdentityDocument struct {
+	InstanceID string `json:"instanceId"`
+	AccountID  string `json:"accountId"`
+	Region     string `json:"region"`
+}
+
+// AWSVerifier verifies an EC2 Instance Identity Document's PKCS#7 signature
+// against AWS's published RSA public certificate.
+//
+// Security features:
+// - Signature verified against AWS's own certificate, never a value supplied by the caller
+// - AccountID and Region are matched against an explicit allow-list, so possessing a genuinely AWS-signed document is not by itself sufficient
+//
+// Construct with NewAWSVerifier.
+type AWSVerifier struct {
+	cfg  AWSConfig
+	cert *x509.Certificate
+}
+
+// NewAWSVerifier parses cfg.SigningCertPEM and returns an AWSVerifier scoped by cfg.
+//
+// Returns error if the certificate cannot be parsed.
+func NewAWSVerifier(cfg AWSConfig) (*AWSVerifier, error) {
+	block, _ := pem.Decode(cfg.SigningCertPEM)
+	if block == nil {
+		return nil, fmt.Errorf("invalid AWS signing certificate: not PEM-encoded")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse AWS signing certificate: %v", err)
+	}
+
+	return &AWSVerifier{cfg: cfg, cert: cert}, nil
+}
+
+// Verify parses credential as a JSON-encoded awsCredential, validates its
+// PKCS#7 signature against AWS's public certificate, then checks the
+// identity document's account and region against the configured allow-lists.
+//
+// Returns error if the envelope cannot be parsed, the signature does not
+// verify, or the document names an account or region outside the allow-list.
+func (v *AWSVerifier) Verify(ctx context.Context, credential string) (*Identity, error) {
+	var envelope awsCredential
+	if err := json.Unmarshal([]byte(credential), &envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse AWS credential envelope: %v", err)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(envelope.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 signature encoding: %v", err)
+	}
+
+	p7, err := pkcs7.Parse(wrapPKCS7(signature, envelope.Document))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PKCS#7 signature: %v", err)
+	}
+	p7.Certificates = []*x509.Certificate{v.cert}
+	if err := p7.Verify(); err != nil {
+		return nil, fmt.Errorf("PKCS#7 signature verification failed: %v", err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(p7.Content), bytes.TrimSpace([]byte(envelope.Document))) {
+		return nil, fmt.Errorf("instance identity document does not match the signed PKCS#7 content")
+	}
+
+	var doc awsInstanceIdentityDocument
+	if err := json.Unmarshal([]byte(envelope.Document), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse instance identity document: %v", err)
+	}
+
+	if !containsFold(v.cfg.AllowedAccountIDs, doc.AccountID) {
+		return nil, fmt.Errorf("account %q is not in the allowed accounts list", doc.AccountID)
+	}
+	if !containsFold(v.cfg.AllowedRegions, doc.Region) {
+		return nil, fmt.Errorf("region %q is not in the allowed regions list", doc.Region)
+	}
+
+	return &Identity{Principal: doc.InstanceID, Tenant: doc.AccountID}, nil
+}
+
+// wrapPKCS7 returns signature unchanged for pkcs7.Parse; document is not
+// used, since the signature already carries it as embedded content.
+func wrapPKCS7(signature []byte, document string) []byte {
+	// EC2's "pkcs7" endpoint already returns a full SignedData structure
+	// (base64-encoded, no PEM armor) with the document embedded as its
+	// ContentInfo payload, so no reassembly is needed beyond decoding.
+	_ = document
+	return signature
+}
@@ -0,0 +1,215 @@
+package workloadid
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// azureResourceIDPattern extracts the subscription, resource group, and
+// resource name from an Azure Managed Identity access token's xms_mirid
+// claim, e.g.
+// "/subscriptions/.../resourceGroups/.../providers/Microsoft.Compute/virtualMachines/...".
+var azureResourceIDPattern = regexp.MustCompile(`(?i)^/subscriptions/([^/]+)/resourceGroups/([^/]+)/providers/Microsoft\.(Compute/virtualMachines|ManagedIdentity/userAssignedIdentities)/([^/]+)$`)
+
+// azureJWKSRefreshInterval bounds how often AzureVerifier refetches the
+// tenant's signing keys, so a stolen or rotated key cannot be relied upon
+// indefinitely, without refetching on every single request.
+const azureJWKSRefreshInterval = 1 * time.Hour
+
+// azureClaims holds the Azure AD v2.0 token claims this verifier relies on.
+type azureClaims struct {
+	jwt.RegisteredClaims
+	ObjectID string `json:"oid"`
+	MIRID    string `json:"xms_mirid"`
+}
+
+// AzureConfig scopes which Azure tenant, subscriptions, and resource groups
+// AzureVerifier accepts, so an arbitrary Azure tenant cannot self-register.
+type AzureConfig struct {
+	TenantID              string           // Azure AD tenant the token must be issued by
+	Audience              string           // Expected "aud" claim
+	AllowedSubscriptions  []string         // Subscription IDs permitted to register
+	AllowedResourceGroups []*regexp.Regexp // Resource group name patterns permitted to register
+}
+
+// AzureVerifier verifies Azure Managed Identity access tokens issued to VMs
+// or user-assigned managed identities, following the Azure provisioner
+// pattern of parsing xms_mirid out of the token.
+//
+// Security features:
+// - JWKS fetched from login.microsoftonline.com and cached for azureJWKSRefreshInterval, never a hardcoded key
+// - Signature, issuer, and audience validated before any claim is trusted
+// - xms_mirid's subscription and resource group are matched against an explicit allow-list, so possessing a validly signed token from the tenant is not by itself sufficient
+//
+// Construct with NewAzureVerifier.
+type AzureVerifier struct {
+	cfg        AzureConfig
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	keysFetched time.Time
+}
+
+// NewAzureVerifier returns an AzureVerifier scoped by cfg. Signing keys are
+// fetched lazily on the first Verify call.
+func NewAzureVerifier(cfg AzureConfig) *AzureVerifier {
+	return &AzureVerifier{cfg: cfg, httpClient: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Verify parses and validates an Azure AD v2.0 JWT, then checks its
+// xms_mirid claim against the configured subscription and resource group allow-lists.
+//
+// Returns error if the token's signature, issuer, or audience is invalid, or
+// if xms_mirid names a subscription or resource group outside the allow-list.
+func (v *AzureVerifier) Verify(ctx context.Context, credential string) (*Identity, error) {
+	var claims azureClaims
+	token, err := jwt.ParseWithClaims(credential, &claims, v.keyFunc(ctx), jwt.WithAudience(v.cfg.Audience),
+		jwt.WithIssuer(fmt.Sprintf("https://login.microsoftonline.com/%s/v2.0", v.cfg.TenantID)))
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("failed to validate Azure token: %v", err)
+	}
+
+	match := azureResourceIDPattern.FindStringSubmatch(claims.MIRID)
+	if match == nil {
+		return nil, fmt.Errorf("xms_mirid %q does not name a virtual machine or managed identity", claims.MIRID)
+	}
+	subscriptionID, resourceGroup, resourceName := match[1], match[2], match[4]
+
+	if !containsFold(v.cfg.AllowedSubscriptions, subscriptionID) {
+		return nil, fmt.Errorf("subscription %q is not in the allowed subscriptions list", subscriptionID)
+	}
+	if !matchesAny(v.cfg.AllowedResourceGroups, resourceGroup) {
+		return nil, fmt.Errorf("resource group %q is not in the allowed resource groups list", resourceGroup)
+	}
+
+	principal := claims.ObjectID
+	if principal == "" {
+		principal = resourceName
+	}
+
+	return &Identity{Principal: principal, Tenant: v.cfg.TenantID}, nil
+}
+
+// keyFunc resolves the RSA public key named by a token's "kid" header,
+// refreshing the cached JWKS document if the key is unknown or stale.
+func (v *AzureVerifier) keyFunc(ctx context.Context) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, fmt.Errorf("token has no kid header")
+		}
+
+		v.mu.Lock()
+		defer v.mu.Unlock()
+
+		if key, ok := v.keys[kid]; ok && time.Since(v.keysFetched) < azureJWKSRefreshInterval {
+			return key, nil
+		}
+
+		keys, err := fetchAzureJWKS(ctx, v.httpClient, v.cfg.TenantID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch Azure JWKS: %v", err)
+		}
+		v.keys = keys
+		v.keysFetched = time.Now()
+
+		key, ok := v.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("no signing key found for kid %q", kid)
+		}
+		return key, nil
+	}
+}
+
+// azureJWK is a single entry from Azure AD's discovery JWKS document.
+type azureJWK struct {
+	Kid string `json:"kid"`
+	N   string `json:"n"` // Base64url-encoded RSA modulus
+	E   string `json:"e"` // Base64url-encoded RSA public exponent
+}
+
+// fetchAzureJWKS retrieves and parses the tenant's current RSA signing keys.
+func fetchAzureJWKS(ctx context.Context, client *http.Client, tenantID string) (map[string]*rsa.PublicKey, error) {
+	url := fmt.Sprintf("https://login.microsoftonline.com/%s/discovery/v2.0/keys", tenantID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d fetching JWKS", resp.StatusCode)
+	}
+
+	var doc struct {
+		Keys []azureJWK `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS document: %v", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		pub, err := jwkToRSAPublicKey(jwk)
+		if err != nil {
+			continue // Skip keys this verifier does not know how to use (e.g. non-RSA)
+		}
+		keys[jwk.Kid] = pub
+	}
+	return keys, nil
+}
+
+// jwkToRSAPublicKey decodes a JWK's base64url modulus/exponent into an RSA public key.
+func jwkToRSAPublicKey(jwk azureJWK) (*rsa.PublicKey, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus encoding: %v", err)
+	}
+	eBytes, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent encoding: %v", err)
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(nBytes),
+		E: int(new(big.Int).SetBytes(eBytes).Int64()),
+	}, nil
+}
+
+// containsFold reports whether values contains target, ignoring case.
+func containsFold(values []string, target string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny reports whether value matches at least one of patterns.
+func matchesAny(patterns []*regexp.Regexp, value string) bool {
+	for _, p := range patterns {
+		if p.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package workloadid
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// gcpJWKSURL serves Google's current OAuth2 signing keys for instance identity tokens.
+const gcpJWKSURL = "https://www.googleapis.com/oauth2/v3/certs"
+
+// gcpJWKSRefreshInterval bounds how often GCPVerifier refetches Google's
+// signing keys, mirroring AzureVerifier's cache policy.
+const gcpJWKSRefreshInterval = 1 * time.Hour
+
+// gcpClaims holds the Google-signed instance identity token claims this verifier relies on.
+type gcpClaims struct {
+	jwt.RegisteredClaims
+	Google struct {
+		ComputeEngine struct {
+			ProjectID  string `json:"project_id"`
+			InstanceID string `json:"instance_id"`
+			Zone       string `json:"zone"`
+		} `json:"compute_engine"`
+	} `json:"google"`
+}
+
+// GCPConfig scopes which GCP projects GCPVerifier accepts.
+type GCPConfig struct {
+	Audience          string   // Expected "aud" claim (the registration endpoint's own URL, by GCP convention)
+	AllowedProjectIDs []string // GCP project IDs permitted to register
+}
+
+// GCPVerifier verifies Google-signed GCE instance identity tokens.
+//
+// Security features:
+// - JWKS fetched from Google and cached for gcpJWKSRefreshInterval, never a hardcoded key
+// - Signature, issuer, and audience validated before any claim is trusted
+// - google.compute_engine.project_id is matched against an explicit allow-list, so possessing a validly signed Google token is not by itself sufficient
+//
+// Construct with NewGCPVerifier.
+type GCPVerifier struct {
+	cfg        GCPConfig
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	keysFetched time.Time
+}
+
+// NewGCPVerifier returns a GCPVerifier scoped by cfg. Signing keys are
+// fetched lazily on the first Verify call.
+func NewGCPVerifier(cfg GCPConfig) *GCPVerifier {
+	return &GCPVerifier{cfg: cfg, httpClient: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Verify parses and validates a Google-signed instance identity JWT, then
+// checks its project ID against the configured allow-list.
+//
+// Returns error if the token's signature, issuer, or audience is invalid, or
+// if the project ID is outside the allow-list.
+func (v *GCPVerifier) Verify(ctx context.Context, credential string) (*Identity, error) {
+	var claims gcpClaims
+	token, err := jwt.ParseWithClaims(credential, &claims, v.keyFunc(ctx),
+		jwt.WithAudience(v.cfg.Audience),
+		jwt.WithIssuer("https://accounts.google.com"))
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("failed to validate GCP token: %v", err)
+	}
+
+	projectID := claims.Google.ComputeEngine.ProjectID
+	if !containsFold(v.cfg.AllowedProjectIDs, projectID) {
+		return nil, fmt.Errorf("project %q is not in the allowed projects list", projectID)
+	}
+
+	return &Identity{Principal: claims.Google.ComputeEngine.InstanceID, Tenant: projectID}, nil
+}
+
+// keyFunc resolves the RSA public key named by a token's "kid" header,
+// refreshing the cached JWKS document if the key is unknown or stale.
+func (v *GCPVerifier) keyFunc(ctx context.Context) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, fmt.Errorf("token has no kid header")
+		}
+
+		v.mu.Lock()
+		defer v.mu.Unlock()
+
+		if key, ok := v.keys[kid]; ok && time.Since(v.keysFetched) < gcpJWKSRefreshInterval {
+			return key, nil
+		}
+
+		keys, err := fetchGoogleJWKS(ctx, v.httpClient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch Google JWKS: %v", err)
+		}
+		v.keys = keys
+		v.keysFetched = time.Now()
+
+		key, ok := v.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("no signing key found for kid %q", kid)
+		}
+		return key, nil
+	}
+}
+
+// fetchGoogleJWKS retrieves and parses Google's current RSA signing keys.
+func fetchGoogleJWKS(ctx context.Context, client *http.Client) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpJWKSURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d fetching JWKS", resp.StatusCode)
+	}
+
+	var doc struct {
+		Keys []azureJWK `json:"keys"` // Same RSA JWK shape Azure's discovery document uses
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS document: %v", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		pub, err := jwkToRSAPublicKey(jwk)
+		if err != nil {
+			continue // Skip keys this verifier does not know how to use (e.g. non-RSA)
+		}
+		keys[jwk.Kid] = pub
+	}
+	return keys, nil
+}
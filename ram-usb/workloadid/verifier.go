@@ -0,0 +1,42 @@
+/*
+Cloud workload identity verification.
+
+Lets a VM or managed identity register with R.A.M.-U.S.B. by presenting a
+cloud-provider-issued identity credential - an Azure Managed Identity access
+token, an AWS EC2 Instance Identity Document, or a GCP instance identity
+token - instead of an email and password. Each cloud provider gets its own
+Verifier implementation; Entry-Hub's /api/register/workload handler is
+provider-agnostic and just needs a Verifier to try the credential against.
+*/
+package workloadid
+
+import (
+	"context"
+	"fmt"
+)
+
+// Identity is the workload principal a Verifier extracted from a verified credential.
+//
+// Security features:
+// - Only ever constructed after signature, audience, and issuer (or, for AWS, document signature) validation succeeds
+// - Tenant scopes the synthetic account so identities from different clouds or tenants never collide
+type Identity struct {
+	Principal string // Stable workload identifier (Azure object ID, AWS instance ID, GCP instance ID)
+	Tenant    string // Cloud account/tenant/project the workload belongs to
+}
+
+// Email synthesizes the account email R.A.M.-U.S.B. registers this identity
+// under, in place of a user-supplied address.
+func (id Identity) Email() string {
+	return fmt.Sprintf("%s@%s.workload.local", id.Principal, id.Tenant)
+}
+
+// Verifier validates a cloud-provider-issued workload identity credential
+// and extracts the principal it was issued to.
+//
+// Returns error if the credential's signature does not check out, or if it
+// checks out but names a tenant/subscription/resource group outside the
+// verifier's configured allow-list.
+type Verifier interface {
+	Verify(ctx context.Context, credential string) (*Identity, error)
+}